@@ -0,0 +1,74 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// PlatformConfigSpec defines environment-specific values passed to platform
+// modules when rendering instances
+type PlatformConfigSpec struct {
+	// NamespaceSelector selects the namespaces whose instances receive the
+	// values. It only applies to PlatformConfigs in the operator's platform
+	// config namespace; elsewhere, and when unset, only instances in the
+	// PlatformConfig's own namespace receive them. An empty selector selects
+	// every namespace.
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+
+	// Priority orders PlatformConfigs selecting the same namespace. Where
+	// their values set the same field, the higher priority wins.
+	// +optional
+	Priority int32 `json:"priority,omitempty"`
+
+	// Values are passed to modules as #Render.input.config, e.g.
+	// {"baseDomain": "staging.example.com", "registry": "registry.example.com"}
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Type=object
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Values runtime.RawExtension `json:"values"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName=pc
+// +kubebuilder:printcolumn:name="Priority",type=integer,JSONPath=`.spec.priority`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// PlatformConfig is the Schema for the platformconfigs API.
+// PlatformConfig holds per-environment values, such as base domains or image
+// registries, that platform modules receive alongside the instance spec.
+type PlatformConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec PlatformConfigSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// PlatformConfigList contains a list of PlatformConfig
+type PlatformConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PlatformConfig `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&PlatformConfig{}, &PlatformConfigList{})
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+const (
+	// MaxInlineNodes is the most nodes stored uncompressed in Nodes
+	MaxInlineNodes = 100
+
+	// MaxInlineNodesSize is the largest encoded size in bytes of nodes
+	// stored uncompressed in Nodes
+	MaxInlineNodesSize = 256 * 1024
+
+	// MaxCompressedNodesSize bounds CompressedNodes to leave room for the
+	// rest of the ResourceGraph within etcd's 1.5MiB object size limit
+	MaxCompressedNodesSize = 1024 * 1024
+)
+
+// SetNodes stores the graph's nodes, compressing them into CompressedNodes
+// when there are more than MaxInlineNodes or they encode to more than
+// MaxInlineNodesSize bytes
+func (s *ResourceGraphSpec) SetNodes(nodes []ResourceNode) error {
+	s.NodeCount = len(nodes)
+	s.Nodes = nil
+	s.CompressedNodes = nil
+
+	raw, err := json.Marshal(nodes)
+	if err != nil {
+		return fmt.Errorf("failed to encode nodes: %w", err)
+	}
+	if len(nodes) <= MaxInlineNodes && len(raw) <= MaxInlineNodesSize {
+		s.Nodes = nodes
+		return nil
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(raw); err != nil {
+		return fmt.Errorf("failed to compress nodes: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to compress nodes: %w", err)
+	}
+	if buf.Len() > MaxCompressedNodesSize {
+		return fmt.Errorf("graph of %d nodes is too large: %d bytes compressed, limit is %d",
+			len(nodes), buf.Len(), MaxCompressedNodesSize)
+	}
+	s.CompressedNodes = buf.Bytes()
+	return nil
+}
+
+// GetNodes returns the graph's nodes, whether stored inline or compressed
+func (s *ResourceGraphSpec) GetNodes() ([]ResourceNode, error) {
+	if len(s.CompressedNodes) == 0 {
+		return s.Nodes, nil
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(s.CompressedNodes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress nodes: %w", err)
+	}
+	defer func() { _ = zr.Close() }()
+
+	raw, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress nodes: %w", err)
+	}
+	var nodes []ResourceNode
+	if err := json.Unmarshal(raw, &nodes); err != nil {
+		return nil, fmt.Errorf("failed to decode compressed nodes: %w", err)
+	}
+	return nodes, nil
+}
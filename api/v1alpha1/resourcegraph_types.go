@@ -22,6 +22,7 @@ import (
 )
 
 // ResourceGraphSpec defines the rendered graph of Kubernetes resources
+// +kubebuilder:validation:XValidation:rule="(has(self.nodes) && size(self.nodes) > 0) || has(self.compressedNodes)",message="a ResourceGraph needs nodes or compressedNodes"
 type ResourceGraphSpec struct {
 	// SourceRef references the source resource that generated this graph
 	// (e.g., WebService, Database, etc.)
@@ -32,11 +33,21 @@ type ResourceGraphSpec struct {
 	// +kubebuilder:validation:Required
 	Metadata GraphMetadata `json:"metadata"`
 
-	// Nodes contains all the resources to be applied
-	// Limited to prevent etcd size issues
+	// Nodes contains all the resources to be applied. Graphs too large to
+	// store inline are kept in CompressedNodes instead; use GetNodes to read
+	// either form.
 	// +kubebuilder:validation:MaxItems=100
-	// +kubebuilder:validation:MinItems=1
-	Nodes []ResourceNode `json:"nodes"`
+	// +optional
+	Nodes []ResourceNode `json:"nodes,omitempty"`
+
+	// CompressedNodes holds the gzip compressed JSON list of nodes of a graph
+	// with more than MaxInlineNodes nodes or MaxInlineNodesSize bytes
+	// +optional
+	CompressedNodes []byte `json:"compressedNodes,omitempty"`
+
+	// NodeCount is the number of nodes in the graph
+	// +optional
+	NodeCount int `json:"nodeCount,omitempty"`
 
 	// Violations contains any policy violations found during rendering
 	// +optional
@@ -47,6 +58,17 @@ type ResourceGraphSpec struct {
 	// +optional
 	Adopt *AdoptSpec `json:"adopt,omitempty"`
 
+	// Rollback names the revision to restore if this graph fails.
+	// Set only when rollback on failure is enabled for the instance.
+	// +optional
+	Rollback *RollbackSpec `json:"rollback,omitempty"`
+
+	// SensitiveValuesRef references the Secret holding the values of the
+	// nodes' sensitive fields, which are left out of the node objects, and
+	// the #Render input the graph was rendered from
+	// +optional
+	SensitiveValuesRef *LocalObjectReference `json:"sensitiveValuesRef,omitempty"`
+
 	// RenderHash is a hash of the rendered graph for change detection
 	// +kubebuilder:validation:Required
 	RenderHash string `json:"renderHash"`
@@ -56,6 +78,14 @@ type ResourceGraphSpec struct {
 	RenderedAt metav1.Time `json:"renderedAt"`
 }
 
+// RollbackSpec identifies the revision a failed graph is rolled back to
+type RollbackSpec struct {
+	// PreviousRevision is the name of the last Completed ResourceGraph
+	// of the same instance
+	// +kubebuilder:validation:Required
+	PreviousRevision string `json:"previousRevision"`
+}
+
 // ObjectReference contains enough information to locate a Kubernetes object
 type ObjectReference struct {
 	// APIVersion of the referent
@@ -119,6 +149,82 @@ type ResourceNode struct {
 	// ReadyWhen defines the conditions for this resource to be considered ready
 	// +optional
 	ReadyWhen []ReadinessPredicate `json:"readyWhen,omitempty"`
+
+	// Hook makes this node a lifecycle hook Job that runs once per render
+	// hash at the given phase instead of being applied like other resources
+	// +optional
+	Hook *Hook `json:"hook,omitempty"`
+
+	// Generate makes this node a Secret whose values are generated once by
+	// the controller and never regenerated. The object must not set data.
+	// +optional
+	Generate *GeneratedSecret `json:"generate,omitempty"`
+
+	// SensitiveFields lists field paths of the object whose values are
+	// stored in the SensitiveValuesRef Secret instead of the object
+	// +optional
+	SensitiveFields []string `json:"sensitiveFields,omitempty"`
+
+	// Instance marks the object as a platform instance of a kind generated
+	// by a Transform. Instances are deleted in reverse dependency order
+	// before their parent's own resources.
+	// +optional
+	Instance bool `json:"instance,omitempty"`
+}
+
+// Hook defines when a lifecycle hook Job runs and when it is cleaned up
+type Hook struct {
+	// Phase is when the hook runs: before other nodes are applied, after
+	// they are all ready, or when the instance is deleted
+	// +kubebuilder:validation:Enum=PreApply;PostApply;PreDelete
+	// +kubebuilder:validation:Required
+	Phase string `json:"phase"`
+
+	// CleanupPolicy is when the hook's Job is deleted: BeforeRun keeps it
+	// until the hook runs again, OnSuccess deletes it once it succeeds and
+	// OnCompletion deletes it once it succeeds or fails
+	// +kubebuilder:validation:Enum=BeforeRun;OnSuccess;OnCompletion
+	// +kubebuilder:default="BeforeRun"
+	// +optional
+	CleanupPolicy string `json:"cleanupPolicy,omitempty"`
+}
+
+// GeneratedSecret defines the values generated for a Secret node
+type GeneratedSecret struct {
+	// Keys lists the Secret data keys to generate
+	// +kubebuilder:validation:MinItems=1
+	Keys []GeneratedKey `json:"keys"`
+}
+
+// GeneratedKey defines the value generated for one Secret data key
+type GeneratedKey struct {
+	// Name is the Secret data key. Keypairs store the private key under
+	// Name and the public key under Name + ".pub".
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Type is the kind of value to generate
+	// +kubebuilder:validation:Enum=Random;RSA;ECDSA;Ed25519
+	// +kubebuilder:default="Random"
+	// +optional
+	Type string `json:"type,omitempty"`
+
+	// Length is the number of characters of a Random value
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=1024
+	// +optional
+	Length int `json:"length,omitempty"`
+
+	// Charset is the alphabet of a Random value
+	// +kubebuilder:validation:Enum=Alphanumeric;Numeric;Hex;Printable
+	// +optional
+	Charset string `json:"charset,omitempty"`
+
+	// Bits is the size of an RSA key
+	// +kubebuilder:validation:Enum=2048;3072;4096
+	// +optional
+	Bits int `json:"bits,omitempty"`
 }
 
 // ApplyPolicy defines how a resource should be applied
@@ -133,15 +239,72 @@ type ApplyPolicy struct {
 	// +kubebuilder:default="Error"
 	ConflictPolicy string `json:"conflictPolicy"`
 
+	// ConflictResolution forces ownership of selected fields or managers
+	// when ConflictPolicy is Error. The apply is forced only if every
+	// conflicting field matches a rule; otherwise it fails as usual.
+	// +optional
+	ConflictResolution *ConflictResolution `json:"conflictResolution,omitempty"`
+
 	// FieldManager is the name of the field manager for server-side apply
 	// +optional
 	FieldManager string `json:"fieldManager,omitempty"`
+
+	// IgnoreFields lists field paths owned by other controllers
+	// (e.g. "spec.replicas" when an HPA scales a Deployment).
+	// They are excluded from the applied intent and from drift detection.
+	// +optional
+	IgnoreFields []string `json:"ignoreFields,omitempty"`
+
+	// UpdateStrategy defines how changes to immutable fields are handled
+	// (e.g. a Deployment selector or a Service clusterIP)
+	// +optional
+	UpdateStrategy *UpdateStrategy `json:"updateStrategy,omitempty"`
+}
+
+// UpdateStrategy defines how a resource is replaced when an apply changes
+// an immutable field
+type UpdateStrategy struct {
+	// Type is the strategy: InPlace fails the apply, Recreate deletes the
+	// resource, waits for it to disappear and creates it again
+	// +kubebuilder:validation:Enum=InPlace;Recreate
+	// +kubebuilder:default="InPlace"
+	Type string `json:"type"`
+
+	// PropagationPolicy is the deletion propagation used by Recreate
+	// +kubebuilder:validation:Enum=Foreground;Background;Orphan
+	// +optional
+	PropagationPolicy string `json:"propagationPolicy,omitempty"`
+}
+
+// ConflictResolution selects which server-side apply conflicts may be forced
+type ConflictResolution struct {
+	// ForceFields lists field paths (and everything below them) whose
+	// conflicts may be forced, e.g. "spec.template.spec.containers[*].image"
+	// +optional
+	ForceFields []string `json:"forceFields,omitempty"`
+
+	// ForceManagers lists field managers whose fields may be taken over
+	// +optional
+	ForceManagers []string `json:"forceManagers,omitempty"`
+}
+
+// FieldConflict describes a field owned by another field manager
+type FieldConflict struct {
+	// Manager is the field manager that owns the field
+	Manager string `json:"manager"`
+
+	// Field is the conflicting field path as reported by the API server
+	Field string `json:"field"`
+
+	// Operation is how the owning manager last wrote the field
+	// +optional
+	Operation string `json:"operation,omitempty"`
 }
 
 // ReadinessPredicate defines a condition for resource readiness
 type ReadinessPredicate struct {
 	// Type is the type of predicate
-	// +kubebuilder:validation:Enum=ConditionMatch;DeploymentAvailable;Exists
+	// +kubebuilder:validation:Enum=ConditionMatch;DeploymentAvailable;Exists;InstanceReady
 	// +kubebuilder:validation:Required
 	Type string `json:"type"`
 
@@ -173,7 +336,7 @@ type PolicyViolation struct {
 // ResourceGraphStatus defines the execution state of the graph
 type ResourceGraphStatus struct {
 	// Phase indicates the overall execution phase
-	// +kubebuilder:validation:Enum=Pending;Executing;Completed;Failed
+	// +kubebuilder:validation:Enum=Pending;Executing;Completed;Failed;RolledBack
 	// +optional
 	Phase string `json:"phase,omitempty"`
 
@@ -216,6 +379,20 @@ type NodeExecutionState struct {
 	// +optional
 	LastError string `json:"lastError,omitempty"`
 
+	// Terminal indicates that LastError will not succeed on retry, so the
+	// node is not retried until the graph or the cluster changes
+	// +optional
+	Terminal bool `json:"terminal,omitempty"`
+
+	// Reason is a short cause for a terminal LastError, e.g. Forbidden,
+	// Invalid, KindNotFound or AdmissionDenied
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
+	// Conflicts lists the field manager conflicts behind LastError, if any
+	// +optional
+	Conflicts []FieldConflict `json:"conflicts,omitempty"`
+
 	// LastTransitionTime is when the phase last changed
 	// +optional
 	LastTransitionTime *metav1.Time `json:"lastTransitionTime,omitempty"`
@@ -250,6 +427,7 @@ type NodeExecutionState struct {
 // +kubebuilder:resource:shortName=rg
 // +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
 // +kubebuilder:printcolumn:name="Source",type=string,JSONPath=`.spec.sourceRef.name`
+// +kubebuilder:printcolumn:name="Nodes",type=integer,JSONPath=`.spec.nodeCount`
 // +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // ResourceGraph represents a rendered graph of Kubernetes resources to be applied
@@ -18,6 +18,7 @@ package v1alpha1
 
 import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // CueRefType defines the type of CUE reference
@@ -62,6 +63,64 @@ type CueReference struct {
 	// The secret should contain keys like "username", "password" or ".dockerconfigjson"
 	// +optional
 	PullSecretRef *LocalObjectReference `json:"pullSecretRef,omitempty"`
+
+	// RefreshInterval is how often a git or oci ref is resolved again to
+	// pick up a branch or tag that moved, e.g. "5m". When the digest
+	// changes, the CRD is regenerated and every instance is rendered again.
+	// Refs are resolved once if unset. Intervals below 30s are raised to 30s.
+	// +optional
+	RefreshInterval *metav1.Duration `json:"refreshInterval,omitempty"`
+}
+
+// DefaultChannel is the name of the channel of a Transform's spec.cueRef
+const DefaultChannel = "default"
+
+// ModuleChannel is a named version of a Transform's module that instances
+// can select instead of spec.cueRef
+type ModuleChannel struct {
+	// Name identifies the channel, e.g. "stable" or "beta"
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name"`
+
+	// CueRef locates the channel's module. RefreshInterval is not honored
+	// for channels.
+	// +kubebuilder:validation:Required
+	CueRef CueReference `json:"cueRef"`
+}
+
+// ChannelStatus is the module a channel resolved to
+type ChannelStatus struct {
+	// Name is the name of the channel
+	Name string `json:"name"`
+
+	// ResolvedCueRef is the module the channel's instances are rendered from
+	// +optional
+	ResolvedCueRef *ResolvedCueReference `json:"resolvedCueRef,omitempty"`
+}
+
+// APIVersion is a version served by a Transform's generated CRD
+type APIVersion struct {
+	// Name of the version, e.g. "v1beta1". The schema of a version other
+	// than spec.version is the module's #Versions.<name>.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern=`^v[1-9][0-9]*((alpha|beta)[1-9][0-9]*)?$`
+	Name string `json:"name"`
+
+	// Served controls whether the API server serves the version
+	// +kubebuilder:default=true
+	// +optional
+	Served *bool `json:"served,omitempty"`
+
+	// Storage marks the version instances are stored as. Exactly one
+	// version is the storage version.
+	// +optional
+	Storage bool `json:"storage,omitempty"`
+
+	// Deprecated makes the API server warn clients using the version
+	// +optional
+	Deprecated bool `json:"deprecated,omitempty"`
 }
 
 // LocalObjectReference contains enough information to locate a local object
@@ -74,6 +133,18 @@ type LocalObjectReference struct {
 
 // ResolvedCueReference contains the resolved state of a CUE reference
 type ResolvedCueReference struct {
+	// Type is the source type of Ref
+	// +optional
+	Type CueRefType `json:"type,omitempty"`
+
+	// Ref is the reference instances are rendered from: the spec's ref
+	// pinned to Digest for git and oci modules, and the spec's ref itself
+	// otherwise. It is empty for inline modules, whose source the Transform
+	// keeps in a ConfigMap named after Digest. It changes only once the
+	// Transform adopts a new module.
+	// +optional
+	Ref string `json:"ref,omitempty"`
+
 	// Digest is the content hash of the resolved CUE module
 	// For OCI this is the manifest digest, for Git the commit SHA
 	// +optional
@@ -82,6 +153,11 @@ type ResolvedCueReference struct {
 	// FetchedAt is when the module was last fetched
 	// +optional
 	FetchedAt *metav1.Time `json:"fetchedAt,omitempty"`
+
+	// CheckedAt is when the ref was last resolved to check whether it
+	// moved, for refs with a refreshInterval
+	// +optional
+	CheckedAt *metav1.Time `json:"checkedAt,omitempty"`
 }
 
 // RBACScope defines where RBAC resources are created for a Transform
@@ -96,6 +172,81 @@ const (
 	RBACScopeNamespace RBACScope = "Namespace"
 )
 
+// DeletionPolicy defines what happens to a Transform's generated CRD when
+// the Transform is deleted
+// +kubebuilder:validation:Enum=Delete;Orphan
+type DeletionPolicy string
+
+const (
+	// DeletionPolicyDelete deletes the generated CRD once no instances of it
+	// remain
+	DeletionPolicyDelete DeletionPolicy = "Delete"
+
+	// DeletionPolicyOrphan keeps the generated CRD and its instances
+	DeletionPolicyOrphan DeletionPolicy = "Orphan"
+)
+
+// UpgradeAnalysisPolicy defines whether a new module is analyzed against
+// the existing instances before the Transform adopts it
+// +kubebuilder:validation:Enum=None;Report;Gate
+type UpgradeAnalysisPolicy string
+
+const (
+	// UpgradeAnalysisNone adopts new modules without analysis
+	UpgradeAnalysisNone UpgradeAnalysisPolicy = "None"
+
+	// UpgradeAnalysisReport analyzes new modules and adopts them regardless
+	// of the outcome
+	UpgradeAnalysisReport UpgradeAnalysisPolicy = "Report"
+
+	// UpgradeAnalysisGate adopts a new module only if every instance that
+	// renders with the current module also renders with the new one
+	UpgradeAnalysisGate UpgradeAnalysisPolicy = "Gate"
+)
+
+// RolloutStrategy rolls a new module out to a Transform's instances in
+// batches instead of all at once
+type RolloutStrategy struct {
+	// BatchSize is how many instances render the new module at a time, as a
+	// number or a percentage of the instances, rounded up
+	// +kubebuilder:default="25%"
+	// +kubebuilder:validation:XIntOrString
+	// +optional
+	BatchSize *intstr.IntOrString `json:"batchSize,omitempty"`
+
+	// NamespaceOrderLabel is a namespace label ordering the batches.
+	// Instances in namespaces with lower values roll out first, compared as
+	// numbers when both are integers; namespaces without the label go last.
+	// +optional
+	NamespaceOrderLabel string `json:"namespaceOrderLabel,omitempty"`
+
+	// PauseBetweenBatches is how long to wait after a batch is ready before
+	// starting the next one
+	// +optional
+	PauseBetweenBatches *metav1.Duration `json:"pauseBetweenBatches,omitempty"`
+
+	// ProgressDeadline is how long a batch may take to become ready before
+	// the rollout halts as stalled
+	// +kubebuilder:default="10m"
+	// +optional
+	ProgressDeadline *metav1.Duration `json:"progressDeadline,omitempty"`
+}
+
+// RolloutPhase is the state of a rollout
+// +kubebuilder:validation:Enum=Progressing;Paused;Halted;Complete
+type RolloutPhase string
+
+const (
+	// RolloutPhaseProgressing indicates a batch is rendering the new module
+	RolloutPhaseProgressing RolloutPhase = "Progressing"
+	// RolloutPhasePaused indicates the rollout waits between batches
+	RolloutPhasePaused RolloutPhase = "Paused"
+	// RolloutPhaseHalted indicates a batch failed or stalled
+	RolloutPhaseHalted RolloutPhase = "Halted"
+	// RolloutPhaseComplete indicates every instance renders the new module
+	RolloutPhaseComplete RolloutPhase = "Complete"
+)
+
 // ManagedResource defines a Kubernetes resource type that a Transform manages
 type ManagedResource struct {
 	// APIGroup is the API group of the resource (e.g., "apps", "" for core)
@@ -195,6 +346,8 @@ type AdoptedResourceRef struct {
 
 // TransformSpec defines the desired state of Transform.
 // Transform is a platform definition that generates a CRD for developers to use.
+// +kubebuilder:validation:XValidation:rule="!has(self.versions) || self.versions.exists_one(v, has(v.storage) && v.storage)",message="exactly one version must be the storage version"
+// +kubebuilder:validation:XValidation:rule="!has(self.versions) || self.versions.exists(v, v.name == self.version && (!has(v.served) || v.served))",message="versions must serve spec.version"
 type TransformSpec struct {
 	// CueRef specifies how to locate the CUE platform module
 	// +kubebuilder:validation:Required
@@ -208,10 +361,22 @@ type TransformSpec struct {
 
 	// Version is the API version for the generated CRD
 	// Defaults to "v1alpha1"
+	// Instances are read and rendered at this version, whose schema is the
+	// module's #Input.
 	// +kubebuilder:default="v1alpha1"
 	// +optional
 	Version string `json:"version,omitempty"`
 
+	// Versions are the API versions the generated CRD serves, including
+	// spec.version. Instances are converted between versions by the
+	// manager's conversion webhook with the module's #Convert definitions.
+	// Without versions the CRD serves and stores spec.version only.
+	// +listType=map
+	// +listMapKey=name
+	// +kubebuilder:validation:MaxItems=8
+	// +optional
+	Versions []APIVersion `json:"versions,omitempty"`
+
 	// ShortNames are optional short names for the generated CRD
 	// +optional
 	ShortNames []string `json:"shortNames,omitempty"`
@@ -233,6 +398,44 @@ type TransformSpec struct {
 	// +kubebuilder:default=Cluster
 	// +optional
 	RBACScope RBACScope `json:"rbacScope,omitempty"`
+
+	// RollbackOnFailure re-applies the last successfully completed revision
+	// of an instance when a new revision fails, and prunes the resources that
+	// only the failed revision created. Instances can override this with the
+	// pequod.io/rollback-on-failure annotation.
+	// +optional
+	RollbackOnFailure bool `json:"rollbackOnFailure,omitempty"`
+
+	// DeletionPolicy is what happens to the generated CRD when the Transform
+	// is deleted. Delete waits until no instances remain, unless the
+	// Transform has the pequod.io/force-delete annotation; Orphan leaves the
+	// CRD and its instances in place.
+	// +kubebuilder:default=Delete
+	// +optional
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// UpgradeAnalysis is whether a new module digest is first rendered for
+	// every existing instance and compared with the current module, and
+	// whether render failures keep the current module in place.
+	// +kubebuilder:default=Report
+	// +optional
+	UpgradeAnalysis UpgradeAnalysisPolicy `json:"upgradeAnalysis,omitempty"`
+
+	// Rollout, if set, rolls new module digests out to the instances in
+	// batches. Without it every instance renders a new module at once.
+	// +optional
+	Rollout *RolloutStrategy `json:"rollout,omitempty"`
+
+	// Channels are further versions of the module that instances select
+	// with the pequod.io/channel annotation. Instances without it use
+	// spec.cueRef, the "default" channel. The generated CRD accepts the
+	// specs of every channel's #Input.
+	// +listType=map
+	// +listMapKey=name
+	// +kubebuilder:validation:MaxItems=8
+	// +kubebuilder:validation:XValidation:rule="self.all(c, c.name != 'default')",message="the default channel is spec.cueRef"
+	// +optional
+	Channels []ModuleChannel `json:"channels,omitempty"`
 }
 
 // TransformPhase represents the current phase of a Transform
@@ -267,6 +470,215 @@ type GeneratedCRDReference struct {
 	Plural string `json:"plural"`
 }
 
+// InstanceSummary aggregates the health of a Transform's instances
+type InstanceSummary struct {
+	// Total is the number of instances
+	Total int32 `json:"total"`
+
+	// Ready counts instances whose current ResourceGraph completed
+	Ready int32 `json:"ready"`
+
+	// Failed counts instances whose current ResourceGraph failed or was
+	// rolled back
+	Failed int32 `json:"failed"`
+
+	// Reconciling counts instances whose current ResourceGraph has not
+	// finished yet
+	Reconciling int32 `json:"reconciling"`
+
+	// FailingInstances lists up to 10 failed instances, ordered by
+	// namespace and name
+	// +optional
+	FailingInstances []FailingInstance `json:"failingInstances,omitempty"`
+
+	// Digests counts instances by the digest of the module they were last
+	// rendered from, most common first
+	// +optional
+	Digests []DigestCount `json:"digests,omitempty"`
+}
+
+// FailingInstance identifies a failed instance and why it failed
+type FailingInstance struct {
+	// Name is the name of the instance
+	Name string `json:"name"`
+
+	// Namespace is the namespace of the instance
+	Namespace string `json:"namespace"`
+
+	// Reason is the reason of the instance's Ready condition
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
+	// Message is the message of the instance's Ready condition
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// DigestCount is the number of instances rendered from a module digest
+type DigestCount struct {
+	// Digest is the module digest, e.g. an OCI manifest digest or Git commit
+	Digest string `json:"digest"`
+
+	// Count is the number of instances last rendered from the digest
+	Count int32 `json:"count"`
+}
+
+// UpgradeAnalysis compares the renders of every instance with the current
+// and a new module digest
+type UpgradeAnalysis struct {
+	// FromDigest is the digest of the current module
+	FromDigest string `json:"fromDigest"`
+
+	// ToDigest is the digest of the new module
+	ToDigest string `json:"toDigest"`
+
+	// AnalyzedAt is when the instances were rendered
+	AnalyzedAt metav1.Time `json:"analyzedAt"`
+
+	// Instances is the number of instances analyzed
+	Instances int32 `json:"instances"`
+
+	// Changed counts instances whose rendered objects differ
+	Changed int32 `json:"changed"`
+
+	// Failed counts instances that render with the current module but not
+	// with the new one
+	Failed int32 `json:"failed"`
+
+	// NewViolations counts instances with policy violations that the
+	// current module does not report
+	NewViolations int32 `json:"newViolations"`
+
+	// Blocked is true if the Gate policy kept the current module because
+	// of render failures
+	// +optional
+	Blocked bool `json:"blocked,omitempty"`
+
+	// Results details up to 20 affected instances, failures first
+	// +optional
+	Results []InstanceImpact `json:"results,omitempty"`
+}
+
+// InstanceImpact is how a new module changes the render of an instance
+type InstanceImpact struct {
+	// Name is the name of the instance
+	Name string `json:"name"`
+
+	// Namespace is the namespace of the instance
+	Namespace string `json:"namespace"`
+
+	// Added lists the IDs of nodes only the new module renders
+	// +optional
+	Added []string `json:"added,omitempty"`
+
+	// Removed lists the IDs of nodes only the current module renders
+	// +optional
+	Removed []string `json:"removed,omitempty"`
+
+	// Changed lists the IDs of nodes whose objects differ
+	// +optional
+	Changed []string `json:"changed,omitempty"`
+
+	// NewViolations lists policy violations only the new module reports
+	// +optional
+	NewViolations []string `json:"newViolations,omitempty"`
+
+	// Error is the error rendering the instance with the new module
+	// +optional
+	Error string `json:"error,omitempty"`
+}
+
+// RolloutStatus tracks the rollout of a new module to a Transform's
+// instances
+type RolloutStatus struct {
+	// Phase is the state of the rollout
+	Phase RolloutPhase `json:"phase"`
+
+	// From is the module instances render until their batch starts
+	From ResolvedCueReference `json:"from"`
+
+	// ToDigest is the digest of the module being rolled out
+	ToDigest string `json:"toDigest"`
+
+	// BatchNumber counts the batches started, starting at 1
+	// +optional
+	BatchNumber int32 `json:"batchNumber,omitempty"`
+
+	// Batch lists the instances of the current batch as namespace/name
+	// +optional
+	Batch []string `json:"batch,omitempty"`
+
+	// BatchStartedAt is when the current batch started
+	// +optional
+	BatchStartedAt *metav1.Time `json:"batchStartedAt,omitempty"`
+
+	// BatchReadyAt is when every instance of the current batch became ready
+	// +optional
+	BatchReadyAt *metav1.Time `json:"batchReadyAt,omitempty"`
+
+	// Updated counts the instances rendered from the new module
+	// +optional
+	Updated int32 `json:"updated,omitempty"`
+
+	// Total counts the instances to roll out to
+	// +optional
+	Total int32 `json:"total,omitempty"`
+
+	// Message explains why the rollout halted
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// SchemaCompatibility compares the spec schemas of a generated CRD with
+// those of a new module
+type SchemaCompatibility struct {
+	// CheckedAt is when the schemas were compared
+	CheckedAt metav1.Time `json:"checkedAt"`
+
+	// Breaking counts changes that existing instances may not survive:
+	// removed fields, newly required fields without a default, narrowed
+	// enums, tightened bounds, new patterns and changed types
+	Breaking int32 `json:"breaking"`
+
+	// Compatible counts changes every existing instance survives
+	Compatible int32 `json:"compatible"`
+
+	// Blocked is true if breaking changes kept the current CRD and module
+	// +optional
+	Blocked bool `json:"blocked,omitempty"`
+
+	// Allowed is true if breaking changes were applied because of the
+	// pequod.io/allow-breaking-changes annotation
+	// +optional
+	Allowed bool `json:"allowed,omitempty"`
+
+	// Changes details up to 20 changes, breaking changes first
+	// +optional
+	Changes []SchemaChange `json:"changes,omitempty"`
+}
+
+// SchemaChange is a change to the spec schema of one version of a
+// generated CRD
+type SchemaChange struct {
+	// Version is the API version whose schema changed
+	Version string `json:"version"`
+
+	// Path is the changed field, e.g. spec.resources.cpu
+	Path string `json:"path"`
+
+	// Kind classifies the change
+	// +kubebuilder:validation:Enum=FieldAdded;FieldRemoved;FieldRequired;FieldOptional;EnumNarrowed;EnumWidened;TypeChanged;BoundTightened;BoundLoosened;PatternChanged;VersionRemoved
+	Kind string `json:"kind"`
+
+	// Breaking is true if existing instances may be invalid or lose data
+	// under the new schema
+	// +optional
+	Breaking bool `json:"breaking,omitempty"`
+
+	// Message describes the change
+	Message string `json:"message"`
+}
+
 // TransformStatus defines the observed state of Transform
 type TransformStatus struct {
 	// Phase is the current phase of the Transform
@@ -285,12 +697,24 @@ type TransformStatus struct {
 	// +optional
 	ResolvedCueRef *ResolvedCueReference `json:"resolvedCueRef,omitempty"`
 
+	// Channels are the modules the spec's channels resolved to
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	Channels []ChannelStatus `json:"channels,omitempty"`
+
 	// Conditions represent the current state of the Transform
 	// Condition types include:
 	// - "CueFetched": CUE module fetched successfully
 	// - "SchemaExtracted": Input schema extracted from CUE
 	// - "CRDGenerated": CRD generated and applied to cluster
 	// - "RBACConfigured": RBAC resources generated and applied
+	// - "Degraded": instance renders repeatedly exceeded the render budget
+	// - "DeletionBlocked": deletion is waiting for instances to be deleted
+	// - "UpgradeBlocked": a new module failed upgrade analysis
+	// - "RolloutHalted": a rollout batch failed or stalled
+	// - "StorageMigrated": instances are stored as the storage version
+	// - "SchemaCompatible": the module's schema changes are compatible
 	// +listType=map
 	// +listMapKey=type
 	// +optional
@@ -299,6 +723,30 @@ type TransformStatus struct {
 	// ObservedGeneration is the generation observed by the controller
 	// +optional
 	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Instances aggregates the health of the generated CRD's instances
+	// +optional
+	Instances *InstanceSummary `json:"instances,omitempty"`
+
+	// UpgradeAnalysis is the analysis of the latest new module digest
+	// +optional
+	UpgradeAnalysis *UpgradeAnalysis `json:"upgradeAnalysis,omitempty"`
+
+	// Rollout tracks the rollout of the latest module digest, if the
+	// Transform has a rollout strategy
+	// +optional
+	Rollout *RolloutStatus `json:"rollout,omitempty"`
+
+	// SchemaCompatibility is the comparison of the generated CRD's schemas
+	// with those of the latest module that changed them
+	// +optional
+	SchemaCompatibility *SchemaCompatibility `json:"schemaCompatibility,omitempty"`
+
+	// RenderBudgetViolations counts consecutive instance renders that ran
+	// past the render deadline or memory budget. It is reset by the next
+	// successful render.
+	// +optional
+	RenderBudgetViolations int32 `json:"renderBudgetViolations,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -306,6 +754,10 @@ type TransformStatus struct {
 // +kubebuilder:resource:shortName=tf
 // +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
 // +kubebuilder:printcolumn:name="CRD",type=string,JSONPath=`.status.generatedCRD.name`
+// +kubebuilder:printcolumn:name="Instances",type=integer,JSONPath=`.status.instances.total`
+// +kubebuilder:printcolumn:name="Ready",type=integer,JSONPath=`.status.instances.ready`
+// +kubebuilder:printcolumn:name="Failed",type=integer,JSONPath=`.status.instances.failed`
+// +kubebuilder:printcolumn:name="Rollout",type=string,JSONPath=`.status.rollout.phase`,priority=1
 // +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // Transform is the Schema for the transforms API.
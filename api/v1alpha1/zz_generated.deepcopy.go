@@ -23,8 +23,29 @@ package v1alpha1
 import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *APIVersion) DeepCopyInto(out *APIVersion) {
+	*out = *in
+	if in.Served != nil {
+		in, out := &in.Served, &out.Served
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new APIVersion.
+func (in *APIVersion) DeepCopy() *APIVersion {
+	if in == nil {
+		return nil
+	}
+	out := new(APIVersion)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AdoptSpec) DeepCopyInto(out *AdoptSpec) {
 	*out = *in
@@ -68,6 +89,21 @@ func (in *AdoptedResourceRef) DeepCopy() *AdoptedResourceRef {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ApplyPolicy) DeepCopyInto(out *ApplyPolicy) {
 	*out = *in
+	if in.ConflictResolution != nil {
+		in, out := &in.ConflictResolution, &out.ConflictResolution
+		*out = new(ConflictResolution)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.IgnoreFields != nil {
+		in, out := &in.IgnoreFields, &out.IgnoreFields
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.UpdateStrategy != nil {
+		in, out := &in.UpdateStrategy, &out.UpdateStrategy
+		*out = new(UpdateStrategy)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApplyPolicy.
@@ -80,6 +116,51 @@ func (in *ApplyPolicy) DeepCopy() *ApplyPolicy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChannelStatus) DeepCopyInto(out *ChannelStatus) {
+	*out = *in
+	if in.ResolvedCueRef != nil {
+		in, out := &in.ResolvedCueRef, &out.ResolvedCueRef
+		*out = new(ResolvedCueReference)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChannelStatus.
+func (in *ChannelStatus) DeepCopy() *ChannelStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ChannelStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConflictResolution) DeepCopyInto(out *ConflictResolution) {
+	*out = *in
+	if in.ForceFields != nil {
+		in, out := &in.ForceFields, &out.ForceFields
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ForceManagers != nil {
+		in, out := &in.ForceManagers, &out.ForceManagers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConflictResolution.
+func (in *ConflictResolution) DeepCopy() *ConflictResolution {
+	if in == nil {
+		return nil
+	}
+	out := new(ConflictResolution)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CueReference) DeepCopyInto(out *CueReference) {
 	*out = *in
@@ -88,6 +169,11 @@ func (in *CueReference) DeepCopyInto(out *CueReference) {
 		*out = new(LocalObjectReference)
 		**out = **in
 	}
+	if in.RefreshInterval != nil {
+		in, out := &in.RefreshInterval, &out.RefreshInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CueReference.
@@ -100,6 +186,51 @@ func (in *CueReference) DeepCopy() *CueReference {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DigestCount) DeepCopyInto(out *DigestCount) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DigestCount.
+func (in *DigestCount) DeepCopy() *DigestCount {
+	if in == nil {
+		return nil
+	}
+	out := new(DigestCount)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FailingInstance) DeepCopyInto(out *FailingInstance) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FailingInstance.
+func (in *FailingInstance) DeepCopy() *FailingInstance {
+	if in == nil {
+		return nil
+	}
+	out := new(FailingInstance)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FieldConflict) DeepCopyInto(out *FieldConflict) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FieldConflict.
+func (in *FieldConflict) DeepCopy() *FieldConflict {
+	if in == nil {
+		return nil
+	}
+	out := new(FieldConflict)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GeneratedCRDReference) DeepCopyInto(out *GeneratedCRDReference) {
 	*out = *in
@@ -115,6 +246,21 @@ func (in *GeneratedCRDReference) DeepCopy() *GeneratedCRDReference {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GeneratedKey) DeepCopyInto(out *GeneratedKey) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GeneratedKey.
+func (in *GeneratedKey) DeepCopy() *GeneratedKey {
+	if in == nil {
+		return nil
+	}
+	out := new(GeneratedKey)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GeneratedRBACReference) DeepCopyInto(out *GeneratedRBACReference) {
 	*out = *in
@@ -130,6 +276,26 @@ func (in *GeneratedRBACReference) DeepCopy() *GeneratedRBACReference {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GeneratedSecret) DeepCopyInto(out *GeneratedSecret) {
+	*out = *in
+	if in.Keys != nil {
+		in, out := &in.Keys, &out.Keys
+		*out = make([]GeneratedKey, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GeneratedSecret.
+func (in *GeneratedSecret) DeepCopy() *GeneratedSecret {
+	if in == nil {
+		return nil
+	}
+	out := new(GeneratedSecret)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GraphMetadata) DeepCopyInto(out *GraphMetadata) {
 	*out = *in
@@ -145,6 +311,81 @@ func (in *GraphMetadata) DeepCopy() *GraphMetadata {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Hook) DeepCopyInto(out *Hook) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Hook.
+func (in *Hook) DeepCopy() *Hook {
+	if in == nil {
+		return nil
+	}
+	out := new(Hook)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InstanceImpact) DeepCopyInto(out *InstanceImpact) {
+	*out = *in
+	if in.Added != nil {
+		in, out := &in.Added, &out.Added
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Removed != nil {
+		in, out := &in.Removed, &out.Removed
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Changed != nil {
+		in, out := &in.Changed, &out.Changed
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NewViolations != nil {
+		in, out := &in.NewViolations, &out.NewViolations
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InstanceImpact.
+func (in *InstanceImpact) DeepCopy() *InstanceImpact {
+	if in == nil {
+		return nil
+	}
+	out := new(InstanceImpact)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InstanceSummary) DeepCopyInto(out *InstanceSummary) {
+	*out = *in
+	if in.FailingInstances != nil {
+		in, out := &in.FailingInstances, &out.FailingInstances
+		*out = make([]FailingInstance, len(*in))
+		copy(*out, *in)
+	}
+	if in.Digests != nil {
+		in, out := &in.Digests, &out.Digests
+		*out = make([]DigestCount, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InstanceSummary.
+func (in *InstanceSummary) DeepCopy() *InstanceSummary {
+	if in == nil {
+		return nil
+	}
+	out := new(InstanceSummary)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LocalObjectReference) DeepCopyInto(out *LocalObjectReference) {
 	*out = *in
@@ -180,9 +421,30 @@ func (in *ManagedResource) DeepCopy() *ManagedResource {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ModuleChannel) DeepCopyInto(out *ModuleChannel) {
+	*out = *in
+	in.CueRef.DeepCopyInto(&out.CueRef)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ModuleChannel.
+func (in *ModuleChannel) DeepCopy() *ModuleChannel {
+	if in == nil {
+		return nil
+	}
+	out := new(ModuleChannel)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NodeExecutionState) DeepCopyInto(out *NodeExecutionState) {
 	*out = *in
+	if in.Conflicts != nil {
+		in, out := &in.Conflicts, &out.Conflicts
+		*out = make([]FieldConflict, len(*in))
+		copy(*out, *in)
+	}
 	if in.LastTransitionTime != nil {
 		in, out := &in.LastTransitionTime, &out.LastTransitionTime
 		*out = (*in).DeepCopy()
@@ -236,6 +498,85 @@ func (in *ObjectReference) DeepCopy() *ObjectReference {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlatformConfig) DeepCopyInto(out *PlatformConfig) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlatformConfig.
+func (in *PlatformConfig) DeepCopy() *PlatformConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(PlatformConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PlatformConfig) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlatformConfigList) DeepCopyInto(out *PlatformConfigList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]PlatformConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlatformConfigList.
+func (in *PlatformConfigList) DeepCopy() *PlatformConfigList {
+	if in == nil {
+		return nil
+	}
+	out := new(PlatformConfigList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PlatformConfigList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlatformConfigSpec) DeepCopyInto(out *PlatformConfigSpec) {
+	*out = *in
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	in.Values.DeepCopyInto(&out.Values)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlatformConfigSpec.
+func (in *PlatformConfigSpec) DeepCopy() *PlatformConfigSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PlatformConfigSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PolicyViolation) DeepCopyInto(out *PolicyViolation) {
 	*out = *in
@@ -273,6 +614,10 @@ func (in *ResolvedCueReference) DeepCopyInto(out *ResolvedCueReference) {
 		in, out := &in.FetchedAt, &out.FetchedAt
 		*out = (*in).DeepCopy()
 	}
+	if in.CheckedAt != nil {
+		in, out := &in.CheckedAt, &out.CheckedAt
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResolvedCueReference.
@@ -356,6 +701,11 @@ func (in *ResourceGraphSpec) DeepCopyInto(out *ResourceGraphSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.CompressedNodes != nil {
+		in, out := &in.CompressedNodes, &out.CompressedNodes
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
 	if in.Violations != nil {
 		in, out := &in.Violations, &out.Violations
 		*out = make([]PolicyViolation, len(*in))
@@ -366,6 +716,16 @@ func (in *ResourceGraphSpec) DeepCopyInto(out *ResourceGraphSpec) {
 		*out = new(AdoptSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Rollback != nil {
+		in, out := &in.Rollback, &out.Rollback
+		*out = new(RollbackSpec)
+		**out = **in
+	}
+	if in.SensitiveValuesRef != nil {
+		in, out := &in.SensitiveValuesRef, &out.SensitiveValuesRef
+		*out = new(LocalObjectReference)
+		**out = **in
+	}
 	in.RenderedAt.DeepCopyInto(&out.RenderedAt)
 }
 
@@ -420,7 +780,7 @@ func (in *ResourceGraphStatus) DeepCopy() *ResourceGraphStatus {
 func (in *ResourceNode) DeepCopyInto(out *ResourceNode) {
 	*out = *in
 	in.Object.DeepCopyInto(&out.Object)
-	out.ApplyPolicy = in.ApplyPolicy
+	in.ApplyPolicy.DeepCopyInto(&out.ApplyPolicy)
 	if in.DependsOn != nil {
 		in, out := &in.DependsOn, &out.DependsOn
 		*out = make([]string, len(*in))
@@ -431,6 +791,21 @@ func (in *ResourceNode) DeepCopyInto(out *ResourceNode) {
 		*out = make([]ReadinessPredicate, len(*in))
 		copy(*out, *in)
 	}
+	if in.Hook != nil {
+		in, out := &in.Hook, &out.Hook
+		*out = new(Hook)
+		**out = **in
+	}
+	if in.Generate != nil {
+		in, out := &in.Generate, &out.Generate
+		*out = new(GeneratedSecret)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SensitiveFields != nil {
+		in, out := &in.SensitiveFields, &out.SensitiveFields
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceNode.
@@ -443,6 +818,116 @@ func (in *ResourceNode) DeepCopy() *ResourceNode {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RollbackSpec) DeepCopyInto(out *RollbackSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RollbackSpec.
+func (in *RollbackSpec) DeepCopy() *RollbackSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RollbackSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RolloutStatus) DeepCopyInto(out *RolloutStatus) {
+	*out = *in
+	in.From.DeepCopyInto(&out.From)
+	if in.Batch != nil {
+		in, out := &in.Batch, &out.Batch
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.BatchStartedAt != nil {
+		in, out := &in.BatchStartedAt, &out.BatchStartedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.BatchReadyAt != nil {
+		in, out := &in.BatchReadyAt, &out.BatchReadyAt
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RolloutStatus.
+func (in *RolloutStatus) DeepCopy() *RolloutStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RolloutStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RolloutStrategy) DeepCopyInto(out *RolloutStrategy) {
+	*out = *in
+	if in.BatchSize != nil {
+		in, out := &in.BatchSize, &out.BatchSize
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	if in.PauseBetweenBatches != nil {
+		in, out := &in.PauseBetweenBatches, &out.PauseBetweenBatches
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.ProgressDeadline != nil {
+		in, out := &in.ProgressDeadline, &out.ProgressDeadline
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RolloutStrategy.
+func (in *RolloutStrategy) DeepCopy() *RolloutStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(RolloutStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SchemaChange) DeepCopyInto(out *SchemaChange) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SchemaChange.
+func (in *SchemaChange) DeepCopy() *SchemaChange {
+	if in == nil {
+		return nil
+	}
+	out := new(SchemaChange)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SchemaCompatibility) DeepCopyInto(out *SchemaCompatibility) {
+	*out = *in
+	in.CheckedAt.DeepCopyInto(&out.CheckedAt)
+	if in.Changes != nil {
+		in, out := &in.Changes, &out.Changes
+		*out = make([]SchemaChange, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SchemaCompatibility.
+func (in *SchemaCompatibility) DeepCopy() *SchemaCompatibility {
+	if in == nil {
+		return nil
+	}
+	out := new(SchemaCompatibility)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Transform) DeepCopyInto(out *Transform) {
 	*out = *in
@@ -506,6 +991,13 @@ func (in *TransformList) DeepCopyObject() runtime.Object {
 func (in *TransformSpec) DeepCopyInto(out *TransformSpec) {
 	*out = *in
 	in.CueRef.DeepCopyInto(&out.CueRef)
+	if in.Versions != nil {
+		in, out := &in.Versions, &out.Versions
+		*out = make([]APIVersion, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.ShortNames != nil {
 		in, out := &in.ShortNames, &out.ShortNames
 		*out = make([]string, len(*in))
@@ -523,6 +1015,18 @@ func (in *TransformSpec) DeepCopyInto(out *TransformSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Rollout != nil {
+		in, out := &in.Rollout, &out.Rollout
+		*out = new(RolloutStrategy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Channels != nil {
+		in, out := &in.Channels, &out.Channels
+		*out = make([]ModuleChannel, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TransformSpec.
@@ -553,6 +1057,13 @@ func (in *TransformStatus) DeepCopyInto(out *TransformStatus) {
 		*out = new(ResolvedCueReference)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Channels != nil {
+		in, out := &in.Channels, &out.Channels
+		*out = make([]ChannelStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make([]v1.Condition, len(*in))
@@ -560,6 +1071,26 @@ func (in *TransformStatus) DeepCopyInto(out *TransformStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Instances != nil {
+		in, out := &in.Instances, &out.Instances
+		*out = new(InstanceSummary)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.UpgradeAnalysis != nil {
+		in, out := &in.UpgradeAnalysis, &out.UpgradeAnalysis
+		*out = new(UpgradeAnalysis)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Rollout != nil {
+		in, out := &in.Rollout, &out.Rollout
+		*out = new(RolloutStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SchemaCompatibility != nil {
+		in, out := &in.SchemaCompatibility, &out.SchemaCompatibility
+		*out = new(SchemaCompatibility)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TransformStatus.
@@ -571,3 +1102,41 @@ func (in *TransformStatus) DeepCopy() *TransformStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpdateStrategy) DeepCopyInto(out *UpdateStrategy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpdateStrategy.
+func (in *UpdateStrategy) DeepCopy() *UpdateStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(UpdateStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpgradeAnalysis) DeepCopyInto(out *UpgradeAnalysis) {
+	*out = *in
+	in.AnalyzedAt.DeepCopyInto(&out.AnalyzedAt)
+	if in.Results != nil {
+		in, out := &in.Results, &out.Results
+		*out = make([]InstanceImpact, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpgradeAnalysis.
+func (in *UpgradeAnalysis) DeepCopy() *UpgradeAnalysis {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradeAnalysis)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -19,7 +19,10 @@ package main
 import (
 	"crypto/tls"
 	"flag"
+	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -39,7 +42,9 @@ import (
 	platformv1alpha1 "github.com/chazu/pequod/api/v1alpha1"
 	cuembed "github.com/chazu/pequod/cue"
 	"github.com/chazu/pequod/internal/controller"
+	"github.com/chazu/pequod/pkg/crd"
 	"github.com/chazu/pequod/pkg/platformloader"
+	"github.com/chazu/pequod/pkg/reconcile"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -50,17 +55,24 @@ var (
 
 // Config holds the command-line configuration
 type Config struct {
-	MetricsAddr          string
-	MetricsCertPath      string
-	MetricsCertName      string
-	MetricsCertKey       string
-	WebhookCertPath      string
-	WebhookCertName      string
-	WebhookCertKey       string
-	ProbeAddr            string
-	EnableLeaderElection bool
-	SecureMetrics        bool
-	EnableHTTP2          bool
+	MetricsAddr             string
+	MetricsCertPath         string
+	MetricsCertName         string
+	MetricsCertKey          string
+	WebhookCertPath         string
+	WebhookCertName         string
+	WebhookCertKey          string
+	ConversionService       string
+	ProbeAddr               string
+	EnableLeaderElection    bool
+	SecureMetrics           bool
+	EnableHTTP2             bool
+	RenderBudget            platformloader.RenderBudget
+	RenderWorkers           int
+	ClusterFacts            map[string]string
+	LookupNamespaces        []string
+	LookupKinds             []string
+	PlatformConfigNamespace string
 }
 
 func init() {
@@ -73,7 +85,7 @@ func init() {
 
 // parseFlags parses command-line flags and returns configuration
 func parseFlags() Config {
-	cfg := Config{}
+	cfg := Config{ClusterFacts: map[string]string{}}
 	flag.StringVar(&cfg.MetricsAddr, "metrics-bind-address", "0", "The address the metrics endpoint binds to. "+
 		"Use :8443 for HTTPS or :8080 for HTTP, or leave as 0 to disable the metrics service.")
 	flag.StringVar(&cfg.ProbeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
@@ -85,12 +97,48 @@ func parseFlags() Config {
 	flag.StringVar(&cfg.WebhookCertPath, "webhook-cert-path", "", "The directory that contains the webhook certificate.")
 	flag.StringVar(&cfg.WebhookCertName, "webhook-cert-name", "tls.crt", "The name of the webhook certificate file.")
 	flag.StringVar(&cfg.WebhookCertKey, "webhook-cert-key", "tls.key", "The name of the webhook key file.")
+	flag.StringVar(&cfg.ConversionService, "conversion-webhook-service", "",
+		"The namespace/name of the Service in front of the webhook server. If set, generated CRDs with several "+
+			"versions convert instances with the manager's conversion webhook, trusting ca.crt in --webhook-cert-path.")
 	flag.StringVar(&cfg.MetricsCertPath, "metrics-cert-path", "",
 		"The directory that contains the metrics server certificate.")
 	flag.StringVar(&cfg.MetricsCertName, "metrics-cert-name", "tls.crt", "The name of the metrics server certificate file.")
 	flag.StringVar(&cfg.MetricsCertKey, "metrics-cert-key", "tls.key", "The name of the metrics server key file.")
 	flag.BoolVar(&cfg.EnableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
+	flag.DurationVar(&cfg.RenderBudget.Timeout, "render-timeout", platformloader.DefaultRenderTimeout,
+		"The deadline for compiling a CUE module or rendering an instance. 0 disables the deadline.")
+	flag.Uint64Var(&cfg.RenderBudget.MaxMemoryBytes, "render-memory-limit", platformloader.DefaultRenderMemoryBytes,
+		"The bytes of memory a render worker may use. 0 disables the limit.")
+	flag.IntVar(&cfg.RenderWorkers, "render-workers", 4,
+		"The number of worker processes compiling and rendering CUE modules, and so of renders running at once. "+
+			"0 renders in the manager process, where --render-timeout and --render-memory-limit are not enforced.")
+	flag.IntVar(&cfg.RenderBudget.MaxOutputBytes, "render-max-output", platformloader.DefaultRenderOutputBytes,
+		"The size limit in bytes of a rendered graph. 0 disables the limit.")
+	flag.Func("cluster-fact", "A key=value fact about the cluster passed to CUE modules as input.context.cluster. "+
+		"May be repeated, e.g. --cluster-fact name=prod-eu-1 --cluster-fact region=eu-west-1.", func(value string) error {
+		key, val, ok := strings.Cut(value, "=")
+		if !ok || key == "" {
+			return fmt.Errorf("expected key=value, got %q", value)
+		}
+		cfg.ClusterFacts[key] = val
+		return nil
+	})
+
+	flag.Func("lookup-namespace", "A namespace whose objects any CUE module may read with #Lookups, besides the "+
+		"instance's own namespace. May be repeated.", func(value string) error {
+		cfg.LookupNamespaces = append(cfg.LookupNamespaces, value)
+		return nil
+	})
+	flag.Func("lookup-kind", "A kind, as Kind.group (e.g. ConfigMap or Deployment.apps), that CUE modules may "+
+		"read with #Lookups. May be repeated; defaults to ConfigMap.", func(value string) error {
+		cfg.LookupKinds = append(cfg.LookupKinds, value)
+		return nil
+	})
+
+	flag.StringVar(&cfg.PlatformConfigNamespace, "platform-config-namespace", reconcile.DefaultPlatformConfigNamespace,
+		"The namespace whose PlatformConfigs may select other namespaces with a namespaceSelector. "+
+			"PlatformConfigs elsewhere only apply to their own namespace.")
 
 	opts := zap.Options{Development: true}
 	opts.BindFlags(flag.CommandLine)
@@ -146,40 +194,90 @@ func newMetricsServerOptions(cfg Config, tlsOpts []func(*tls.Config)) metricsser
 	return opts
 }
 
+// setupConversionWebhook registers the conversion webhook of generated CRDs
+// with the manager's webhook server, and returns where the CRDs find it.
+// Returns nil if no conversion webhook Service is configured.
+func setupConversionWebhook(mgr ctrl.Manager, cfg Config, renderer *platformloader.Renderer) (*crd.ConversionWebhook, error) {
+	if cfg.ConversionService == "" {
+		return nil, nil
+	}
+	namespace, name, ok := strings.Cut(cfg.ConversionService, "/")
+	if !ok || namespace == "" || name == "" {
+		return nil, fmt.Errorf("expected --conversion-webhook-service as namespace/name, got %q", cfg.ConversionService)
+	}
+	caBundle, err := os.ReadFile(filepath.Join(cfg.WebhookCertPath, "ca.crt"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the webhook CA: %w", err)
+	}
+
+	mgr.GetWebhookServer().Register(crd.ConversionPath, reconcile.NewConversionHandler(mgr.GetClient(), renderer))
+	setupLog.Info("Serving the conversion webhook of generated CRDs", "service", cfg.ConversionService)
+	return &crd.ConversionWebhook{
+		ServiceName:      name,
+		ServiceNamespace: namespace,
+		CABundle:         caBundle,
+	}, nil
+}
+
 // setupControllers sets up all controllers with the manager
-func setupControllers(mgr ctrl.Manager) error {
+func setupControllers(mgr ctrl.Manager, cfg Config) error {
+	// Setup platform loader with K8s client and embedded CUE modules
+	loader := platformloader.NewLoaderWithConfig(platformloader.LoaderConfig{
+		K8sClient:        mgr.GetClient(),
+		APIReader:        mgr.GetAPIReader(),
+		EmbeddedFS:       cuembed.PlatformFS,
+		EmbeddedRootDir:  cuembed.PlatformDir,
+		Budget:           &cfg.RenderBudget,
+		Workers:          &platformloader.WorkerConfig{Count: cfg.RenderWorkers},
+		ClusterFacts:     cfg.ClusterFacts,
+		LookupNamespaces: cfg.LookupNamespaces,
+		LookupKinds:      cfg.LookupKinds,
+	})
+	renderer := platformloader.NewRenderer(loader)
+
 	// Setup ResourceGraph controller (executes rendered graphs)
 	if err := (&controller.ResourceGraphReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:  mgr.GetClient(),
+		Scheme:  mgr.GetScheme(),
+		Outputs: reconcile.NewOutputsProjector(mgr.GetClient(), renderer),
 	}).SetupWithManager(mgr); err != nil {
 		return err
 	}
 
-	// Setup platform loader with K8s client and embedded CUE modules
-	loader := platformloader.NewLoaderWithConfig(platformloader.LoaderConfig{
-		K8sClient:       mgr.GetClient(),
-		EmbeddedFS:      cuembed.PlatformFS,
-		EmbeddedRootDir: cuembed.PlatformDir,
-	})
-	renderer := platformloader.NewRenderer(loader)
+	conversion, err := setupConversionWebhook(mgr, cfg, renderer)
+	if err != nil {
+		return err
+	}
+
+	// Instance health observed by the instance controller is rolled up
+	// into Transform status
+	instanceHealth := reconcile.NewInstanceHealthIndex()
+
+	// Upgrade analysis renders instances with the same PlatformConfigs
+	upgradeAnalyzer := reconcile.NewUpgradeAnalyzer(mgr.GetClient(), mgr.GetScheme(), renderer)
+	upgradeAnalyzer.SetPlatformConfigNamespace(cfg.PlatformConfigNamespace)
 
 	// Setup Transform controller (generates CRDs from Transform definitions)
 	if err := (&controller.TransformReconciler{
-		Client:         mgr.GetClient(),
-		Scheme:         mgr.GetScheme(),
-		PlatformLoader: loader,
-		Recorder:       mgr.GetEventRecorderFor("transform-controller"),
+		Client:            mgr.GetClient(),
+		Scheme:            mgr.GetScheme(),
+		PlatformLoader:    loader,
+		Recorder:          mgr.GetEventRecorderFor("transform-controller"),
+		InstanceHealth:    instanceHealth,
+		UpgradeAnalyzer:   upgradeAnalyzer,
+		ConversionWebhook: conversion,
 	}).SetupWithManager(mgr); err != nil {
 		return err
 	}
 
 	// Setup Platform Instance controller (watches generated CRDs and creates ResourceGraphs)
 	if err := (&controller.PlatformInstanceReconciler{
-		Client:   mgr.GetClient(),
-		Scheme:   mgr.GetScheme(),
-		Recorder: mgr.GetEventRecorderFor("platforminstance-controller"),
-		Renderer: renderer,
+		Client:                  mgr.GetClient(),
+		Scheme:                  mgr.GetScheme(),
+		Recorder:                mgr.GetEventRecorderFor("platforminstance-controller"),
+		Renderer:                renderer,
+		InstanceHealth:          instanceHealth,
+		PlatformConfigNamespace: cfg.PlatformConfigNamespace,
 	}).SetupWithManager(mgr); err != nil {
 		return err
 	}
@@ -188,6 +286,15 @@ func setupControllers(mgr ctrl.Manager) error {
 }
 
 func main() {
+	// The manager starts itself again as render workers
+	if platformloader.IsRenderWorker() {
+		if err := platformloader.ServeRenderWorker(os.Stdin, os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
 	cfg := parseFlags()
 	tlsOpts := getTLSOptions(cfg.EnableHTTP2)
 
@@ -204,7 +311,7 @@ func main() {
 		os.Exit(1)
 	}
 
-	if err := setupControllers(mgr); err != nil {
+	if err := setupControllers(mgr, cfg); err != nil {
 		setupLog.Error(err, "unable to setup controllers")
 		os.Exit(1)
 	}
@@ -18,6 +18,30 @@ package webservice
 	applyPolicy: #ApplyPolicy
 	dependsOn: [...string]
 	readyWhen: [...#ReadinessPredicate]
+	// Runs a Job once per render instead of applying it with the other nodes
+	hook?: #Hook
+	// Generates the values of a Secret node once instead of rendering them
+	generate?: #GeneratedSecret
+}
+
+// #Hook makes a Job node a lifecycle hook
+#Hook: {
+	phase:          "PreApply" | "PostApply" | "PreDelete"
+	cleanupPolicy?: *"BeforeRun" | "OnSuccess" | "OnCompletion"
+}
+
+// #GeneratedSecret lists the values generated for a Secret node
+#GeneratedSecret: {
+	keys: [...#GeneratedKey] & [_, ...]
+}
+
+// #GeneratedKey defines the value generated for one Secret data key
+#GeneratedKey: {
+	name:     string
+	type:     *"Random" | "RSA" | "ECDSA" | "Ed25519"
+	length?:  int & >=1 & <=1024
+	charset?: "Alphanumeric" | "Numeric" | "Hex" | "Printable"
+	bits?:    2048 | 3072 | 4096
 }
 
 // #ApplyPolicy defines how a resource should be applied
@@ -25,11 +49,23 @@ package webservice
 	mode:           *"Apply" | "Create" | "Adopt"
 	conflictPolicy: *"Error" | "Force"
 	fieldManager?:  string
+	// Field paths owned by other controllers, e.g. ["spec.replicas"]
+	ignoreFields?: [...string]
+	// How to handle changes to immutable fields
+	updateStrategy?: {
+		type:               *"InPlace" | "Recreate"
+		propagationPolicy?: "Foreground" | "Background" | "Orphan"
+	}
+	// Conflicts that may be forced when conflictPolicy is "Error"
+	conflictResolution?: {
+		forceFields?: [...string]
+		forceManagers?: [...string]
+	}
 }
 
 // #ReadinessPredicate defines when a resource is ready
 #ReadinessPredicate: {
-	type: "ConditionMatch" | "DeploymentAvailable" | "Exists"
+	type: "ConditionMatch" | "DeploymentAvailable" | "Exists" | "InstanceReady"
 	// For ConditionMatch
 	conditionType?:   string
 	conditionStatus?: string
@@ -18,17 +18,22 @@ package controller
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"sync"
 
+	corev1 "k8s.io/api/core/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/source"
@@ -47,6 +52,15 @@ type PlatformInstanceReconciler struct {
 	Recorder record.EventRecorder
 	Renderer *platformloader.Renderer
 
+	// InstanceHealth, if set, is kept up to date with the status of every
+	// watched instance
+	InstanceHealth *reconcile.InstanceHealthIndex
+
+	// PlatformConfigNamespace is the namespace whose PlatformConfigs may
+	// select other namespaces. Defaults to
+	// reconcile.DefaultPlatformConfigNamespace.
+	PlatformConfigNamespace string
+
 	// handler contains the reconciliation logic
 	handler *reconcile.InstanceHandlers
 
@@ -59,6 +73,12 @@ type PlatformInstanceReconciler struct {
 	instanceGVKIndex map[types.NamespacedName]schema.GroupVersionKind
 	indexMutex       sync.RWMutex
 
+	// moduleDigests tracks the module digest and rollout batch each
+	// Transform was last seen with, so its instances are rendered again when
+	// either changes
+	moduleDigests map[schema.GroupVersionKind]string
+	digestMutex   sync.Mutex
+
 	// controller is the underlying controller for dynamic watch management
 	ctrl controller.Controller
 
@@ -70,6 +90,8 @@ type PlatformInstanceReconciler struct {
 // +kubebuilder:rbac:groups=pequod.io,resources=resourcegraphs,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=pequod.io,resources=resourcegraphs/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=pequod.io,resources=transforms,verbs=get;list;watch
+// +kubebuilder:rbac:groups=platform.platform.example.com,resources=platformconfigs,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
 
 // Reconcile handles platform instance resources (e.g., WebService instances)
 func (r *PlatformInstanceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -153,6 +175,7 @@ func (r *PlatformInstanceReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	r.mgr = mgr
 	r.watchedGVKs = make(map[schema.GroupVersionKind]bool)
 	r.instanceGVKIndex = make(map[types.NamespacedName]schema.GroupVersionKind)
+	r.moduleDigests = make(map[schema.GroupVersionKind]string)
 
 	// Initialize the handler
 	r.handler = reconcile.NewInstanceHandlers(
@@ -161,6 +184,10 @@ func (r *PlatformInstanceReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		r.Recorder,
 		r.Renderer,
 	)
+	if r.PlatformConfigNamespace == "" {
+		r.PlatformConfigNamespace = reconcile.DefaultPlatformConfigNamespace
+	}
+	r.handler.SetPlatformConfigNamespace(r.PlatformConfigNamespace)
 
 	// Build the controller with a watch on Transforms
 	// When a Transform's status is updated with a GeneratedCRD, we add a watch for that CRD type
@@ -171,6 +198,11 @@ func (r *PlatformInstanceReconciler) SetupWithManager(mgr ctrl.Manager) error {
 			&platformv1alpha1.Transform{},
 			handler.EnqueueRequestsFromMapFunc(r.handleTransformChange),
 		).
+		// Re-render instances in the namespaces a PlatformConfig selects
+		Watches(
+			&platformv1alpha1.PlatformConfig{},
+			handler.EnqueueRequestsFromMapFunc(r.handlePlatformConfigChange),
+		).
 		Build(r)
 	if err != nil {
 		return err
@@ -243,7 +275,7 @@ func (r *PlatformInstanceReconciler) handleTransformChange(ctx context.Context,
 	r.watchMutex.RUnlock()
 
 	if watching {
-		return nil
+		return r.moduleChanged(ctx, gvk, tf)
 	}
 
 	// Ensure the CRD is established before adding a watch
@@ -265,12 +297,113 @@ func (r *PlatformInstanceReconciler) handleTransformChange(ctx context.Context,
 	r.watchMutex.Unlock()
 
 	logger.Info("Added watch for platform type", "gvk", gvk.String(), "transform", tf.Name)
+	r.moduleChanged(ctx, gvk, tf)
 
 	// Return nil - we don't need to reconcile anything specific.
 	// The new watch will trigger reconciles for existing instances of this CRD.
 	return nil
 }
 
+// moduleChanged enqueues the instances of gvk that render a new module
+// when the Transform's module moved to a new digest since it was last seen:
+// every known instance, or during a rollout the instances of a new batch.
+// A change to the Transform's channels enqueues every known instance.
+func (r *PlatformInstanceReconciler) moduleChanged(ctx context.Context, gvk schema.GroupVersionKind, tf *platformv1alpha1.Transform) []ctrl.Request {
+	if tf.Status.ResolvedCueRef == nil || tf.Status.ResolvedCueRef.Digest == "" {
+		return nil
+	}
+	digest := tf.Status.ResolvedCueRef.Digest
+	rollout := tf.Status.Rollout
+	seenAs := digest
+	if rollout != nil {
+		seenAs = fmt.Sprintf("%s/%d", digest, rollout.BatchNumber)
+	}
+	channels := make([]string, 0, len(tf.Status.Channels))
+	for _, channel := range tf.Status.Channels {
+		if channel.ResolvedCueRef != nil {
+			channels = append(channels, channel.Name+"="+channel.ResolvedCueRef.Digest)
+		}
+	}
+	seenAs += "|" + strings.Join(channels, ",")
+
+	r.digestMutex.Lock()
+	previous, seen := r.moduleDigests[gvk]
+	r.moduleDigests[gvk] = seenAs
+	r.digestMutex.Unlock()
+	if !seen || previous == seenAs {
+		return nil
+	}
+	_, previousChannels, _ := strings.Cut(previous, "|")
+	_, currentChannels, _ := strings.Cut(seenAs, "|")
+
+	var requests []ctrl.Request
+	if rollout != nil && previousChannels == currentChannels {
+		for _, key := range rollout.Batch {
+			namespace, name, _ := strings.Cut(key, "/")
+			requests = append(requests, ctrl.Request{NamespacedName: types.NamespacedName{Namespace: namespace, Name: name}})
+		}
+	} else {
+		r.indexMutex.RLock()
+		for key, instanceGVK := range r.instanceGVKIndex {
+			if instanceGVK == gvk {
+				requests = append(requests, ctrl.Request{NamespacedName: key})
+			}
+		}
+		r.indexMutex.RUnlock()
+	}
+
+	logf.FromContext(ctx).WithName("transform-watch").Info("Module changed, re-rendering instances",
+		"gvk", gvk.String(), "from", previous, "to", seenAs, "instances", len(requests))
+	return requests
+}
+
+// handlePlatformConfigChange enqueues every known instance in a namespace
+// the PlatformConfig selects. It is called for both the old and new object
+// of an update, so namespaces that are no longer selected are re-rendered too.
+func (r *PlatformInstanceReconciler) handlePlatformConfigChange(ctx context.Context, obj client.Object) []ctrl.Request {
+	logger := logf.FromContext(ctx).WithName("platformconfig-watch")
+
+	pc, ok := obj.(*platformv1alpha1.PlatformConfig)
+	if !ok {
+		return nil
+	}
+
+	r.indexMutex.RLock()
+	keys := make([]types.NamespacedName, 0, len(r.instanceGVKIndex))
+	for key := range r.instanceGVKIndex {
+		keys = append(keys, key)
+	}
+	r.indexMutex.RUnlock()
+
+	// Check each namespace once
+	selected := make(map[string]bool)
+	var requests []ctrl.Request
+	for _, key := range keys {
+		match, checked := selected[key.Namespace]
+		if !checked {
+			ns := &corev1.Namespace{}
+			if err := r.Get(ctx, types.NamespacedName{Name: key.Namespace}, ns); err != nil {
+				logger.V(1).Info("Failed to get namespace", "namespace", key.Namespace, "error", err.Error())
+				continue
+			}
+			var err error
+			if match, err = reconcile.PlatformConfigSelects(pc, ns, r.PlatformConfigNamespace); err != nil {
+				// Renders skip it, so only its own namespace is affected
+				logger.Info("Invalid PlatformConfig", "platformConfig", client.ObjectKeyFromObject(pc), "error", err.Error())
+				match = pc.Namespace == key.Namespace
+			}
+			selected[key.Namespace] = match
+		}
+		if match {
+			requests = append(requests, ctrl.Request{NamespacedName: key})
+		}
+	}
+
+	logger.V(1).Info("PlatformConfig changed, re-rendering instances",
+		"platformConfig", client.ObjectKeyFromObject(pc), "instances", len(requests))
+	return requests
+}
+
 // discoverAndWatchPlatformTypes finds all Transforms with generated CRDs and adds watches
 func (r *PlatformInstanceReconciler) discoverAndWatchPlatformTypes(ctx context.Context) {
 	logger := logf.FromContext(ctx).WithName("watch-discovery")
@@ -338,6 +471,13 @@ func (r *PlatformInstanceReconciler) addWatch(ctx context.Context, gvk schema.Gr
 	u := &unstructured.Unstructured{}
 	u.SetGroupVersionKind(gvk)
 
+	// Record instance health from the same informer, without enqueueing
+	if r.InstanceHealth != nil {
+		if err := r.ctrl.Watch(source.Kind(r.mgr.GetCache(), u, r.instanceHealthHandler())); err != nil {
+			return err
+		}
+	}
+
 	// Add the watch using source.Kind
 	// We use TypedEnqueueRequestsFromMapFunc for unstructured objects
 	return r.ctrl.Watch(
@@ -354,13 +494,33 @@ func (r *PlatformInstanceReconciler) addWatch(ctx context.Context, gvk schema.Gr
 					r.instanceGVKIndex[key] = objGVK
 					r.indexMutex.Unlock()
 
-					return []ctrl.Request{{NamespacedName: key}}
+					// Instances referencing this one consume its outputs
+					requests := []ctrl.Request{{NamespacedName: key}}
+					for _, dependent := range r.handler.Dependents(obj) {
+						requests = append(requests, ctrl.Request{NamespacedName: dependent})
+					}
+					return requests
 				},
 			),
 		),
 	)
 }
 
+// instanceHealthHandler feeds instance events to the InstanceHealth index
+func (r *PlatformInstanceReconciler) instanceHealthHandler() handler.TypedEventHandler[*unstructured.Unstructured, ctrl.Request] {
+	return handler.TypedFuncs[*unstructured.Unstructured, ctrl.Request]{
+		CreateFunc: func(_ context.Context, e event.TypedCreateEvent[*unstructured.Unstructured], _ workqueue.TypedRateLimitingInterface[ctrl.Request]) {
+			r.InstanceHealth.Observe(e.Object)
+		},
+		UpdateFunc: func(_ context.Context, e event.TypedUpdateEvent[*unstructured.Unstructured], _ workqueue.TypedRateLimitingInterface[ctrl.Request]) {
+			r.InstanceHealth.Observe(e.ObjectNew)
+		},
+		DeleteFunc: func(_ context.Context, e event.TypedDeleteEvent[*unstructured.Unstructured], _ workqueue.TypedRateLimitingInterface[ctrl.Request]) {
+			r.InstanceHealth.Forget(e.Object)
+		},
+	}
+}
+
 // RemoveWatch removes a GVK from the watched set.
 // Note: This only removes the GVK from our tracking map. Due to controller-runtime
 // limitations, the underlying informer watch cannot be dynamically removed.
@@ -19,6 +19,8 @@ package controller
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
@@ -37,7 +39,9 @@ import (
 	platformv1alpha1 "github.com/chazu/pequod/api/v1alpha1"
 	"github.com/chazu/pequod/pkg/apply"
 	"github.com/chazu/pequod/pkg/graph"
+	"github.com/chazu/pequod/pkg/preflight"
 	"github.com/chazu/pequod/pkg/readiness"
+	"github.com/chazu/pequod/pkg/reconcile"
 )
 
 const (
@@ -49,20 +53,30 @@ const (
 	PhaseCompleted = "Completed"
 	PhaseFailed    = "Failed"
 
+	// PhaseRolledBack marks a failed graph whose previous revision was restored
+	PhaseRolledBack = "RolledBack"
+
 	// Condition type constants
-	ConditionTypeReady  = "Ready"
-	ConditionTypeFailed = "Failed"
+	ConditionTypeReady           = "Ready"
+	ConditionTypeFailed          = "Failed"
+	ConditionTypePreflightFailed = "PreflightFailed"
+	ConditionTypeRolledBack      = "RolledBack"
 )
 
 // ResourceGraphReconciler reconciles a ResourceGraph object
 type ResourceGraphReconciler struct {
 	client.Client
-	Scheme   *runtime.Scheme
-	Applier  *apply.Applier
-	Adopter  *apply.Adopter
-	Checker  *readiness.Checker
-	Executor *graph.Executor
-	Recorder record.EventRecorder
+	Scheme    *runtime.Scheme
+	Applier   *apply.Applier
+	Adopter   *apply.Adopter
+	Checker   *readiness.Checker
+	Preflight *preflight.Checker
+	Executor  *graph.Executor
+	Recorder  record.EventRecorder
+
+	// Outputs writes module outputs to instance status when a graph
+	// completes. Optional.
+	Outputs *reconcile.OutputsProjector
 
 	// RequeueInterval is the interval to requeue when waiting for readiness
 	// Default: 5 seconds
@@ -72,10 +86,19 @@ type ResourceGraphReconciler struct {
 // DefaultRequeueInterval is the default interval for requeuing
 const DefaultRequeueInterval = 5 * time.Second
 
+// PreflightRequeueInterval is how often a graph that failed preflight checks
+// is checked again, since missing CRDs, RBAC or namespaces are fixed out of band
+const PreflightRequeueInterval = 30 * time.Second
+
+// HookRetryInterval is how often a deletion blocked by a failed PreDelete
+// hook is retried
+const HookRetryInterval = 30 * time.Second
+
 // +kubebuilder:rbac:groups=platform.platform.example.com,resources=resourcegraphs,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=platform.platform.example.com,resources=resourcegraphs/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=platform.platform.example.com,resources=resourcegraphs/finalizers,verbs=update
 // +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups=authorization.k8s.io,resources=selfsubjectaccessreviews,verbs=create
 // +kubebuilder:rbac:groups="",resources=*,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=apps,resources=*,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=batch,resources=*,verbs=get;list;watch;create;update;patch;delete
@@ -127,7 +150,7 @@ func (r *ResourceGraphReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 	}
 
 	// Check if already completed
-	if rg.Status.Phase == PhaseCompleted || rg.Status.Phase == PhaseFailed {
+	if rg.Status.Phase == PhaseCompleted || rg.Status.Phase == PhaseFailed || rg.Status.Phase == PhaseRolledBack {
 		// Allow re-execution if the spec has changed (generation mismatch)
 		if rg.Status.ObservedGeneration == rg.Generation {
 			logger.Info("ResourceGraph already in terminal state", "phase", rg.Status.Phase)
@@ -159,7 +182,12 @@ func (r *ResourceGraphReconciler) executeGraph(ctx context.Context, rg *platform
 	logger := logf.FromContext(ctx)
 
 	// Convert ResourceGraph to internal Graph type
-	internalGraph, err := r.convertToInternalGraph(rg)
+	internalGraph, err := r.convertToInternalGraph(ctx, rg)
+	if errors.IsNotFound(err) {
+		// The instance controller stores the values right after the graph
+		logger.Info("Waiting for sensitive values", "secret", rg.Spec.SensitiveValuesRef.Name)
+		return ctrl.Result{RequeueAfter: DefaultRequeueInterval}, nil
+	}
 	if err != nil {
 		logger.Error(err, "Failed to convert ResourceGraph to internal Graph")
 		r.recordEvent(rg, "Warning", "ConversionFailed", fmt.Sprintf("Failed to convert graph: %v", err))
@@ -183,8 +211,22 @@ func (r *ResourceGraphReconciler) executeGraph(ctx context.Context, rg *platform
 		return r.updateStatusFailed(ctx, rg, fmt.Sprintf("Failed to build DAG: %v", err))
 	}
 
+	// Verify kinds, permissions and namespaces before applying anything
+	if r.Preflight != nil {
+		report, err := r.Preflight.Check(ctx, internalGraph)
+		if err != nil {
+			logger.Error(err, "Preflight checks could not run")
+			return ctrl.Result{}, err
+		}
+		if !report.Passed() {
+			logger.Info("Preflight checks failed", "problems", len(report.Problems))
+			r.recordEvent(rg, "Warning", "PreflightFailed", report.Summary())
+			return r.updateStatusPreflightFailed(ctx, rg, report)
+		}
+	}
+
 	// Update status to Executing
-	if err := r.updateStatusExecuting(ctx, rg); err != nil {
+	if err := r.updateStatusExecuting(ctx, rg, dag); err != nil {
 		logger.Error(err, "Failed to update status to Executing")
 		// Requeue to retry status update
 		return ctrl.Result{Requeue: true}, err
@@ -214,22 +256,57 @@ func (r *ResourceGraphReconciler) executeGraph(ctx context.Context, rg *platform
 	// Execute the DAG with timing
 	dagStartTime := time.Now()
 	logger.Info("Executing DAG", "nodeCount", len(internalGraph.Nodes))
-	executionState, err := r.Executor.Execute(ctx, dag)
+	execCtx := graph.WithRedactor(ctx, internalGraph.Redactor())
+	execCtx = apply.WithRecreateNotifier(execCtx, func(obj *unstructured.Unstructured, reason string) {
+		r.recordEvent(rg, "Normal", "ResourceRecreated",
+			fmt.Sprintf("Recreated %s %s/%s: %s", obj.GetKind(), obj.GetNamespace(), obj.GetName(), reason))
+	})
+	execCtx = graph.WithCompletedHooks(execCtx, completedHooks(rg, internalGraph)...)
+	executionState, err := r.Executor.Execute(execCtx, dag)
 	dagDuration := time.Since(dagStartTime).Seconds()
 
 	if err != nil {
 		logger.Error(err, "DAG execution failed")
 		r.recordEvent(rg, "Warning", "ExecutionFailed", fmt.Sprintf("DAG execution failed: %v", err))
 		RecordDAGExecution(rg.Namespace, "failed", dagDuration)
-		return r.updateStatusFromExecution(ctx, rg, executionState, false)
+		return r.finishExecution(ctx, rg, internalGraph, executionState, false)
 	}
 
 	// Record success event and metrics
 	r.recordEvent(rg, "Normal", "ExecutionCompleted", fmt.Sprintf("Successfully applied %d resources", len(internalGraph.Nodes)))
 	RecordDAGExecution(rg.Namespace, "success", dagDuration)
 
-	// Update status from execution state
-	return r.updateStatusFromExecution(ctx, rg, executionState, true)
+	return r.finishExecution(ctx, rg, internalGraph, executionState, true)
+}
+
+// finishExecution records the execution result, projects the instance's
+// outputs once the graph completes and, for graphs with a rollback target,
+// restores the previous revision on failure or releases it on success
+func (r *ResourceGraphReconciler) finishExecution(
+	ctx context.Context,
+	rg *platformv1alpha1.ResourceGraph,
+	internalGraph *graph.Graph,
+	state *graph.ExecutionState,
+	success bool,
+) (ctrl.Result, error) {
+	result, err := r.updateStatusFromExecution(ctx, rg, state, success)
+	if err == nil && success && state.IsComplete() && !state.HasErrors() {
+		r.projectOutputs(ctx, rg, internalGraph)
+	}
+	if err != nil || rg.Spec.Rollback == nil {
+		return result, err
+	}
+
+	if !success || state.HasErrors() {
+		return r.rollback(ctx, rg, internalGraph, state)
+	}
+	if state.IsComplete() {
+		logger := logf.FromContext(ctx)
+		if err := r.releasePreviousRevision(ctx, rg); err != nil {
+			logger.Error(err, "Failed to delete previous revision", "previousRevision", rg.Spec.Rollback.PreviousRevision)
+		}
+	}
+	return result, nil
 }
 
 // runAdoption executes the adoption phase
@@ -331,6 +408,17 @@ func (r *ResourceGraphReconciler) updateStatusWithAdoption(
 	return r.Status().Update(ctx, latest)
 }
 
+// recordConflictEvent records a warning listing the fields another field
+// manager owns, so the conflicting controller can be identified from events
+func (r *ResourceGraphReconciler) recordConflictEvent(rg *platformv1alpha1.ResourceGraph, nodeID string, conflicts []graph.FieldConflict) {
+	fields := make([]string, 0, len(conflicts))
+	for _, c := range conflicts {
+		fields = append(fields, c.String())
+	}
+	r.recordEvent(rg, "Warning", "FieldConflict",
+		fmt.Sprintf("Node %s conflicts with other field managers: %s", nodeID, strings.Join(fields, ", ")))
+}
+
 // recordEvent records an event if the recorder is available
 func (r *ResourceGraphReconciler) recordEvent(rg *platformv1alpha1.ResourceGraph, eventType, reason, message string) {
 	if r.Recorder != nil {
@@ -343,11 +431,18 @@ func (r *ResourceGraphReconciler) handleDeletion(ctx context.Context, rg *platfo
 	logger := logf.FromContext(ctx)
 	logger.Info("Handling ResourceGraph deletion")
 
-	// ResourceGraph doesn't own the resources it applies
-	// Those are owned by the source (WebService, etc.)
-	// So we just remove the finalizer
+	// Applied resources are owned by the ResourceGraph and garbage collected
+	// once it is gone, so PreDelete hooks run while the finalizer holds it
 
 	if controllerutil.ContainsFinalizer(rg, resourceGraphFinalizer) {
+		done, err := r.runPreDeleteHooks(ctx, rg)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if !done {
+			return ctrl.Result{RequeueAfter: HookRetryInterval}, nil
+		}
+
 		controllerutil.RemoveFinalizer(rg, resourceGraphFinalizer)
 		if err := r.Update(ctx, rg); err != nil {
 			logger.Error(err, "Failed to remove finalizer")
@@ -370,8 +465,13 @@ func (r *ResourceGraphReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	if r.Checker == nil {
 		r.Checker = readiness.NewChecker(r.Client)
 	}
+	if r.Preflight == nil {
+		r.Preflight = preflight.NewChecker(r.Client)
+	}
 	if r.Executor == nil {
-		r.Executor = graph.NewExecutor(r.Applier, r.Checker, r.Client, graph.DefaultExecutorConfig())
+		r.Executor = graph.NewExecutor(r.Applier, r.Checker, r.Client, graph.DefaultExecutorConfig()).
+			WithHookRunner(apply.NewHookRunner(r.Client)).
+			WithSecretGenerator(apply.NewSecretGenerator(r.Client))
 	}
 	if r.Recorder == nil {
 		r.Recorder = mgr.GetEventRecorderFor("resourcegraph-controller")
@@ -394,22 +494,16 @@ func (r *ResourceGraphReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		Complete(r)
 }
 
-// convertToInternalGraph converts a ResourceGraph CR to the internal Graph type
-func (r *ResourceGraphReconciler) convertToInternalGraph(rg *platformv1alpha1.ResourceGraph) (*graph.Graph, error) {
-	nodes := make([]graph.Node, 0, len(rg.Spec.Nodes))
-
-	// Create owner reference for applied resources
-	// Resources will be owned by the ResourceGraph for proper cleanup
-	ownerRef := metav1.OwnerReference{
-		APIVersion:         rg.APIVersion,
-		Kind:               rg.Kind,
-		Name:               rg.Name,
-		UID:                rg.UID,
-		Controller:         ptr(true),
-		BlockOwnerDeletion: ptr(true),
+// convertToInternalGraph converts a ResourceGraph CR to the internal Graph
+// type, restoring the values of sensitive fields
+func (r *ResourceGraphReconciler) convertToInternalGraph(ctx context.Context, rg *platformv1alpha1.ResourceGraph) (*graph.Graph, error) {
+	rgNodes, err := rg.Spec.GetNodes()
+	if err != nil {
+		return nil, err
 	}
+	nodes := make([]graph.Node, 0, len(rgNodes))
 
-	for _, rgNode := range rg.Spec.Nodes {
+	for _, rgNode := range rgNodes {
 		// Decode RawExtension to Unstructured
 		unstructuredObj := &unstructured.Unstructured{}
 		if err := unstructuredObj.UnmarshalJSON(rgNode.Object.Raw); err != nil {
@@ -417,12 +511,13 @@ func (r *ResourceGraphReconciler) convertToInternalGraph(rg *platformv1alpha1.Re
 		}
 
 		// Inject owner reference into the object
-		// This ensures resources are cleaned up when ResourceGraph is deleted
+		// This ensures resources are cleaned up when their owner is deleted
+		ownerRef := reconcile.NodeOwnerReference(rg, &rgNode)
 		existingRefs := unstructuredObj.GetOwnerReferences()
 		// Check if owner reference already exists to avoid duplicates
 		hasOwnerRef := false
 		for _, ref := range existingRefs {
-			if ref.UID == rg.UID {
+			if ref.UID == ownerRef.UID {
 				hasOwnerRef = true
 				break
 			}
@@ -437,6 +532,19 @@ func (r *ResourceGraphReconciler) convertToInternalGraph(rg *platformv1alpha1.Re
 			Mode:           graph.ApplyMode(rgNode.ApplyPolicy.Mode),
 			ConflictPolicy: graph.ConflictPolicy(rgNode.ApplyPolicy.ConflictPolicy),
 			FieldManager:   rgNode.ApplyPolicy.FieldManager,
+			IgnoreFields:   rgNode.ApplyPolicy.IgnoreFields,
+		}
+		if us := rgNode.ApplyPolicy.UpdateStrategy; us != nil {
+			applyPolicy.UpdateStrategy = &graph.UpdateStrategy{
+				Type:              graph.UpdateStrategyType(us.Type),
+				PropagationPolicy: us.PropagationPolicy,
+			}
+		}
+		if cr := rgNode.ApplyPolicy.ConflictResolution; cr != nil {
+			applyPolicy.ConflictResolution = &graph.ConflictResolution{
+				ForceFields:   cr.ForceFields,
+				ForceManagers: cr.ForceManagers,
+			}
 		}
 
 		// Convert ReadyWhen predicates
@@ -452,11 +560,32 @@ func (r *ResourceGraphReconciler) convertToInternalGraph(rg *platformv1alpha1.Re
 
 		// Create internal node with unstructured object
 		node := graph.Node{
-			ID:          rgNode.ID,
-			Object:      *unstructuredObj,
-			ApplyPolicy: applyPolicy,
-			DependsOn:   rgNode.DependsOn,
-			ReadyWhen:   readyWhen,
+			ID:              rgNode.ID,
+			Object:          *unstructuredObj,
+			ApplyPolicy:     applyPolicy,
+			DependsOn:       rgNode.DependsOn,
+			ReadyWhen:       readyWhen,
+			SensitiveFields: rgNode.SensitiveFields,
+			Instance:        rgNode.Instance,
+		}
+		if rgNode.Hook != nil {
+			node.Hook = &graph.Hook{
+				Phase:         graph.HookPhase(rgNode.Hook.Phase),
+				CleanupPolicy: graph.HookCleanupPolicy(rgNode.Hook.CleanupPolicy),
+			}
+		}
+		if rgNode.Generate != nil {
+			keys := make([]graph.GeneratedKey, len(rgNode.Generate.Keys))
+			for i, key := range rgNode.Generate.Keys {
+				keys[i] = graph.GeneratedKey{
+					Name:    key.Name,
+					Type:    graph.GeneratedKeyType(key.Type),
+					Length:  key.Length,
+					Charset: graph.Charset(key.Charset),
+					Bits:    key.Bits,
+				}
+			}
+			node.Generate = &graph.GeneratedSecret{Keys: keys}
 		}
 
 		nodes = append(nodes, node)
@@ -472,7 +601,7 @@ func (r *ResourceGraphReconciler) convertToInternalGraph(rg *platformv1alpha1.Re
 		})
 	}
 
-	return &graph.Graph{
+	g := &graph.Graph{
 		Metadata: graph.GraphMetadata{
 			Name:        rg.Spec.Metadata.Name,
 			Version:     rg.Spec.Metadata.Version,
@@ -481,11 +610,15 @@ func (r *ResourceGraphReconciler) convertToInternalGraph(rg *platformv1alpha1.Re
 		},
 		Nodes:      nodes,
 		Violations: violations,
-	}, nil
+	}
+	if err := r.restoreSensitiveValues(ctx, rg, g); err != nil {
+		return nil, err
+	}
+	return g, nil
 }
 
 // updateStatusExecuting updates the ResourceGraph status to Executing
-func (r *ResourceGraphReconciler) updateStatusExecuting(ctx context.Context, rg *platformv1alpha1.ResourceGraph) error {
+func (r *ResourceGraphReconciler) updateStatusExecuting(ctx context.Context, rg *platformv1alpha1.ResourceGraph, dag *graph.DAG) error {
 	// Re-fetch the object to get the latest resourceVersion to avoid conflicts
 	latest := &platformv1alpha1.ResourceGraph{}
 	if err := r.Get(ctx, client.ObjectKeyFromObject(rg), latest); err != nil {
@@ -501,9 +634,10 @@ func (r *ResourceGraphReconciler) updateStatusExecuting(ctx context.Context, rg
 	if latest.Status.NodeStates == nil {
 		latest.Status.NodeStates = make(map[string]platformv1alpha1.NodeExecutionState)
 	}
-	for _, node := range latest.Spec.Nodes {
-		if _, exists := latest.Status.NodeStates[node.ID]; !exists {
-			latest.Status.NodeStates[node.ID] = platformv1alpha1.NodeExecutionState{
+	// The DAG leaves out PreDelete hooks, which only run on deletion
+	for _, nodeID := range dag.GetOrder() {
+		if _, exists := latest.Status.NodeStates[nodeID]; !exists {
+			latest.Status.NodeStates[nodeID] = platformv1alpha1.NodeExecutionState{
 				Phase:              PhasePending,
 				LastTransitionTime: &now,
 			}
@@ -541,10 +675,40 @@ func (r *ResourceGraphReconciler) updateStatusFailed(ctx context.Context, rg *pl
 		// Requeue to retry status update
 		return ctrl.Result{Requeue: true}, err
 	}
+	r.rollUpInstanceStatus(ctx, latest)
 
 	return ctrl.Result{}, nil
 }
 
+// updateStatusPreflightFailed records every preflight problem on the
+// ResourceGraph. The phase stays Pending so the graph is re-checked once the
+// missing prerequisites are in place.
+func (r *ResourceGraphReconciler) updateStatusPreflightFailed(ctx context.Context, rg *platformv1alpha1.ResourceGraph, report *preflight.Report) (ctrl.Result, error) {
+	latest := &platformv1alpha1.ResourceGraph{}
+	if err := r.Get(ctx, client.ObjectKeyFromObject(rg), latest); err != nil {
+		return ctrl.Result{Requeue: true}, fmt.Errorf("failed to get latest ResourceGraph: %w", err)
+	}
+
+	now := metav1.Now()
+	latest.Status.Phase = PhasePending
+	latest.Status.Conditions = []metav1.Condition{
+		{
+			Type:               ConditionTypePreflightFailed,
+			Status:             metav1.ConditionTrue,
+			LastTransitionTime: now,
+			Reason:             "PreflightChecksFailed",
+			Message:            fmt.Sprintf("%d problem(s) found before applying: %s", len(report.Problems), report.Summary()),
+		},
+	}
+
+	if err := r.Status().Update(ctx, latest); err != nil {
+		return ctrl.Result{Requeue: true}, err
+	}
+	r.rollUpInstanceStatus(ctx, latest)
+
+	return ctrl.Result{RequeueAfter: PreflightRequeueInterval}, nil
+}
+
 // updateStatusFromExecution updates the ResourceGraph status from execution state
 func (r *ResourceGraphReconciler) updateStatusFromExecution(ctx context.Context, rg *platformv1alpha1.ResourceGraph, state *graph.ExecutionState, success bool) (ctrl.Result, error) {
 	// Re-fetch the object to get the latest resourceVersion to avoid conflicts
@@ -577,6 +741,21 @@ func (r *ResourceGraphReconciler) updateStatusFromExecution(ctx context.Context,
 		if nodeStatus.Error != "" {
 			execState.LastError = nodeStatus.Error
 		}
+		if nodeState == graph.NodeStateError && nodeStatus.Terminal {
+			execState.Terminal = true
+			execState.Reason = nodeStatus.Reason
+			execState.Message = fmt.Sprintf("Failed with a terminal error (%s); not retried", nodeStatus.Reason)
+		}
+		for _, c := range nodeStatus.Conflicts {
+			execState.Conflicts = append(execState.Conflicts, platformv1alpha1.FieldConflict{
+				Manager:   c.Manager,
+				Field:     c.Field,
+				Operation: c.Operation,
+			})
+		}
+		if nodeState == graph.NodeStateError && len(nodeStatus.Conflicts) > 0 {
+			r.recordConflictEvent(rg, nodeID, nodeStatus.Conflicts)
+		}
 
 		// Set timestamps based on state
 		if nodeStatus.StartTime != nil {
@@ -590,7 +769,8 @@ func (r *ResourceGraphReconciler) updateStatusFromExecution(ctx context.Context,
 	}
 
 	// Update overall phase
-	if success && state.IsComplete() {
+	terminalNodes := state.GetTerminalNodes()
+	if success && state.IsComplete() && !state.HasErrors() {
 		latest.Status.Phase = PhaseCompleted
 		latest.Status.CompletedAt = &now
 		latest.Status.Conditions = []metav1.Condition{
@@ -602,7 +782,27 @@ func (r *ResourceGraphReconciler) updateStatusFromExecution(ctx context.Context,
 				Message:            "All resources applied successfully",
 			},
 		}
+	} else if success && !state.HasErrors() {
+		waiting := state.GetNodesInState(graph.NodeStateWaitingReady)
+		sort.Strings(waiting)
+		latest.Status.Phase = PhaseExecuting
+		latest.Status.Conditions = []metav1.Condition{
+			{
+				Type:               ConditionTypeReady,
+				Status:             metav1.ConditionFalse,
+				LastTransitionTime: now,
+				Reason:             "WaitingForReadiness",
+				Message:            fmt.Sprintf("Waiting for nodes to become ready: %s", strings.Join(waiting, ", ")),
+			},
+		}
 	} else {
+		reason := "ExecutionFailed"
+		message := "One or more resources failed to apply"
+		if len(terminalNodes) > 0 {
+			reason = "TerminalError"
+			message = fmt.Sprintf("Nodes failed with errors that will not succeed on retry: %s",
+				strings.Join(terminalNodes, ", "))
+		}
 		latest.Status.Phase = PhaseFailed
 		latest.Status.CompletedAt = &now
 		latest.Status.Conditions = []metav1.Condition{
@@ -610,8 +810,8 @@ func (r *ResourceGraphReconciler) updateStatusFromExecution(ctx context.Context,
 				Type:               ConditionTypeFailed,
 				Status:             metav1.ConditionTrue,
 				LastTransitionTime: now,
-				Reason:             "ExecutionFailed",
-				Message:            "One or more resources failed to apply",
+				Reason:             reason,
+				Message:            message,
 			},
 		}
 	}
@@ -622,6 +822,7 @@ func (r *ResourceGraphReconciler) updateStatusFromExecution(ctx context.Context,
 		// Requeue to retry status update
 		return ctrl.Result{Requeue: true}, err
 	}
+	r.rollUpInstanceStatus(ctx, latest)
 
 	// Requeue if not complete to check readiness
 	if !state.IsComplete() {
@@ -28,11 +28,14 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	platformv1alpha1 "github.com/chazu/pequod/api/v1alpha1"
+	"github.com/chazu/pequod/pkg/apply"
+	"github.com/chazu/pequod/pkg/graph"
 )
 
 const (
@@ -305,5 +308,39 @@ var _ = Describe("ResourceGraph Controller", Ordered, func() {
 				return depErr == nil && svcErr == nil
 			}, timeout, interval).Should(BeTrue())
 		})
+
+		It("should keep a field applied before once it is ignored", func() {
+			deploymentName := fmt.Sprintf("test-deploy-ignore-%d", GinkgoRandomSeed())
+			deployment := &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"metadata":   map[string]interface{}{"name": deploymentName, "namespace": namespace},
+				"spec": map[string]interface{}{
+					"replicas": int64(3),
+					"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": deploymentName}},
+					"template": map[string]interface{}{
+						"metadata": map[string]interface{}{"labels": map[string]interface{}{"app": deploymentName}},
+						"spec": map[string]interface{}{
+							"containers": []interface{}{map[string]interface{}{"name": "nginx", "image": "nginx:latest"}},
+						},
+					},
+				},
+			}}
+			defer deleteAndWait(&appsv1.Deployment{}, types.NamespacedName{Name: deploymentName, Namespace: namespace})
+
+			By("Applying the Deployment with its replicas")
+			applier := apply.NewApplier(k8sClient)
+			policy := graph.ApplyPolicy{Mode: graph.ApplyModeApply, FieldManager: "pequod-test"}
+			Expect(applier.Apply(ctx, deployment.DeepCopy(), policy)).To(Succeed())
+
+			By("Applying it again with the replicas ignored")
+			policy.IgnoreFields = []string{"spec.replicas"}
+			Expect(applier.Apply(ctx, deployment.DeepCopy(), policy)).To(Succeed())
+
+			By("Checking that the replicas were not reset to the default")
+			dep := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: deploymentName, Namespace: namespace}, dep)).To(Succeed())
+			Expect(dep.Spec.Replicas).To(HaveValue(Equal(int32(3))))
+		})
 	})
 })
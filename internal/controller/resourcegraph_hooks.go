@@ -0,0 +1,125 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	platformv1alpha1 "github.com/chazu/pequod/api/v1alpha1"
+	"github.com/chazu/pequod/pkg/graph"
+)
+
+// completedHooks returns the hook nodes that already ran to completion for
+// this ResourceGraph. The name of a ResourceGraph includes its render hash,
+// so a Ready hook in its status ran for the current render.
+func completedHooks(rg *platformv1alpha1.ResourceGraph, g *graph.Graph) []string {
+	var ids []string
+	for _, node := range g.Nodes {
+		if node.Hook == nil {
+			continue
+		}
+		if state, ok := rg.Status.NodeStates[node.ID]; ok && state.Phase == string(graph.NodeStateReady) {
+			ids = append(ids, node.ID)
+		}
+	}
+	return ids
+}
+
+// runPreDeleteHooks runs the PreDelete hooks of a ResourceGraph whose
+// instance is being deleted. It returns false if a hook failed, in which
+// case deletion waits until the hook succeeds.
+func (r *ResourceGraphReconciler) runPreDeleteHooks(ctx context.Context, rg *platformv1alpha1.ResourceGraph) (bool, error) {
+	logger := logf.FromContext(ctx)
+
+	internalGraph, err := r.convertToInternalGraph(ctx, rg)
+	if err != nil {
+		return false, err
+	}
+	dag, err := graph.BuildDeletionDAG(internalGraph)
+	if err != nil {
+		return false, err
+	}
+	if dag.Size() == 0 {
+		return true, nil
+	}
+
+	// Replaced revisions are deleted too; only the live one runs its hooks
+	live, err := r.isLiveRevision(ctx, rg)
+	if err != nil {
+		return false, err
+	}
+	if !live {
+		return true, nil
+	}
+
+	logger.Info("Running PreDelete hooks", "count", dag.Size())
+	state, err := r.Executor.Execute(graph.WithRedactor(ctx, internalGraph.Redactor()), dag)
+	if err != nil {
+		return false, err
+	}
+	if state.HasErrors() {
+		r.recordEvent(rg, "Warning", "PreDeleteHookFailed",
+			fmt.Sprintf("Deletion is blocked until the hook succeeds: %s", failureCause(state)))
+		return false, nil
+	}
+
+	r.recordEvent(rg, "Normal", "PreDeleteHooksCompleted", fmt.Sprintf("Ran %d PreDelete hook(s)", dag.Size()))
+	return true, nil
+}
+
+// isLiveRevision reports whether rg is being deleted along with its
+// instance and is the revision whose resources are in the cluster, as
+// opposed to a revision replaced by a newer render or a rolled back one
+func (r *ResourceGraphReconciler) isLiveRevision(ctx context.Context, rg *platformv1alpha1.ResourceGraph) (bool, error) {
+	if rg.Status.Phase == PhaseRolledBack {
+		return false, nil
+	}
+
+	ref := rg.Spec.SourceRef
+	instance := &unstructured.Unstructured{}
+	instance.SetAPIVersion(ref.APIVersion)
+	instance.SetKind(ref.Kind)
+	err := r.Get(ctx, client.ObjectKey{Namespace: ref.Namespace, Name: ref.Name}, instance)
+	if client.IgnoreNotFound(err) != nil {
+		return false, err
+	}
+	if err == nil && instance.GetDeletionTimestamp().IsZero() {
+		return false, nil
+	}
+
+	// A previous revision kept for rollback is superseded once a newer
+	// revision of the instance completed
+	revisions := &platformv1alpha1.ResourceGraphList{}
+	if err := r.List(ctx, revisions,
+		client.InNamespace(rg.Namespace),
+		client.MatchingLabels{"pequod.io/instance": ref.Name},
+	); err != nil {
+		return false, err
+	}
+	for _, other := range revisions.Items {
+		if other.Spec.Rollback != nil && other.Spec.Rollback.PreviousRevision == rg.Name &&
+			other.Status.Phase == PhaseCompleted {
+			return false, nil
+		}
+	}
+	return true, nil
+}
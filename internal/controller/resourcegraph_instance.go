@@ -0,0 +1,87 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	platformv1alpha1 "github.com/chazu/pequod/api/v1alpha1"
+	"github.com/chazu/pequod/pkg/reconcile"
+)
+
+// rollUpInstanceStatus mirrors the phase and readiness of rg on its source
+// instance, so that instances rendered into other graphs are ready once
+// their own graph completes. Revisions other than the one the instance
+// currently points at are ignored.
+func (r *ResourceGraphReconciler) rollUpInstanceStatus(ctx context.Context, rg *platformv1alpha1.ResourceGraph) {
+	ready := instanceReadyCondition(rg)
+	err := r.updateInstanceStatus(ctx, rg, func(instance *unstructured.Unstructured) (bool, error) {
+		current, _, _ := unstructured.NestedString(instance.Object, "status", "resourceGraphRef", "name")
+		if current != rg.Name {
+			return false, nil
+		}
+		before, _, _ := unstructured.NestedMap(instance.Object, "status")
+
+		if err := unstructured.SetNestedField(instance.Object, rg.Status.Phase, "status", "phase"); err != nil {
+			return false, err
+		}
+		if err := reconcile.SetInstanceCondition(instance, ready); err != nil {
+			return false, err
+		}
+		if ready.Status == metav1.ConditionTrue {
+			if err := reconcile.RemoveInstanceCondition(instance, ConditionTypeRolledBack); err != nil {
+				return false, err
+			}
+		}
+
+		after, _, _ := unstructured.NestedMap(instance.Object, "status")
+		return !equality.Semantic.DeepEqual(before, after), nil
+	})
+	if err != nil {
+		logf.FromContext(ctx).Error(err, "Failed to roll up status to instance")
+	}
+}
+
+// instanceReadyCondition derives the instance's Ready condition from the
+// conditions of its ResourceGraph
+func instanceReadyCondition(rg *platformv1alpha1.ResourceGraph) metav1.Condition {
+	if ready := meta.FindStatusCondition(rg.Status.Conditions, ConditionTypeReady); ready != nil {
+		return metav1.Condition{
+			Type:    reconcile.ConditionTypeReady,
+			Status:  ready.Status,
+			Reason:  ready.Reason,
+			Message: ready.Message,
+		}
+	}
+	condition := metav1.Condition{
+		Type:    reconcile.ConditionTypeReady,
+		Status:  metav1.ConditionFalse,
+		Reason:  rg.Status.Phase,
+		Message: "ResourceGraph " + rg.Name + " has not completed",
+	}
+	if len(rg.Status.Conditions) > 0 {
+		condition.Reason = rg.Status.Conditions[0].Reason
+		condition.Message = rg.Status.Conditions[0].Message
+	}
+	return condition
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	platformv1alpha1 "github.com/chazu/pequod/api/v1alpha1"
+	"github.com/chazu/pequod/pkg/graph"
+)
+
+// projectOutputs writes the module's #Outputs, computed from the live
+// objects of a completed graph, to the source instance's status. Failures
+// are reported as events and do not fail the graph.
+func (r *ResourceGraphReconciler) projectOutputs(
+	ctx context.Context,
+	rg *platformv1alpha1.ResourceGraph,
+	g *graph.Graph,
+) {
+	if r.Outputs == nil {
+		return
+	}
+
+	live, err := r.liveObjects(ctx, g)
+	if err == nil {
+		err = r.Outputs.Project(ctx, rg, live)
+	}
+	if err != nil {
+		logf.FromContext(ctx).Error(err, "Failed to compute instance outputs")
+		r.recordEvent(rg, "Warning", "OutputsFailed", fmt.Sprintf("Failed to compute instance outputs: %v", err))
+	}
+}
+
+// liveObjects fetches the applied objects of a graph by node ID. Hook Jobs
+// and objects that no longer exist are left out, and Secret values are
+// removed so they cannot end up in instance status.
+func (r *ResourceGraphReconciler) liveObjects(ctx context.Context, g *graph.Graph) (map[string]interface{}, error) {
+	live := make(map[string]interface{}, len(g.Nodes))
+	for _, node := range g.Nodes {
+		if node.Hook != nil {
+			continue
+		}
+
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(node.Object.GroupVersionKind())
+		if err := r.Get(ctx, client.ObjectKeyFromObject(&node.Object), obj); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to get node %s: %w", node.ID, err)
+		}
+
+		obj.SetManagedFields(nil)
+		if obj.GetAPIVersion() == "v1" && obj.GetKind() == "Secret" {
+			unstructured.RemoveNestedField(obj.Object, "data")
+			unstructured.RemoveNestedField(obj.Object, "stringData")
+		}
+		live[node.ID] = obj.Object
+	}
+	return live, nil
+}
@@ -0,0 +1,249 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	platformv1alpha1 "github.com/chazu/pequod/api/v1alpha1"
+	"github.com/chazu/pequod/pkg/apply"
+	"github.com/chazu/pequod/pkg/graph"
+	"github.com/chazu/pequod/pkg/inventory"
+	"github.com/chazu/pequod/pkg/reconcile"
+)
+
+// rollback restores the previous revision of an instance after rg failed.
+// The previous graph is re-applied, resources that only rg created are
+// pruned, and both rg and its source instance are marked RolledBack with the
+// error of the node that failed.
+func (r *ResourceGraphReconciler) rollback(
+	ctx context.Context,
+	rg *platformv1alpha1.ResourceGraph,
+	failedGraph *graph.Graph,
+	state *graph.ExecutionState,
+) (ctrl.Result, error) {
+	logger := logf.FromContext(ctx)
+	previousName := rg.Spec.Rollback.PreviousRevision
+
+	prev := &platformv1alpha1.ResourceGraph{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: rg.Namespace, Name: previousName}, prev); err != nil {
+		if errors.IsNotFound(err) {
+			r.recordEvent(rg, "Warning", "RollbackSkipped",
+				fmt.Sprintf("Previous revision %s no longer exists", previousName))
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, fmt.Errorf("failed to get previous revision %s: %w", previousName, err)
+	}
+	if prev.Status.Phase != PhaseCompleted {
+		r.recordEvent(rg, "Warning", "RollbackSkipped",
+			fmt.Sprintf("Previous revision %s is %s, not %s", previousName, prev.Status.Phase, PhaseCompleted))
+		return ctrl.Result{}, nil
+	}
+
+	logger.Info("Rolling back to previous revision", "previousRevision", previousName)
+
+	prevGraph, err := r.convertToInternalGraph(ctx, prev)
+	if err != nil {
+		return r.rollbackFailed(rg, previousName, err)
+	}
+	dag, err := graph.BuildDAG(prevGraph)
+	if err != nil {
+		return r.rollbackFailed(rg, previousName, err)
+	}
+	execCtx := graph.WithRedactor(ctx, prevGraph.Redactor())
+	prevState, err := r.Executor.Execute(graph.WithCompletedHooks(execCtx, completedHooks(prev, prevGraph)...), dag)
+	if err == nil && (!prevState.IsComplete() || prevState.HasErrors()) {
+		err = fmt.Errorf("nodes failed: %v", prevState.GetNodesInState(graph.NodeStateError))
+	}
+	if err != nil {
+		return r.rollbackFailed(rg, previousName, err)
+	}
+
+	pruned, err := r.pruneFailedRevision(ctx, rg, failedGraph, prevGraph)
+	if err != nil {
+		return r.rollbackFailed(rg, previousName, err)
+	}
+
+	cause := failureCause(state)
+	message := fmt.Sprintf("Rolled back to %s: %s", previousName, cause)
+	if err := r.updateStatusRolledBack(ctx, rg, message); err != nil {
+		return ctrl.Result{Requeue: true}, err
+	}
+	if err := r.updateInstanceRolledBack(ctx, rg, message); err != nil {
+		logger.Error(err, "Failed to mark instance as rolled back")
+	}
+
+	r.recordEvent(rg, "Normal", "RolledBack",
+		fmt.Sprintf("Restored revision %s and pruned %d resource(s) created by this revision", previousName, pruned))
+	return ctrl.Result{}, nil
+}
+
+// rollbackFailed records that the previous revision could not be restored.
+// The graph keeps its Failed status so the instance owner can intervene.
+func (r *ResourceGraphReconciler) rollbackFailed(rg *platformv1alpha1.ResourceGraph, previousName string, err error) (ctrl.Result, error) {
+	r.recordEvent(rg, "Warning", "RollbackFailed",
+		fmt.Sprintf("Failed to roll back to %s: %v", previousName, err))
+	return ctrl.Result{}, nil
+}
+
+// pruneFailedRevision deletes the resources that exist in the failed graph but
+// not in the previous one. Only resources still controlled by the failed
+// ResourceGraph are pruned, and the prune-protection annotation is honoured.
+func (r *ResourceGraphReconciler) pruneFailedRevision(
+	ctx context.Context,
+	rg *platformv1alpha1.ResourceGraph,
+	failedGraph, prevGraph *graph.Graph,
+) (int, error) {
+	kept := make(map[string]bool, len(prevGraph.Nodes))
+	for _, node := range prevGraph.Nodes {
+		kept[objectIdentity(&node.Object)] = true
+	}
+
+	tracker := inventory.NewTracker()
+	for _, node := range failedGraph.Nodes {
+		id := objectIdentity(&node.Object)
+		if kept[id] {
+			continue
+		}
+		live := &unstructured.Unstructured{}
+		live.SetGroupVersionKind(node.Object.GroupVersionKind())
+		err := r.Get(ctx, client.ObjectKey{Namespace: node.Object.GetNamespace(), Name: node.Object.GetName()}, live)
+		if errors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			continue
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to get %s: %w", id, err)
+		}
+		if metav1.IsControlledBy(live, rg) {
+			tracker.RecordApplied(id, live)
+		}
+	}
+
+	opts := apply.DefaultPruneOptions()
+	opts.GracePeriod = 0
+	result, err := apply.NewPruner(r.Client).Prune(ctx, tracker, kept, opts)
+	if err != nil {
+		return 0, err
+	}
+	if len(result.Errors) > 0 {
+		return len(result.Pruned), fmt.Errorf("failed to prune %s: %w", result.Errors[0].Resource.ID, result.Errors[0].Error)
+	}
+	return len(result.Pruned), nil
+}
+
+// objectIdentity identifies a resource independently of its node ID, which
+// may differ between revisions
+func objectIdentity(obj *unstructured.Unstructured) string {
+	gvk := obj.GroupVersionKind()
+	return fmt.Sprintf("%s/%s/%s/%s", gvk.Group, gvk.Kind, obj.GetNamespace(), obj.GetName())
+}
+
+// failureCause describes the first failed node of an execution
+func failureCause(state *graph.ExecutionState) string {
+	if state == nil {
+		return "execution failed"
+	}
+	failed := state.GetNodesInState(graph.NodeStateError)
+	if len(failed) == 0 {
+		return "execution did not complete"
+	}
+	sort.Strings(failed)
+	status, err := state.GetStatus(failed[0])
+	if err != nil || status.Error == "" {
+		return fmt.Sprintf("node %s failed", failed[0])
+	}
+	return fmt.Sprintf("node %s failed: %s", failed[0], status.Error)
+}
+
+// updateStatusRolledBack marks the failed ResourceGraph as RolledBack
+func (r *ResourceGraphReconciler) updateStatusRolledBack(ctx context.Context, rg *platformv1alpha1.ResourceGraph, message string) error {
+	latest := &platformv1alpha1.ResourceGraph{}
+	if err := r.Get(ctx, client.ObjectKeyFromObject(rg), latest); err != nil {
+		return fmt.Errorf("failed to get latest ResourceGraph: %w", err)
+	}
+
+	latest.Status.Phase = PhaseRolledBack
+	meta.SetStatusCondition(&latest.Status.Conditions, metav1.Condition{
+		Type:    ConditionTypeRolledBack,
+		Status:  metav1.ConditionTrue,
+		Reason:  "PreviousRevisionRestored",
+		Message: message,
+	})
+
+	return r.Status().Update(ctx, latest)
+}
+
+// updateInstanceRolledBack records the rollback on the source instance
+func (r *ResourceGraphReconciler) updateInstanceRolledBack(ctx context.Context, rg *platformv1alpha1.ResourceGraph, message string) error {
+	return r.updateInstanceStatus(ctx, rg, func(instance *unstructured.Unstructured) (bool, error) {
+		if err := unstructured.SetNestedField(instance.Object, PhaseRolledBack, "status", "phase"); err != nil {
+			return false, err
+		}
+		err := reconcile.SetInstanceCondition(instance, metav1.Condition{
+			Type:               ConditionTypeRolledBack,
+			Status:             metav1.ConditionTrue,
+			LastTransitionTime: metav1.Now(),
+			Reason:             "ExecutionFailed",
+			Message:            message,
+		})
+		return err == nil, err
+	})
+}
+
+// updateInstanceStatus fetches the source instance of rg, applies mutate and
+// writes back its status if mutate reports a change. A missing instance is
+// not an error.
+func (r *ResourceGraphReconciler) updateInstanceStatus(
+	ctx context.Context,
+	rg *platformv1alpha1.ResourceGraph,
+	mutate func(*unstructured.Unstructured) (bool, error),
+) error {
+	ref := rg.Spec.SourceRef
+	instance := &unstructured.Unstructured{}
+	instance.SetAPIVersion(ref.APIVersion)
+	instance.SetKind(ref.Kind)
+	if err := r.Get(ctx, client.ObjectKey{Namespace: ref.Namespace, Name: ref.Name}, instance); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	changed, err := mutate(instance)
+	if err != nil || !changed {
+		return err
+	}
+	return r.Status().Update(ctx, instance)
+}
+
+// releasePreviousRevision deletes the revision kept for rollback once rg has
+// completed and no longer needs it
+func (r *ResourceGraphReconciler) releasePreviousRevision(ctx context.Context, rg *platformv1alpha1.ResourceGraph) error {
+	prev := &platformv1alpha1.ResourceGraph{}
+	err := r.Get(ctx, client.ObjectKey{Namespace: rg.Namespace, Name: rg.Spec.Rollback.PreviousRevision}, prev)
+	if err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	return client.IgnoreNotFound(r.Delete(ctx, prev))
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	platformv1alpha1 "github.com/chazu/pequod/api/v1alpha1"
+	"github.com/chazu/pequod/pkg/graph"
+	"github.com/chazu/pequod/pkg/reconcile"
+)
+
+// restoreSensitiveValues puts the values of sensitive fields, which the
+// instance controller keeps in a Secret next to the ResourceGraph, back into
+// the graph's objects just before they are applied. A missing Secret is
+// returned as a NotFound error.
+func (r *ResourceGraphReconciler) restoreSensitiveValues(
+	ctx context.Context,
+	rg *platformv1alpha1.ResourceGraph,
+	g *graph.Graph,
+) error {
+	ref := rg.Spec.SensitiveValuesRef
+	if ref == nil {
+		return nil
+	}
+
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: rg.Namespace, Name: ref.Name}, secret); err != nil {
+		return fmt.Errorf("failed to get sensitive values: %w", err)
+	}
+
+	// The Secret may hold only the render input
+	raw, ok := secret.Data[reconcile.SensitiveValuesKey]
+	if !ok {
+		return nil
+	}
+	var values map[string]map[string]interface{}
+	if err := json.Unmarshal(raw, &values); err != nil {
+		return fmt.Errorf("failed to decode sensitive values from Secret %s: %w", ref.Name, err)
+	}
+
+	for i := range g.Nodes {
+		if err := g.Nodes[i].RestoreSensitive(values[g.Nodes[i].ID]); err != nil {
+			return fmt.Errorf("node %s: %w", g.Nodes[i].ID, err)
+		}
+	}
+	return nil
+}
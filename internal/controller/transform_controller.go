@@ -23,9 +23,12 @@ import (
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	platformv1alpha1 "github.com/chazu/pequod/api/v1alpha1"
+	"github.com/chazu/pequod/pkg/crd"
 	"github.com/chazu/pequod/pkg/platformloader"
 	"github.com/chazu/pequod/pkg/reconcile"
 )
@@ -39,6 +42,18 @@ type TransformReconciler struct {
 	PlatformLoader *platformloader.Loader
 	Recorder       record.EventRecorder
 
+	// InstanceHealth, if set, is rolled up into the status of each
+	// Transform, which is reconciled when its instances' health changes
+	InstanceHealth *reconcile.InstanceHealthIndex
+
+	// UpgradeAnalyzer, if set, renders the existing instances with each new
+	// module digest before the Transform adopts it
+	UpgradeAnalyzer *reconcile.UpgradeAnalyzer
+
+	// ConversionWebhook, if set, converts the instances of generated CRDs
+	// with several versions
+	ConversionWebhook *crd.ConversionWebhook
+
 	// Handler-based reconciler
 	reconciler *reconcile.TransformReconciler
 }
@@ -69,9 +84,34 @@ func (r *TransformReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		r.PlatformLoader,
 	)
 	r.reconciler.SetRecorder(r.Recorder)
+	if r.UpgradeAnalyzer != nil {
+		r.reconciler.SetUpgradeAnalyzer(r.UpgradeAnalyzer)
+	}
+	r.reconciler.SetConversionWebhook(r.ConversionWebhook)
 
-	return ctrl.NewControllerManagedBy(mgr).
+	builder := ctrl.NewControllerManagedBy(mgr).
 		For(&platformv1alpha1.Transform{}).
-		Named("transform").
-		Complete(r)
+		Named("transform")
+	if r.InstanceHealth != nil {
+		r.reconciler.SetInstanceHealth(r.InstanceHealth)
+		builder = builder.WatchesRawSource(source.Channel(
+			r.InstanceHealth.Events(),
+			handler.EnqueueRequestsFromMapFunc(r.transformForInstance),
+		))
+	}
+	return builder.Complete(r)
+}
+
+// transformForInstance maps an instance to the Transform generating its kind
+func (r *TransformReconciler) transformForInstance(ctx context.Context, obj client.Object) []ctrl.Request {
+	// Later changes notify again even if no Transform is found or reconciled;
+	// the workqueue coalesces their requests
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	r.InstanceHealth.Delivered(gvk.GroupKind())
+
+	transform, err := reconcile.FindTransformForGVK(ctx, r.Client, gvk)
+	if err != nil {
+		return nil
+	}
+	return []ctrl.Request{{NamespacedName: client.ObjectKeyFromObject(transform)}}
 }
@@ -3,6 +3,8 @@ package apply
 import (
 	"context"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
@@ -83,8 +85,13 @@ func (a *Applier) Apply(ctx context.Context, obj *unstructured.Unstructured, pol
 	duration := time.Since(startTime).Seconds()
 	gvk := gvkString(obj)
 	if err != nil {
+		class, reason := ClassifyError(err)
 		metrics.RecordApply("failure", string(policy.Mode), gvk, duration)
-		logger.Error(err, "Failed to apply resource")
+		metrics.RecordApplyError(string(class), reason, string(policy.Mode), gvk)
+		logger.Error(graph.RedactorFrom(ctx).Error(err), "Failed to apply resource", "errorClass", class, "reason", reason)
+		if class == graph.ErrorClassTerminal {
+			err = &graph.TerminalError{Reason: reason, Err: err}
+		}
 	} else {
 		metrics.RecordApply("success", string(policy.Mode), gvk, duration)
 		logger.V(1).Info("Successfully applied resource", "duration_ms", duration*1000)
@@ -114,18 +121,62 @@ func (a *Applier) applySSA(ctx context.Context, obj *unstructured.Unstructured,
 		logger.V(1).Info("Running in dry-run mode")
 	}
 
+	// Remove fields owned by other controllers from the applied intent so
+	// that SSA does not claim them, after releasing any the field manager
+	// applied before so that SSA does not reset them either. Dry runs write
+	// nothing, so they do not release fields.
+	if len(policy.IgnoreFields) > 0 {
+		if !a.dryRun {
+			if err := a.releaseIgnoredFields(ctx, obj, policy, logger); err != nil {
+				return err
+			}
+		}
+		obj = graph.StripFields(obj, policy.IgnoreFields)
+		logger.V(2).Info("Excluding ignored fields from apply", "ignoreFields", policy.IgnoreFields)
+	}
+
 	logger.V(2).Info("Applying resource via SSA", "fieldManager", policy.FieldManager)
 
 	// Apply the resource
-	if err := a.client.Patch(ctx, obj, client.Apply, patchOpts...); err != nil {
-		// Check if this is a conflict error
-		if errors.IsConflict(err) {
+	err := a.client.Patch(ctx, obj, client.Apply, patchOpts...)
+	if err != nil && errors.IsConflict(err) {
+		conflicts := ParseConflicts(err)
+
+		// Force ownership only when the conflict resolution policy allows
+		// every conflicting field; the forced apply then takes over exactly
+		// those fields and nothing else
+		if policy.ConflictPolicy == graph.ConflictPolicyError && policy.ConflictResolution.AllowsAll(conflicts) {
+			logger.Info("Forcing ownership of conflicting fields allowed by conflict resolution",
+				"conflicts", len(conflicts))
+			forceOpts := append(slices.Clone(patchOpts), client.ForceOwnership)
+			err = a.client.Patch(ctx, obj, client.Apply, forceOpts...)
+			if err != nil && errors.IsConflict(err) {
+				conflicts = ParseConflicts(err)
+			}
+		}
+
+		if err != nil && errors.IsConflict(err) {
 			return &ConflictError{
 				Resource:     fmt.Sprintf("%s/%s", obj.GetNamespace(), obj.GetName()),
 				FieldManager: policy.FieldManager,
+				Conflicts:    conflicts,
 				Err:          err,
 			}
 		}
+	}
+	if fields := immutableFields(err); len(fields) > 0 {
+		immutableErr := &ImmutableFieldError{
+			Resource: fmt.Sprintf("%s/%s", obj.GetNamespace(), obj.GetName()),
+			Fields:   fields,
+			Err:      err,
+		}
+		// Never delete anything in dry-run mode
+		if !policy.RecreateOnImmutableChange() || a.dryRun {
+			return immutableErr
+		}
+		return a.recreate(ctx, obj, policy, patchOpts, immutableErr, logger)
+	}
+	if err != nil {
 		return fmt.Errorf("failed to apply resource %s/%s: %w", obj.GetNamespace(), obj.GetName(), err)
 	}
 
@@ -188,6 +239,17 @@ func (a *Applier) applyAdopt(ctx context.Context, obj *unstructured.Unstructured
 		logger.V(1).Info("Running in dry-run mode")
 	}
 
+	// Adoption must not take ownership of fields owned by other controllers,
+	// and releases any the field manager applied before
+	if len(policy.IgnoreFields) > 0 {
+		if !a.dryRun {
+			if err := a.releaseIgnoredFields(ctx, obj, policy, logger); err != nil {
+				return err
+			}
+		}
+		obj = graph.StripFields(obj, policy.IgnoreFields)
+	}
+
 	if err := a.client.Patch(ctx, obj, client.Apply, patchOpts...); err != nil {
 		return fmt.Errorf("failed to adopt resource %s/%s: %w", obj.GetNamespace(), obj.GetName(), err)
 	}
@@ -200,11 +262,27 @@ func (a *Applier) applyAdopt(ctx context.Context, obj *unstructured.Unstructured
 type ConflictError struct {
 	Resource     string
 	FieldManager string
-	Err          error
+	// Conflicts lists the conflicting fields and their owners, when the
+	// API server reported them
+	Conflicts []graph.FieldConflict
+	Err       error
 }
 
 func (e *ConflictError) Error() string {
-	return fmt.Sprintf("field manager conflict for %s (field manager: %s): %v", e.Resource, e.FieldManager, e.Err)
+	if len(e.Conflicts) == 0 {
+		return fmt.Sprintf("field manager conflict for %s (field manager: %s): %v", e.Resource, e.FieldManager, e.Err)
+	}
+	fields := make([]string, 0, len(e.Conflicts))
+	for _, c := range e.Conflicts {
+		fields = append(fields, c.String())
+	}
+	return fmt.Sprintf("field manager conflict for %s (field manager: %s): %s",
+		e.Resource, e.FieldManager, strings.Join(fields, ", "))
+}
+
+// FieldConflicts returns the structured conflicts for status reporting
+func (e *ConflictError) FieldConflicts() []graph.FieldConflict {
+	return e.Conflicts
 }
 
 func (e *ConflictError) Unwrap() error {
@@ -3,8 +3,11 @@ package apply
 import (
 	"context"
 	"fmt"
+	"strings"
 	"testing"
 
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -92,6 +95,112 @@ func TestApplier_ApplySSA(t *testing.T) {
 	}
 }
 
+func TestApplier_ApplySSA_IgnoreFields(t *testing.T) {
+	c := fake.NewClientBuilder().Build()
+	applier := NewApplier(c)
+
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata": map[string]interface{}{
+				"name":      "test-cm-ignore",
+				"namespace": "default",
+			},
+			"data": map[string]interface{}{
+				"owned":  "pequod",
+				"shared": "rendered",
+			},
+		},
+	}
+	policy := graph.ApplyPolicy{
+		Mode:         graph.ApplyModeApply,
+		FieldManager: "test-manager",
+		IgnoreFields: []string{"data.shared"},
+	}
+
+	if err := applier.Apply(context.Background(), obj, policy); err != nil {
+		t.Fatalf("Apply() failed: %v", err)
+	}
+
+	got := &unstructured.Unstructured{}
+	got.SetGroupVersionKind(obj.GroupVersionKind())
+	if err := c.Get(context.Background(), client.ObjectKeyFromObject(obj), got); err != nil {
+		t.Fatalf("Failed to get applied object: %v", err)
+	}
+
+	data, _, _ := unstructured.NestedStringMap(got.Object, "data")
+	if data["owned"] != "pequod" {
+		t.Errorf("expected owned field to be applied, got %v", data)
+	}
+	if _, found := data["shared"]; found {
+		t.Errorf("expected ignored field to be excluded from apply, got %v", data)
+	}
+
+	// The caller's intent must not be mutated
+	if _, found, _ := unstructured.NestedString(obj.Object, "data", "shared"); !found {
+		t.Error("expected the original object to keep the ignored field")
+	}
+}
+
+func TestApplier_ApplySSA_IgnoreFieldsOfExistingObject(t *testing.T) {
+	// The field manager applied data.shared before it was ignored
+	existing := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-cm-release",
+			Namespace: "default",
+			ManagedFields: []metav1.ManagedFieldsEntry{{
+				Manager:    "test-manager",
+				Operation:  metav1.ManagedFieldsOperationApply,
+				APIVersion: "v1",
+				FieldsType: "FieldsV1",
+				FieldsV1:   &metav1.FieldsV1{Raw: []byte(`{"f:data":{"f:owned":{},"f:shared":{}}}`)},
+			}},
+		},
+		Data: map[string]string{"owned": "pequod", "shared": "scaled"},
+	}
+	c := fake.NewClientBuilder().WithObjects(existing).WithReturnManagedFields().Build()
+	applier := NewApplier(c)
+
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata": map[string]interface{}{
+				"name":      "test-cm-release",
+				"namespace": "default",
+			},
+			"data": map[string]interface{}{
+				"owned":  "pequod",
+				"shared": "rendered",
+			},
+		},
+	}
+	policy := graph.ApplyPolicy{
+		Mode:         graph.ApplyModeApply,
+		FieldManager: "test-manager",
+		IgnoreFields: []string{"data.shared"},
+	}
+	if err := applier.Apply(context.Background(), obj, policy); err != nil {
+		t.Fatalf("Apply() failed: %v", err)
+	}
+
+	got := &unstructured.Unstructured{}
+	got.SetGroupVersionKind(obj.GroupVersionKind())
+	if err := c.Get(context.Background(), client.ObjectKeyFromObject(obj), got); err != nil {
+		t.Fatalf("Failed to get applied object: %v", err)
+	}
+	data, _, _ := unstructured.NestedStringMap(got.Object, "data")
+	if data["shared"] != "scaled" {
+		t.Errorf("expected the ignored field to keep its live value, got %v", data)
+	}
+	for _, entry := range got.GetManagedFields() {
+		if entry.Manager == "test-manager" && strings.Contains(string(entry.FieldsV1.Raw), "f:shared") {
+			t.Errorf("expected the ignored field to be released, got %s", entry.FieldsV1.Raw)
+		}
+	}
+}
+
 func TestApplier_ApplyCreate(t *testing.T) {
 	c := fake.NewClientBuilder().Build()
 	applier := NewApplier(c)
@@ -188,6 +297,64 @@ func TestApplier_ApplyAdopt(t *testing.T) {
 	}
 }
 
+func TestApplier_ApplyAdopt_IgnoreFieldsOfExistingObject(t *testing.T) {
+	// The field manager applied data.shared before it was ignored
+	existing := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-cm-adopt-release",
+			Namespace: "default",
+			ManagedFields: []metav1.ManagedFieldsEntry{{
+				Manager:    "test-manager",
+				Operation:  metav1.ManagedFieldsOperationApply,
+				APIVersion: "v1",
+				FieldsType: "FieldsV1",
+				FieldsV1:   &metav1.FieldsV1{Raw: []byte(`{"f:data":{"f:owned":{},"f:shared":{}}}`)},
+			}},
+		},
+		Data: map[string]string{"owned": "pequod", "shared": "scaled"},
+	}
+	c := fake.NewClientBuilder().WithObjects(existing).WithReturnManagedFields().Build()
+	applier := NewApplier(c)
+
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata": map[string]interface{}{
+				"name":      "test-cm-adopt-release",
+				"namespace": "default",
+			},
+			"data": map[string]interface{}{
+				"owned":  "pequod",
+				"shared": "rendered",
+			},
+		},
+	}
+	policy := graph.ApplyPolicy{
+		Mode:         graph.ApplyModeAdopt,
+		FieldManager: "test-manager",
+		IgnoreFields: []string{"data.shared"},
+	}
+	if err := applier.Apply(context.Background(), obj, policy); err != nil {
+		t.Fatalf("Apply() with Adopt mode failed: %v", err)
+	}
+
+	got := &unstructured.Unstructured{}
+	got.SetGroupVersionKind(obj.GroupVersionKind())
+	if err := c.Get(context.Background(), client.ObjectKeyFromObject(obj), got); err != nil {
+		t.Fatalf("Failed to get adopted object: %v", err)
+	}
+	data, _, _ := unstructured.NestedStringMap(got.Object, "data")
+	if data["shared"] != "scaled" {
+		t.Errorf("expected the ignored field to keep its live value, got %v", data)
+	}
+	for _, entry := range got.GetManagedFields() {
+		if entry.Manager == "test-manager" && strings.Contains(string(entry.FieldsV1.Raw), "f:shared") {
+			t.Errorf("expected the ignored field to be released, got %s", entry.FieldsV1.Raw)
+		}
+	}
+}
+
 func TestApplier_DryRun(t *testing.T) {
 	obj := &unstructured.Unstructured{
 		Object: map[string]interface{}{
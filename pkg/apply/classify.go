@@ -0,0 +1,87 @@
+package apply
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+
+	"github.com/chazu/pequod/pkg/graph"
+)
+
+// Error reasons reported by ClassifyError
+const (
+	ReasonConflict        = "Conflict"
+	ReasonTimeout         = "Timeout"
+	ReasonThrottled       = "Throttled"
+	ReasonServerError     = "ServerError"
+	ReasonQuotaExceeded   = "QuotaExceeded"
+	ReasonInvalid         = "Invalid"
+	ReasonImmutableField  = "ImmutableField"
+	ReasonForbidden       = "Forbidden"
+	ReasonKindNotFound    = "KindNotFound"
+	ReasonAdmissionDenied = "AdmissionDenied"
+	ReasonUnknown         = "Unknown"
+)
+
+// ClassifyError decides whether an apply error can succeed on retry and
+// returns a short reason for it. Errors that are not recognised are treated
+// as transient so that nothing is given up on by mistake.
+func ClassifyError(err error) (graph.ErrorClass, string) {
+	var immutableErr *ImmutableFieldError
+
+	switch {
+	case err == nil:
+		return "", ""
+
+	// Transient: the same request may succeed later
+	case apierrors.IsConflict(err):
+		return graph.ErrorClassTransient, ReasonConflict
+	case apierrors.IsTimeout(err), apierrors.IsServerTimeout(err),
+		errors.Is(err, context.DeadlineExceeded):
+		return graph.ErrorClassTransient, ReasonTimeout
+	case apierrors.IsTooManyRequests(err):
+		return graph.ErrorClassTransient, ReasonThrottled
+
+	// Terminal: the request itself is rejected
+	case isAdmissionDenial(err):
+		return graph.ErrorClassTerminal, ReasonAdmissionDenied
+	case errors.As(err, &immutableErr):
+		return graph.ErrorClassTerminal, ReasonImmutableField
+	case meta.IsNoMatchError(err):
+		return graph.ErrorClassTerminal, ReasonKindNotFound
+	case apierrors.IsInvalid(err), apierrors.IsBadRequest(err):
+		return graph.ErrorClassTerminal, ReasonInvalid
+	case apierrors.IsForbidden(err):
+		// Quota is released as other workloads shrink, so it is worth retrying
+		if strings.Contains(err.Error(), "exceeded quota") {
+			return graph.ErrorClassTransient, ReasonQuotaExceeded
+		}
+		return graph.ErrorClassTerminal, ReasonForbidden
+
+	case statusCode(err) >= http.StatusInternalServerError:
+		return graph.ErrorClassTransient, ReasonServerError
+	}
+
+	return graph.ErrorClassTransient, ReasonUnknown
+}
+
+// isAdmissionDenial reports whether a validating webhook or admission policy
+// rejected the request
+func isAdmissionDenial(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "admission webhook") && strings.Contains(msg, "denied the request") ||
+		strings.Contains(msg, "ValidatingAdmissionPolicy") && strings.Contains(msg, "denied request")
+}
+
+// statusCode returns the HTTP status code of an API error, or 0
+func statusCode(err error) int32 {
+	var statusErr apierrors.APIStatus
+	if errors.As(err, &statusErr) {
+		return statusErr.Status().Code
+	}
+	return 0
+}
@@ -0,0 +1,150 @@
+package apply
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/chazu/pequod/pkg/graph"
+)
+
+func TestClassifyError(t *testing.T) {
+	gr := schema.GroupResource{Group: "apps", Resource: "deployments"}
+
+	tests := []struct {
+		name       string
+		err        error
+		wantClass  graph.ErrorClass
+		wantReason string
+	}{
+		{
+			name:       "conflict",
+			err:        apierrors.NewConflict(gr, "web", errors.New("conflict")),
+			wantClass:  graph.ErrorClassTransient,
+			wantReason: ReasonConflict,
+		},
+		{
+			name:       "server timeout",
+			err:        apierrors.NewServerTimeout(gr, "patch", 1),
+			wantClass:  graph.ErrorClassTransient,
+			wantReason: ReasonTimeout,
+		},
+		{
+			name:       "context deadline",
+			err:        fmt.Errorf("failed to apply: %w", context.DeadlineExceeded),
+			wantClass:  graph.ErrorClassTransient,
+			wantReason: ReasonTimeout,
+		},
+		{
+			name:       "throttled",
+			err:        apierrors.NewTooManyRequests("slow down", 1),
+			wantClass:  graph.ErrorClassTransient,
+			wantReason: ReasonThrottled,
+		},
+		{
+			name:       "internal error",
+			err:        apierrors.NewInternalError(errors.New("etcd unavailable")),
+			wantClass:  graph.ErrorClassTransient,
+			wantReason: ReasonServerError,
+		},
+		{
+			name:       "service unavailable",
+			err:        apierrors.NewServiceUnavailable("unavailable"),
+			wantClass:  graph.ErrorClassTransient,
+			wantReason: ReasonServerError,
+		},
+		{
+			name: "invalid",
+			err: apierrors.NewInvalid(schema.GroupKind{Group: "apps", Kind: "Deployment"}, "web", field.ErrorList{
+				field.Required(field.NewPath("spec", "selector"), ""),
+			}),
+			wantClass:  graph.ErrorClassTerminal,
+			wantReason: ReasonInvalid,
+		},
+		{
+			name:       "immutable field",
+			err:        &ImmutableFieldError{Resource: "default/web", Fields: []string{"spec.selector"}, Err: newImmutableError()},
+			wantClass:  graph.ErrorClassTerminal,
+			wantReason: ReasonImmutableField,
+		},
+		{
+			name:       "forbidden",
+			err:        apierrors.NewForbidden(gr, "web", errors.New("RBAC: access denied")),
+			wantClass:  graph.ErrorClassTerminal,
+			wantReason: ReasonForbidden,
+		},
+		{
+			name:       "quota exceeded",
+			err:        apierrors.NewForbidden(gr, "web", errors.New("exceeded quota: compute, requested: cpu=2")),
+			wantClass:  graph.ErrorClassTransient,
+			wantReason: ReasonQuotaExceeded,
+		},
+		{
+			name:       "unknown kind",
+			err:        &meta.NoKindMatchError{GroupKind: schema.GroupKind{Group: "example.com", Kind: "Widget"}},
+			wantClass:  graph.ErrorClassTerminal,
+			wantReason: ReasonKindNotFound,
+		},
+		{
+			name: "webhook denial",
+			err: apierrors.NewForbidden(gr, "web",
+				errors.New(`admission webhook "policy.example.com" denied the request: images must be signed`)),
+			wantClass:  graph.ErrorClassTerminal,
+			wantReason: ReasonAdmissionDenied,
+		},
+		{
+			name:       "unrecognised error",
+			err:        errors.New("connection reset by peer"),
+			wantClass:  graph.ErrorClassTransient,
+			wantReason: ReasonUnknown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			class, reason := ClassifyError(tt.err)
+			if class != tt.wantClass || reason != tt.wantReason {
+				t.Errorf("ClassifyError() = (%s, %s), want (%s, %s)", class, reason, tt.wantClass, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestApplier_WrapsTerminalErrors(t *testing.T) {
+	c := fake.NewClientBuilder().WithInterceptorFuncs(interceptor.Funcs{
+		Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+			return apierrors.NewForbidden(schema.GroupResource{Resource: "configmaps"}, obj.GetName(), errors.New("denied"))
+		},
+	}).Build()
+	applier := NewApplier(c)
+
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]interface{}{"name": "test-cm", "namespace": "default"},
+		},
+	}
+	err := applier.Apply(context.Background(), obj, graph.ApplyPolicy{Mode: graph.ApplyModeApply})
+
+	var terminalErr *graph.TerminalError
+	if !errors.As(err, &terminalErr) {
+		t.Fatalf("Apply() error = %v, want *graph.TerminalError", err)
+	}
+	if terminalErr.Reason != ReasonForbidden {
+		t.Errorf("expected reason %s, got %s", ReasonForbidden, terminalErr.Reason)
+	}
+	if !apierrors.IsForbidden(err) {
+		t.Error("expected the original API error to remain inspectable")
+	}
+}
@@ -0,0 +1,76 @@
+package apply
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/chazu/pequod/pkg/graph"
+)
+
+// ParseConflicts extracts the field manager conflicts from a server-side
+// apply conflict error. The API server reports one FieldManagerConflict
+// cause per field with a message of the form:
+//
+//	conflict with "manager"[ with subresource "scale"][ using apps/v1[ at <time>]]
+//
+// where the "using" clause is only present for Update operations.
+func ParseConflicts(err error) []graph.FieldConflict {
+	var statusErr apierrors.APIStatus
+	if !errors.As(err, &statusErr) {
+		return nil
+	}
+	details := statusErr.Status().Details
+	if details == nil {
+		return nil
+	}
+
+	var conflicts []graph.FieldConflict
+	for _, cause := range details.Causes {
+		if cause.Type != metav1.CauseTypeFieldManagerConflict {
+			continue
+		}
+		conflicts = append(conflicts, parseConflictCause(cause))
+	}
+	return conflicts
+}
+
+// parseConflictCause converts a single conflict cause into a FieldConflict
+func parseConflictCause(cause metav1.StatusCause) graph.FieldConflict {
+	conflict := graph.FieldConflict{
+		Field:     cause.Field,
+		Operation: string(metav1.ManagedFieldsOperationApply),
+	}
+
+	msg := strings.TrimPrefix(cause.Message, "conflict with ")
+	manager, rest, err := unquotePrefix(msg)
+	if err != nil {
+		// Unrecognised format: keep the raw message so nothing is lost
+		conflict.Manager = msg
+		conflict.Operation = ""
+		return conflict
+	}
+	conflict.Manager = manager
+
+	if strings.Contains(rest, " using ") {
+		conflict.Operation = string(metav1.ManagedFieldsOperationUpdate)
+	}
+	return conflict
+}
+
+// unquotePrefix unquotes the Go-quoted string at the start of s and returns
+// it together with the remainder of s
+func unquotePrefix(s string) (string, string, error) {
+	prefix, err := strconv.QuotedPrefix(s)
+	if err != nil {
+		return "", "", err
+	}
+	value, err := strconv.Unquote(prefix)
+	if err != nil {
+		return "", "", err
+	}
+	return value, s[len(prefix):], nil
+}
@@ -0,0 +1,176 @@
+package apply
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/chazu/pequod/pkg/graph"
+)
+
+// newConflictError builds an SSA conflict error the way the API server reports it
+func newConflictError(causes ...metav1.StatusCause) error {
+	err := apierrors.NewConflict(schema.GroupResource{Resource: "configmaps"}, "test-cm",
+		errors.New("Apply failed with conflicts"))
+	err.ErrStatus.Details.Causes = causes
+	return err
+}
+
+func TestParseConflicts(t *testing.T) {
+	err := newConflictError(
+		metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldManagerConflict,
+			Message: `conflict with "kubectl-edit" using v1 at 2025-01-01T00:00:00Z`,
+			Field:   ".data.key",
+		},
+		metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldManagerConflict,
+			Message: `conflict with "other-operator"`,
+			Field:   ".metadata.labels.team",
+		},
+		metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldManagerConflict,
+			Message: `conflict with "hpa" with subresource "scale" using apps/v1`,
+			Field:   ".spec.replicas",
+		},
+		metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Message: "not a conflict",
+			Field:   ".data",
+		},
+	)
+
+	got := ParseConflicts(err)
+	want := []graph.FieldConflict{
+		{Manager: "kubectl-edit", Field: ".data.key", Operation: "Update"},
+		{Manager: "other-operator", Field: ".metadata.labels.team", Operation: "Apply"},
+		{Manager: "hpa", Field: ".spec.replicas", Operation: "Update"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("ParseConflicts() returned %d conflicts, want %d: %v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("conflict[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	if ParseConflicts(errors.New("plain error")) != nil {
+		t.Error("expected no conflicts for a non-API error")
+	}
+}
+
+func newConflictingClient(conflict error) (client.Client, *int) {
+	forcedApplies := 0
+	c := fake.NewClientBuilder().WithInterceptorFuncs(interceptor.Funcs{
+		Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+			applyOpts := &client.PatchOptions{}
+			applyOpts.ApplyOptions(opts)
+			if applyOpts.Force != nil && *applyOpts.Force {
+				forcedApplies++
+				return c.Patch(ctx, obj, patch, opts...)
+			}
+			return conflict
+		},
+	}).Build()
+	return c, &forcedApplies
+}
+
+func TestApplier_ConflictResolution(t *testing.T) {
+	conflict := newConflictError(
+		metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldManagerConflict,
+			Message: `conflict with "kubectl-edit" using v1`,
+			Field:   ".data.key",
+		},
+		metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldManagerConflict,
+			Message: `conflict with "other-operator"`,
+			Field:   ".data.other",
+		},
+	)
+
+	tests := []struct {
+		name       string
+		resolution *graph.ConflictResolution
+		wantForced bool
+	}{
+		{
+			name:       "no resolution reports conflicts",
+			resolution: nil,
+		},
+		{
+			name:       "partial field coverage is not forced",
+			resolution: &graph.ConflictResolution{ForceFields: []string{"data.key"}},
+		},
+		{
+			name:       "all conflicting fields covered",
+			resolution: &graph.ConflictResolution{ForceFields: []string{"data"}},
+			wantForced: true,
+		},
+		{
+			name: "managers and fields combined",
+			resolution: &graph.ConflictResolution{
+				ForceFields:   []string{"data.key"},
+				ForceManagers: []string{"other-operator"},
+			},
+			wantForced: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, forced := newConflictingClient(conflict)
+			applier := NewApplier(c)
+
+			obj := &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"apiVersion": "v1",
+					"kind":       "ConfigMap",
+					"metadata": map[string]interface{}{
+						"name":      "test-cm",
+						"namespace": "default",
+					},
+					"data": map[string]interface{}{"key": "value", "other": "value"},
+				},
+			}
+			policy := graph.ApplyPolicy{
+				Mode:               graph.ApplyModeApply,
+				ConflictPolicy:     graph.ConflictPolicyError,
+				FieldManager:       "test-manager",
+				ConflictResolution: tt.resolution,
+			}
+
+			err := applier.Apply(context.Background(), obj, policy)
+
+			if tt.wantForced {
+				if err != nil {
+					t.Fatalf("Apply() error = %v, want forced apply to succeed", err)
+				}
+				if *forced != 1 {
+					t.Errorf("expected exactly one forced apply, got %d", *forced)
+				}
+				return
+			}
+
+			if *forced != 0 {
+				t.Errorf("expected no forced apply, got %d", *forced)
+			}
+			var conflictErr *ConflictError
+			if !errors.As(err, &conflictErr) {
+				t.Fatalf("Apply() error = %v, want *ConflictError", err)
+			}
+			if len(conflictErr.Conflicts) != 2 {
+				t.Errorf("expected 2 structured conflicts, got %v", conflictErr.Conflicts)
+			}
+		})
+	}
+}
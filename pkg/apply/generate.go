@@ -0,0 +1,209 @@
+package apply
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/chazu/pequod/pkg/graph"
+)
+
+// charsets maps each Charset to its alphabet
+var charsets = map[graph.Charset]string{
+	graph.CharsetAlphanumeric: "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789",
+	graph.CharsetNumeric:      "0123456789",
+	graph.CharsetHex:          "0123456789abcdef",
+	graph.CharsetPrintable:    "!\"#$%&'()*+,-./0123456789:;<=>?@ABCDEFGHIJKLMNOPQRSTUVWXYZ[\\]^_`abcdefghijklmnopqrstuvwxyz{|}~",
+}
+
+// SecretGenerator creates Secrets with generated values. Values already in
+// the Secret are kept as they are, so a Secret is populated once and stays
+// stable across renders; only keys missing from it are generated.
+type SecretGenerator struct {
+	client client.Client
+}
+
+// NewSecretGenerator creates a new secret generator
+func NewSecretGenerator(c client.Client) *SecretGenerator {
+	return &SecretGenerator{client: c}
+}
+
+// Generate applies the Secret with its existing values plus newly generated
+// values for any missing keys. The rendered metadata, such as labels and
+// owner references, is applied on every call.
+func (g *SecretGenerator) Generate(ctx context.Context, obj *unstructured.Unstructured, spec graph.GeneratedSecret) error {
+	logger := log.FromContext(ctx).WithValues("secret", obj.GetName(), "namespace", obj.GetNamespace())
+
+	existing := &unstructured.Unstructured{}
+	existing.SetGroupVersionKind(obj.GroupVersionKind())
+	err := g.client.Get(ctx, client.ObjectKeyFromObject(obj), existing)
+	if client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("failed to get generated Secret: %w", err)
+	}
+	current, _, _ := unstructured.NestedStringMap(existing.Object, "data")
+
+	data := make(map[string]interface{})
+	var generated []string
+	for _, key := range spec.Keys {
+		if values, ok := existingValues(current, key); ok {
+			for name, value := range values {
+				data[name] = value
+			}
+			continue
+		}
+
+		values, err := generateValues(key)
+		if err != nil {
+			return &graph.TerminalError{Reason: "SecretGenerationFailed", Err: fmt.Errorf("key %q: %w", key.Name, err)}
+		}
+		for name, value := range values {
+			data[name] = base64.StdEncoding.EncodeToString(value)
+		}
+		generated = append(generated, key.Name)
+	}
+
+	secret := obj.DeepCopy()
+	secret.Object["data"] = data
+
+	// Create rather than apply a new Secret so that two concurrent runs
+	// cannot both generate values and overwrite each other's
+	if apierrors.IsNotFound(err) {
+		if err := g.client.Create(ctx, secret, client.FieldOwner(DefaultFieldManager)); err != nil {
+			return fmt.Errorf("failed to create generated Secret %s/%s: %w", obj.GetNamespace(), obj.GetName(), err)
+		}
+	} else if err := g.handOver(ctx, existing, obj); err != nil {
+		return err
+	} else if err := g.client.Patch(ctx, secret, client.Apply,
+		client.FieldOwner(DefaultFieldManager), client.ForceOwnership); err != nil {
+		return fmt.Errorf("failed to apply generated Secret %s/%s: %w", obj.GetNamespace(), obj.GetName(), err)
+	}
+
+	if len(generated) > 0 {
+		logger.Info("Generated Secret values", "keys", generated)
+	}
+	return nil
+}
+
+// handOver drops the controller reference of an existing Secret if the
+// rendered Secret has another controller, e.g. when a Secret generated for
+// an earlier revision is handed over to its instance. The API server
+// refuses a second controller, and applying does not remove references
+// that were set when the Secret was created.
+func (g *SecretGenerator) handOver(ctx context.Context, existing, obj *unstructured.Unstructured) error {
+	current := metav1.GetControllerOfNoCopy(existing)
+	rendered := metav1.GetControllerOfNoCopy(obj)
+	if current == nil || rendered == nil || current.UID == rendered.UID {
+		return nil
+	}
+
+	patch := client.MergeFrom(existing.DeepCopy())
+	var refs []metav1.OwnerReference
+	for _, ref := range existing.GetOwnerReferences() {
+		if ref.UID != current.UID {
+			refs = append(refs, ref)
+		}
+	}
+	existing.SetOwnerReferences(refs)
+	if err := g.client.Patch(ctx, existing, patch); err != nil {
+		return fmt.Errorf("failed to hand generated Secret %s/%s over to %s %s: %w",
+			obj.GetNamespace(), obj.GetName(), rendered.Kind, rendered.Name, err)
+	}
+	return nil
+}
+
+// existingValues returns the encoded values of a generated key already in
+// the Secret. A keypair only counts as present if both halves are.
+func existingValues(current map[string]string, key graph.GeneratedKey) (map[string]string, bool) {
+	values := make(map[string]string)
+	for _, name := range key.DataKeys() {
+		value, ok := current[name]
+		if !ok {
+			return nil, false
+		}
+		values[name] = value
+	}
+	return values, true
+}
+
+// generateValues generates the raw values of a key, by data key
+func generateValues(key graph.GeneratedKey) (map[string][]byte, error) {
+	switch key.Type {
+	case graph.GeneratedKeyRandom, "":
+		value, err := randomString(key.Length, key.Charset)
+		if err != nil {
+			return nil, err
+		}
+		return map[string][]byte{key.Name: value}, nil
+	case graph.GeneratedKeyRSA:
+		private, err := rsa.GenerateKey(rand.Reader, key.Bits)
+		if err != nil {
+			return nil, err
+		}
+		return encodeKeypair(key.Name, private, private.Public())
+	case graph.GeneratedKeyECDSA:
+		private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		return encodeKeypair(key.Name, private, private.Public())
+	case graph.GeneratedKeyEd25519:
+		public, private, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		return encodeKeypair(key.Name, private, public)
+	default:
+		return nil, fmt.Errorf("unknown generated key type: %s", key.Type)
+	}
+}
+
+// randomString returns length characters drawn uniformly from the charset
+func randomString(length int, charset graph.Charset) ([]byte, error) {
+	alphabet, ok := charsets[charset]
+	if !ok {
+		return nil, fmt.Errorf("unknown charset: %s", charset)
+	}
+
+	size := big.NewInt(int64(len(alphabet)))
+	value := make([]byte, length)
+	for i := range value {
+		n, err := rand.Int(rand.Reader, size)
+		if err != nil {
+			return nil, err
+		}
+		value[i] = alphabet[n.Int64()]
+	}
+	return value, nil
+}
+
+// encodeKeypair PEM encodes a private key as PKCS #8 under name and its
+// public key as PKIX under name + ".pub"
+func encodeKeypair(name string, private crypto.PrivateKey, public crypto.PublicKey) (map[string][]byte, error) {
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		return nil, err
+	}
+	return map[string][]byte{
+		name:          pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}),
+		name + ".pub": pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}),
+	}, nil
+}
@@ -0,0 +1,140 @@
+package apply
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/chazu/pequod/pkg/graph"
+)
+
+func newGeneratedSecret() *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"metadata": map[string]interface{}{
+				"name":      "db-credentials",
+				"namespace": "default",
+			},
+		},
+	}
+}
+
+func getGeneratedSecret(t *testing.T, c client.Client) *corev1.Secret {
+	t.Helper()
+	secret := &corev1.Secret{}
+	if err := c.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "db-credentials"}, secret); err != nil {
+		t.Fatalf("failed to get Secret: %v", err)
+	}
+	return secret
+}
+
+func TestSecretGenerator_GeneratesOnce(t *testing.T) {
+	c := fake.NewClientBuilder().Build()
+	generator := NewSecretGenerator(c)
+
+	spec := graph.GeneratedSecret{Keys: []graph.GeneratedKey{
+		{Name: "password", Type: graph.GeneratedKeyRandom, Length: 24, Charset: graph.CharsetHex},
+	}}
+	if err := generator.Generate(context.Background(), newGeneratedSecret(), spec); err != nil {
+		t.Fatalf("Generate() failed: %v", err)
+	}
+
+	password := string(getGeneratedSecret(t, c).Data["password"])
+	if len(password) != 24 {
+		t.Fatalf("expected a 24 character password, got %q", password)
+	}
+	for _, r := range password {
+		if !('0' <= r && r <= '9' || 'a' <= r && r <= 'f') {
+			t.Fatalf("expected a hex password, got %q", password)
+		}
+	}
+
+	// A second key is added without regenerating the first
+	spec.Keys = append(spec.Keys, graph.GeneratedKey{Name: "token", Type: graph.GeneratedKeyRandom, Length: 32, Charset: graph.CharsetAlphanumeric})
+	if err := generator.Generate(context.Background(), newGeneratedSecret(), spec); err != nil {
+		t.Fatalf("Generate() failed: %v", err)
+	}
+
+	secret := getGeneratedSecret(t, c)
+	if got := string(secret.Data["password"]); got != password {
+		t.Errorf("expected password to stay %q, got %q", password, got)
+	}
+	if len(secret.Data["token"]) != 32 {
+		t.Errorf("expected a 32 character token, got %q", secret.Data["token"])
+	}
+}
+
+func TestSecretGenerator_ReRender(t *testing.T) {
+	c := fake.NewClientBuilder().Build()
+	generator := NewSecretGenerator(c)
+	spec := graph.GeneratedSecret{Keys: []graph.GeneratedKey{
+		{Name: "password", Type: graph.GeneratedKeyRandom, Length: 24, Charset: graph.CharsetHex},
+	}}
+
+	// A Secret generated before generated Secrets were controlled by their
+	// instance is controlled by the revision that generated it
+	controller := true
+	owned := func(kind, name, uid string) *unstructured.Unstructured {
+		obj := newGeneratedSecret()
+		obj.SetOwnerReferences([]metav1.OwnerReference{{
+			APIVersion: "example.com/v1", Kind: kind, Name: name, UID: types.UID(uid), Controller: &controller,
+		}})
+		return obj
+	}
+	if err := generator.Generate(context.Background(), owned("ResourceGraph", "db-aaaaaaaa", "rg-uid"), spec); err != nil {
+		t.Fatalf("Generate() failed: %v", err)
+	}
+	password := string(getGeneratedSecret(t, c).Data["password"])
+
+	// The next render hands it over to the instance, keeping its values
+	if err := generator.Generate(context.Background(), owned("Database", "db", "db-uid"), spec); err != nil {
+		t.Fatalf("Generate() failed: %v", err)
+	}
+	secret := getGeneratedSecret(t, c)
+	if got := string(secret.Data["password"]); got != password {
+		t.Errorf("expected password to stay %q, got %q", password, got)
+	}
+	if refs := secret.OwnerReferences; len(refs) != 1 || refs[0].UID != "db-uid" {
+		t.Errorf("expected the Secret to be controlled by the instance only, got %+v", refs)
+	}
+}
+
+func TestSecretGenerator_Keypairs(t *testing.T) {
+	keyTypes := []graph.GeneratedKeyType{graph.GeneratedKeyRSA, graph.GeneratedKeyECDSA, graph.GeneratedKeyEd25519}
+
+	for _, keyType := range keyTypes {
+		t.Run(string(keyType), func(t *testing.T) {
+			c := fake.NewClientBuilder().Build()
+			spec := graph.GeneratedSecret{Keys: []graph.GeneratedKey{{Name: "signing", Type: keyType, Bits: 2048}}}
+			if err := NewSecretGenerator(c).Generate(context.Background(), newGeneratedSecret(), spec); err != nil {
+				t.Fatalf("Generate() failed: %v", err)
+			}
+
+			secret := getGeneratedSecret(t, c)
+			private, _ := pem.Decode(secret.Data["signing"])
+			if private == nil {
+				t.Fatal("expected a PEM private key")
+			}
+			if _, err := x509.ParsePKCS8PrivateKey(private.Bytes); err != nil {
+				t.Errorf("failed to parse private key: %v", err)
+			}
+			public, _ := pem.Decode(secret.Data["signing.pub"])
+			if public == nil {
+				t.Fatal("expected a PEM public key")
+			}
+			if _, err := x509.ParsePKIXPublicKey(public.Bytes); err != nil {
+				t.Errorf("failed to parse public key: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package apply
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/chazu/pequod/pkg/graph"
+)
+
+const (
+	// HookRenderHashAnnotation records the render hash a hook Job was created for
+	HookRenderHashAnnotation = "pequod.io/hook-render-hash"
+
+	// HookPhaseAnnotation records the phase of a hook Job
+	HookPhaseAnnotation = "pequod.io/hook-phase"
+
+	// ReasonHookFailed is the terminal error reason of a failed hook Job
+	ReasonHookFailed = "HookFailed"
+
+	// DefaultHookTimeout bounds how long a hook Job may run
+	DefaultHookTimeout = 10 * time.Minute
+
+	// DefaultHookPollInterval is how often a hook Job's status is checked
+	DefaultHookPollInterval = 2 * time.Second
+)
+
+// HookRunner runs lifecycle hook Jobs. A Job is created at most once per
+// render hash: if a Job for the same hash exists it is waited on rather than
+// replaced, and a Job left from an earlier render is deleted first.
+type HookRunner struct {
+	client client.Client
+
+	// Timeout bounds how long RunHook waits for the Job to finish
+	Timeout time.Duration
+
+	// PollInterval is how often the Job's status is checked
+	PollInterval time.Duration
+}
+
+// NewHookRunner creates a new hook runner
+func NewHookRunner(c client.Client) *HookRunner {
+	return &HookRunner{
+		client:       c,
+		Timeout:      DefaultHookTimeout,
+		PollInterval: DefaultHookPollInterval,
+	}
+}
+
+// RunHook creates the hook Job for renderHash if needed and waits for it to
+// finish. A failed Job is reported as a terminal error.
+func (h *HookRunner) RunHook(ctx context.Context, obj *unstructured.Unstructured, hook graph.Hook, renderHash string) error {
+	logger := log.FromContext(ctx).WithValues("hook", obj.GetName(), "phase", hook.Phase)
+
+	job := obj.DeepCopy()
+	annotations := job.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[HookRenderHashAnnotation] = renderHash
+	annotations[HookPhaseAnnotation] = string(hook.Phase)
+	job.SetAnnotations(annotations)
+
+	existing := &unstructured.Unstructured{}
+	existing.SetGroupVersionKind(job.GroupVersionKind())
+	err := h.client.Get(ctx, client.ObjectKeyFromObject(job), existing)
+	switch {
+	case apierrors.IsNotFound(err):
+		existing = nil
+	case err != nil:
+		return fmt.Errorf("failed to get hook Job: %w", err)
+	}
+
+	// A Job from an earlier render is replaced so the hook runs again
+	if existing != nil && existing.GetAnnotations()[HookRenderHashAnnotation] != renderHash {
+		logger.Info("Replacing hook Job from an earlier render")
+		if err := h.deleteJob(ctx, existing); err != nil {
+			return err
+		}
+		if err := waitForDeletion(ctx, h.client, existing); err != nil {
+			return err
+		}
+		existing = nil
+	}
+
+	if existing == nil {
+		logger.Info("Starting hook Job")
+		if err := h.client.Create(ctx, job); err != nil && !apierrors.IsAlreadyExists(err) {
+			return fmt.Errorf("failed to create hook Job: %w", err)
+		}
+	}
+
+	succeeded, message, err := h.waitForJob(ctx, job)
+	if err != nil {
+		return err
+	}
+
+	cleanup := hook.CleanupPolicy
+	if cleanup == graph.HookCleanupOnCompletion || succeeded && cleanup == graph.HookCleanupOnSuccess {
+		if err := h.deleteJob(ctx, job); err != nil {
+			logger.Error(err, "Failed to clean up hook Job")
+		}
+	}
+
+	if !succeeded {
+		return &graph.TerminalError{
+			Reason: ReasonHookFailed,
+			Err:    fmt.Errorf("Job %s/%s failed: %s", job.GetNamespace(), job.GetName(), message),
+		}
+	}
+	logger.Info("Hook Job succeeded")
+	return nil
+}
+
+// waitForJob polls the Job until it has a Complete or Failed condition and
+// returns whether it succeeded along with the condition message
+func (h *HookRunner) waitForJob(ctx context.Context, job *unstructured.Unstructured) (bool, string, error) {
+	var succeeded bool
+	var message string
+
+	err := wait.PollUntilContextTimeout(ctx, h.PollInterval, h.Timeout, true, func(ctx context.Context) (bool, error) {
+		current := &unstructured.Unstructured{}
+		current.SetGroupVersionKind(job.GroupVersionKind())
+		if err := h.client.Get(ctx, client.ObjectKeyFromObject(job), current); err != nil {
+			return false, err
+		}
+
+		conditions, _, _ := unstructured.NestedSlice(current.Object, "status", "conditions")
+		for _, c := range conditions {
+			cond, ok := c.(map[string]interface{})
+			if !ok || cond["status"] != string(metav1.ConditionTrue) {
+				continue
+			}
+			switch cond["type"] {
+			case "Complete":
+				succeeded = true
+				return true, nil
+			case "Failed":
+				message, _ = cond["message"].(string)
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+	if err != nil {
+		return false, "", fmt.Errorf("waiting for hook Job %s/%s: %w", job.GetNamespace(), job.GetName(), err)
+	}
+	return succeeded, message, nil
+}
+
+// deleteJob deletes a hook Job together with its pods
+func (h *HookRunner) deleteJob(ctx context.Context, job *unstructured.Unstructured) error {
+	err := h.client.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground))
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete hook Job %s/%s: %w", job.GetNamespace(), job.GetName(), err)
+	}
+	return nil
+}
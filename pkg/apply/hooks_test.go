@@ -0,0 +1,121 @@
+package apply
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/chazu/pequod/pkg/graph"
+)
+
+// newHookJob returns a hook Job; a non-empty condition type marks it finished
+func newHookJob(renderHash, conditionType string) *unstructured.Unstructured {
+	job := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "batch/v1",
+			"kind":       "Job",
+			"metadata": map[string]interface{}{
+				"name":      "migrate",
+				"namespace": "default",
+			},
+		},
+	}
+	if renderHash != "" {
+		job.SetAnnotations(map[string]string{HookRenderHashAnnotation: renderHash})
+	}
+	if conditionType != "" {
+		_ = unstructured.SetNestedSlice(job.Object, []interface{}{
+			map[string]interface{}{"type": conditionType, "status": "True", "message": "BackoffLimitExceeded"},
+		}, "status", "conditions")
+	}
+	return job
+}
+
+func newTestHookRunner(objs ...client.Object) (*HookRunner, client.Client) {
+	c := fake.NewClientBuilder().WithObjects(objs...).Build()
+	runner := NewHookRunner(c)
+	runner.PollInterval = time.Millisecond
+	runner.Timeout = 50 * time.Millisecond
+	return runner, c
+}
+
+func getHookJob(t *testing.T, c client.Client) (*unstructured.Unstructured, bool) {
+	t.Helper()
+	job := newHookJob("", "")
+	err := c.Get(context.Background(), client.ObjectKeyFromObject(job), job)
+	if apierrors.IsNotFound(err) {
+		return nil, false
+	}
+	if err != nil {
+		t.Fatalf("failed to get Job: %v", err)
+	}
+	return job, true
+}
+
+func TestHookRunner_SkipsJobThatRanForRenderHash(t *testing.T) {
+	runner, c := newTestHookRunner(newHookJob("abc", "Complete"))
+
+	hook := graph.Hook{Phase: graph.HookPhasePreApply, CleanupPolicy: graph.HookCleanupBeforeRun}
+	if err := runner.RunHook(context.Background(), newHookJob("", ""), hook, "abc"); err != nil {
+		t.Fatalf("RunHook() failed: %v", err)
+	}
+
+	if _, found := getHookJob(t, c); !found {
+		t.Error("expected BeforeRun to keep the completed Job")
+	}
+}
+
+func TestHookRunner_CleansUpOnSuccess(t *testing.T) {
+	runner, c := newTestHookRunner(newHookJob("abc", "Complete"))
+
+	hook := graph.Hook{Phase: graph.HookPhasePostApply, CleanupPolicy: graph.HookCleanupOnSuccess}
+	if err := runner.RunHook(context.Background(), newHookJob("", ""), hook, "abc"); err != nil {
+		t.Fatalf("RunHook() failed: %v", err)
+	}
+
+	if _, found := getHookJob(t, c); found {
+		t.Error("expected OnSuccess to delete the completed Job")
+	}
+}
+
+func TestHookRunner_ReportsFailedJobAsTerminal(t *testing.T) {
+	runner, c := newTestHookRunner(newHookJob("abc", "Failed"))
+
+	hook := graph.Hook{Phase: graph.HookPhasePreApply, CleanupPolicy: graph.HookCleanupOnSuccess}
+	err := runner.RunHook(context.Background(), newHookJob("", ""), hook, "abc")
+
+	var terminalErr *graph.TerminalError
+	if !errors.As(err, &terminalErr) || terminalErr.Reason != ReasonHookFailed {
+		t.Fatalf("expected terminal HookFailed error, got %v", err)
+	}
+	if _, found := getHookJob(t, c); !found {
+		t.Error("expected OnSuccess to keep the failed Job for debugging")
+	}
+}
+
+func TestHookRunner_ReplacesJobFromEarlierRender(t *testing.T) {
+	runner, c := newTestHookRunner(newHookJob("old", "Complete"))
+
+	hook := graph.Hook{Phase: graph.HookPhasePreApply, CleanupPolicy: graph.HookCleanupBeforeRun}
+	// The new Job never finishes in the fake client, so the wait times out
+	if err := runner.RunHook(context.Background(), newHookJob("", ""), hook, "new"); err == nil {
+		t.Fatal("expected RunHook to time out waiting for the new Job")
+	}
+
+	job, found := getHookJob(t, c)
+	if !found {
+		t.Fatal("expected the Job to be recreated")
+	}
+	if hash := job.GetAnnotations()[HookRenderHashAnnotation]; hash != "new" {
+		t.Errorf("expected Job for render hash new, got %q", hash)
+	}
+	if _, finished, _ := unstructured.NestedSlice(job.Object, "status", "conditions"); finished {
+		t.Error("expected the recreated Job to start without a status")
+	}
+}
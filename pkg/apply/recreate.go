@@ -0,0 +1,171 @@
+package apply
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/chazu/pequod/pkg/graph"
+)
+
+const (
+	// recreateTimeout bounds how long a Recreate waits for the old object to disappear
+	recreateTimeout = 2 * time.Minute
+
+	// recreatePollInterval is how often a Recreate checks whether the old object is gone
+	recreatePollInterval = time.Second
+)
+
+// immutableFieldMarkers are the phrases API server validation uses when an
+// update changes a field that cannot be changed after creation
+var immutableFieldMarkers = []string{
+	"is immutable",
+	"may not change once set",
+}
+
+// ImmutableFieldError indicates that an apply was rejected because it
+// changes one or more immutable fields
+type ImmutableFieldError struct {
+	Resource string
+	// Fields lists the immutable field paths reported by the API server
+	Fields []string
+	Err    error
+}
+
+func (e *ImmutableFieldError) Error() string {
+	return fmt.Sprintf("immutable field change for %s (fields: %s); set updateStrategy Recreate to replace the resource: %v",
+		e.Resource, strings.Join(e.Fields, ", "), e.Err)
+}
+
+func (e *ImmutableFieldError) Unwrap() error {
+	return e.Err
+}
+
+// Reason describes why the resource had to be replaced
+func (e *ImmutableFieldError) Reason() string {
+	return fmt.Sprintf("immutable fields changed: %s", strings.Join(e.Fields, ", "))
+}
+
+// immutableFields returns the immutable field paths reported in an Invalid
+// error, or nil if the error is not an immutable-field rejection
+func immutableFields(err error) []string {
+	if !apierrors.IsInvalid(err) {
+		return nil
+	}
+
+	var fields []string
+	var statusErr apierrors.APIStatus
+	if errors.As(err, &statusErr) && statusErr.Status().Details != nil {
+		for _, cause := range statusErr.Status().Details.Causes {
+			if isImmutableMessage(cause.Message) {
+				fields = append(fields, cause.Field)
+			}
+		}
+	}
+	if len(fields) == 0 && isImmutableMessage(err.Error()) {
+		// Some validators only report the message, not the causes
+		fields = []string{"<unknown>"}
+	}
+	return fields
+}
+
+// isImmutableMessage reports whether a validation message is about an immutable field
+func isImmutableMessage(msg string) bool {
+	for _, marker := range immutableFieldMarkers {
+		if strings.Contains(msg, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// RecreateNotifier is called after a resource has been deleted and created
+// again because an immutable field changed
+type RecreateNotifier func(obj *unstructured.Unstructured, reason string)
+
+type recreateNotifierKey struct{}
+
+// WithRecreateNotifier returns a context that notifies n about recreated resources
+func WithRecreateNotifier(ctx context.Context, n RecreateNotifier) context.Context {
+	return context.WithValue(ctx, recreateNotifierKey{}, n)
+}
+
+// notifyRecreated calls the RecreateNotifier in ctx, if any
+func notifyRecreated(ctx context.Context, obj *unstructured.Unstructured, reason string) {
+	if n, ok := ctx.Value(recreateNotifierKey{}).(RecreateNotifier); ok && n != nil {
+		n(obj, reason)
+	}
+}
+
+// recreate deletes the existing resource, waits for it to disappear and
+// applies obj again so it is created from scratch
+func (a *Applier) recreate(
+	ctx context.Context,
+	obj *unstructured.Unstructured,
+	policy graph.ApplyPolicy,
+	patchOpts []client.PatchOption,
+	cause *ImmutableFieldError,
+	logger logr.Logger,
+) error {
+	key := client.ObjectKeyFromObject(obj)
+	existing := &unstructured.Unstructured{}
+	existing.SetGroupVersionKind(obj.GroupVersionKind())
+	if err := a.client.Get(ctx, key, existing); err != nil {
+		return fmt.Errorf("failed to get resource for recreate: %w", err)
+	}
+
+	propagation := metav1.DeletionPropagation(policy.UpdateStrategy.PropagationPolicy)
+	logger.Info("Recreating resource", "reason", cause.Reason(), "propagationPolicy", propagation)
+
+	// Guard with the UID so a replacement created by someone else is never deleted
+	uid := existing.GetUID()
+	if err := a.client.Delete(ctx, existing,
+		client.PropagationPolicy(propagation),
+		client.Preconditions{UID: &uid},
+	); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete resource for recreate: %w", err)
+	}
+
+	if err := waitForDeletion(ctx, a.client, existing); err != nil {
+		return err
+	}
+
+	if err := a.client.Patch(ctx, obj, client.Apply, patchOpts...); err != nil {
+		return fmt.Errorf("failed to recreate resource %s/%s: %w", obj.GetNamespace(), obj.GetName(), err)
+	}
+
+	notifyRecreated(ctx, obj, cause.Reason())
+	return nil
+}
+
+// waitForDeletion polls until obj is gone or replaced by an object with a
+// different UID
+func waitForDeletion(ctx context.Context, c client.Client, obj *unstructured.Unstructured) error {
+	key := client.ObjectKeyFromObject(obj)
+	uid := obj.GetUID()
+	err := wait.PollUntilContextTimeout(ctx, recreatePollInterval, recreateTimeout, true, func(ctx context.Context) (bool, error) {
+		current := &unstructured.Unstructured{}
+		current.SetGroupVersionKind(obj.GroupVersionKind())
+		if err := c.Get(ctx, key, current); err != nil {
+			if apierrors.IsNotFound(err) {
+				return true, nil
+			}
+			return false, err
+		}
+		// A different UID means the old object is gone and something else recreated it
+		return current.GetUID() != uid, nil
+	})
+	if err != nil {
+		return fmt.Errorf("waiting for %s/%s to be deleted: %w", obj.GetNamespace(), obj.GetName(), err)
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package apply
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/chazu/pequod/pkg/graph"
+)
+
+func newImmutableError() error {
+	return apierrors.NewInvalid(schema.GroupKind{Group: "apps", Kind: "Deployment"}, "web", field.ErrorList{
+		field.Invalid(field.NewPath("spec", "selector"), "new", "field is immutable"),
+	})
+}
+
+func newDeployment(selector string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata": map[string]interface{}{
+				"name":      "web",
+				"namespace": "default",
+			},
+			"spec": map[string]interface{}{
+				"selector": map[string]interface{}{
+					"matchLabels": map[string]interface{}{"app": selector},
+				},
+			},
+		},
+	}
+}
+
+// newImmutableClient returns a client that rejects applies to an existing
+// Deployment with an immutable-field error, as the API server would when the
+// selector changes
+func newImmutableClient(existing *unstructured.Unstructured) (client.Client, *int) {
+	deletes := 0
+	c := fake.NewClientBuilder().
+		WithObjects(existing).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+				current := &unstructured.Unstructured{}
+				current.SetGroupVersionKind(obj.GetObjectKind().GroupVersionKind())
+				if err := c.Get(ctx, client.ObjectKeyFromObject(obj), current); err == nil {
+					return newImmutableError()
+				}
+				return c.Patch(ctx, obj, patch, opts...)
+			},
+			Delete: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+				deletes++
+				return c.Delete(ctx, obj, opts...)
+			},
+		}).Build()
+	return c, &deletes
+}
+
+func TestApplier_ImmutableFieldError(t *testing.T) {
+	c, deletes := newImmutableClient(newDeployment("old"))
+	applier := NewApplier(c)
+
+	err := applier.Apply(context.Background(), newDeployment("new"), graph.ApplyPolicy{Mode: graph.ApplyModeApply})
+
+	var immutableErr *ImmutableFieldError
+	if !errors.As(err, &immutableErr) {
+		t.Fatalf("Apply() error = %v, want *ImmutableFieldError", err)
+	}
+	if len(immutableErr.Fields) != 1 || immutableErr.Fields[0] != "spec.selector" {
+		t.Errorf("expected spec.selector to be reported, got %v", immutableErr.Fields)
+	}
+	if *deletes != 0 {
+		t.Errorf("expected no delete without Recreate strategy, got %d", *deletes)
+	}
+}
+
+func TestApplier_Recreate(t *testing.T) {
+	c, deletes := newImmutableClient(newDeployment("old"))
+	applier := NewApplier(c)
+
+	var notified string
+	ctx := WithRecreateNotifier(context.Background(), func(obj *unstructured.Unstructured, reason string) {
+		notified = reason
+	})
+
+	policy := graph.ApplyPolicy{
+		Mode: graph.ApplyModeApply,
+		UpdateStrategy: &graph.UpdateStrategy{
+			Type:              graph.UpdateStrategyRecreate,
+			PropagationPolicy: "Background",
+		},
+	}
+	if err := applier.Apply(ctx, newDeployment("new"), policy); err != nil {
+		t.Fatalf("Apply() failed: %v", err)
+	}
+
+	if *deletes != 1 {
+		t.Errorf("expected exactly one delete, got %d", *deletes)
+	}
+	if notified != "immutable fields changed: spec.selector" {
+		t.Errorf("unexpected recreate reason %q", notified)
+	}
+
+	got := &unstructured.Unstructured{}
+	got.SetGroupVersionKind(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"})
+	if err := c.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "web"}, got); err != nil {
+		t.Fatalf("expected recreated object: %v", err)
+	}
+	selector, _, _ := unstructured.NestedString(got.Object, "spec", "selector", "matchLabels", "app")
+	if selector != "new" {
+		t.Errorf("expected recreated object to have the new selector, got %q", selector)
+	}
+}
+
+func TestApplier_RecreateSkippedInDryRun(t *testing.T) {
+	c, deletes := newImmutableClient(newDeployment("old"))
+	applier := NewApplier(c).WithDryRun(true)
+
+	policy := graph.ApplyPolicy{
+		Mode:           graph.ApplyModeApply,
+		UpdateStrategy: &graph.UpdateStrategy{Type: graph.UpdateStrategyRecreate},
+	}
+	err := applier.Apply(context.Background(), newDeployment("new"), policy)
+
+	var immutableErr *ImmutableFieldError
+	if !errors.As(err, &immutableErr) {
+		t.Fatalf("Apply() error = %v, want *ImmutableFieldError", err)
+	}
+	if *deletes != 0 {
+		t.Errorf("expected no delete in dry-run mode, got %d", *deletes)
+	}
+}
@@ -0,0 +1,87 @@
+package apply
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/structured-merge-diff/v6/fieldpath"
+
+	"github.com/chazu/pequod/pkg/graph"
+)
+
+// releaseIgnoredFields hands the ignored fields of obj over to the other
+// controllers by removing them from the field manager's applied set on the
+// live object. SSA removes fields a manager applied before and no longer
+// applies, so without the hand-off, ignoring a field Pequod used to apply,
+// e.g. spec.replicas, would reset it on the next apply. Released fields are
+// left unowned until another manager writes them.
+func (a *Applier) releaseIgnoredFields(
+	ctx context.Context, obj *unstructured.Unstructured, policy graph.ApplyPolicy, logger logr.Logger,
+) error {
+	paths := make([]graph.FieldPath, 0, len(policy.IgnoreFields))
+	for _, raw := range policy.IgnoreFields {
+		p, err := graph.ParseFieldPath(raw)
+		if err != nil {
+			continue
+		}
+		paths = append(paths, p)
+	}
+
+	live := &unstructured.Unstructured{}
+	live.SetGroupVersionKind(obj.GroupVersionKind())
+	if err := a.client.Get(ctx, client.ObjectKeyFromObject(obj), live); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to get resource %s/%s: %w", obj.GetNamespace(), obj.GetName(), err)
+	}
+
+	entries := live.GetManagedFields()
+	released := 0
+	for i := range entries {
+		entry := &entries[i]
+		if entry.Manager != policy.FieldManager || entry.Operation != metav1.ManagedFieldsOperationApply ||
+			entry.FieldsV1 == nil {
+			continue
+		}
+		owned := &fieldpath.Set{}
+		if err := owned.FromJSON(bytes.NewReader(entry.FieldsV1.Raw)); err != nil {
+			return fmt.Errorf("failed to decode managed fields of %s: %w", entry.Manager, err)
+		}
+		ignored := &fieldpath.Set{}
+		owned.Iterate(func(path fieldpath.Path) {
+			for _, p := range paths {
+				if p.Covers(path.String()) {
+					ignored.Insert(path)
+					return
+				}
+			}
+		})
+		if ignored.Empty() {
+			continue
+		}
+		raw, err := owned.Difference(ignored).ToJSON()
+		if err != nil {
+			return fmt.Errorf("failed to encode managed fields of %s: %w", entry.Manager, err)
+		}
+		entry.FieldsV1 = &metav1.FieldsV1{Raw: raw}
+		released += ignored.Size()
+	}
+	if released == 0 {
+		return nil
+	}
+
+	logger.V(1).Info("Releasing ignored fields from field manager", "fields", released)
+	patch := client.MergeFromWithOptions(live.DeepCopy(), client.MergeFromWithOptimisticLock{})
+	live.SetManagedFields(entries)
+	if err := a.client.Patch(ctx, live, patch); err != nil {
+		return fmt.Errorf("failed to release ignored fields of %s/%s: %w", obj.GetNamespace(), obj.GetName(), err)
+	}
+	return nil
+}
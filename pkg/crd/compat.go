@@ -0,0 +1,346 @@
+package crd
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// SchemaChangeKind classifies a change between two schemas
+type SchemaChangeKind string
+
+const (
+	// FieldAdded is a property only the new schema has
+	FieldAdded SchemaChangeKind = "FieldAdded"
+
+	// FieldRemoved is a property only the old schema has. The API server
+	// prunes it from stored objects, so it is breaking unless the new schema
+	// preserves unknown fields.
+	FieldRemoved SchemaChangeKind = "FieldRemoved"
+
+	// FieldRequired is a property only the new schema requires. It is
+	// breaking unless the property has a default.
+	FieldRequired SchemaChangeKind = "FieldRequired"
+
+	// FieldOptional is a property only the old schema requires
+	FieldOptional SchemaChangeKind = "FieldOptional"
+
+	// EnumNarrowed is an enum that no longer allows some values. It is
+	// breaking.
+	EnumNarrowed SchemaChangeKind = "EnumNarrowed"
+
+	// EnumWidened is an enum that allows further values
+	EnumWidened SchemaChangeKind = "EnumWidened"
+
+	// TypeChanged is a value of another type. It is breaking unless the
+	// new type accepts every value of the old one.
+	TypeChanged SchemaChangeKind = "TypeChanged"
+
+	// BoundTightened is a minimum, maximum or length, item or property
+	// count limit that rejects some values the old one allowed. It is
+	// breaking.
+	BoundTightened SchemaChangeKind = "BoundTightened"
+
+	// BoundLoosened is such a limit that allows further values
+	BoundLoosened SchemaChangeKind = "BoundLoosened"
+
+	// PatternChanged is a string pattern that was added, changed or
+	// removed. It is breaking unless it was removed.
+	PatternChanged SchemaChangeKind = "PatternChanged"
+
+	// VersionRemoved is a served API version the new CRD no longer serves.
+	// Clients using it fail, so it is breaking.
+	VersionRemoved SchemaChangeKind = "VersionRemoved"
+)
+
+// SchemaChange is a change at one path between two schemas
+type SchemaChange struct {
+	// Path is the changed property, e.g. resources.cpu or ports[].name,
+	// and empty for the root
+	Path string
+
+	// Kind classifies the change
+	Kind SchemaChangeKind
+
+	// Breaking is true if objects valid under the old schema may be
+	// invalid, or lose data, under the new one
+	Breaking bool
+
+	// Message describes the change
+	Message string
+}
+
+// CompareSchemas returns the changes from an old schema to a new one,
+// ordered by path. A nil schema accepts anything. Only changes that affect
+// which objects are valid are reported; descriptions and formats are not
+// compared.
+func CompareSchemas(oldSchema, newSchema *apiextensionsv1.JSONSchemaProps) []SchemaChange {
+	var changes []SchemaChange
+	compareSchema("", oldSchema, newSchema, &changes)
+	return changes
+}
+
+// compareSchema appends the changes at path and below it
+func compareSchema(path string, oldSchema, newSchema *apiextensionsv1.JSONSchemaProps, changes *[]SchemaChange) {
+	switch {
+	case acceptsAnything(newSchema):
+		if !acceptsAnything(oldSchema) {
+			*changes = append(*changes, SchemaChange{Path: path, Kind: TypeChanged,
+				Message: fmt.Sprintf("type changed from %s to any value", oldSchema.Type)})
+		}
+		return
+	case acceptsAnything(oldSchema):
+		*changes = append(*changes, SchemaChange{Path: path, Kind: TypeChanged, Breaking: true,
+			Message: fmt.Sprintf("type changed from any value to %s", newSchema.Type)})
+		return
+	case oldSchema.Type != newSchema.Type:
+		// Every integer is a number
+		widened := oldSchema.Type == "integer" && newSchema.Type == "number"
+		*changes = append(*changes, SchemaChange{Path: path, Kind: TypeChanged, Breaking: !widened,
+			Message: fmt.Sprintf("type changed from %s to %s", oldSchema.Type, newSchema.Type)})
+		return
+	}
+
+	compareEnum(path, oldSchema.Enum, newSchema.Enum, changes)
+	compareBounds(path, oldSchema, newSchema, changes)
+	comparePattern(path, oldSchema.Pattern, newSchema.Pattern, changes)
+
+	switch newSchema.Type {
+	case "object":
+		compareProperties(path, oldSchema, newSchema, changes)
+		if oldSchema.AdditionalProperties != nil && newSchema.AdditionalProperties != nil {
+			compareSchema(path+"[*]", oldSchema.AdditionalProperties.Schema, newSchema.AdditionalProperties.Schema, changes)
+		}
+	case "array":
+		if oldSchema.Items != nil && newSchema.Items != nil {
+			compareSchema(path+"[]", oldSchema.Items.Schema, newSchema.Items.Schema, changes)
+		}
+	}
+}
+
+// compareProperties appends the changes to the properties of two object
+// schemas
+func compareProperties(path string, oldSchema, newSchema *apiextensionsv1.JSONSchemaProps, changes *[]SchemaChange) {
+	names := make([]string, 0, len(oldSchema.Properties)+len(newSchema.Properties))
+	for name := range oldSchema.Properties {
+		names = append(names, name)
+	}
+	for name := range newSchema.Properties {
+		if _, ok := oldSchema.Properties[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	oldRequired := stringSet(oldSchema.Required)
+	newRequired := stringSet(newSchema.Required)
+	for _, name := range names {
+		fieldPath := name
+		if path != "" {
+			fieldPath = path + "." + name
+		}
+		oldProp, inOld := oldSchema.Properties[name]
+		newProp, inNew := newSchema.Properties[name]
+
+		switch {
+		case !inNew && isTrue(newSchema.XPreserveUnknownFields):
+			*changes = append(*changes, SchemaChange{Path: fieldPath, Kind: FieldRemoved,
+				Message: "field removed, and kept as an unknown field"})
+			continue
+		case !inNew:
+			*changes = append(*changes, SchemaChange{Path: fieldPath, Kind: FieldRemoved, Breaking: true,
+				Message: "field removed"})
+			continue
+		case !inOld:
+			*changes = append(*changes, SchemaChange{Path: fieldPath, Kind: FieldAdded, Message: "field added"})
+		default:
+			compareSchema(fieldPath, &oldProp, &newProp, changes)
+		}
+
+		switch {
+		case newRequired[name] && !oldRequired[name]:
+			// Defaults are applied to stored objects when they are read
+			if newProp.Default != nil {
+				*changes = append(*changes, SchemaChange{Path: fieldPath, Kind: FieldRequired,
+					Message: "field is now required, with a default"})
+			} else {
+				*changes = append(*changes, SchemaChange{Path: fieldPath, Kind: FieldRequired, Breaking: true,
+					Message: "field is now required"})
+			}
+		case oldRequired[name] && !newRequired[name]:
+			*changes = append(*changes, SchemaChange{Path: fieldPath, Kind: FieldOptional,
+				Message: "field is no longer required"})
+		}
+	}
+}
+
+// compareEnum appends the change between the enums of two schemas of the
+// same type, where an empty enum allows any value
+func compareEnum(path string, oldEnum, newEnum []apiextensionsv1.JSON, changes *[]SchemaChange) {
+	oldValues := enumValues(oldEnum)
+	newValues := enumValues(newEnum)
+
+	if len(newEnum) > 0 {
+		var dropped []string
+		for _, value := range sortedKeys(oldValues) {
+			if !newValues[value] {
+				dropped = append(dropped, value)
+			}
+		}
+		switch {
+		case len(oldEnum) == 0:
+			*changes = append(*changes, SchemaChange{Path: path, Kind: EnumNarrowed, Breaking: true,
+				Message: fmt.Sprintf("values restricted to %s", strings.Join(sortedKeys(newValues), ", "))})
+			return
+		case len(dropped) > 0:
+			*changes = append(*changes, SchemaChange{Path: path, Kind: EnumNarrowed, Breaking: true,
+				Message: fmt.Sprintf("values %s no longer allowed", strings.Join(dropped, ", "))})
+			return
+		}
+	}
+
+	if len(oldEnum) == 0 {
+		return
+	}
+	if len(newEnum) == 0 {
+		*changes = append(*changes, SchemaChange{Path: path, Kind: EnumWidened, Message: "values no longer restricted"})
+		return
+	}
+	var added []string
+	for _, value := range sortedKeys(newValues) {
+		if !oldValues[value] {
+			added = append(added, value)
+		}
+	}
+	if len(added) > 0 {
+		*changes = append(*changes, SchemaChange{Path: path, Kind: EnumWidened,
+			Message: fmt.Sprintf("values %s now allowed", strings.Join(added, ", "))})
+	}
+}
+
+// compareBounds appends the changes to the value, length, item and
+// property count limits of two schemas of the same type
+func compareBounds(path string, oldSchema, newSchema *apiextensionsv1.JSONSchemaProps, changes *[]SchemaChange) {
+	compareBound(path, "minimum", false, oldSchema.Minimum, newSchema.Minimum,
+		oldSchema.ExclusiveMinimum, newSchema.ExclusiveMinimum, changes)
+	compareBound(path, "maximum", true, oldSchema.Maximum, newSchema.Maximum,
+		oldSchema.ExclusiveMaximum, newSchema.ExclusiveMaximum, changes)
+	compareBound(path, "minLength", false, floatPtr(oldSchema.MinLength), floatPtr(newSchema.MinLength), false, false, changes)
+	compareBound(path, "maxLength", true, floatPtr(oldSchema.MaxLength), floatPtr(newSchema.MaxLength), false, false, changes)
+	compareBound(path, "minItems", false, floatPtr(oldSchema.MinItems), floatPtr(newSchema.MinItems), false, false, changes)
+	compareBound(path, "maxItems", true, floatPtr(oldSchema.MaxItems), floatPtr(newSchema.MaxItems), false, false, changes)
+	compareBound(path, "minProperties", false, floatPtr(oldSchema.MinProperties), floatPtr(newSchema.MinProperties),
+		false, false, changes)
+	compareBound(path, "maxProperties", true, floatPtr(oldSchema.MaxProperties), floatPtr(newSchema.MaxProperties),
+		false, false, changes)
+}
+
+// compareBound appends the change between two lower or upper limits named
+// name, where a nil limit allows any value
+func compareBound(
+	path, name string, upper bool, oldValue, newValue *float64, oldExclusive, newExclusive bool, changes *[]SchemaChange,
+) {
+	var tightened bool
+	var message string
+	switch {
+	case oldValue == nil && newValue == nil:
+		return
+	case oldValue == nil:
+		tightened = true
+		message = fmt.Sprintf("%s %s added", name, formatBound(*newValue, newExclusive))
+	case newValue == nil:
+		message = fmt.Sprintf("%s %s removed", name, formatBound(*oldValue, oldExclusive))
+	case *oldValue == *newValue && oldExclusive == newExclusive:
+		return
+	case *oldValue == *newValue:
+		// An exclusive limit rejects the limit itself
+		tightened = newExclusive
+		message = fmt.Sprintf("%s changed from %s to %s", name,
+			formatBound(*oldValue, oldExclusive), formatBound(*newValue, newExclusive))
+	default:
+		tightened = (*newValue < *oldValue) == upper
+		direction := "raised"
+		if *newValue < *oldValue {
+			direction = "lowered"
+		}
+		message = fmt.Sprintf("%s %s from %s to %s", name, direction,
+			formatBound(*oldValue, oldExclusive), formatBound(*newValue, newExclusive))
+	}
+
+	kind := BoundLoosened
+	if tightened {
+		kind = BoundTightened
+	}
+	*changes = append(*changes, SchemaChange{Path: path, Kind: kind, Breaking: tightened, Message: message})
+}
+
+// comparePattern appends the change between the patterns of two string
+// schemas, where an empty pattern allows any value. Whether one pattern
+// accepts every value of another is not decided, so any new pattern is
+// breaking.
+func comparePattern(path, oldPattern, newPattern string, changes *[]SchemaChange) {
+	switch {
+	case oldPattern == newPattern:
+		return
+	case oldPattern == "":
+		*changes = append(*changes, SchemaChange{Path: path, Kind: PatternChanged, Breaking: true,
+			Message: fmt.Sprintf("pattern %q added", newPattern)})
+	case newPattern == "":
+		*changes = append(*changes, SchemaChange{Path: path, Kind: PatternChanged,
+			Message: fmt.Sprintf("pattern %q removed", oldPattern)})
+	default:
+		*changes = append(*changes, SchemaChange{Path: path, Kind: PatternChanged, Breaking: true,
+			Message: fmt.Sprintf("pattern changed from %q to %q", oldPattern, newPattern)})
+	}
+}
+
+// formatBound formats a limit, marking an exclusive one
+func formatBound(value float64, exclusive bool) string {
+	formatted := strconv.FormatFloat(value, 'g', -1, 64)
+	if exclusive {
+		return formatted + " (exclusive)"
+	}
+	return formatted
+}
+
+func floatPtr(value *int64) *float64 {
+	if value == nil {
+		return nil
+	}
+	f := float64(*value)
+	return &f
+}
+
+// acceptsAnything reports whether a schema leaves its values unvalidated
+func acceptsAnything(s *apiextensionsv1.JSONSchemaProps) bool {
+	return s == nil || (s.Type == "" && !s.XIntOrString && len(s.Properties) == 0 && len(s.Enum) == 0)
+}
+
+// enumValues returns the JSON encodings of an enum's values as a set
+func enumValues(enum []apiextensionsv1.JSON) map[string]bool {
+	values := make(map[string]bool, len(enum))
+	for _, value := range enum {
+		values[string(value.Raw)] = true
+	}
+	return values
+}
+
+func stringSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[value] = true
+	}
+	return set
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
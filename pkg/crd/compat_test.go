@@ -0,0 +1,201 @@
+package crd
+
+import (
+	"reflect"
+	"testing"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+func TestCompareSchemas(t *testing.T) {
+	enum := func(values ...string) []apiextensionsv1.JSON {
+		out := make([]apiextensionsv1.JSON, 0, len(values))
+		for _, v := range values {
+			out = append(out, apiextensionsv1.JSON{Raw: []byte(`"` + v + `"`)})
+		}
+		return out
+	}
+	object := func(required []string, properties map[string]apiextensionsv1.JSONSchemaProps) *apiextensionsv1.JSONSchemaProps {
+		return &apiextensionsv1.JSONSchemaProps{Type: "object", Properties: properties, Required: required}
+	}
+	stringItems := &apiextensionsv1.JSONSchemaPropsOrArray{Schema: &apiextensionsv1.JSONSchemaProps{Type: "string"}}
+	integerItems := &apiextensionsv1.JSONSchemaPropsOrArray{Schema: &apiextensionsv1.JSONSchemaProps{Type: "integer"}}
+
+	current := object([]string{"image"}, map[string]apiextensionsv1.JSONSchemaProps{
+		"image": {Type: "string"},
+		"tier":  {Type: "string", Enum: enum("small", "large")},
+		"tags":  {Type: "array", Items: stringItems},
+	})
+
+	tests := []struct {
+		name   string
+		schema *apiextensionsv1.JSONSchemaProps
+		want   []SchemaChange
+	}{
+		{
+			name:   "unchanged",
+			schema: current,
+		},
+		{
+			name: "compatible",
+			schema: object(nil, map[string]apiextensionsv1.JSONSchemaProps{
+				"image":    {Type: "string"},
+				"tier":     {Type: "string", Enum: enum("small", "medium", "large")},
+				"tags":     {Type: "array", Items: stringItems},
+				"replicas": {Type: "integer"},
+			}),
+			want: []SchemaChange{
+				{Path: "image", Kind: FieldOptional, Message: "field is no longer required"},
+				{Path: "replicas", Kind: FieldAdded, Message: "field added"},
+				{Path: "tier", Kind: EnumWidened, Message: `values "medium" now allowed`},
+			},
+		},
+		{
+			name: "breaking",
+			schema: object([]string{"image", "port"}, map[string]apiextensionsv1.JSONSchemaProps{
+				"image": {Type: "string"},
+				"tier":  {Type: "string", Enum: enum("small")},
+				"tags":  {Type: "array", Items: integerItems},
+				"port":  {Type: "integer"},
+			}),
+			want: []SchemaChange{
+				{Path: "port", Kind: FieldAdded, Message: "field added"},
+				{Path: "port", Kind: FieldRequired, Breaking: true, Message: "field is now required"},
+				{Path: "tags[]", Kind: TypeChanged, Breaking: true, Message: "type changed from string to integer"},
+				{Path: "tier", Kind: EnumNarrowed, Breaking: true, Message: `values "large" no longer allowed`},
+			},
+		},
+		{
+			name: "removed and defaulted",
+			schema: object([]string{"image", "tier"}, map[string]apiextensionsv1.JSONSchemaProps{
+				"image": {Type: "string"},
+				"tier":  {Type: "string", Enum: enum("small", "large"), Default: &apiextensionsv1.JSON{Raw: []byte(`"small"`)}},
+			}),
+			want: []SchemaChange{
+				{Path: "tags", Kind: FieldRemoved, Breaking: true, Message: "field removed"},
+				{Path: "tier", Kind: FieldRequired, Message: "field is now required, with a default"},
+			},
+		},
+		{
+			name: "removed into unknown fields",
+			schema: &apiextensionsv1.JSONSchemaProps{
+				Type:                   "object",
+				Required:               []string{"image"},
+				XPreserveUnknownFields: boolPtr(true),
+				Properties: map[string]apiextensionsv1.JSONSchemaProps{
+					"image": {Type: "string"},
+					"tier":  {Type: "string", Enum: enum("small", "large")},
+				},
+			},
+			want: []SchemaChange{
+				{Path: "tags", Kind: FieldRemoved, Message: "field removed, and kept as an unknown field"},
+			},
+		},
+		{
+			name:   "free-form",
+			schema: &apiextensionsv1.JSONSchemaProps{XPreserveUnknownFields: boolPtr(true)},
+			want: []SchemaChange{
+				{Path: "", Kind: TypeChanged, Message: "type changed from object to any value"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CompareSchemas(current, tt.schema); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("unexpected changes:\ngot:  %+v\nwant: %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompareSchemas_Types(t *testing.T) {
+	integer := &apiextensionsv1.JSONSchemaProps{Type: "integer"}
+	number := &apiextensionsv1.JSONSchemaProps{Type: "number"}
+
+	if changes := CompareSchemas(integer, number); len(changes) != 1 || changes[0].Breaking {
+		t.Errorf("expected widening integer to number to be compatible, got %+v", changes)
+	}
+	if changes := CompareSchemas(number, integer); len(changes) != 1 || !changes[0].Breaking {
+		t.Errorf("expected narrowing number to integer to be breaking, got %+v", changes)
+	}
+	if changes := CompareSchemas(nil, integer); len(changes) != 1 || !changes[0].Breaking {
+		t.Errorf("expected constraining a free-form value to be breaking, got %+v", changes)
+	}
+}
+
+func TestCompareSchemas_Bounds(t *testing.T) {
+	float := func(v float64) *float64 { return &v }
+	count := func(v int64) *int64 { return &v }
+	current := &apiextensionsv1.JSONSchemaProps{
+		Type: "object",
+		Properties: map[string]apiextensionsv1.JSONSchemaProps{
+			"port":  {Type: "integer", Minimum: float(1024), Maximum: float(65535)},
+			"name":  {Type: "string", MaxLength: count(63), Pattern: "^[a-z]+$"},
+			"zones": {Type: "array", MaxItems: count(3)},
+		},
+	}
+	withProperties := func(properties map[string]apiextensionsv1.JSONSchemaProps) *apiextensionsv1.JSONSchemaProps {
+		return &apiextensionsv1.JSONSchemaProps{Type: "object", Properties: properties}
+	}
+
+	tests := []struct {
+		name   string
+		schema *apiextensionsv1.JSONSchemaProps
+		want   []SchemaChange
+	}{
+		{
+			name: "tightened",
+			schema: withProperties(map[string]apiextensionsv1.JSONSchemaProps{
+				"port":  {Type: "integer", Minimum: float(2048), Maximum: float(8080)},
+				"name":  {Type: "string", MinLength: count(1), MaxLength: count(32), Pattern: "^[a-z0-9]+$"},
+				"zones": {Type: "array", MaxItems: count(2)},
+			}),
+			want: []SchemaChange{
+				{Path: "name", Kind: BoundTightened, Breaking: true, Message: "minLength 1 added"},
+				{Path: "name", Kind: BoundTightened, Breaking: true, Message: "maxLength lowered from 63 to 32"},
+				{Path: "name", Kind: PatternChanged, Breaking: true, Message: `pattern changed from "^[a-z]+$" to "^[a-z0-9]+$"`},
+				{Path: "port", Kind: BoundTightened, Breaking: true, Message: "minimum raised from 1024 to 2048"},
+				{Path: "port", Kind: BoundTightened, Breaking: true, Message: "maximum lowered from 65535 to 8080"},
+				{Path: "zones", Kind: BoundTightened, Breaking: true, Message: "maxItems lowered from 3 to 2"},
+			},
+		},
+		{
+			name: "loosened",
+			schema: withProperties(map[string]apiextensionsv1.JSONSchemaProps{
+				"port":  {Type: "integer", Minimum: float(1), Maximum: float(65535)},
+				"name":  {Type: "string", MaxLength: count(253)},
+				"zones": {Type: "array"},
+			}),
+			want: []SchemaChange{
+				{Path: "name", Kind: BoundLoosened, Message: "maxLength raised from 63 to 253"},
+				{Path: "name", Kind: PatternChanged, Message: `pattern "^[a-z]+$" removed`},
+				{Path: "port", Kind: BoundLoosened, Message: "minimum lowered from 1024 to 1"},
+				{Path: "zones", Kind: BoundLoosened, Message: "maxItems 3 removed"},
+			},
+		},
+		{
+			name: "exclusive",
+			schema: withProperties(map[string]apiextensionsv1.JSONSchemaProps{
+				"port":  {Type: "integer", Minimum: float(1024), Maximum: float(65535), ExclusiveMaximum: true},
+				"name":  {Type: "string", MaxLength: count(63), Pattern: "^[a-z]+$"},
+				"zones": {Type: "array", MaxItems: count(3)},
+			}),
+			want: []SchemaChange{
+				{Path: "port", Kind: BoundTightened, Breaking: true, Message: "maximum changed from 65535 to 65535 (exclusive)"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CompareSchemas(current, tt.schema); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("unexpected changes:\ngot:  %+v\nwant: %+v", got, tt.want)
+			}
+		})
+	}
+
+	unbounded := &apiextensionsv1.JSONSchemaProps{Type: "string"}
+	patterned := &apiextensionsv1.JSONSchemaProps{Type: "string", Pattern: "^[a-z]+$"}
+	if changes := CompareSchemas(unbounded, patterned); len(changes) != 1 || !changes[0].Breaking {
+		t.Errorf("expected a new pattern to be breaking, got %+v", changes)
+	}
+}
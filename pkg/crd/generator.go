@@ -30,8 +30,41 @@ const (
 
 	// TransformLabel links a CRD to its source Transform
 	TransformLabel = "pequod.io/transform"
+
+	// ConversionPath is the path the manager serves the conversion webhook
+	// of generated CRDs at
+	ConversionPath = "/convert"
 )
 
+// VersionConfig is a version served by a generated CRD
+type VersionConfig struct {
+	// Name is the version name, e.g. v1beta1
+	Name string
+
+	// Schema is the version's spec schema
+	Schema *apiextensionsv1.JSONSchemaProps
+
+	// Served, Storage and Deprecated are set on the CRD version as is
+	Served     bool
+	Storage    bool
+	Deprecated bool
+}
+
+// ConversionWebhook locates the webhook converting instances between the
+// versions of generated CRDs
+type ConversionWebhook struct {
+	// ServiceName and ServiceNamespace identify the Service in front of the
+	// manager's webhook server
+	ServiceName      string
+	ServiceNamespace string
+
+	// Port is the Service port, 443 if zero
+	Port int32
+
+	// CABundle is the PEM encoded CA the webhook's certificate is signed by
+	CABundle []byte
+}
+
 // GeneratorConfig contains configuration for CRD generation
 type GeneratorConfig struct {
 	// Group is the API group (default: pequod.io)
@@ -51,6 +84,22 @@ type GeneratorConfig struct {
 
 	// TransformNamespace is the namespace of the source Transform
 	TransformNamespace string
+
+	// OutputsSchema is the schema of the module's #Outputs, published as
+	// status.outputs. Optional.
+	OutputsSchema *apiextensionsv1.JSONSchemaProps
+
+	// Channels adds a printer column for the module channel instances
+	// select, for Transforms that declare channels
+	Channels bool
+
+	// Versions, if set, are the versions the CRD serves instead of Version
+	// alone, each with its own spec schema
+	Versions []VersionConfig
+
+	// Conversion is the webhook converting instances of a CRD with several
+	// versions. Without it the API server only rewrites apiVersion.
+	Conversion *ConversionWebhook
 }
 
 // Generator creates Kubernetes CRDs from extracted schemas
@@ -81,8 +130,27 @@ func (g *Generator) GenerateCRD(platformName string, inputSchema *apiextensionsv
 	plural := toPlural(platformName)
 	singular := strings.ToLower(platformName)
 
-	// Build the full OpenAPI schema including metadata
-	openAPISchema := buildOpenAPISchema(inputSchema)
+	versions := config.Versions
+	if len(versions) == 0 {
+		versions = []VersionConfig{{Name: version, Schema: inputSchema, Served: true, Storage: true}}
+	}
+	crdVersions := make([]apiextensionsv1.CustomResourceDefinitionVersion, 0, len(versions))
+	for _, v := range versions {
+		crdVersions = append(crdVersions, apiextensionsv1.CustomResourceDefinitionVersion{
+			Name:       v.Name,
+			Served:     v.Served,
+			Storage:    v.Storage,
+			Deprecated: v.Deprecated,
+			Schema: &apiextensionsv1.CustomResourceValidation{
+				// Build the full OpenAPI schema including metadata
+				OpenAPIV3Schema: buildOpenAPISchema(v.Schema, config.OutputsSchema),
+			},
+			Subresources: &apiextensionsv1.CustomResourceSubresources{
+				Status: &apiextensionsv1.CustomResourceSubresourceStatus{},
+			},
+			AdditionalPrinterColumns: printerColumns(config),
+		})
+	}
 
 	// Build labels
 	labels := map[string]string{
@@ -110,35 +178,70 @@ func (g *Generator) GenerateCRD(platformName string, inputSchema *apiextensionsv
 				ShortNames: config.ShortNames,
 				Categories: config.Categories,
 			},
-			Scope: apiextensionsv1.NamespaceScoped,
-			Versions: []apiextensionsv1.CustomResourceDefinitionVersion{
-				{
-					Name:    version,
-					Served:  true,
-					Storage: true,
-					Schema: &apiextensionsv1.CustomResourceValidation{
-						OpenAPIV3Schema: openAPISchema,
-					},
-					Subresources: &apiextensionsv1.CustomResourceSubresources{
-						Status: &apiextensionsv1.CustomResourceSubresourceStatus{},
-					},
-					AdditionalPrinterColumns: []apiextensionsv1.CustomResourceColumnDefinition{
-						{
-							Name:     "Age",
-							Type:     "date",
-							JSONPath: ".metadata.creationTimestamp",
-						},
-					},
+			Scope:    apiextensionsv1.NamespaceScoped,
+			Versions: crdVersions,
+		},
+	}
+	if len(crdVersions) > 1 && config.Conversion != nil {
+		crd.Spec.Conversion = conversion(config.Conversion)
+	}
+
+	return crd
+}
+
+// conversion returns the webhook conversion of a CRD
+func conversion(webhook *ConversionWebhook) *apiextensionsv1.CustomResourceConversion {
+	port := webhook.Port
+	if port == 0 {
+		port = 443
+	}
+	path := ConversionPath
+	return &apiextensionsv1.CustomResourceConversion{
+		Strategy: apiextensionsv1.WebhookConverter,
+		Webhook: &apiextensionsv1.WebhookConversion{
+			ClientConfig: &apiextensionsv1.WebhookClientConfig{
+				Service: &apiextensionsv1.ServiceReference{
+					Namespace: webhook.ServiceNamespace,
+					Name:      webhook.ServiceName,
+					Path:      &path,
+					Port:      &port,
 				},
+				CABundle: webhook.CABundle,
 			},
+			ConversionReviewVersions: []string{"v1"},
 		},
 	}
+}
 
-	return crd
+// printerColumns returns the printer columns of a generated CRD
+func printerColumns(config GeneratorConfig) []apiextensionsv1.CustomResourceColumnDefinition {
+	var columns []apiextensionsv1.CustomResourceColumnDefinition
+	if config.Channels {
+		columns = append(columns, apiextensionsv1.CustomResourceColumnDefinition{
+			Name:     "Channel",
+			Type:     "string",
+			JSONPath: ".status.channel",
+		})
+	}
+	return append(columns, apiextensionsv1.CustomResourceColumnDefinition{
+		Name:     "Age",
+		Type:     "date",
+		JSONPath: ".metadata.creationTimestamp",
+	})
 }
 
-// buildOpenAPISchema wraps the input schema in the full CRD OpenAPI schema structure
-func buildOpenAPISchema(inputSchema *apiextensionsv1.JSONSchemaProps) *apiextensionsv1.JSONSchemaProps {
+// buildOpenAPISchema wraps the input schema in the full CRD OpenAPI schema
+// structure. Modules without outputs get a free-form status.outputs.
+func buildOpenAPISchema(inputSchema, outputsSchema *apiextensionsv1.JSONSchemaProps) *apiextensionsv1.JSONSchemaProps {
+	outputs := apiextensionsv1.JSONSchemaProps{
+		Type:                   "object",
+		XPreserveUnknownFields: boolPtr(true),
+	}
+	if outputsSchema != nil {
+		outputs = *outputsSchema.DeepCopy()
+	}
+	outputs.Description = "Outputs computed by the platform module from the applied resources"
+
 	return &apiextensionsv1.JSONSchemaProps{
 		Type: "object",
 		Properties: map[string]apiextensionsv1.JSONSchemaProps{
@@ -204,6 +307,15 @@ func buildOpenAPISchema(inputSchema *apiextensionsv1.JSONSchemaProps) *apiextens
 						Format:      "int64",
 						Description: "The generation observed by the controller",
 					},
+					"moduleDigest": {
+						Type:        "string",
+						Description: "Digest of the platform module the instance was last rendered from",
+					},
+					"channel": {
+						Type:        "string",
+						Description: "Module channel the instance was last rendered from",
+					},
+					"outputs": outputs,
 				},
 			},
 		},
@@ -265,6 +265,35 @@ func TestGenerator_GenerateCRD_StatusSchema(t *testing.T) {
 	}
 }
 
+func TestGenerator_GenerateCRD_OutputsSchema(t *testing.T) {
+	generator := NewGenerator()
+	inputSchema := &apiextensionsv1.JSONSchemaProps{Type: "object"}
+
+	// Without #Outputs, outputs are free-form
+	crd := generator.GenerateCRD("test", inputSchema, GeneratorConfig{})
+	outputs := crd.Spec.Versions[0].Schema.OpenAPIV3Schema.Properties["status"].Properties["outputs"]
+	if outputs.XPreserveUnknownFields == nil || !*outputs.XPreserveUnknownFields {
+		t.Errorf("expected free-form outputs, got %+v", outputs)
+	}
+
+	config := GeneratorConfig{
+		OutputsSchema: &apiextensionsv1.JSONSchemaProps{
+			Type: "object",
+			Properties: map[string]apiextensionsv1.JSONSchemaProps{
+				"endpoint": {Type: "string"},
+			},
+		},
+	}
+	crd = generator.GenerateCRD("test", inputSchema, config)
+	outputs = crd.Spec.Versions[0].Schema.OpenAPIV3Schema.Properties["status"].Properties["outputs"]
+	if outputs.Properties["endpoint"].Type != "string" || outputs.XPreserveUnknownFields != nil {
+		t.Errorf("expected the outputs schema, got %+v", outputs)
+	}
+	if outputs.Description == "" || config.OutputsSchema.Description != "" {
+		t.Errorf("expected a description on a copy of the outputs schema")
+	}
+}
+
 func TestGenerator_GenerateCRD_PrinterColumns(t *testing.T) {
 	generator := NewGenerator()
 
@@ -294,3 +323,75 @@ func TestGenerator_GenerateCRD_PrinterColumns(t *testing.T) {
 		t.Error("expected 'Age' printer column")
 	}
 }
+
+func TestGenerator_GenerateCRD_ChannelColumn(t *testing.T) {
+	generator := NewGenerator()
+	inputSchema := &apiextensionsv1.JSONSchemaProps{Type: "object"}
+
+	crd := generator.GenerateCRD("test", inputSchema, GeneratorConfig{})
+	for _, col := range crd.Spec.Versions[0].AdditionalPrinterColumns {
+		if col.Name == "Channel" {
+			t.Error("expected no Channel column without channels")
+		}
+	}
+
+	crd = generator.GenerateCRD("test", inputSchema, GeneratorConfig{Channels: true})
+	columns := crd.Spec.Versions[0].AdditionalPrinterColumns
+	if len(columns) != 2 || columns[0].Name != "Channel" || columns[0].JSONPath != ".status.channel" {
+		t.Errorf("expected a Channel column before Age, got %+v", columns)
+	}
+	status := crd.Spec.Versions[0].Schema.OpenAPIV3Schema.Properties["status"]
+	if status.Properties["channel"].Type != "string" {
+		t.Errorf("expected 'channel' in status, got %+v", status.Properties["channel"])
+	}
+}
+
+func TestGenerator_GenerateCRD_Versions(t *testing.T) {
+	generator := NewGenerator()
+	v1alpha1 := &apiextensionsv1.JSONSchemaProps{
+		Type:       "object",
+		Properties: map[string]apiextensionsv1.JSONSchemaProps{"size": {Type: "string"}},
+	}
+	v1beta1 := &apiextensionsv1.JSONSchemaProps{
+		Type:       "object",
+		Properties: map[string]apiextensionsv1.JSONSchemaProps{"replicas": {Type: "integer"}},
+	}
+	config := GeneratorConfig{
+		Group: "apps.example.com",
+		Versions: []VersionConfig{
+			{Name: "v1alpha1", Schema: v1alpha1, Served: true, Deprecated: true},
+			{Name: "v1beta1", Schema: v1beta1, Served: true, Storage: true},
+		},
+		Conversion: &ConversionWebhook{ServiceName: "pequod-webhook", ServiceNamespace: "pequod-system", CABundle: []byte("ca")},
+	}
+
+	crd := generator.GenerateCRD("test", nil, config)
+	versions := crd.Spec.Versions
+	if len(versions) != 2 {
+		t.Fatalf("expected 2 versions, got %d", len(versions))
+	}
+	if !versions[0].Deprecated || versions[0].Storage || !versions[1].Storage {
+		t.Errorf("expected v1alpha1 deprecated and v1beta1 stored, got %+v", versions)
+	}
+	if _, ok := versions[0].Schema.OpenAPIV3Schema.Properties["spec"].Properties["size"]; !ok {
+		t.Error("expected v1alpha1 to have its own spec schema")
+	}
+	if _, ok := versions[1].Schema.OpenAPIV3Schema.Properties["spec"].Properties["replicas"]; !ok {
+		t.Error("expected v1beta1 to have its own spec schema")
+	}
+
+	conversion := crd.Spec.Conversion
+	if conversion == nil || conversion.Strategy != apiextensionsv1.WebhookConverter {
+		t.Fatalf("expected webhook conversion, got %+v", conversion)
+	}
+	service := conversion.Webhook.ClientConfig.Service
+	if service.Name != "pequod-webhook" || *service.Path != ConversionPath || *service.Port != 443 {
+		t.Errorf("unexpected conversion service %+v", service)
+	}
+
+	// A single version needs no conversion
+	config.Versions = config.Versions[1:]
+	if crd := generator.GenerateCRD("test", nil, config); crd.Spec.Conversion != nil {
+		t.Errorf("expected no conversion for a single version, got %+v", crd.Spec.Conversion)
+	}
+}
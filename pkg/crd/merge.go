@@ -0,0 +1,217 @@
+package crd
+
+import (
+	"sort"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+)
+
+// MergeSchemas returns a schema that accepts every object accepted by any of
+// the schemas, so that one CRD can serve several versions of a module.
+// Object properties are merged recursively and only fields required by all
+// schemas stay required. Where nodes share a type, their validations are
+// loosened to accept either: enums are joined, bounds widened, and other
+// constraints and defaults kept only where the schemas agree. Where the
+// schemas disagree on a type, the merged node is left free-form and each
+// module validates its own instances when rendering. A nil schema accepts
+// anything, so any nil schema yields nil.
+func MergeSchemas(schemas ...*apiextensionsv1.JSONSchemaProps) *apiextensionsv1.JSONSchemaProps {
+	if len(schemas) == 0 {
+		return nil
+	}
+	for _, s := range schemas {
+		if s == nil {
+			return nil
+		}
+	}
+	merged := schemas[0].DeepCopy()
+	for _, s := range schemas[1:] {
+		merged = mergeSchema(merged, s)
+	}
+	return merged
+}
+
+// mergeSchema merges two non-nil schemas
+func mergeSchema(a, b *apiextensionsv1.JSONSchemaProps) *apiextensionsv1.JSONSchemaProps {
+	if equality.Semantic.DeepEqual(a, b) {
+		return a.DeepCopy()
+	}
+	if a.Type != b.Type {
+		return freeForm(a, b)
+	}
+
+	merged := &apiextensionsv1.JSONSchemaProps{
+		Type:        a.Type,
+		Description: a.Description,
+	}
+	if a.Format == b.Format {
+		merged.Format = a.Format
+	}
+	mergeValidations(merged, a, b)
+
+	switch a.Type {
+	case "object":
+		if a.Properties == nil || b.Properties == nil {
+			merged.XPreserveUnknownFields = boolPtr(true)
+			return merged
+		}
+		merged.Properties = make(map[string]apiextensionsv1.JSONSchemaProps, len(a.Properties))
+		for name, prop := range a.Properties {
+			if other, ok := b.Properties[name]; ok {
+				merged.Properties[name] = *mergeSchema(&prop, &other)
+			} else {
+				merged.Properties[name] = *prop.DeepCopy()
+			}
+		}
+		for name, prop := range b.Properties {
+			if _, ok := a.Properties[name]; !ok {
+				merged.Properties[name] = *prop.DeepCopy()
+			}
+		}
+		merged.Required = sharedRequired(a.Required, b.Required)
+		if isTrue(a.XPreserveUnknownFields) || isTrue(b.XPreserveUnknownFields) {
+			merged.XPreserveUnknownFields = boolPtr(true)
+		}
+	case "array":
+		if a.Items == nil || a.Items.Schema == nil || b.Items == nil || b.Items.Schema == nil {
+			return freeForm(a, b)
+		}
+		merged.Items = &apiextensionsv1.JSONSchemaPropsOrArray{
+			Schema: mergeSchema(a.Items.Schema, b.Items.Schema),
+		}
+	}
+	return merged
+}
+
+// mergeValidations sets the validations of merged to accept any value
+// either a or b accepts. A constraint only one of them has is dropped.
+func mergeValidations(merged, a, b *apiextensionsv1.JSONSchemaProps) {
+	if len(a.Enum) > 0 && len(b.Enum) > 0 {
+		merged.Enum = unionEnum(a.Enum, b.Enum)
+	}
+	if a.Default != nil && equality.Semantic.DeepEqual(a.Default, b.Default) {
+		merged.Default = a.Default.DeepCopy()
+	}
+	merged.Nullable = a.Nullable || b.Nullable
+
+	if a.Minimum != nil && b.Minimum != nil {
+		merged.Minimum, merged.ExclusiveMinimum = looserBound(
+			*a.Minimum, a.ExclusiveMinimum, *b.Minimum, b.ExclusiveMinimum, *a.Minimum > *b.Minimum)
+	}
+	if a.Maximum != nil && b.Maximum != nil {
+		merged.Maximum, merged.ExclusiveMaximum = looserBound(
+			*a.Maximum, a.ExclusiveMaximum, *b.Maximum, b.ExclusiveMaximum, *a.Maximum < *b.Maximum)
+	}
+	if a.MultipleOf != nil && b.MultipleOf != nil && *a.MultipleOf == *b.MultipleOf {
+		merged.MultipleOf = float64Ptr(*a.MultipleOf)
+	}
+
+	merged.MinLength = lowerLimit(a.MinLength, b.MinLength)
+	merged.MaxLength = higherLimit(a.MaxLength, b.MaxLength)
+	if a.Pattern == b.Pattern {
+		merged.Pattern = a.Pattern
+	}
+
+	merged.MinItems = lowerLimit(a.MinItems, b.MinItems)
+	merged.MaxItems = higherLimit(a.MaxItems, b.MaxItems)
+	merged.UniqueItems = a.UniqueItems && b.UniqueItems
+	merged.MinProperties = lowerLimit(a.MinProperties, b.MinProperties)
+	merged.MaxProperties = higherLimit(a.MaxProperties, b.MaxProperties)
+
+	for _, rule := range a.XValidations {
+		for _, other := range b.XValidations {
+			if equality.Semantic.DeepEqual(rule, other) {
+				merged.XValidations = append(merged.XValidations, rule)
+				break
+			}
+		}
+	}
+}
+
+// unionEnum returns the values of both enums, without duplicates
+func unionEnum(a, b []apiextensionsv1.JSON) []apiextensionsv1.JSON {
+	seen := make(map[string]bool, len(a)+len(b))
+	var union []apiextensionsv1.JSON
+	for _, values := range [][]apiextensionsv1.JSON{a, b} {
+		for _, value := range values {
+			if !seen[string(value.Raw)] {
+				seen[string(value.Raw)] = true
+				union = append(union, *value.DeepCopy())
+			}
+		}
+	}
+	return union
+}
+
+// looserBound returns the looser of two minimums or maximums, where
+// aTighter tells whether bound a excludes values bound b allows. Equal
+// bounds are exclusive only if both are.
+func looserBound(a float64, aExclusive bool, b float64, bExclusive bool, aTighter bool) (*float64, bool) {
+	switch {
+	case a == b:
+		return float64Ptr(a), aExclusive && bExclusive
+	case aTighter:
+		return float64Ptr(b), bExclusive
+	default:
+		return float64Ptr(a), aExclusive
+	}
+}
+
+// lowerLimit returns the lower of two lower limits, or nil unless both are
+// set
+func lowerLimit(a, b *int64) *int64 {
+	if a == nil || b == nil {
+		return nil
+	}
+	return int64Ptr(min(*a, *b))
+}
+
+// higherLimit returns the higher of two upper limits, or nil unless both
+// are set
+func higherLimit(a, b *int64) *int64 {
+	if a == nil || b == nil {
+		return nil
+	}
+	return int64Ptr(max(*a, *b))
+}
+
+// freeForm returns a node that accepts any value where two schemas disagree
+func freeForm(a, b *apiextensionsv1.JSONSchemaProps) *apiextensionsv1.JSONSchemaProps {
+	description := a.Description
+	if description == "" {
+		description = b.Description
+	}
+	return &apiextensionsv1.JSONSchemaProps{
+		Description:            description,
+		XPreserveUnknownFields: boolPtr(true),
+	}
+}
+
+// sharedRequired returns the fields required by both lists, sorted
+func sharedRequired(a, b []string) []string {
+	inB := make(map[string]bool, len(b))
+	for _, name := range b {
+		inB[name] = true
+	}
+	var shared []string
+	for _, name := range a {
+		if inB[name] {
+			shared = append(shared, name)
+		}
+	}
+	sort.Strings(shared)
+	return shared
+}
+
+func isTrue(b *bool) bool {
+	return b != nil && *b
+}
+
+func float64Ptr(f float64) *float64 {
+	return &f
+}
+
+func int64Ptr(i int64) *int64 {
+	return &i
+}
@@ -0,0 +1,105 @@
+package crd
+
+import (
+	"reflect"
+	"testing"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+func TestMergeSchemas(t *testing.T) {
+	stable := &apiextensionsv1.JSONSchemaProps{
+		Type: "object",
+		Properties: map[string]apiextensionsv1.JSONSchemaProps{
+			"image": {Type: "string"},
+			"port":  {Type: "integer", Minimum: float64Ptr(1)},
+			"tier":  {Type: "string", Enum: []apiextensionsv1.JSON{{Raw: []byte(`"small"`)}}},
+			"tags":  {Type: "array", Items: &apiextensionsv1.JSONSchemaPropsOrArray{Schema: &apiextensionsv1.JSONSchemaProps{Type: "string"}}},
+		},
+		Required: []string{"image", "port"},
+	}
+	beta := &apiextensionsv1.JSONSchemaProps{
+		Type: "object",
+		Properties: map[string]apiextensionsv1.JSONSchemaProps{
+			"image":    {Type: "string"},
+			"port":     {Type: "string"},
+			"tier":     {Type: "string", Enum: []apiextensionsv1.JSON{{Raw: []byte(`"large"`)}}},
+			"tags":     {Type: "array", Items: &apiextensionsv1.JSONSchemaPropsOrArray{Schema: &apiextensionsv1.JSONSchemaProps{Type: "string"}}},
+			"replicas": {Type: "integer"},
+		},
+		Required: []string{"image", "replicas"},
+	}
+
+	merged := MergeSchemas(stable, beta)
+	want := &apiextensionsv1.JSONSchemaProps{
+		Type: "object",
+		Properties: map[string]apiextensionsv1.JSONSchemaProps{
+			"image": {Type: "string"},
+			"port":  {XPreserveUnknownFields: boolPtr(true)},
+			"tier": {Type: "string", Enum: []apiextensionsv1.JSON{
+				{Raw: []byte(`"small"`)}, {Raw: []byte(`"large"`)},
+			}},
+			"tags":     {Type: "array", Items: &apiextensionsv1.JSONSchemaPropsOrArray{Schema: &apiextensionsv1.JSONSchemaProps{Type: "string"}}},
+			"replicas": {Type: "integer"},
+		},
+		Required: []string{"image"},
+	}
+	if !reflect.DeepEqual(merged, want) {
+		t.Errorf("unexpected merged schema:\ngot:  %+v\nwant: %+v", merged, want)
+	}
+
+	// The inputs are left untouched
+	if len(stable.Properties) != 4 || stable.Properties["port"].Minimum == nil {
+		t.Errorf("expected the input schema to be unchanged, got %+v", stable)
+	}
+}
+
+func TestMergeSchemas_Single(t *testing.T) {
+	schema := &apiextensionsv1.JSONSchemaProps{Type: "object", Required: []string{"image"}}
+	merged := MergeSchemas(schema)
+	if !reflect.DeepEqual(merged, schema) || merged == schema {
+		t.Errorf("expected a copy of the only schema, got %+v", merged)
+	}
+}
+
+func TestMergeSchemas_Nil(t *testing.T) {
+	schema := &apiextensionsv1.JSONSchemaProps{Type: "object"}
+	if merged := MergeSchemas(schema, nil); merged != nil {
+		t.Errorf("expected nil when a schema is missing, got %+v", merged)
+	}
+	if merged := MergeSchemas(); merged != nil {
+		t.Errorf("expected nil without schemas, got %+v", merged)
+	}
+}
+
+func TestMergeSchemas_Validations(t *testing.T) {
+	stable := &apiextensionsv1.JSONSchemaProps{
+		Type: "object",
+		Properties: map[string]apiextensionsv1.JSONSchemaProps{
+			"replicas": {Type: "integer", Minimum: float64Ptr(1), Maximum: float64Ptr(10), Default: &apiextensionsv1.JSON{Raw: []byte(`1`)}},
+			"name":     {Type: "string", MaxLength: int64Ptr(20), Pattern: "^[a-z]+$"},
+			"size":     {Type: "integer", Minimum: float64Ptr(0), ExclusiveMinimum: true, Default: &apiextensionsv1.JSON{Raw: []byte(`5`)}},
+		},
+	}
+	beta := &apiextensionsv1.JSONSchemaProps{
+		Type: "object",
+		Properties: map[string]apiextensionsv1.JSONSchemaProps{
+			"replicas": {Type: "integer", Minimum: float64Ptr(0), Maximum: float64Ptr(5), Default: &apiextensionsv1.JSON{Raw: []byte(`1`)}},
+			"name":     {Type: "string", MaxLength: int64Ptr(40), Pattern: "^[a-z0-9]+$"},
+			"size":     {Type: "integer", Minimum: float64Ptr(0), Default: &apiextensionsv1.JSON{Raw: []byte(`3`)}},
+		},
+	}
+
+	merged := MergeSchemas(stable, beta)
+	want := map[string]apiextensionsv1.JSONSchemaProps{
+		// Bounds are widened, and the shared default kept
+		"replicas": {Type: "integer", Minimum: float64Ptr(0), Maximum: float64Ptr(10), Default: &apiextensionsv1.JSON{Raw: []byte(`1`)}},
+		// Differing patterns are dropped
+		"name": {Type: "string", MaxLength: int64Ptr(40)},
+		// Differing defaults are dropped, and only one bound is exclusive
+		"size": {Type: "integer", Minimum: float64Ptr(0)},
+	}
+	if !reflect.DeepEqual(merged.Properties, want) {
+		t.Errorf("unexpected merged properties:\ngot:  %+v\nwant: %+v", merged.Properties, want)
+	}
+}
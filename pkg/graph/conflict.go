@@ -0,0 +1,95 @@
+package graph
+
+import (
+	"fmt"
+	"slices"
+)
+
+// FieldConflict describes a single field that another field manager owns
+// and that a server-side apply tried to change
+type FieldConflict struct {
+	// Manager is the name of the field manager that owns the field
+	Manager string `json:"manager"`
+
+	// Field is the conflicting field path as reported by the API server,
+	// e.g. ".spec.template.spec.containers[name=\"app\"].image"
+	Field string `json:"field"`
+
+	// Operation is how the owning manager last wrote the field ("Apply" or "Update")
+	Operation string `json:"operation,omitempty"`
+}
+
+// String returns a human-readable description of the conflict
+func (c FieldConflict) String() string {
+	if c.Operation == "" {
+		return fmt.Sprintf("%s (owned by %q)", c.Field, c.Manager)
+	}
+	return fmt.Sprintf("%s (owned by %q via %s)", c.Field, c.Manager, c.Operation)
+}
+
+// FieldConflictError is implemented by apply errors that carry the
+// structured field manager conflicts reported by the API server
+type FieldConflictError interface {
+	error
+	FieldConflicts() []FieldConflict
+}
+
+// ConflictResolution selectively forces ownership of conflicting fields.
+// A conflicting apply is retried with forced ownership only when every
+// conflict is allowed by at least one rule; otherwise the apply fails and
+// the conflicts are reported.
+type ConflictResolution struct {
+	// ForceFields lists field paths whose conflicts may be forced.
+	// A path also covers everything below it. See ParseFieldPath for syntax.
+	ForceFields []string `json:"forceFields,omitempty"`
+
+	// ForceManagers lists field managers whose fields may be taken over
+	ForceManagers []string `json:"forceManagers,omitempty"`
+}
+
+// Validate checks the integrity of a ConflictResolution
+func (cr *ConflictResolution) Validate() error {
+	if err := ValidateFieldPaths(cr.ForceFields); err != nil {
+		return fmt.Errorf("forceFields: %w", err)
+	}
+	for i, m := range cr.ForceManagers {
+		if m == "" {
+			return fmt.Errorf("forceManagers[%d]: manager name cannot be empty", i)
+		}
+	}
+	return nil
+}
+
+// Allows reports whether the conflict may be forced
+func (cr *ConflictResolution) Allows(c FieldConflict) bool {
+	if cr == nil {
+		return false
+	}
+	if slices.Contains(cr.ForceManagers, c.Manager) {
+		return true
+	}
+	for _, raw := range cr.ForceFields {
+		p, err := ParseFieldPath(raw)
+		if err != nil {
+			continue
+		}
+		if p.Covers(c.Field) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsAll reports whether every conflict may be forced. It returns false
+// for an empty list, since there is nothing to decide on.
+func (cr *ConflictResolution) AllowsAll(conflicts []FieldConflict) bool {
+	if cr == nil || len(conflicts) == 0 {
+		return false
+	}
+	for _, c := range conflicts {
+		if !cr.Allows(c) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,114 @@
+package graph
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestFieldPath_Covers(t *testing.T) {
+	tests := []struct {
+		path        string
+		managedPath string
+		want        bool
+	}{
+		{"spec.replicas", ".spec.replicas", true},
+		{"spec", ".spec.replicas", true},
+		{"spec.replica", ".spec.replicas", false},
+		{"spec.template.spec.containers[*].image", `.spec.template.spec.containers[name="app"].image`, true},
+		{"spec.template.spec.containers", `.spec.template.spec.containers[name="app"].image`, true},
+		{"spec.template.spec.containers[*].image", `.spec.template.spec.containers[name="app"].resources`, false},
+		{"spec.ports[*]", `.spec.ports[port=80,protocol="TCP"]`, true},
+		{"spec.ports[*].name", `.spec.ports[name="a]b"].name`, true},
+		{`metadata.annotations["example.com/owner"]`, ".metadata.annotations.example.com/owner", true},
+		{"metadata.labels", ".metadata.annotations.team", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s/%s", tt.path, tt.managedPath), func(t *testing.T) {
+			p, err := ParseFieldPath(tt.path)
+			if err != nil {
+				t.Fatalf("ParseFieldPath(%q) failed: %v", tt.path, err)
+			}
+			if got := p.Covers(tt.managedPath); got != tt.want {
+				t.Errorf("Covers(%q) = %v, want %v", tt.managedPath, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConflictResolution_AllowsAll(t *testing.T) {
+	conflicts := []FieldConflict{
+		{Manager: "kubectl-edit", Field: ".spec.replicas", Operation: "Update"},
+		{Manager: "other-operator", Field: ".metadata.labels.team", Operation: "Apply"},
+	}
+
+	tests := []struct {
+		name       string
+		resolution *ConflictResolution
+		conflicts  []FieldConflict
+		want       bool
+	}{
+		{name: "nil resolution", resolution: nil, conflicts: conflicts, want: false},
+		{name: "no conflicts", resolution: &ConflictResolution{ForceManagers: []string{"x"}}, want: false},
+		{
+			name:       "managers cover all",
+			resolution: &ConflictResolution{ForceManagers: []string{"kubectl-edit", "other-operator"}},
+			conflicts:  conflicts,
+			want:       true,
+		},
+		{
+			name:       "fields cover all",
+			resolution: &ConflictResolution{ForceFields: []string{"spec.replicas", "metadata.labels"}},
+			conflicts:  conflicts,
+			want:       true,
+		},
+		{
+			name:       "one conflict uncovered",
+			resolution: &ConflictResolution{ForceFields: []string{"spec.replicas"}},
+			conflicts:  conflicts,
+			want:       false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.resolution.AllowsAll(tt.conflicts); got != tt.want {
+				t.Errorf("AllowsAll() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyPolicy_ValidateConflictResolution(t *testing.T) {
+	invalid := ApplyPolicy{ConflictResolution: &ConflictResolution{ForceFields: []string{"spec..replicas"}}}
+	if err := invalid.Validate(); err == nil {
+		t.Error("expected error for invalid forceFields path")
+	}
+
+	emptyManager := ApplyPolicy{ConflictResolution: &ConflictResolution{ForceManagers: []string{""}}}
+	if err := emptyManager.Validate(); err == nil {
+		t.Error("expected error for empty manager name")
+	}
+}
+
+type testConflictError struct{ conflicts []FieldConflict }
+
+func (e *testConflictError) Error() string                   { return "conflict" }
+func (e *testConflictError) FieldConflicts() []FieldConflict { return e.conflicts }
+
+func TestSetError_RecordsConflicts(t *testing.T) {
+	state := NewExecutionState([]string{"node1"})
+	conflicts := []FieldConflict{{Manager: "kubectl-edit", Field: ".spec.replicas"}}
+
+	_ = state.SetError("node1", fmt.Errorf("failed to apply: %w", &testConflictError{conflicts: conflicts}))
+	status, _ := state.GetStatus("node1")
+	if len(status.Conflicts) != 1 || status.Conflicts[0] != conflicts[0] {
+		t.Errorf("expected conflicts to be recorded, got %v", status.Conflicts)
+	}
+
+	_ = state.SetError("node1", fmt.Errorf("some other failure"))
+	status, _ = state.GetStatus("node1")
+	if len(status.Conflicts) != 0 {
+		t.Errorf("expected conflicts to be cleared, got %v", status.Conflicts)
+	}
+}
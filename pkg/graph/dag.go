@@ -2,6 +2,7 @@ package graph
 
 import (
 	"fmt"
+	"slices"
 
 	"github.com/dominikbraun/graph"
 )
@@ -14,13 +15,31 @@ type DAG struct {
 	// nodeMap provides quick lookup of nodes by ID
 	nodeMap map[string]*Node
 
+	// deps maps node IDs to their declared and implicit (hook) dependencies
+	deps map[string][]string
+
 	// order contains the topologically sorted node IDs
 	order []string
+
+	// renderHash is the render hash of the source graph
+	renderHash string
 }
 
 // BuildDAG converts a Graph artifact into an executable DAG
-// It validates the graph structure, detects cycles, and computes topological order
+// It validates the graph structure, detects cycles, and computes topological order.
+// PreDelete hooks are left out; see BuildDeletionDAG.
 func BuildDAG(g *Graph) (*DAG, error) {
+	return buildDAG(g, func(n *Node) bool { return !n.IsHook(HookPhasePreDelete) })
+}
+
+// BuildDeletionDAG builds a DAG of only the graph's PreDelete hooks, which
+// run when the instance is deleted
+func BuildDeletionDAG(g *Graph) (*DAG, error) {
+	return buildDAG(g, func(n *Node) bool { return n.IsHook(HookPhasePreDelete) })
+}
+
+// buildDAG builds a DAG from the nodes for which include returns true
+func buildDAG(g *Graph, include func(*Node) bool) (*DAG, error) {
 	if g == nil {
 		return nil, fmt.Errorf("graph cannot be nil")
 	}
@@ -37,8 +56,11 @@ func BuildDAG(g *Graph) (*DAG, error) {
 	nodeMap := make(map[string]*Node, len(g.Nodes))
 	for i := range g.Nodes {
 		node := &g.Nodes[i]
-		nodeMap[node.ID] = node
+		if include(node) {
+			nodeMap[node.ID] = node
+		}
 	}
+	deps := hookDependencies(g, nodeMap)
 
 	// Add all vertices first
 	for id := range nodeMap {
@@ -50,8 +72,8 @@ func BuildDAG(g *Graph) (*DAG, error) {
 	// Add edges based on dependencies
 	// Note: In dominikbraun/graph, AddEdge(source, target) means source -> target
 	// In our model, if node B depends on node A, we want A -> B (A must complete before B)
-	for id, node := range nodeMap {
-		for _, depID := range node.DependsOn {
+	for id := range nodeMap {
+		for _, depID := range deps[id] {
 			// depID must complete before id can start
 			// So we add edge: depID -> id
 			if err := dg.AddEdge(depID, id); err != nil {
@@ -67,12 +89,51 @@ func BuildDAG(g *Graph) (*DAG, error) {
 	}
 
 	return &DAG{
-		graph:   dg,
-		nodeMap: nodeMap,
-		order:   order,
+		graph:      dg,
+		nodeMap:    nodeMap,
+		deps:       deps,
+		order:      order,
+		renderHash: g.Metadata.RenderHash,
 	}, nil
 }
 
+// hookDependencies returns each node's declared dependencies plus the
+// implicit ones that order hooks: every regular node waits for all PreApply
+// hooks, and every PostApply hook waits for all regular nodes
+func hookDependencies(g *Graph, nodeMap map[string]*Node) map[string][]string {
+	var preApply, regular []string
+	for _, node := range g.Nodes {
+		if _, included := nodeMap[node.ID]; !included {
+			continue
+		}
+		switch {
+		case node.IsHook(HookPhasePreApply):
+			preApply = append(preApply, node.ID)
+		case node.Hook == nil:
+			regular = append(regular, node.ID)
+		}
+	}
+
+	deps := make(map[string][]string, len(nodeMap))
+	for id, node := range nodeMap {
+		d := slices.Clone(node.DependsOn)
+		var implicit []string
+		switch {
+		case node.Hook == nil:
+			implicit = preApply
+		case node.IsHook(HookPhasePostApply):
+			implicit = regular
+		}
+		for _, depID := range implicit {
+			if !slices.Contains(d, depID) {
+				d = append(d, depID)
+			}
+		}
+		deps[id] = d
+	}
+	return deps
+}
+
 // GetNode retrieves a node by ID
 func (d *DAG) GetNode(id string) (*Node, bool) {
 	node, found := d.nodeMap[id]
@@ -85,13 +146,18 @@ func (d *DAG) GetOrder() []string {
 	return d.order
 }
 
-// GetDependencies returns the IDs of nodes that the given node depends on
+// RenderHash returns the render hash of the graph the DAG was built from
+func (d *DAG) RenderHash() string {
+	return d.renderHash
+}
+
+// GetDependencies returns the IDs of nodes that the given node depends on,
+// including the implicit dependencies of hooks
 func (d *DAG) GetDependencies(id string) ([]string, error) {
-	node, found := d.nodeMap[id]
-	if !found {
+	if _, found := d.nodeMap[id]; !found {
 		return nil, fmt.Errorf("node %s not found", id)
 	}
-	return node.DependsOn, nil
+	return d.deps[id], nil
 }
 
 // GetDependents returns the IDs of nodes that depend on the given node
@@ -101,8 +167,8 @@ func (d *DAG) GetDependents(id string) ([]string, error) {
 	}
 
 	var dependents []string
-	for nodeID, node := range d.nodeMap {
-		for _, depID := range node.DependsOn {
+	for nodeID := range d.nodeMap {
+		for _, depID := range d.deps[nodeID] {
 			if depID == id {
 				dependents = append(dependents, nodeID)
 				break
@@ -127,8 +193,8 @@ func (d *DAG) HasCycles() bool {
 // GetRootNodes returns nodes that have no dependencies
 func (d *DAG) GetRootNodes() []string {
 	var roots []string
-	for id, node := range d.nodeMap {
-		if len(node.DependsOn) == 0 {
+	for id := range d.nodeMap {
+		if len(d.deps[id]) == 0 {
 			roots = append(roots, id)
 		}
 	}
@@ -139,8 +205,8 @@ func (d *DAG) GetRootNodes() []string {
 func (d *DAG) GetLeafNodes() []string {
 	// Build a set of all nodes that are dependencies
 	hasDependents := make(map[string]bool)
-	for _, node := range d.nodeMap {
-		for _, depID := range node.DependsOn {
+	for id := range d.nodeMap {
+		for _, depID := range d.deps[id] {
 			hasDependents[depID] = true
 		}
 	}
@@ -0,0 +1,49 @@
+package graph
+
+import (
+	"errors"
+)
+
+// ErrorClass classifies an apply error by whether retrying can help
+type ErrorClass string
+
+const (
+	// ErrorClassTransient errors may succeed on retry (conflicts, timeouts,
+	// throttling, server errors)
+	ErrorClassTransient ErrorClass = "Transient"
+
+	// ErrorClassTerminal errors will not succeed without a change to the
+	// graph or the cluster (invalid objects, RBAC, unknown kinds, admission denials)
+	ErrorClassTerminal ErrorClass = "Terminal"
+)
+
+// TerminalError wraps an error that will not succeed on retry. The executor
+// fails the node immediately instead of retrying it.
+type TerminalError struct {
+	// Reason is a short CamelCase cause, e.g. "Forbidden" or "Invalid"
+	Reason string
+	Err    error
+}
+
+func (e *TerminalError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *TerminalError) Unwrap() error {
+	return e.Err
+}
+
+// IsTerminal reports whether err, or any error it wraps, is a TerminalError
+func IsTerminal(err error) bool {
+	var terminalErr *TerminalError
+	return errors.As(err, &terminalErr)
+}
+
+// terminalReason returns the reason of the TerminalError in err's chain, if any
+func terminalReason(err error) string {
+	var terminalErr *TerminalError
+	if errors.As(err, &terminalErr) {
+		return terminalErr.Reason
+	}
+	return ""
+}
@@ -57,6 +57,8 @@ type Executor struct {
 	config           ExecutorConfig
 	applier          Applier
 	readinessChecker ReadinessChecker
+	hookRunner       HookRunner
+	secretGenerator  SecretGenerator
 	client           client.Client
 }
 
@@ -70,6 +72,18 @@ func NewExecutor(applier Applier, readinessChecker ReadinessChecker, k8sClient c
 	}
 }
 
+// WithHookRunner sets the runner used for lifecycle hook nodes
+func (e *Executor) WithHookRunner(runner HookRunner) *Executor {
+	e.hookRunner = runner
+	return e
+}
+
+// WithSecretGenerator sets the generator used for generated Secret nodes
+func (e *Executor) WithSecretGenerator(generator SecretGenerator) *Executor {
+	e.secretGenerator = generator
+	return e
+}
+
 // Execute executes the DAG with dependency-aware parallel execution
 func (e *Executor) Execute(ctx context.Context, dag *DAG) (*ExecutionState, error) {
 	if dag == nil {
@@ -79,6 +93,7 @@ func (e *Executor) Execute(ctx context.Context, dag *DAG) (*ExecutionState, erro
 	// Initialize execution state
 	nodeIDs := dag.GetOrder()
 	state := NewExecutionState(nodeIDs)
+	state.redactor = RedactorFrom(ctx)
 
 	// Execute nodes in waves based on dependencies
 	for !state.IsComplete() {
@@ -121,10 +136,11 @@ func (e *Executor) findReadyNodes(dag *DAG, state *ExecutionState) []string {
 			continue
 		}
 
-		// Check if this is a retry and we've exceeded max retries
+		// Check if this is a retry and we've exceeded max retries, or the
+		// error can never succeed
 		if nodeState == NodeStateError {
 			status, _ := state.GetStatus(nodeID)
-			if status.RetryCount >= e.config.MaxRetries {
+			if status.Terminal || status.RetryCount >= e.config.MaxRetries {
 				continue
 			}
 		}
@@ -192,6 +208,12 @@ func (e *Executor) executeNode(ctx context.Context, dag *DAG, state *ExecutionSt
 
 		// Increment retry count (error ignored: retry proceeds regardless)
 		_ = state.IncrementRetry(nodeID)
+
+		// Error may only move back to Pending before being applied again
+		if err := state.SetState(nodeID, NodeStatePending); err != nil {
+			_ = state.SetError(nodeID, err)
+			return err
+		}
 	}
 
 	// Transition to Applying state
@@ -200,6 +222,16 @@ func (e *Executor) executeNode(ctx context.Context, dag *DAG, state *ExecutionSt
 		return err
 	}
 
+	// Hooks run their Job to completion instead of being applied
+	if node.Hook != nil {
+		return e.runHook(ctx, dag, state, node)
+	}
+
+	// Generated Secrets are created once and never re-applied
+	if node.Generate != nil {
+		return e.generateSecret(ctx, state, node)
+	}
+
 	// Apply the resource with its policy
 	if err := e.applier.Apply(ctx, &node.Object, node.ApplyPolicy); err != nil {
 		_ = state.SetError(nodeID, fmt.Errorf("failed to apply: %w", err))
@@ -222,8 +254,19 @@ func (e *Executor) executeNode(ctx context.Context, dag *DAG, state *ExecutionSt
 		return err
 	}
 
-	// Wait for readiness
-	if err := e.waitForReadiness(ctx, node, state, nodeID); err != nil {
+	// Instances are rolled out by their own ResourceGraph, which may be
+	// waiting for this worker, so they are checked once and left waiting
+	// until the graph is executed again
+	if node.Instance {
+		ready, err := e.readinessChecker.Check(ctx, &node.Object, node.ReadyWhen)
+		if err != nil {
+			_ = state.SetError(nodeID, fmt.Errorf("readiness check failed: %w", err))
+			return err
+		}
+		if !ready {
+			return nil
+		}
+	} else if err := e.waitForReadiness(ctx, node, state, nodeID); err != nil {
 		_ = state.SetError(nodeID, fmt.Errorf("readiness check failed: %w", err))
 		return err
 	}
@@ -237,6 +280,47 @@ func (e *Executor) executeNode(ctx context.Context, dag *DAG, state *ExecutionSt
 	return nil
 }
 
+// runHook runs a hook node's Job unless it already ran for the render hash
+func (e *Executor) runHook(ctx context.Context, dag *DAG, state *ExecutionState, node *Node) error {
+	if !hookCompleted(ctx, node.ID) {
+		if e.hookRunner == nil {
+			err := &TerminalError{Reason: "HookRunnerMissing", Err: fmt.Errorf("no hook runner configured")}
+			_ = state.SetError(node.ID, err)
+			return err
+		}
+		if err := e.hookRunner.RunHook(ctx, &node.Object, *node.Hook, dag.RenderHash()); err != nil {
+			_ = state.SetError(node.ID, fmt.Errorf("%s hook failed: %w", node.Hook.Phase, err))
+			return err
+		}
+	}
+
+	if err := state.SetState(node.ID, NodeStateReady); err != nil {
+		_ = state.SetError(node.ID, err)
+		return err
+	}
+	return nil
+}
+
+// generateSecret creates a generated Secret node, filling in only the values
+// that do not exist yet
+func (e *Executor) generateSecret(ctx context.Context, state *ExecutionState, node *Node) error {
+	if e.secretGenerator == nil {
+		err := &TerminalError{Reason: "SecretGeneratorMissing", Err: fmt.Errorf("no secret generator configured")}
+		_ = state.SetError(node.ID, err)
+		return err
+	}
+	if err := e.secretGenerator.Generate(ctx, &node.Object, *node.Generate); err != nil {
+		_ = state.SetError(node.ID, fmt.Errorf("failed to generate secret: %w", err))
+		return err
+	}
+
+	if err := state.SetState(node.ID, NodeStateReady); err != nil {
+		_ = state.SetError(node.ID, err)
+		return err
+	}
+	return nil
+}
+
 // waitForReadiness polls the resource until all readiness predicates are satisfied
 func (e *Executor) waitForReadiness(ctx context.Context, node *Node, state *ExecutionState, nodeID string) error {
 	// Determine timeout - use the maximum timeout from all predicates, or default to 5 minutes
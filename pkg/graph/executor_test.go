@@ -401,3 +401,146 @@ func TestExecutor_ErrorHandling(t *testing.T) {
 		t.Error("Execution should have errors")
 	}
 }
+
+// countingApplier fails every apply with the configured error and counts attempts
+type countingApplier struct {
+	mu       sync.Mutex
+	attempts map[string]int
+	errs     map[string]error
+}
+
+func (m *countingApplier) Apply(ctx context.Context, obj *unstructured.Unstructured, policy ApplyPolicy) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.attempts[obj.GetName()]++
+	return m.errs[obj.GetName()]
+}
+
+func TestExecutor_TerminalErrorsAreNotRetried(t *testing.T) {
+	newNode := func(id string, deps ...string) Node {
+		return Node{
+			ID: id,
+			Object: unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"apiVersion": "v1",
+					"kind":       "ConfigMap",
+					"metadata":   map[string]interface{}{"name": id},
+				},
+			},
+			ApplyPolicy: ApplyPolicy{Mode: ApplyModeApply},
+			DependsOn:   deps,
+		}
+	}
+
+	// z stays pending behind y, which keeps the executor retrying y
+	g := &Graph{
+		Metadata: GraphMetadata{Name: "test", Version: "v1"},
+		Nodes:    []Node{newNode("x"), newNode("y"), newNode("z", "y")},
+	}
+	dag, err := BuildDAG(g)
+	if err != nil {
+		t.Fatalf("BuildDAG() failed: %v", err)
+	}
+
+	applier := &countingApplier{
+		attempts: map[string]int{},
+		errs: map[string]error{
+			"x": &TerminalError{Reason: "Forbidden", Err: errors.New("forbidden")},
+			"y": errors.New("timeout"),
+		},
+	}
+
+	config := DefaultExecutorConfig()
+	config.MaxRetries = 2
+	config.RetryBackoffBase = time.Millisecond
+	executor := NewExecutor(applier, newMockReadinessChecker(), nil, config)
+
+	state, err := executor.Execute(context.Background(), dag)
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+
+	if applier.attempts["x"] != 1 {
+		t.Errorf("expected terminal node to be applied once, got %d", applier.attempts["x"])
+	}
+	if applier.attempts["y"] != 3 {
+		t.Errorf("expected transient node to be retried twice, got %d attempts", applier.attempts["y"])
+	}
+
+	status, _ := state.GetStatus("x")
+	if !status.Terminal || status.Reason != "Forbidden" {
+		t.Errorf("expected x to be terminal with reason Forbidden, got terminal=%v reason=%q", status.Terminal, status.Reason)
+	}
+	if terminal := state.GetTerminalNodes(); len(terminal) != 1 || terminal[0] != "x" {
+		t.Errorf("expected only x to be terminal, got %v", terminal)
+	}
+}
+
+func TestExecutor_InstanceNodesDoNotBlock(t *testing.T) {
+	// db is a platform instance that app depends on
+	g := &Graph{
+		Metadata: GraphMetadata{Name: "test", Version: "v1"},
+		Nodes: []Node{
+			{
+				ID: "db",
+				Object: unstructured.Unstructured{
+					Object: map[string]interface{}{
+						"apiVersion": "apps.example.com/v1alpha1",
+						"kind":       "Database",
+						"metadata":   map[string]interface{}{"name": "db"},
+					},
+				},
+				ApplyPolicy: ApplyPolicy{Mode: ApplyModeApply},
+				ReadyWhen:   []ReadinessPredicate{{Type: PredicateTypeInstanceReady}},
+				Instance:    true,
+			},
+			{
+				ID: "app",
+				Object: unstructured.Unstructured{
+					Object: map[string]interface{}{
+						"apiVersion": "v1",
+						"kind":       "ConfigMap",
+						"metadata":   map[string]interface{}{"name": "app"},
+					},
+				},
+				ApplyPolicy: ApplyPolicy{Mode: ApplyModeApply},
+				DependsOn:   []string{"db"},
+			},
+		},
+	}
+
+	dag, err := BuildDAG(g)
+	if err != nil {
+		t.Fatalf("BuildDAG() failed: %v", err)
+	}
+	applier := newMockApplier()
+	checker := newMockReadinessChecker()
+	executor := NewExecutor(applier, checker, nil, DefaultExecutorConfig())
+
+	// A pending instance leaves the graph incomplete without waiting
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	state, err := executor.Execute(ctx, dag)
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	if state.IsComplete() || state.HasErrors() {
+		t.Errorf("expected an incomplete graph without errors, got complete=%v errors=%v", state.IsComplete(), state.HasErrors())
+	}
+	if dbState, _ := state.GetState("db"); dbState != NodeStateWaitingReady {
+		t.Errorf("expected db to be waiting, got %s", dbState)
+	}
+	if applied := applier.getAppliedNodes(); len(applied) != 1 {
+		t.Errorf("expected only db to be applied, got %v", applied)
+	}
+
+	// Once the instance is ready the graph completes
+	checker.setReady("db", true)
+	state, err = executor.Execute(ctx, dag)
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	if !state.IsComplete() || state.HasErrors() {
+		t.Errorf("expected the graph to complete, got complete=%v errors=%v", state.IsComplete(), state.HasErrors())
+	}
+}
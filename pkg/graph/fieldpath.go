@@ -0,0 +1,227 @@
+package graph
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// protectedFieldPaths are object fields that identify the resource and can
+// never be excluded from the applied intent
+var protectedFieldPaths = map[string]bool{
+	"apiVersion":         true,
+	"kind":               true,
+	"metadata":           true,
+	"metadata.name":      true,
+	"metadata.namespace": true,
+}
+
+// fieldPathSegment is a single step in a parsed field path
+type fieldPathSegment struct {
+	// key is the map key to descend into (empty for list wildcards)
+	key string
+
+	// allItems descends into every element of a list
+	allItems bool
+}
+
+// FieldPath is a parsed field path used to exclude parts of an object from
+// apply, drift and diff computation.
+//
+// Paths are dot-separated map keys, e.g. "spec.replicas". A "[*]" suffix
+// selects every element of a list ("spec.template.spec.containers[*].image"),
+// and keys containing dots can be quoted in brackets
+// (`metadata.annotations["sidecar.istio.io/status"]`).
+type FieldPath struct {
+	raw      string
+	segments []fieldPathSegment
+}
+
+// ParseFieldPath parses a field path expression
+func ParseFieldPath(path string) (FieldPath, error) {
+	if strings.TrimSpace(path) == "" {
+		return FieldPath{}, fmt.Errorf("field path cannot be empty")
+	}
+
+	var segments []fieldPathSegment
+	var key strings.Builder
+
+	flushKey := func() {
+		if key.Len() > 0 {
+			segments = append(segments, fieldPathSegment{key: key.String()})
+			key.Reset()
+		}
+	}
+
+	for i := 0; i < len(path); i++ {
+		switch c := path[i]; c {
+		case '.':
+			if key.Len() == 0 && (i == 0 || path[i-1] != ']') {
+				return FieldPath{}, fmt.Errorf("field path %q has an empty segment", path)
+			}
+			flushKey()
+		case '[':
+			flushKey()
+			end := strings.IndexByte(path[i:], ']')
+			if end < 0 {
+				return FieldPath{}, fmt.Errorf("field path %q has an unterminated '['", path)
+			}
+			inner := path[i+1 : i+end]
+			switch {
+			case inner == "*":
+				if len(segments) == 0 {
+					return FieldPath{}, fmt.Errorf("field path %q cannot start with a list wildcard", path)
+				}
+				segments = append(segments, fieldPathSegment{allItems: true})
+			case len(inner) >= 2 && inner[0] == '"' && inner[len(inner)-1] == '"':
+				if len(inner) == 2 {
+					return FieldPath{}, fmt.Errorf("field path %q has an empty quoted key", path)
+				}
+				segments = append(segments, fieldPathSegment{key: inner[1 : len(inner)-1]})
+			default:
+				return FieldPath{}, fmt.Errorf("field path %q: unsupported selector [%s] (use [*] or [\"key\"])", path, inner)
+			}
+			i += end
+		default:
+			key.WriteByte(c)
+		}
+	}
+
+	if strings.HasSuffix(path, ".") {
+		return FieldPath{}, fmt.Errorf("field path %q has an empty segment", path)
+	}
+	flushKey()
+
+	// Protected paths are matched however their keys are spelled
+	parsed := FieldPath{raw: path, segments: segments}
+	if protectedFieldPaths[parsed.canonical()] {
+		return FieldPath{}, fmt.Errorf("field path %q identifies the resource and cannot be ignored", path)
+	}
+
+	return parsed, nil
+}
+
+// String returns the original path expression
+func (p FieldPath) String() string {
+	return p.raw
+}
+
+// RemoveFrom deletes the field from the given object content, if present
+func (p FieldPath) RemoveFrom(obj map[string]interface{}) {
+	removeSegments(obj, p.segments)
+}
+
+// Covers reports whether a field path as reported by the API server in
+// managed fields and conflict causes (e.g. `.spec.containers[name="app"].image`)
+// is this path or lies below it. List selectors on either side match any element.
+func (p FieldPath) Covers(managedPath string) bool {
+	own := p.canonical()
+	other := canonicalManagedPath(managedPath)
+	return other == own ||
+		strings.HasPrefix(other, own+".") ||
+		strings.HasPrefix(other, own+"[")
+}
+
+// canonical renders the path with every list selector written as "[*]"
+func (p FieldPath) canonical() string {
+	var b strings.Builder
+	for _, seg := range p.segments {
+		if seg.allItems {
+			b.WriteString("[*]")
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteByte('.')
+		}
+		b.WriteString(seg.key)
+	}
+	return b.String()
+}
+
+// canonicalManagedPath converts an API server field path to the canonical
+// form used by FieldPath: no leading dot and every list selector as "[*]"
+func canonicalManagedPath(path string) string {
+	path = strings.TrimPrefix(path, ".")
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		if path[i] != '[' {
+			b.WriteByte(path[i])
+			continue
+		}
+		// Skip to the matching ']' while honouring quoted values
+		inQuote := false
+	selector:
+		for i++; i < len(path); i++ {
+			switch c := path[i]; {
+			case c == '\\' && inQuote:
+				i++
+			case c == '"':
+				inQuote = !inQuote
+			case c == ']' && !inQuote:
+				break selector
+			}
+		}
+		b.WriteString("[*]")
+	}
+	return b.String()
+}
+
+// removeSegments walks the remaining segments and deletes the final field
+func removeSegments(value interface{}, segments []fieldPathSegment) {
+	if len(segments) == 0 {
+		return
+	}
+	seg := segments[0]
+	last := len(segments) == 1
+
+	if seg.allItems {
+		items, ok := value.([]interface{})
+		if !ok {
+			return
+		}
+		for _, item := range items {
+			removeSegments(item, segments[1:])
+		}
+		return
+	}
+
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return
+	}
+	if last {
+		delete(m, seg.key)
+		return
+	}
+	if child, found := m[seg.key]; found {
+		removeSegments(child, segments[1:])
+	}
+}
+
+// ValidateFieldPaths checks that every path in the list can be parsed
+func ValidateFieldPaths(paths []string) error {
+	for _, p := range paths {
+		if _, err := ParseFieldPath(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StripFields returns a copy of obj with the given field paths removed.
+// Invalid paths are skipped; callers validate them up front via ApplyPolicy.Validate.
+func StripFields(obj *unstructured.Unstructured, paths []string) *unstructured.Unstructured {
+	if obj == nil {
+		return nil
+	}
+	stripped := obj.DeepCopy()
+	for _, raw := range paths {
+		p, err := ParseFieldPath(raw)
+		if err != nil {
+			continue
+		}
+		p.RemoveFrom(stripped.Object)
+	}
+	return stripped
+}
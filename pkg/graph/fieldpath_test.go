@@ -0,0 +1,145 @@
+package graph
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestParseFieldPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		wantErr bool
+	}{
+		{name: "simple path", path: "spec.replicas"},
+		{name: "list wildcard", path: "spec.template.spec.containers[*].image"},
+		{name: "quoted key", path: `metadata.annotations["sidecar.istio.io/status"]`},
+		{name: "labels are allowed", path: "metadata.labels"},
+		{name: "empty path", path: "", wantErr: true},
+		{name: "empty segment", path: "spec..replicas", wantErr: true},
+		{name: "trailing dot", path: "spec.", wantErr: true},
+		{name: "leading dot", path: ".spec", wantErr: true},
+		{name: "unterminated bracket", path: "spec.containers[*", wantErr: true},
+		{name: "index selector unsupported", path: "spec.containers[0]", wantErr: true},
+		{name: "leading wildcard", path: "[*].name", wantErr: true},
+		{name: "kind is protected", path: "kind", wantErr: true},
+		{name: "name is protected", path: "metadata.name", wantErr: true},
+		{name: "namespace is protected", path: "metadata.namespace", wantErr: true},
+		{name: "quoted metadata is protected", path: `["metadata"]`, wantErr: true},
+		{name: "quoted name is protected", path: `metadata["name"]`, wantErr: true},
+		{name: "quoted kind is protected", path: `["kind"]`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseFieldPath(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseFieldPath(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func newDeploymentObject() *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata": map[string]interface{}{
+				"name":      "web",
+				"namespace": "default",
+				"annotations": map[string]interface{}{
+					"sidecar.istio.io/status": "injected",
+					"keep":                    "me",
+				},
+			},
+			"spec": map[string]interface{}{
+				"replicas": int64(3),
+				"template": map[string]interface{}{
+					"spec": map[string]interface{}{
+						"containers": []interface{}{
+							map[string]interface{}{"name": "app", "image": "nginx", "resources": map[string]interface{}{}},
+							map[string]interface{}{"name": "proxy", "image": "envoy", "resources": map[string]interface{}{}},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestStripFields(t *testing.T) {
+	obj := newDeploymentObject()
+
+	stripped := StripFields(obj, []string{
+		"spec.replicas",
+		"spec.template.spec.containers[*].resources",
+		`metadata.annotations["sidecar.istio.io/status"]`,
+		"spec.doesNotExist.deeper",
+	})
+
+	if _, found, _ := unstructured.NestedFieldNoCopy(stripped.Object, "spec", "replicas"); found {
+		t.Error("expected spec.replicas to be removed")
+	}
+
+	containers, _, _ := unstructured.NestedSlice(stripped.Object, "spec", "template", "spec", "containers")
+	for i, c := range containers {
+		if _, found := c.(map[string]interface{})["resources"]; found {
+			t.Errorf("expected containers[%d].resources to be removed", i)
+		}
+		if _, found := c.(map[string]interface{})["image"]; !found {
+			t.Errorf("expected containers[%d].image to be kept", i)
+		}
+	}
+
+	annotations := stripped.GetAnnotations()
+	if _, found := annotations["sidecar.istio.io/status"]; found {
+		t.Error("expected quoted annotation to be removed")
+	}
+	if annotations["keep"] != "me" {
+		t.Error("expected unrelated annotation to be kept")
+	}
+
+	// The original object must not be modified
+	if _, found, _ := unstructured.NestedFieldNoCopy(obj.Object, "spec", "replicas"); !found {
+		t.Error("expected original object to be unchanged")
+	}
+}
+
+func TestApplyPolicy_ValidateIgnoreFields(t *testing.T) {
+	valid := ApplyPolicy{IgnoreFields: []string{"spec.replicas"}}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("expected valid policy, got %v", err)
+	}
+
+	invalid := ApplyPolicy{IgnoreFields: []string{"metadata.name"}}
+	if err := invalid.Validate(); err == nil {
+		t.Error("expected error for protected field path")
+	}
+}
+
+func TestComputeHash_IgnoresExcludedFields(t *testing.T) {
+	newGraph := func(replicas int64) *Graph {
+		obj := newDeploymentObject()
+		_ = unstructured.SetNestedField(obj.Object, replicas, "spec", "replicas")
+		return &Graph{
+			Metadata: GraphMetadata{Name: "test", Version: "v1"},
+			Nodes: []Node{{
+				ID:          "deployment",
+				Object:      *obj,
+				ApplyPolicy: ApplyPolicy{IgnoreFields: []string{"spec.replicas"}},
+			}},
+		}
+	}
+
+	if newGraph(1).ComputeHash() != newGraph(5).ComputeHash() {
+		t.Error("expected changes to ignored fields not to affect the hash")
+	}
+
+	changed := newGraph(1)
+	_ = unstructured.SetNestedField(changed.Nodes[0].Object.Object, "other", "metadata", "annotations", "keep")
+	if newGraph(1).ComputeHash() == changed.ComputeHash() {
+		t.Error("expected changes to other fields to affect the hash")
+	}
+}
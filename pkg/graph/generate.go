@@ -0,0 +1,174 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// GeneratedSecret marks a Secret node whose values are generated by the
+// executor. The Secret is created once with random or keypair material and
+// existing values are never regenerated, so the rendered graph stays pure and
+// never contains the values.
+type GeneratedSecret struct {
+	// Keys lists the Secret data keys to generate
+	Keys []GeneratedKey `json:"keys"`
+}
+
+// GeneratedKey describes the value generated for one Secret data key
+type GeneratedKey struct {
+	// Name is the Secret data key. Keypairs store the private key under Name
+	// and the public key under Name + ".pub".
+	Name string `json:"name"`
+
+	// Type is the kind of value to generate. Defaults to Random.
+	Type GeneratedKeyType `json:"type,omitempty"`
+
+	// Length is the number of characters of a Random value. Defaults to 32.
+	Length int `json:"length,omitempty"`
+
+	// Charset is the alphabet of a Random value. Defaults to Alphanumeric.
+	Charset Charset `json:"charset,omitempty"`
+
+	// Bits is the size of an RSA key. Defaults to 2048.
+	Bits int `json:"bits,omitempty"`
+}
+
+// GeneratedKeyType defines the kind of generated value
+type GeneratedKeyType string
+
+const (
+	// GeneratedKeyRandom is a random string, e.g. a password or token
+	GeneratedKeyRandom GeneratedKeyType = "Random"
+
+	// GeneratedKeyRSA is a PEM encoded RSA keypair
+	GeneratedKeyRSA GeneratedKeyType = "RSA"
+
+	// GeneratedKeyECDSA is a PEM encoded ECDSA P-256 keypair
+	GeneratedKeyECDSA GeneratedKeyType = "ECDSA"
+
+	// GeneratedKeyEd25519 is a PEM encoded Ed25519 keypair
+	GeneratedKeyEd25519 GeneratedKeyType = "Ed25519"
+)
+
+// Charset defines the alphabet of a Random value
+type Charset string
+
+const (
+	// CharsetAlphanumeric is upper and lower case letters and digits
+	CharsetAlphanumeric Charset = "Alphanumeric"
+
+	// CharsetNumeric is digits only
+	CharsetNumeric Charset = "Numeric"
+
+	// CharsetHex is lower case hexadecimal digits
+	CharsetHex Charset = "Hex"
+
+	// CharsetPrintable is every printable ASCII character except space
+	CharsetPrintable Charset = "Printable"
+)
+
+const (
+	// DefaultGeneratedLength is the length of a Random value
+	DefaultGeneratedLength = 32
+
+	// DefaultRSABits is the size of a generated RSA key
+	DefaultRSABits = 2048
+)
+
+// SecretGenerator creates generated Secrets
+type SecretGenerator interface {
+	// Generate creates the Secret with values for its generated keys, or adds
+	// values for keys missing from an existing Secret
+	Generate(ctx context.Context, obj *unstructured.Unstructured, spec GeneratedSecret) error
+}
+
+// Validate checks the generated secret spec and sets defaults
+func (s *GeneratedSecret) Validate(obj *unstructured.Unstructured) error {
+	gvk := obj.GroupVersionKind()
+	if gvk.Group != "" || gvk.Kind != "Secret" {
+		return fmt.Errorf("generated object must be a Secret, got %s", gvk.GroupKind())
+	}
+	// Values in the object would end up in the graph and its render hash
+	for _, field := range []string{"data", "stringData"} {
+		if _, found := obj.Object[field]; found {
+			return fmt.Errorf("generated Secret must not set %s", field)
+		}
+	}
+
+	if len(s.Keys) == 0 {
+		return fmt.Errorf("generated Secret must declare at least one key")
+	}
+
+	names := make(map[string]bool, len(s.Keys))
+	for i := range s.Keys {
+		key := &s.Keys[i]
+		if err := key.Validate(); err != nil {
+			return fmt.Errorf("key %q: %w", key.Name, err)
+		}
+		for _, name := range key.DataKeys() {
+			if names[name] {
+				return fmt.Errorf("duplicate generated key %q", name)
+			}
+			names[name] = true
+		}
+	}
+
+	return nil
+}
+
+// Validate checks the generated key and sets defaults
+func (k *GeneratedKey) Validate() error {
+	if k.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if k.Type == "" {
+		k.Type = GeneratedKeyRandom
+	}
+
+	switch k.Type {
+	case GeneratedKeyRandom:
+		if k.Length == 0 {
+			k.Length = DefaultGeneratedLength
+		}
+		if k.Length < 1 || k.Length > 1024 {
+			return fmt.Errorf("length must be between 1 and 1024, got %d", k.Length)
+		}
+		if k.Charset == "" {
+			k.Charset = CharsetAlphanumeric
+		}
+		switch k.Charset {
+		case CharsetAlphanumeric, CharsetNumeric, CharsetHex, CharsetPrintable:
+			// Valid
+		default:
+			return fmt.Errorf("invalid charset: %s", k.Charset)
+		}
+	case GeneratedKeyRSA:
+		if k.Bits == 0 {
+			k.Bits = DefaultRSABits
+		}
+		if k.Bits != 2048 && k.Bits != 3072 && k.Bits != 4096 {
+			return fmt.Errorf("RSA bits must be 2048, 3072 or 4096, got %d", k.Bits)
+		}
+	case GeneratedKeyECDSA, GeneratedKeyEd25519:
+		// Valid
+	default:
+		return fmt.Errorf("invalid generated key type: %s", k.Type)
+	}
+
+	return nil
+}
+
+// IsKeypair reports whether the key generates a private and public key
+func (k *GeneratedKey) IsKeypair() bool {
+	return k.Type == GeneratedKeyRSA || k.Type == GeneratedKeyECDSA || k.Type == GeneratedKeyEd25519
+}
+
+// DataKeys returns the Secret data keys the generated key is stored under
+func (k *GeneratedKey) DataKeys() []string {
+	if k.IsKeypair() {
+		return []string{k.Name, k.Name + ".pub"}
+	}
+	return []string{k.Name}
+}
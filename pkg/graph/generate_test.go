@@ -0,0 +1,150 @@
+package graph
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newGeneratedSecretNode(id string, keys ...GeneratedKey) Node {
+	return Node{
+		ID: id,
+		Object: unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Secret",
+				"metadata":   map[string]interface{}{"name": id},
+			},
+		},
+		ApplyPolicy: ApplyPolicy{Mode: ApplyModeApply},
+		Generate:    &GeneratedSecret{Keys: keys},
+	}
+}
+
+func TestGeneratedSecret_Validate(t *testing.T) {
+	withData := newGeneratedSecretNode("db", GeneratedKey{Name: "password"})
+	withData.Object.Object["stringData"] = map[string]interface{}{"password": "hunter2"}
+
+	notASecret := newGeneratedSecretNode("db", GeneratedKey{Name: "password"})
+	notASecret.Object.SetKind("ConfigMap")
+
+	tests := []struct {
+		name    string
+		node    Node
+		wantErr string
+	}{
+		{
+			name: "random and keypair keys",
+			node: newGeneratedSecretNode("db", GeneratedKey{Name: "password"}, GeneratedKey{Name: "signing", Type: GeneratedKeyEd25519}),
+		},
+		{
+			name:    "object must be a Secret",
+			node:    notASecret,
+			wantErr: "must be a Secret",
+		},
+		{
+			name:    "values must not be rendered",
+			node:    withData,
+			wantErr: "must not set stringData",
+		},
+		{
+			name:    "at least one key",
+			node:    newGeneratedSecretNode("db"),
+			wantErr: "at least one key",
+		},
+		{
+			name:    "keypair public key clashes with another key",
+			node:    newGeneratedSecretNode("db", GeneratedKey{Name: "tls", Type: GeneratedKeyRSA}, GeneratedKey{Name: "tls.pub"}),
+			wantErr: `duplicate generated key "tls.pub"`,
+		},
+		{
+			name:    "invalid charset",
+			node:    newGeneratedSecretNode("db", GeneratedKey{Name: "password", Charset: "Emoji"}),
+			wantErr: "invalid charset",
+		},
+		{
+			name:    "invalid RSA size",
+			node:    newGeneratedSecretNode("db", GeneratedKey{Name: "tls", Type: GeneratedKeyRSA, Bits: 1024}),
+			wantErr: "RSA bits",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newHookGraph(tt.node).Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate() failed: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGeneratedKey_Defaults(t *testing.T) {
+	key := GeneratedKey{Name: "password"}
+	if err := key.Validate(); err != nil {
+		t.Fatalf("Validate() failed: %v", err)
+	}
+	if key.Type != GeneratedKeyRandom || key.Length != DefaultGeneratedLength || key.Charset != CharsetAlphanumeric {
+		t.Errorf("expected Random/%d/Alphanumeric defaults, got %s/%d/%s", DefaultGeneratedLength, key.Type, key.Length, key.Charset)
+	}
+
+	rsaKey := GeneratedKey{Name: "tls", Type: GeneratedKeyRSA}
+	if err := rsaKey.Validate(); err != nil {
+		t.Fatalf("Validate() failed: %v", err)
+	}
+	if rsaKey.Bits != DefaultRSABits {
+		t.Errorf("expected %d bits, got %d", DefaultRSABits, rsaKey.Bits)
+	}
+}
+
+// mockSecretGenerator records the Secrets it was asked to generate
+type mockSecretGenerator struct {
+	mu        sync.Mutex
+	generated []string
+}
+
+func (m *mockSecretGenerator) Generate(ctx context.Context, obj *unstructured.Unstructured, spec GeneratedSecret) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.generated = append(m.generated, obj.GetName())
+	return nil
+}
+
+func TestExecutor_GeneratesSecrets(t *testing.T) {
+	g := newHookGraph(
+		newGeneratedSecretNode("db-credentials", GeneratedKey{Name: "password"}),
+		newConfigMapNode("app", "db-credentials"),
+	)
+	dag, err := BuildDAG(g)
+	if err != nil {
+		t.Fatalf("BuildDAG() failed: %v", err)
+	}
+
+	applier := newMockApplier()
+	generator := &mockSecretGenerator{}
+	executor := NewExecutor(applier, newMockReadinessChecker(), nil, DefaultExecutorConfig()).WithSecretGenerator(generator)
+
+	state, err := executor.Execute(context.Background(), dag)
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	if state.HasErrors() {
+		t.Fatalf("expected successful execution, got %+v", state.GetSummary())
+	}
+
+	if len(generator.generated) != 1 || generator.generated[0] != "db-credentials" {
+		t.Errorf("expected db-credentials to be generated, got %v", generator.generated)
+	}
+	if applied := applier.getAppliedNodes(); len(applied) != 1 || applied[0] != "app" {
+		t.Errorf("expected generated Secret not to be applied, got %v", applied)
+	}
+}
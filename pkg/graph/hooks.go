@@ -0,0 +1,142 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Hook marks a node as a lifecycle hook. Hook objects must be Jobs: they are
+// created once per render hash and the graph waits for them to finish
+// instead of re-applying them on every execution.
+type Hook struct {
+	// Phase is when the hook runs
+	Phase HookPhase `json:"phase"`
+
+	// CleanupPolicy is when the hook's Job is deleted. Defaults to BeforeRun.
+	CleanupPolicy HookCleanupPolicy `json:"cleanupPolicy,omitempty"`
+}
+
+// HookPhase defines when a hook runs
+type HookPhase string
+
+const (
+	// HookPhasePreApply hooks run before any other node is applied
+	HookPhasePreApply HookPhase = "PreApply"
+
+	// HookPhasePostApply hooks run after every other node is ready
+	HookPhasePostApply HookPhase = "PostApply"
+
+	// HookPhasePreDelete hooks run when the instance is deleted, before its
+	// resources are removed. They are not part of normal execution.
+	HookPhasePreDelete HookPhase = "PreDelete"
+)
+
+// HookCleanupPolicy defines when a hook's Job is deleted
+type HookCleanupPolicy string
+
+const (
+	// HookCleanupBeforeRun keeps the Job until the hook runs again for a new
+	// render hash, then replaces it
+	HookCleanupBeforeRun HookCleanupPolicy = "BeforeRun"
+
+	// HookCleanupOnSuccess deletes the Job once it succeeds. Failed Jobs are
+	// kept for debugging.
+	HookCleanupOnSuccess HookCleanupPolicy = "OnSuccess"
+
+	// HookCleanupOnCompletion deletes the Job once it succeeds or fails
+	HookCleanupOnCompletion HookCleanupPolicy = "OnCompletion"
+)
+
+// HookRunner runs a hook Job to completion
+type HookRunner interface {
+	// RunHook creates the hook's Job unless it already ran for renderHash,
+	// and waits for it to succeed or fail
+	RunHook(ctx context.Context, obj *unstructured.Unstructured, hook Hook, renderHash string) error
+}
+
+// IsHook reports whether the node is a lifecycle hook in the given phase
+func (n *Node) IsHook(phase HookPhase) bool {
+	return n.Hook != nil && n.Hook.Phase == phase
+}
+
+// Validate checks the hook and sets defaults
+func (h *Hook) Validate(obj *unstructured.Unstructured) error {
+	if h.CleanupPolicy == "" {
+		h.CleanupPolicy = HookCleanupBeforeRun
+	}
+
+	switch h.Phase {
+	case HookPhasePreApply, HookPhasePostApply, HookPhasePreDelete:
+		// Valid
+	default:
+		return fmt.Errorf("invalid hook phase: %s", h.Phase)
+	}
+
+	switch h.CleanupPolicy {
+	case HookCleanupBeforeRun, HookCleanupOnSuccess, HookCleanupOnCompletion:
+		// Valid
+	default:
+		return fmt.Errorf("invalid hook cleanup policy: %s", h.CleanupPolicy)
+	}
+
+	gvk := obj.GroupVersionKind()
+	if gvk.Group != "batch" || gvk.Kind != "Job" {
+		return fmt.Errorf("hook object must be a batch Job, got %s", gvk.GroupKind())
+	}
+
+	return nil
+}
+
+// validateHookDependencies rejects dependencies that would cycle with the
+// implicit ordering of hooks: PreApply hooks run before every other node and
+// PostApply hooks after, while PreDelete hooks only run on deletion
+func (g *Graph) validateHookDependencies() error {
+	nodes := make(map[string]*Node, len(g.Nodes))
+	for i := range g.Nodes {
+		nodes[g.Nodes[i].ID] = &g.Nodes[i]
+	}
+
+	for _, node := range g.Nodes {
+		for _, depID := range node.DependsOn {
+			dep := nodes[depID]
+			if dep == nil {
+				continue
+			}
+			switch {
+			case node.IsHook(HookPhasePreApply) && !dep.IsHook(HookPhasePreApply):
+				return fmt.Errorf("node %s: PreApply hooks may only depend on other PreApply hooks, not %s", node.ID, depID)
+			case node.IsHook(HookPhasePreDelete) != dep.IsHook(HookPhasePreDelete):
+				return fmt.Errorf("node %s: PreDelete hooks and other nodes may not depend on each other (%s)", node.ID, depID)
+			case !node.IsHook(HookPhasePostApply) && dep.IsHook(HookPhasePostApply):
+				return fmt.Errorf("node %s: only PostApply hooks may depend on PostApply hook %s", node.ID, depID)
+			}
+		}
+	}
+
+	return nil
+}
+
+// completedHooksKey is the context key for hooks that already ran
+type completedHooksKey struct{}
+
+// WithCompletedHooks returns a context telling the executor that the given
+// hook nodes already ran for the graph's render hash, so they are marked
+// ready without being run again
+func WithCompletedHooks(ctx context.Context, nodeIDs ...string) context.Context {
+	if len(nodeIDs) == 0 {
+		return ctx
+	}
+	completed := make(map[string]bool, len(nodeIDs))
+	for _, id := range nodeIDs {
+		completed[id] = true
+	}
+	return context.WithValue(ctx, completedHooksKey{}, completed)
+}
+
+// hookCompleted reports whether the hook node is recorded as completed in ctx
+func hookCompleted(ctx context.Context, nodeID string) bool {
+	completed, _ := ctx.Value(completedHooksKey{}).(map[string]bool)
+	return completed[nodeID]
+}
@@ -0,0 +1,256 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newConfigMapNode(id string, deps ...string) Node {
+	return Node{
+		ID: id,
+		Object: unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+				"metadata":   map[string]interface{}{"name": id},
+			},
+		},
+		ApplyPolicy: ApplyPolicy{Mode: ApplyModeApply},
+		DependsOn:   deps,
+	}
+}
+
+func newHookNode(id string, phase HookPhase, deps ...string) Node {
+	return Node{
+		ID: id,
+		Object: unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": "batch/v1",
+				"kind":       "Job",
+				"metadata":   map[string]interface{}{"name": id},
+			},
+		},
+		ApplyPolicy: ApplyPolicy{Mode: ApplyModeApply},
+		DependsOn:   deps,
+		Hook:        &Hook{Phase: phase},
+	}
+}
+
+func newHookGraph(nodes ...Node) *Graph {
+	return &Graph{
+		Metadata: GraphMetadata{Name: "test", Version: "v1", RenderHash: "abc123"},
+		Nodes:    nodes,
+	}
+}
+
+func TestGraph_Validate_Hooks(t *testing.T) {
+	notAJob := newHookNode("migrate", HookPhasePreApply)
+	notAJob.Object.SetAPIVersion("v1")
+	notAJob.Object.SetKind("Pod")
+
+	badPhase := newHookNode("migrate", HookPhase("Sometimes"))
+
+	tests := []struct {
+		name    string
+		nodes   []Node
+		wantErr string
+	}{
+		{
+			name:  "valid hooks",
+			nodes: []Node{newHookNode("migrate", HookPhasePreApply), newConfigMapNode("app"), newHookNode("smoke", HookPhasePostApply, "app")},
+		},
+		{
+			name:    "hook must be a Job",
+			nodes:   []Node{notAJob},
+			wantErr: "must be a batch Job",
+		},
+		{
+			name:    "invalid phase",
+			nodes:   []Node{badPhase},
+			wantErr: "invalid hook phase",
+		},
+		{
+			name:    "PreApply hook depends on regular node",
+			nodes:   []Node{newConfigMapNode("app"), newHookNode("migrate", HookPhasePreApply, "app")},
+			wantErr: "PreApply hooks may only depend",
+		},
+		{
+			name:    "regular node depends on PostApply hook",
+			nodes:   []Node{newHookNode("smoke", HookPhasePostApply), newConfigMapNode("app", "smoke")},
+			wantErr: "only PostApply hooks may depend",
+		},
+		{
+			name:    "PreDelete hook depends on regular node",
+			nodes:   []Node{newConfigMapNode("app"), newHookNode("backup", HookPhasePreDelete, "app")},
+			wantErr: "PreDelete hooks and other nodes",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newHookGraph(tt.nodes...).Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate() failed: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBuildDAG_HookOrdering(t *testing.T) {
+	g := newHookGraph(
+		newConfigMapNode("config"),
+		newConfigMapNode("app", "config"),
+		newHookNode("migrate", HookPhasePreApply),
+		newHookNode("smoke", HookPhasePostApply),
+		newHookNode("backup", HookPhasePreDelete),
+	)
+
+	dag, err := BuildDAG(g)
+	if err != nil {
+		t.Fatalf("BuildDAG() failed: %v", err)
+	}
+
+	if dag.Size() != 4 {
+		t.Errorf("expected PreDelete hook to be left out, got %d nodes", dag.Size())
+	}
+	if dag.RenderHash() != "abc123" {
+		t.Errorf("expected render hash abc123, got %q", dag.RenderHash())
+	}
+
+	order := dag.GetOrder()
+	if slices.Index(order, "migrate") > slices.Index(order, "config") {
+		t.Errorf("expected PreApply hook before regular nodes, got %v", order)
+	}
+	if slices.Index(order, "smoke") < slices.Index(order, "app") {
+		t.Errorf("expected PostApply hook after regular nodes, got %v", order)
+	}
+
+	deps, _ := dag.GetDependencies("app")
+	if !slices.Contains(deps, "config") || !slices.Contains(deps, "migrate") {
+		t.Errorf("expected app to depend on config and migrate, got %v", deps)
+	}
+
+	deletion, err := BuildDeletionDAG(g)
+	if err != nil {
+		t.Fatalf("BuildDeletionDAG() failed: %v", err)
+	}
+	if order := deletion.GetOrder(); len(order) != 1 || order[0] != "backup" {
+		t.Errorf("expected deletion DAG to contain only backup, got %v", order)
+	}
+}
+
+// mockHookRunner records hook runs and fails the configured hooks
+type mockHookRunner struct {
+	mu     sync.Mutex
+	runs   []string
+	hashes []string
+	errs   map[string]error
+}
+
+func (m *mockHookRunner) RunHook(ctx context.Context, obj *unstructured.Unstructured, hook Hook, renderHash string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.runs = append(m.runs, obj.GetName())
+	m.hashes = append(m.hashes, renderHash)
+	return m.errs[obj.GetName()]
+}
+
+func TestExecutor_RunsHooks(t *testing.T) {
+	g := newHookGraph(
+		newHookNode("migrate", HookPhasePreApply),
+		newConfigMapNode("app"),
+		newHookNode("smoke", HookPhasePostApply),
+	)
+	dag, err := BuildDAG(g)
+	if err != nil {
+		t.Fatalf("BuildDAG() failed: %v", err)
+	}
+
+	applier := newMockApplier()
+	runner := &mockHookRunner{}
+	executor := NewExecutor(applier, newMockReadinessChecker(), nil, DefaultExecutorConfig()).WithHookRunner(runner)
+
+	state, err := executor.Execute(context.Background(), dag)
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	if !state.IsComplete() || state.HasErrors() {
+		t.Fatalf("expected successful execution, got %+v", state.GetSummary())
+	}
+
+	if applied := applier.getAppliedNodes(); len(applied) != 1 || applied[0] != "app" {
+		t.Errorf("expected only app to be applied, got %v", applied)
+	}
+	if !slices.Equal(runner.runs, []string{"migrate", "smoke"}) {
+		t.Errorf("expected hooks to run in phase order, got %v", runner.runs)
+	}
+	if runner.hashes[0] != "abc123" {
+		t.Errorf("expected hooks to run for render hash abc123, got %q", runner.hashes[0])
+	}
+}
+
+func TestExecutor_SkipsCompletedHooks(t *testing.T) {
+	g := newHookGraph(newHookNode("migrate", HookPhasePreApply), newConfigMapNode("app"))
+	dag, err := BuildDAG(g)
+	if err != nil {
+		t.Fatalf("BuildDAG() failed: %v", err)
+	}
+
+	runner := &mockHookRunner{}
+	executor := NewExecutor(newMockApplier(), newMockReadinessChecker(), nil, DefaultExecutorConfig()).WithHookRunner(runner)
+
+	state, err := executor.Execute(WithCompletedHooks(context.Background(), "migrate"), dag)
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	if state.HasErrors() {
+		t.Fatalf("expected successful execution, got %+v", state.GetSummary())
+	}
+	if len(runner.runs) != 0 {
+		t.Errorf("expected completed hook not to run again, got %v", runner.runs)
+	}
+}
+
+func TestExecutor_HookFailureBlocksGraph(t *testing.T) {
+	g := newHookGraph(newHookNode("migrate", HookPhasePreApply), newConfigMapNode("app"))
+	dag, err := BuildDAG(g)
+	if err != nil {
+		t.Fatalf("BuildDAG() failed: %v", err)
+	}
+
+	applier := newMockApplier()
+	runner := &mockHookRunner{errs: map[string]error{
+		"migrate": &TerminalError{Reason: "HookFailed", Err: errors.New("BackoffLimitExceeded")},
+	}}
+	config := DefaultExecutorConfig()
+	config.RetryBackoffBase = time.Millisecond
+	executor := NewExecutor(applier, newMockReadinessChecker(), nil, config).WithHookRunner(runner)
+
+	state, err := executor.Execute(context.Background(), dag)
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+
+	if len(applier.getAppliedNodes()) != 0 {
+		t.Errorf("expected no nodes to be applied after a failed PreApply hook, got %v", applier.getAppliedNodes())
+	}
+	if len(runner.runs) != 1 {
+		t.Errorf("expected failed hook not to be retried, got %d runs", len(runner.runs))
+	}
+	if s, _ := state.GetState("app"); s != NodeStatePending {
+		t.Errorf("expected app to stay Pending, got %s", s)
+	}
+}
@@ -0,0 +1,211 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Redacted replaces sensitive values in errors, events and logs
+const Redacted = "[REDACTED]"
+
+// minRedactedLength is the shortest value that is redacted. Shorter values
+// would mangle unrelated text without hiding anything worth protecting.
+const minRedactedLength = 4
+
+// secretDataFields are always sensitive in core Secrets
+var secretDataFields = []string{"data", "stringData"}
+
+// MarkSecretData adds the data fields of a core Secret to the node's
+// sensitive fields, so Secret values are never stored in a ResourceGraph
+func (n *Node) MarkSecretData() {
+	if n.Object.GetAPIVersion() != "v1" || n.Object.GetKind() != "Secret" {
+		return
+	}
+	for _, field := range secretDataFields {
+		if _, found := n.Object.Object[field]; found && !slices.Contains(n.SensitiveFields, field) {
+			n.SensitiveFields = append(n.SensitiveFields, field)
+		}
+	}
+}
+
+// ExtractSensitive removes the node's sensitive fields from its object and
+// returns their values by field path
+func (n *Node) ExtractSensitive() (map[string]interface{}, error) {
+	values := make(map[string]interface{}, len(n.SensitiveFields))
+	for _, path := range n.SensitiveFields {
+		p, err := parseSensitivePath(path)
+		if err != nil {
+			return nil, err
+		}
+		if value, found := p.valueIn(n.Object.Object); found {
+			values[path] = value
+			p.RemoveFrom(n.Object.Object)
+		}
+	}
+	return values, nil
+}
+
+// RestoreSensitive puts values returned by ExtractSensitive back into the
+// node's object
+func (n *Node) RestoreSensitive(values map[string]interface{}) error {
+	for _, path := range n.SensitiveFields {
+		value, found := values[path]
+		if !found {
+			continue
+		}
+		p, err := parseSensitivePath(path)
+		if err != nil {
+			return err
+		}
+		if err := p.setIn(n.Object.Object, value); err != nil {
+			return fmt.Errorf("failed to restore sensitive field %q: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// parseSensitivePath parses a sensitive field path. Only map keys are
+// supported since list positions are not stable across renders.
+func parseSensitivePath(path string) (FieldPath, error) {
+	p, err := ParseFieldPath(path)
+	if err != nil {
+		return FieldPath{}, err
+	}
+	for _, seg := range p.segments {
+		if seg.allItems {
+			return FieldPath{}, fmt.Errorf("sensitive field %q cannot be inside a list", path)
+		}
+	}
+	return p, nil
+}
+
+// valueIn returns the value at a path of map keys
+func (p FieldPath) valueIn(obj map[string]interface{}) (interface{}, bool) {
+	var value interface{} = obj
+	for _, seg := range p.segments {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = m[seg.key]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// setIn sets the value at a path of map keys, creating missing maps
+func (p FieldPath) setIn(obj map[string]interface{}, value interface{}) error {
+	m := obj
+	for _, seg := range p.segments[:len(p.segments)-1] {
+		child, found := m[seg.key]
+		if !found {
+			child = make(map[string]interface{})
+			m[seg.key] = child
+		}
+		next, ok := child.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s is not an object", seg.key)
+		}
+		m = next
+	}
+	m[p.segments[len(p.segments)-1].key] = value
+	return nil
+}
+
+// Redactor replaces sensitive values in text with Redacted. A nil Redactor
+// leaves text unchanged.
+type Redactor struct {
+	values []string
+}
+
+// NewRedactor creates a redactor for the given values
+func NewRedactor(values ...string) *Redactor {
+	r := &Redactor{}
+	for _, v := range values {
+		if len(v) >= minRedactedLength && !slices.Contains(r.values, v) {
+			r.values = append(r.values, v)
+		}
+	}
+	// Replace longer values first so that a value containing another is
+	// redacted as a whole
+	slices.SortFunc(r.values, func(a, b string) int { return len(b) - len(a) })
+	return r
+}
+
+// Redactor returns a redactor for the string values of every sensitive
+// field in the graph. The values must have been restored into the objects.
+func (g *Graph) Redactor() *Redactor {
+	var values []string
+	for i := range g.Nodes {
+		node := &g.Nodes[i]
+		for _, path := range node.SensitiveFields {
+			p, err := parseSensitivePath(path)
+			if err != nil {
+				continue
+			}
+			if value, found := p.valueIn(node.Object.Object); found {
+				values = appendStrings(values, value)
+			}
+		}
+	}
+	return NewRedactor(values...)
+}
+
+// appendStrings appends every string found in value
+func appendStrings(values []string, value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return append(values, v)
+	case map[string]interface{}:
+		for _, item := range v {
+			values = appendStrings(values, item)
+		}
+	case []interface{}:
+		for _, item := range v {
+			values = appendStrings(values, item)
+		}
+	}
+	return values
+}
+
+// Redact replaces every sensitive value in s
+func (r *Redactor) Redact(s string) string {
+	if r == nil {
+		return s
+	}
+	for _, v := range r.values {
+		s = strings.ReplaceAll(s, v, Redacted)
+	}
+	return s
+}
+
+// Error returns err with sensitive values replaced, for logging. The result
+// does not wrap err, so it must not be used for error classification.
+func (r *Redactor) Error(err error) error {
+	if r == nil || err == nil {
+		return err
+	}
+	if msg := r.Redact(err.Error()); msg != err.Error() {
+		return errors.New(msg)
+	}
+	return err
+}
+
+// redactorKey is the context key for the redactor
+type redactorKey struct{}
+
+// WithRedactor returns a context carrying the redactor used for errors and
+// logs while executing a graph with sensitive fields
+func WithRedactor(ctx context.Context, r *Redactor) context.Context {
+	return context.WithValue(ctx, redactorKey{}, r)
+}
+
+// RedactorFrom returns the redactor carried by ctx, or nil
+func RedactorFrom(ctx context.Context) *Redactor {
+	r, _ := ctx.Value(redactorKey{}).(*Redactor)
+	return r
+}
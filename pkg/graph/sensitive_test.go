@@ -0,0 +1,100 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func newSensitiveSecretNode() Node {
+	node := newConfigMapNode("db")
+	node.Object.SetKind("Secret")
+	node.Object.Object["stringData"] = map[string]interface{}{"password": "hunter22"}
+	node.Object.SetAnnotations(map[string]string{"example.com/dsn": "postgres://app:hunter22@db"})
+	node.SensitiveFields = []string{`metadata.annotations["example.com/dsn"]`}
+	return node
+}
+
+func TestNode_ExtractAndRestoreSensitive(t *testing.T) {
+	node := newSensitiveSecretNode()
+	original := node.Object.DeepCopy()
+
+	node.MarkSecretData()
+	if !reflect.DeepEqual(node.SensitiveFields, []string{`metadata.annotations["example.com/dsn"]`, "stringData"}) {
+		t.Fatalf("expected stringData to be marked sensitive, got %v", node.SensitiveFields)
+	}
+
+	values, err := node.ExtractSensitive()
+	if err != nil {
+		t.Fatalf("ExtractSensitive() failed: %v", err)
+	}
+	if len(values) != 2 {
+		t.Fatalf("expected 2 extracted values, got %v", values)
+	}
+	raw, _ := node.Object.MarshalJSON()
+	if strings.Contains(string(raw), "hunter22") {
+		t.Errorf("expected sensitive values to be removed from the object, got %s", raw)
+	}
+
+	if err := node.RestoreSensitive(values); err != nil {
+		t.Fatalf("RestoreSensitive() failed: %v", err)
+	}
+	if !reflect.DeepEqual(node.Object.Object, original.Object) {
+		t.Errorf("expected restored object %v, got %v", original.Object, node.Object.Object)
+	}
+}
+
+func TestNode_Validate_SensitiveFieldInList(t *testing.T) {
+	node := newConfigMapNode("app")
+	node.SensitiveFields = []string{"spec.containers[*].env"}
+
+	err := newHookGraph(node).Validate()
+	if err == nil || !strings.Contains(err.Error(), "inside a list") {
+		t.Fatalf("expected list path to be rejected, got %v", err)
+	}
+}
+
+func TestRedactor(t *testing.T) {
+	g := newHookGraph(newSensitiveSecretNode())
+	g.Nodes[0].MarkSecretData()
+	r := g.Redactor()
+
+	msg := `Secret "db" is invalid: annotations: "postgres://app:hunter22@db", password "hunter22"`
+	want := `Secret "db" is invalid: annotations: "[REDACTED]", password "[REDACTED]"`
+	if got := r.Redact(msg); got != want {
+		t.Errorf("Redact() = %q, want %q", got, want)
+	}
+
+	var nilRedactor *Redactor
+	if got := nilRedactor.Redact(msg); got != msg {
+		t.Errorf("expected nil redactor to leave the message alone, got %q", got)
+	}
+	if got := NewRedactor("abc").Redact("abc"); got != "abc" {
+		t.Errorf("expected short values not to be redacted, got %q", got)
+	}
+}
+
+func TestExecutor_RedactsNodeErrors(t *testing.T) {
+	g := newHookGraph(newConfigMapNode("app"))
+	dag, err := BuildDAG(g)
+	if err != nil {
+		t.Fatalf("BuildDAG() failed: %v", err)
+	}
+
+	applier := newMockApplier()
+	applier.failNodes["app"] = &TerminalError{Reason: "Invalid", Err: errors.New(`invalid value "hunter22"`)}
+	executor := NewExecutor(applier, newMockReadinessChecker(), nil, DefaultExecutorConfig())
+
+	ctx := WithRedactor(context.Background(), NewRedactor("hunter22"))
+	state, err := executor.Execute(ctx, dag)
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+
+	status, _ := state.GetStatus("app")
+	if strings.Contains(status.Error, "hunter22") || !strings.Contains(status.Error, Redacted) {
+		t.Errorf("expected the node error to be redacted, got %q", status.Error)
+	}
+}
@@ -1,7 +1,9 @@
 package graph
 
 import (
+	"errors"
 	"fmt"
+	"slices"
 	"sync"
 	"time"
 )
@@ -34,6 +36,15 @@ type NodeStatus struct {
 	// Error contains the error message if State is NodeStateError
 	Error string
 
+	// Conflicts lists the field manager conflicts behind Error, if any
+	Conflicts []FieldConflict
+
+	// Terminal is true if Error will not succeed on retry
+	Terminal bool
+
+	// Reason is a short CamelCase cause of a terminal Error, e.g. "Forbidden"
+	Reason string
+
 	// StartTime is when the node started applying
 	StartTime *time.Time
 
@@ -59,6 +70,9 @@ type ExecutionState struct {
 
 	// endTime is when execution completed (or failed)
 	endTime *time.Time
+
+	// redactor removes sensitive values from error messages
+	redactor *Redactor
 }
 
 // NewExecutionState creates a new execution state tracker
@@ -145,7 +159,16 @@ func (es *ExecutionState) SetError(nodeID string, err error) error {
 	}
 
 	status.State = NodeStateError
-	status.Error = err.Error()
+	status.Error = es.redactor.Redact(err.Error())
+
+	status.Terminal = IsTerminal(err)
+	status.Reason = terminalReason(err)
+
+	status.Conflicts = nil
+	var conflictErr FieldConflictError
+	if errors.As(err, &conflictErr) {
+		status.Conflicts = conflictErr.FieldConflicts()
+	}
 
 	return nil
 }
@@ -219,6 +242,21 @@ func (es *ExecutionState) HasErrors() bool {
 	return false
 }
 
+// GetTerminalNodes returns the IDs of nodes that failed with a terminal error
+func (es *ExecutionState) GetTerminalNodes() []string {
+	es.mu.RLock()
+	defer es.mu.RUnlock()
+
+	var nodes []string
+	for id, status := range es.nodeStates {
+		if status.State == NodeStateError && status.Terminal {
+			nodes = append(nodes, id)
+		}
+	}
+	slices.Sort(nodes)
+	return nodes
+}
+
 // GetSummary returns a summary of execution state
 func (es *ExecutionState) GetSummary() ExecutionSummary {
 	es.mu.RLock()
@@ -3,6 +3,7 @@ package graph
 import (
 	"encoding/json"
 	"fmt"
+	"slices"
 
 	"github.com/cespare/xxhash/v2"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -33,6 +34,10 @@ type GraphMetadata struct {
 
 	// RenderHash is a hash of the rendered graph for change detection
 	RenderHash string `json:"renderHash,omitempty"`
+
+	// Input is the #Render input the graph was rendered from, as JSON. It is
+	// set by the renderer rather than by modules.
+	Input json.RawMessage `json:"-"`
 }
 
 // Node represents a single resource in the graph
@@ -51,6 +56,22 @@ type Node struct {
 
 	// ReadyWhen defines the conditions for this resource to be considered ready
 	ReadyWhen []ReadinessPredicate `json:"readyWhen,omitempty"`
+
+	// Hook makes the node a lifecycle hook Job instead of a regular resource
+	Hook *Hook `json:"hook,omitempty"`
+
+	// Generate makes the node a Secret whose values are generated once by
+	// the executor instead of rendered
+	Generate *GeneratedSecret `json:"generate,omitempty"`
+
+	// SensitiveFields lists field paths of the object whose values are kept
+	// out of the ResourceGraph spec and redacted from errors and events
+	SensitiveFields []string `json:"sensitiveFields,omitempty"`
+
+	// Instance marks a node whose object is a platform instance of a kind
+	// generated by a Transform. Its readiness is checked without waiting,
+	// since the instance is rolled out by its own ResourceGraph.
+	Instance bool `json:"instance,omitempty"`
 }
 
 // ApplyPolicy defines how a resource should be applied
@@ -66,11 +87,55 @@ type ApplyPolicy struct {
 	// - "Force": Force ownership of conflicting fields
 	ConflictPolicy ConflictPolicy `json:"conflictPolicy,omitempty"`
 
+	// ConflictResolution forces ownership of selected fields or managers
+	// when ConflictPolicy is "Error". It has no effect with "Force".
+	ConflictResolution *ConflictResolution `json:"conflictResolution,omitempty"`
+
 	// FieldManager is the name to use for field management
 	// Defaults to "pequod-operator"
 	FieldManager string `json:"fieldManager,omitempty"`
+
+	// IgnoreFields lists field paths owned by other controllers (e.g. an HPA
+	// managing "spec.replicas"). They are removed from the applied intent and
+	// excluded from drift and diff computation. See ParseFieldPath for syntax.
+	IgnoreFields []string `json:"ignoreFields,omitempty"`
+
+	// UpdateStrategy determines how changes to immutable fields are handled
+	// in Apply mode. Defaults to InPlace.
+	UpdateStrategy *UpdateStrategy `json:"updateStrategy,omitempty"`
 }
 
+// UpdateStrategy defines how a resource is updated when an apply is
+// rejected because it changes an immutable field
+type UpdateStrategy struct {
+	// Type is the strategy type
+	// - "InPlace": Fail the apply (default)
+	// - "Recreate": Delete the resource, wait for it to disappear, create it again
+	Type UpdateStrategyType `json:"type,omitempty"`
+
+	// PropagationPolicy is the deletion propagation used by Recreate
+	// ("Foreground", "Background" or "Orphan"). Defaults to Foreground.
+	PropagationPolicy string `json:"propagationPolicy,omitempty"`
+}
+
+// RecreateOnImmutableChange reports whether the policy replaces resources
+// whose immutable fields changed
+func (ap ApplyPolicy) RecreateOnImmutableChange() bool {
+	return ap.UpdateStrategy != nil && ap.UpdateStrategy.Type == UpdateStrategyRecreate
+}
+
+// UpdateStrategyType defines the update strategy
+type UpdateStrategyType string
+
+const (
+	// UpdateStrategyInPlace updates resources in place only
+	UpdateStrategyInPlace UpdateStrategyType = "InPlace"
+
+	// UpdateStrategyRecreate deletes and recreates resources whose
+	// immutable fields changed
+	UpdateStrategyRecreate UpdateStrategyType = "Recreate"
+)
+
 // ApplyMode defines the apply behavior
 type ApplyMode string
 
@@ -123,6 +188,10 @@ const (
 
 	// PredicateTypeExists checks if the resource exists
 	PredicateTypeExists PredicateType = "Exists"
+
+	// PredicateTypeInstanceReady checks if a platform instance's own
+	// ResourceGraph completed for its current generation
+	PredicateTypeInstanceReady PredicateType = "InstanceReady"
 )
 
 // Violation represents a policy violation
@@ -158,8 +227,18 @@ func (g *Graph) ComputeHash() string {
 		Violations []Violation `json:"violations"`
 	}
 
+	// Ignored fields are owned by other controllers, so changes to them
+	// must not be treated as a change to the graph
+	nodes := make([]Node, len(g.Nodes))
+	for i, node := range g.Nodes {
+		nodes[i] = node
+		if len(node.ApplyPolicy.IgnoreFields) > 0 {
+			nodes[i].Object = *StripFields(&node.Object, node.ApplyPolicy.IgnoreFields)
+		}
+	}
+
 	h := hashableGraph{
-		Nodes:      g.Nodes,
+		Nodes:      nodes,
 		Violations: g.Violations,
 	}
 
@@ -186,3 +265,45 @@ func (g *Graph) HasChanged(previousHash string) bool {
 	}
 	return g.ComputeHash() != previousHash
 }
+
+// DeepCopy returns a copy of the graph that shares no state with it
+func (g *Graph) DeepCopy() *Graph {
+	out := &Graph{
+		Metadata:   g.Metadata,
+		Nodes:      make([]Node, len(g.Nodes)),
+		Violations: slices.Clone(g.Violations),
+	}
+	out.Metadata.Input = slices.Clone(g.Metadata.Input)
+	for i := range g.Nodes {
+		out.Nodes[i] = g.Nodes[i].DeepCopy()
+	}
+	return out
+}
+
+// DeepCopy returns a copy of the node that shares no state with it
+func (n *Node) DeepCopy() Node {
+	out := *n
+	out.Object = *n.Object.DeepCopy()
+	out.DependsOn = slices.Clone(n.DependsOn)
+	out.ReadyWhen = slices.Clone(n.ReadyWhen)
+	out.SensitiveFields = slices.Clone(n.SensitiveFields)
+	out.ApplyPolicy.IgnoreFields = slices.Clone(n.ApplyPolicy.IgnoreFields)
+	if cr := n.ApplyPolicy.ConflictResolution; cr != nil {
+		out.ApplyPolicy.ConflictResolution = &ConflictResolution{
+			ForceFields:   slices.Clone(cr.ForceFields),
+			ForceManagers: slices.Clone(cr.ForceManagers),
+		}
+	}
+	if us := n.ApplyPolicy.UpdateStrategy; us != nil {
+		strategy := *us
+		out.ApplyPolicy.UpdateStrategy = &strategy
+	}
+	if n.Hook != nil {
+		hook := *n.Hook
+		out.Hook = &hook
+	}
+	if n.Generate != nil {
+		out.Generate = &GeneratedSecret{Keys: slices.Clone(n.Generate.Keys)}
+	}
+	return out
+}
@@ -2,6 +2,8 @@ package graph
 
 import (
 	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // Validate checks the integrity of the Graph
@@ -43,7 +45,7 @@ func (g *Graph) Validate() error {
 		}
 	}
 
-	return nil
+	return g.validateHookDependencies()
 }
 
 // Validate checks the integrity of a Node
@@ -84,6 +86,30 @@ func (n *Node) Validate(allNodeIDs map[string]bool) error {
 		}
 	}
 
+	// Validate lifecycle hook
+	if n.Hook != nil {
+		if err := n.Hook.Validate(&n.Object); err != nil {
+			return fmt.Errorf("hook: %w", err)
+		}
+	}
+
+	// Validate sensitive fields
+	for _, path := range n.SensitiveFields {
+		if _, err := parseSensitivePath(path); err != nil {
+			return fmt.Errorf("sensitiveFields: %w", err)
+		}
+	}
+
+	// Validate generated secret
+	if n.Generate != nil {
+		if n.Hook != nil {
+			return fmt.Errorf("a node cannot be both a hook and a generated Secret")
+		}
+		if err := n.Generate.Validate(&n.Object); err != nil {
+			return fmt.Errorf("generate: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -102,6 +128,15 @@ func (ap *ApplyPolicy) Validate() error {
 		ap.FieldManager = "pequod-operator"
 	}
 
+	if us := ap.UpdateStrategy; us != nil {
+		if us.Type == "" {
+			us.Type = UpdateStrategyInPlace
+		}
+		if us.Type == UpdateStrategyRecreate && us.PropagationPolicy == "" {
+			us.PropagationPolicy = string(metav1.DeletePropagationForeground)
+		}
+	}
+
 	// Validate mode
 	switch ap.Mode {
 	case ApplyModeApply, ApplyModeCreate, ApplyModeAdopt:
@@ -118,6 +153,35 @@ func (ap *ApplyPolicy) Validate() error {
 		return fmt.Errorf("invalid conflict policy: %s", ap.ConflictPolicy)
 	}
 
+	// Validate update strategy
+	if us := ap.UpdateStrategy; us != nil {
+		switch us.Type {
+		case UpdateStrategyInPlace, UpdateStrategyRecreate:
+			// Valid
+		default:
+			return fmt.Errorf("invalid update strategy: %s", us.Type)
+		}
+
+		switch metav1.DeletionPropagation(us.PropagationPolicy) {
+		case "", metav1.DeletePropagationForeground, metav1.DeletePropagationBackground, metav1.DeletePropagationOrphan:
+			// Valid
+		default:
+			return fmt.Errorf("invalid propagation policy: %s", us.PropagationPolicy)
+		}
+	}
+
+	// Validate selective conflict resolution
+	if ap.ConflictResolution != nil {
+		if err := ap.ConflictResolution.Validate(); err != nil {
+			return fmt.Errorf("conflictResolution: %w", err)
+		}
+	}
+
+	// Validate ignored field paths
+	if err := ValidateFieldPaths(ap.IgnoreFields); err != nil {
+		return fmt.Errorf("ignoreFields: %w", err)
+	}
+
 	return nil
 }
 
@@ -132,7 +196,7 @@ func (rp *ReadinessPredicate) Validate() error {
 		if rp.ConditionStatus == "" {
 			return fmt.Errorf("conditionStatus is required for ConditionMatch predicate")
 		}
-	case PredicateTypeDeploymentAvailable, PredicateTypeExists:
+	case PredicateTypeDeploymentAvailable, PredicateTypeExists, PredicateTypeInstanceReady:
 		// No additional validation needed
 	default:
 		return fmt.Errorf("invalid predicate type: %s", rp.Type)
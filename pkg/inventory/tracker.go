@@ -9,6 +9,8 @@ import (
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/chazu/pequod/pkg/graph"
 )
 
 // InventoryItem represents a tracked resource
@@ -30,6 +32,10 @@ type InventoryItem struct {
 
 	// Status tracks the current state of the resource
 	Status ItemStatus `json:"status"`
+
+	// IgnoreFields lists field paths excluded from the hash because other
+	// controllers own them
+	IgnoreFields []string `json:"ignoreFields,omitempty"`
 }
 
 // ItemStatus represents the status of an inventory item
@@ -94,17 +100,18 @@ func NewTrackerFromInventory(inv *Inventory) *Tracker {
 }
 
 // RecordApplied records that a resource was successfully applied
-func (t *Tracker) RecordApplied(id string, obj *unstructured.Unstructured) InventoryItem {
+func (t *Tracker) RecordApplied(id string, obj *unstructured.Unstructured, ignoreFields ...string) InventoryItem {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
 	item := InventoryItem{
-		ID:        id,
-		GVK:       obj.GroupVersionKind(),
-		Namespace: obj.GetNamespace(),
-		Name:      obj.GetName(),
-		Hash:      ComputeHash(obj),
-		Status:    ItemStatusApplied,
+		ID:           id,
+		GVK:          obj.GroupVersionKind(),
+		Namespace:    obj.GetNamespace(),
+		Name:         obj.GetName(),
+		Hash:         ComputeHash(obj, ignoreFields...),
+		Status:       ItemStatusApplied,
+		IgnoreFields: ignoreFields,
 	}
 
 	t.inventory.Items[id] = item
@@ -114,17 +121,18 @@ func (t *Tracker) RecordApplied(id string, obj *unstructured.Unstructured) Inven
 }
 
 // RecordAdopted records that a resource was adopted
-func (t *Tracker) RecordAdopted(id string, obj *unstructured.Unstructured) InventoryItem {
+func (t *Tracker) RecordAdopted(id string, obj *unstructured.Unstructured, ignoreFields ...string) InventoryItem {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
 	item := InventoryItem{
-		ID:        id,
-		GVK:       obj.GroupVersionKind(),
-		Namespace: obj.GetNamespace(),
-		Name:      obj.GetName(),
-		Hash:      ComputeHash(obj),
-		Status:    ItemStatusAdopted,
+		ID:           id,
+		GVK:          obj.GroupVersionKind(),
+		Namespace:    obj.GetNamespace(),
+		Name:         obj.GetName(),
+		Hash:         ComputeHash(obj, ignoreFields...),
+		Status:       ItemStatusAdopted,
+		IgnoreFields: ignoreFields,
 	}
 
 	t.inventory.Items[id] = item
@@ -134,17 +142,18 @@ func (t *Tracker) RecordAdopted(id string, obj *unstructured.Unstructured) Inven
 }
 
 // RecordFailed records that a resource failed to apply
-func (t *Tracker) RecordFailed(id string, obj *unstructured.Unstructured) InventoryItem {
+func (t *Tracker) RecordFailed(id string, obj *unstructured.Unstructured, ignoreFields ...string) InventoryItem {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
 	item := InventoryItem{
-		ID:        id,
-		GVK:       obj.GroupVersionKind(),
-		Namespace: obj.GetNamespace(),
-		Name:      obj.GetName(),
-		Hash:      ComputeHash(obj),
-		Status:    ItemStatusFailed,
+		ID:           id,
+		GVK:          obj.GroupVersionKind(),
+		Namespace:    obj.GetNamespace(),
+		Name:         obj.GetName(),
+		Hash:         ComputeHash(obj, ignoreFields...),
+		Status:       ItemStatusFailed,
+		IgnoreFields: ignoreFields,
 	}
 
 	t.inventory.Items[id] = item
@@ -278,7 +287,7 @@ func (t *Tracker) HasDrift(id string, currentObj *unstructured.Unstructured) boo
 		return false // Not tracked, can't detect drift
 	}
 
-	currentHash := ComputeHash(currentObj)
+	currentHash := ComputeHash(currentObj, item.IgnoreFields...)
 	return item.Hash != currentHash
 }
 
@@ -318,15 +327,16 @@ func (t *Tracker) Clear() {
 }
 
 // ComputeHash computes a content hash for an unstructured object
-// This is used for drift detection
-func ComputeHash(obj *unstructured.Unstructured) string {
+// This is used for drift detection. Any ignoreFields paths are excluded
+// so that changes made by other controllers are not reported as drift.
+func ComputeHash(obj *unstructured.Unstructured, ignoreFields ...string) string {
 	if obj == nil {
 		return ""
 	}
 
 	// Create a copy and remove fields that change frequently
 	// (resourceVersion, generation, managedFields, etc.)
-	objCopy := obj.DeepCopy()
+	objCopy := graph.StripFields(obj, ignoreFields)
 	unstructured.RemoveNestedField(objCopy.Object, "metadata", "resourceVersion")
 	unstructured.RemoveNestedField(objCopy.Object, "metadata", "generation")
 	unstructured.RemoveNestedField(objCopy.Object, "metadata", "uid")
@@ -224,6 +224,33 @@ func TestTracker_HasDrift(t *testing.T) {
 	}
 }
 
+func TestTracker_HasDrift_IgnoresExcludedFields(t *testing.T) {
+	tracker := NewTracker()
+
+	gvk := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+	obj := createTestObject("my-deployment", "default", gvk)
+	obj.Object["spec"] = map[string]interface{}{
+		"replicas": int64(3),
+		"paused":   false,
+	}
+
+	tracker.RecordApplied("node-1", obj, "spec.replicas")
+
+	// Replicas changed by another controller (e.g. an HPA) - no drift
+	scaled := obj.DeepCopy()
+	scaled.Object["spec"].(map[string]interface{})["replicas"] = int64(7)
+	if tracker.HasDrift("node-1", scaled) {
+		t.Error("Should not detect drift for ignored field")
+	}
+
+	// Any other change is still drift
+	paused := obj.DeepCopy()
+	paused.Object["spec"].(map[string]interface{})["paused"] = true
+	if !tracker.HasDrift("node-1", paused) {
+		t.Error("Should detect drift for non-ignored field")
+	}
+}
+
 func TestTracker_Serialization(t *testing.T) {
 	tracker := NewTracker()
 
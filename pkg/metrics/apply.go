@@ -34,6 +34,11 @@ var (
 		Buckets: prometheus.ExponentialBuckets(0.001, 2, 12), // 1ms to ~4s
 	}, []string{"mode", "gvk"})
 
+	applyErrorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "pequod_apply_errors_total",
+		Help: "Total number of failed resource apply operations by error class",
+	}, []string{"class", "reason", "mode", "gvk"})
+
 	resourcesManaged = prometheus.NewGaugeVec(prometheus.GaugeOpts{
 		Name: "pequod_resources_managed",
 		Help: "Number of resources currently managed by pequod",
@@ -45,6 +50,7 @@ func init() {
 	metrics.Registry.MustRegister(
 		applyTotal,
 		applyDuration,
+		applyErrorsTotal,
 		resourcesManaged,
 	)
 }
@@ -58,6 +64,13 @@ func RecordApply(result, mode, gvk string, durationSeconds float64) {
 	applyDuration.WithLabelValues(mode, gvk).Observe(durationSeconds)
 }
 
+// RecordApplyError records a failed apply operation
+// class: "Transient" or "Terminal"
+// reason: short cause of the failure (e.g., "Forbidden", "Timeout")
+func RecordApplyError(class, reason, mode, gvk string) {
+	applyErrorsTotal.WithLabelValues(class, reason, mode, gvk).Inc()
+}
+
 // SetManagedResources sets the gauge for managed resources
 func SetManagedResources(gvk, namespace string, count int) {
 	resourcesManaged.WithLabelValues(gvk, namespace).Set(float64(count))
@@ -0,0 +1,85 @@
+package platformloader
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// ReasonRenderTimeout reports a compile or render that ran past its deadline
+	ReasonRenderTimeout = "RenderTimeout"
+
+	// ReasonRenderTooLarge reports a compile or render that allocated too
+	// much memory or produced too large a graph
+	ReasonRenderTooLarge = "RenderTooLarge"
+)
+
+const (
+	// DefaultRenderTimeout is the default deadline for one compile or render
+	DefaultRenderTimeout = 30 * time.Second
+
+	// DefaultRenderMemoryBytes is the default memory of a render worker
+	DefaultRenderMemoryBytes = 1 << 30 // 1GiB
+
+	// DefaultRenderOutputBytes is the default size limit of a rendered
+	// graph, as JSON
+	DefaultRenderOutputBytes = 8 << 20 // 8MiB
+)
+
+// RenderBudget limits the resources a single compile or render may use.
+// A zero limit disables that check. Timeout and MaxMemoryBytes are enforced
+// by render workers only, see WorkerConfig; CUE evaluation cannot be
+// interrupted, so a render in the manager process runs to completion.
+type RenderBudget struct {
+	// Timeout is the longest a compile or render may run once a render
+	// worker picked it up. The worker is killed when it runs longer.
+	Timeout time.Duration
+
+	// MaxMemoryBytes is the memory a render worker may use, enforced with
+	// an rlimit. A worker running out of memory exits and is replaced.
+	MaxMemoryBytes uint64
+
+	// MaxOutputBytes is the size limit of a rendered graph, as JSON
+	MaxOutputBytes int
+}
+
+// DefaultRenderBudget returns the budget used unless one is configured
+func DefaultRenderBudget() RenderBudget {
+	return RenderBudget{
+		Timeout:        DefaultRenderTimeout,
+		MaxMemoryBytes: DefaultRenderMemoryBytes,
+		MaxOutputBytes: DefaultRenderOutputBytes,
+	}
+}
+
+// BudgetError reports a compile or render that exceeded its budget
+type BudgetError struct {
+	// Reason is ReasonRenderTimeout or ReasonRenderTooLarge
+	Reason string
+
+	// Message describes the limit that was exceeded
+	Message string
+}
+
+func (e *BudgetError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Reason, e.Message)
+}
+
+// AsBudgetError returns the BudgetError in err's chain, if any
+func AsBudgetError(err error) (*BudgetError, bool) {
+	var budgetErr *BudgetError
+	ok := errors.As(err, &budgetErr)
+	return budgetErr, ok
+}
+
+// checkOutput fails if a rendered graph is larger than the budget allows
+func (b RenderBudget) checkOutput(size int) error {
+	if b.MaxOutputBytes > 0 && size > b.MaxOutputBytes {
+		return &BudgetError{
+			Reason:  ReasonRenderTooLarge,
+			Message: fmt.Sprintf("rendered graph is %d bytes, over the limit of %d", size, b.MaxOutputBytes),
+		}
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package platformloader
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestRenderTransformWithCueRef_OutputTooLarge(t *testing.T) {
+	loader := createTestLoader()
+	loader.budget = RenderBudget{MaxOutputBytes: 64}
+	renderer := NewRenderer(loader)
+
+	_, _, err := renderer.RenderTransformWithCueRef(context.Background(), "test-app", "default",
+		runtime.RawExtension{Raw: []byte(`{"image":"nginx:latest","port":80}`)},
+		CueRefInput{Type: "embedded", Ref: "webservice"})
+	budgetErr, ok := AsBudgetError(err)
+	if !ok || budgetErr.Reason != ReasonRenderTooLarge {
+		t.Fatalf("expected a %s error, got %v", ReasonRenderTooLarge, err)
+	}
+}
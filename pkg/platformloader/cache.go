@@ -1,62 +1,119 @@
 package platformloader
 
 import (
+	"container/list"
 	"sync"
 
 	"cuelang.org/go/cue"
 )
 
-// Cache provides thread-safe caching of CUE values
+// DefaultCacheEntries is the number of compiled modules kept in memory
+const DefaultCacheEntries = 64
+
+// Cache provides thread-safe caching of CUE values. When full, the least
+// recently used value is evicted.
 type Cache struct {
-	mu    sync.RWMutex
-	items map[string]cue.Value
+	*lruCache[cue.Value]
 }
 
-// NewCache creates a new cache instance
+// NewCache creates a new cache instance holding DefaultCacheEntries values
 func NewCache() *Cache {
-	return &Cache{
-		items: make(map[string]cue.Value),
+	return NewCacheWithLimit(DefaultCacheEntries)
+}
+
+// NewCacheWithLimit creates a cache holding at most maxEntries values.
+// A limit of zero or less means the cache is unbounded.
+func NewCacheWithLimit(maxEntries int) *Cache {
+	return &Cache{newLRUCache[cue.Value](maxEntries, RecordCacheEviction)}
+}
+
+// lruCache is a thread-safe, size-bounded map evicting the least recently
+// used entry first
+type lruCache[V any] struct {
+	mu         sync.Mutex
+	maxEntries int
+	order      *list.List
+	items      map[string]*list.Element
+	onEvict    func()
+}
+
+// lruEntry is an element of lruCache.order
+type lruEntry[V any] struct {
+	key   string
+	value V
+}
+
+func newLRUCache[V any](maxEntries int, onEvict func()) *lruCache[V] {
+	return &lruCache[V]{
+		maxEntries: maxEntries,
+		order:      list.New(),
+		items:      make(map[string]*list.Element),
+		onEvict:    onEvict,
 	}
 }
 
 // Get retrieves a value from the cache
 // Returns the value and true if found, zero value and false otherwise
-func (c *Cache) Get(key string) (cue.Value, bool) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+func (c *lruCache[V]) Get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	value, found := c.items[key]
-	return value, found
+	elem, found := c.items[key]
+	if !found {
+		var zero V
+		return zero, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*lruEntry[V]).value, true
 }
 
-// Set stores a value in the cache
-func (c *Cache) Set(key string, value cue.Value) {
+// Set stores a value in the cache, evicting the least recently used value
+// if the cache is full
+func (c *lruCache[V]) Set(key string, value V) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	c.items[key] = value
+	if elem, found := c.items[key]; found {
+		elem.Value.(*lruEntry[V]).value = value
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.items[key] = c.order.PushFront(&lruEntry[V]{key: key, value: value})
+
+	for c.maxEntries > 0 && c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*lruEntry[V]).key)
+		if c.onEvict != nil {
+			c.onEvict()
+		}
+	}
 }
 
 // Delete removes a value from the cache
-func (c *Cache) Delete(key string) {
+func (c *lruCache[V]) Delete(key string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	delete(c.items, key)
+	if elem, found := c.items[key]; found {
+		c.order.Remove(elem)
+		delete(c.items, key)
+	}
 }
 
 // Clear removes all values from the cache
-func (c *Cache) Clear() {
+func (c *lruCache[V]) Clear() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	c.items = make(map[string]cue.Value)
+	c.order.Init()
+	c.items = make(map[string]*list.Element)
 }
 
 // Size returns the number of items in the cache
-func (c *Cache) Size() int {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+func (c *lruCache[V]) Size() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	return len(c.items)
+	return c.order.Len()
 }
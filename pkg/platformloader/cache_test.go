@@ -124,3 +124,29 @@ func TestCacheConcurrency(t *testing.T) {
 
 	wg.Wait()
 }
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := NewCacheWithLimit(2)
+	ctx := cuecontext.New()
+
+	cache.Set("key1", ctx.CompileString(`x: 1`))
+	cache.Set("key2", ctx.CompileString(`x: 2`))
+
+	// Reading key1 makes key2 the least recently used
+	if _, found := cache.Get("key1"); !found {
+		t.Fatal("expected to find key1")
+	}
+	cache.Set("key3", ctx.CompileString(`x: 3`))
+
+	if cache.Size() != 2 {
+		t.Errorf("expected cache size 2, got %d", cache.Size())
+	}
+	if _, found := cache.Get("key2"); found {
+		t.Error("expected key2 to be evicted")
+	}
+	for _, key := range []string{"key1", "key3"} {
+		if _, found := cache.Get(key); !found {
+			t.Errorf("expected to find %s", key)
+		}
+	}
+}
@@ -13,6 +13,12 @@ import (
 const (
 	// CUEContentKey is the default key for CUE content in a ConfigMap
 	CUEContentKey = "module.cue"
+
+	// ModuleDigestAnnotation on a ConfigMap is the digest of the module it
+	// holds, reported instead of one derived from the ConfigMap's version.
+	// Transforms keep the previous inline modules they render in such
+	// ConfigMaps, so that they keep their inline digest.
+	ModuleDigestAnnotation = "pequod.io/module-digest"
 )
 
 // ConfigMapFetcher fetches CUE modules from Kubernetes ConfigMaps
@@ -59,6 +65,9 @@ func (f *ConfigMapFetcher) Fetch(ctx context.Context, ref string, _ *corev1.Secr
 
 	// Use resourceVersion as the digest for change detection
 	digest := string(cm.UID) + ":" + cm.ResourceVersion
+	if declared := cm.Annotations[ModuleDigestAnnotation]; declared != "" {
+		digest = declared
+	}
 
 	return &FetchResult{
 		Content: content,
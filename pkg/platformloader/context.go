@@ -0,0 +1,292 @@
+package platformloader
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"cuelang.org/go/cue"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	inputschema "github.com/chazu/pequod/pkg/schema"
+)
+
+const (
+	// ContextField is the field of #Render.input holding the RenderContext.
+	// It is only filled for modules whose input accepts it.
+	ContextField = "context"
+
+	// ConfigField is the field of #Render.input holding the PlatformConfig
+	// values selected for the instance. It is only filled for modules whose
+	// input accepts it.
+	ConfigField = "config"
+
+	// LookupsDefinition is the module definition declaring the existing
+	// objects a module reads at render time
+	LookupsDefinition = "#Lookups"
+)
+
+// DefaultLookupKinds are the kinds modules may look up unless the operator
+// configures others
+var DefaultLookupKinds = []string{"ConfigMap"}
+
+// RenderContext is the cluster state passed to a module as input.context
+type RenderContext struct {
+	// Instance is the metadata of the instance being rendered
+	Instance ObjectContext `json:"instance"`
+
+	// Namespace is the metadata of the instance's namespace
+	Namespace ObjectContext `json:"namespace"`
+
+	// Cluster holds the facts configured for the operator, e.g. the
+	// cluster name or region
+	Cluster map[string]string `json:"cluster"`
+
+	// Lookups holds the objects declared in #Lookups by name. An optional
+	// lookup whose object does not exist is null.
+	Lookups map[string]interface{} `json:"lookups"`
+}
+
+// ObjectContext is the metadata of an object visible to a module
+type ObjectContext struct {
+	Name        string            `json:"name"`
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+// newObjectContext returns the context of an object, with empty rather than
+// missing maps so modules can index them without guards
+func newObjectContext(obj metav1.Object) ObjectContext {
+	oc := ObjectContext{Name: obj.GetName(), Labels: obj.GetLabels(), Annotations: obj.GetAnnotations()}
+	if oc.Labels == nil {
+		oc.Labels = map[string]string{}
+	}
+	if oc.Annotations == nil {
+		oc.Annotations = map[string]string{}
+	}
+	return oc
+}
+
+// Lookup is an existing object a module reads at render time. Lookups are
+// declared in the module's #Lookups definition by name, e.g.
+//
+//	#Lookups: settings: {apiVersion: "v1", kind: "ConfigMap", name: "env-settings"}
+type Lookup struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+
+	// Namespace of a namespaced object. Defaults to the instance's namespace.
+	Namespace string `json:"namespace,omitempty"`
+
+	// Optional lookups of missing objects resolve to null instead of
+	// failing the render
+	Optional bool `json:"optional,omitempty"`
+}
+
+// moduleInfo is what the renderer needs to know about a compiled module
+// before filling #Render
+type moduleInfo struct {
+	// acceptsContext is true if #Render.input has a context field
+	acceptsContext bool
+
+	// acceptsConfig is true if #Render.input has a config field
+	acceptsConfig bool
+
+	// acceptsRefs is true if #Render.input has a refs field
+	acceptsRefs bool
+
+	// instanceRefs are the module's @instanceRef fields
+	instanceRefs []inputschema.InstanceRefField
+
+	// lookups are the module's #Lookups by name
+	lookups map[string]Lookup
+}
+
+// moduleInfoJSON is the encoding of moduleInfo
+type moduleInfoJSON struct {
+	AcceptsContext bool                           `json:"acceptsContext,omitempty"`
+	AcceptsConfig  bool                           `json:"acceptsConfig,omitempty"`
+	AcceptsRefs    bool                           `json:"acceptsRefs,omitempty"`
+	InstanceRefs   []inputschema.InstanceRefField `json:"instanceRefs,omitempty"`
+	Lookups        map[string]Lookup              `json:"lookups,omitempty"`
+}
+
+// MarshalJSON encodes a moduleInfo read by a render worker
+func (info *moduleInfo) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moduleInfoJSON{
+		AcceptsContext: info.acceptsContext,
+		AcceptsConfig:  info.acceptsConfig,
+		AcceptsRefs:    info.acceptsRefs,
+		InstanceRefs:   info.instanceRefs,
+		Lookups:        info.lookups,
+	})
+}
+
+// UnmarshalJSON decodes a moduleInfo read by a render worker
+func (info *moduleInfo) UnmarshalJSON(data []byte) error {
+	var decoded moduleInfoJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*info = moduleInfo{
+		acceptsContext: decoded.AcceptsContext,
+		acceptsConfig:  decoded.AcceptsConfig,
+		acceptsRefs:    decoded.AcceptsRefs,
+		instanceRefs:   decoded.InstanceRefs,
+		lookups:        decoded.Lookups,
+	}
+	return nil
+}
+
+// runInspect is the inspect evaluation task
+func runInspect(v cue.Value, _ *evalRequest) (interface{}, error) {
+	return inspectModule(v)
+}
+
+// inspectModule reads the module's #Lookups and @instanceRef fields and
+// whether it accepts a context and config
+func inspectModule(v cue.Value) (*moduleInfo, error) {
+	info := &moduleInfo{}
+	if err := inspectInstanceRefs(v, info); err != nil {
+		return nil, err
+	}
+
+	input := v.LookupPath(cue.ParsePath("#Render.input"))
+	info.acceptsContext = input.Exists() && input.Allows(cue.Str(ContextField))
+	info.acceptsConfig = input.Exists() && input.Allows(cue.Str(ConfigField))
+
+	lookups := v.LookupPath(cue.ParsePath(LookupsDefinition))
+	if !lookups.Exists() {
+		return info, nil
+	}
+	if err := lookups.Decode(&info.lookups); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", LookupsDefinition, err)
+	}
+	if len(info.lookups) > 0 && !info.acceptsContext {
+		return nil, fmt.Errorf("module declares %s but #Render.input has no %s field", LookupsDefinition, ContextField)
+	}
+	for name, lookup := range info.lookups {
+		if lookup.APIVersion == "" || lookup.Kind == "" || lookup.Name == "" {
+			return nil, fmt.Errorf("lookup %q must set apiVersion, kind and name", name)
+		}
+		// Secret values would end up in rendered objects and ResourceGraphs
+		if lookup.APIVersion == "v1" && lookup.Kind == "Secret" {
+			return nil, fmt.Errorf("lookup %q: Secrets cannot be looked up; reference them from rendered objects instead", name)
+		}
+	}
+	return info, nil
+}
+
+// renderContext builds the context for rendering instance, resolving the
+// module's lookups
+func (r *Renderer) renderContext(ctx context.Context, instance metav1.Object, info *moduleInfo) (*RenderContext, error) {
+	rc := &RenderContext{
+		Instance:  newObjectContext(instance),
+		Namespace: ObjectContext{Name: instance.GetNamespace(), Labels: map[string]string{}, Annotations: map[string]string{}},
+		Cluster:   r.loader.clusterFacts,
+		Lookups:   map[string]interface{}{},
+	}
+	if rc.Cluster == nil {
+		rc.Cluster = map[string]string{}
+	}
+
+	reader := r.loader.reader
+	if reader == nil {
+		if len(info.lookups) > 0 {
+			return nil, fmt.Errorf("%s require a Kubernetes client", LookupsDefinition)
+		}
+		return rc, nil
+	}
+
+	if instance.GetNamespace() != "" {
+		ns := &corev1.Namespace{}
+		if err := reader.Get(ctx, client.ObjectKey{Name: instance.GetNamespace()}, ns); err != nil {
+			return nil, fmt.Errorf("failed to get namespace %s: %w", instance.GetNamespace(), err)
+		}
+		rc.Namespace = newObjectContext(ns)
+	}
+
+	// Resolve in name order so errors are stable
+	names := make([]string, 0, len(info.lookups))
+	for name := range info.lookups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		obj, err := r.resolveLookup(ctx, info.lookups[name], instance.GetNamespace())
+		if err != nil {
+			return nil, fmt.Errorf("lookup %q: %w", name, err)
+		}
+		rc.Lookups[name] = obj
+	}
+	return rc, nil
+}
+
+// resolveLookup reads the object of a lookup, or returns nil for a missing
+// optional object. The operator reads lookups with its own permissions, so
+// modules may only read the kinds the operator allows, in the instance's
+// namespace and in the operator's lookup namespaces. A module cannot read
+// other tenants' objects, nor kinds such as Secrets that tenants may not be
+// allowed to read in their own namespace.
+func (r *Renderer) resolveLookup(ctx context.Context, lookup Lookup, instanceNamespace string) (interface{}, error) {
+	gvk := schema.FromAPIVersionAndKind(lookup.APIVersion, lookup.Kind)
+	if !r.loader.lookupKinds[gvk.GroupKind()] {
+		return nil, fmt.Errorf("%s is not a lookup kind", gvk.GroupKind())
+	}
+	mapping, err := r.loader.client.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", gvk, err)
+	}
+	if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+		return nil, fmt.Errorf("%s are cluster-scoped; only namespaced objects can be looked up", mapping.Resource.GroupResource())
+	}
+
+	namespace := lookup.Namespace
+	if namespace == "" {
+		namespace = instanceNamespace
+	}
+	if namespace != instanceNamespace && !r.loader.lookupNamespaces[namespace] {
+		return nil, fmt.Errorf("%s %s is outside the instance's namespace and namespace %s is not a lookup namespace",
+			mapping.Resource.GroupResource(), lookupKey(namespace, lookup.Name), namespace)
+	}
+
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	if err := r.loader.reader.Get(ctx, client.ObjectKey{Namespace: namespace, Name: lookup.Name}, obj); err != nil {
+		if apierrors.IsNotFound(err) && lookup.Optional {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get %s %s: %w", lookup.Kind, lookupKey(namespace, lookup.Name), err)
+	}
+
+	// Server-managed metadata only churns the render cache
+	unstructured.RemoveNestedField(obj.Object, "metadata", "managedFields")
+	unstructured.RemoveNestedField(obj.Object, "metadata", "resourceVersion")
+	unstructured.RemoveNestedField(obj.Object, "metadata", "generation")
+	return obj.Object, nil
+}
+
+// lookupKindSet parses Kind.group strings into a set of GroupKinds
+func lookupKindSet(kinds []string) map[schema.GroupKind]bool {
+	set := make(map[schema.GroupKind]bool, len(kinds))
+	for _, kind := range kinds {
+		set[schema.ParseGroupKind(kind)] = true
+	}
+	return set
+}
+
+// lookupKey formats an object key for messages
+func lookupKey(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + "/" + name
+}
@@ -0,0 +1,318 @@
+package platformloader
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const contextModule = `
+#Lookups: {
+	settings: {apiVersion: "v1", kind: "ConfigMap", name: "env-settings", namespace: "platform"}
+	overrides: {apiVersion: "v1", kind: "ConfigMap", name: "overrides", optional: true}
+}
+
+#Render: {
+	input: {
+		metadata: {name: string, namespace: string}
+		spec: {...}
+		context: {
+			instance: {name: string, labels: [string]: string, annotations: [string]: string}
+			namespace: {name: string, labels: [string]: string, annotations: [string]: string}
+			cluster: [string]: string
+			lookups: settings: {data: tier: string, ...}
+			lookups: overrides: null | {...}
+		}
+	}
+	output: {
+		metadata: {name: input.metadata.name, version: "v1alpha1"}
+		nodes: [{
+			id: "config"
+			object: {
+				apiVersion: "v1"
+				kind:       "ConfigMap"
+				metadata: {name: input.metadata.name, namespace: input.metadata.namespace}
+				data: {
+					team:        input.context.instance.labels.team
+					environment: input.context.namespace.labels.environment
+					cluster:     input.context.cluster.name
+					tier:        input.context.lookups.settings.data.tier
+					overridden:  "\(input.context.lookups.overrides != null)"
+				}
+			}
+			applyPolicy: {mode: "Apply"}
+		}]
+		violations: []
+	}
+}
+`
+
+// newLookupClient returns a client with the objects modules look up
+func newLookupClient() client.Client {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}, meta.RESTScopeRoot)
+
+	return fake.NewClientBuilder().
+		WithRESTMapper(mapper).
+		WithObjects(
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default", Labels: map[string]string{"environment": "staging"}}},
+			&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "env-settings", Namespace: "platform"},
+				Data:       map[string]string{"tier": "gold"},
+			},
+		).Build()
+}
+
+func newContextRenderer(c client.Client) *Renderer {
+	loader := createTestLoader()
+	loader.client = c
+	loader.reader = c
+	loader.clusterFacts = map[string]string{"name": "prod-eu-1"}
+	loader.lookupNamespaces = map[string]bool{"platform": true}
+	// Namespaces are allowed so that cluster-scoped lookups reach the scope check
+	loader.lookupKinds = lookupKindSet([]string{"ConfigMap", "Namespace"})
+	return NewRenderer(loader)
+}
+
+func TestRenderInstance_ContextAndLookups(t *testing.T) {
+	renderer := newContextRenderer(newLookupClient())
+	instance := &metav1.ObjectMeta{Name: "app", Namespace: "default", Labels: map[string]string{"team": "payments"}}
+
+	g, _, err := renderer.RenderInstance(context.Background(), instance, runtime.RawExtension{}, CueRefInput{Type: InlineType, Ref: contextModule}, RenderOptions{})
+	if err != nil {
+		t.Fatalf("failed to render: %v", err)
+	}
+
+	data, _, _ := unstructured.NestedStringMap(g.Nodes[0].Object.Object, "data")
+	want := map[string]string{"team": "payments", "environment": "staging", "cluster": "prod-eu-1", "tier": "gold", "overridden": "false"}
+	for key, value := range want {
+		if data[key] != value {
+			t.Errorf("expected data.%s = %q, got %q", key, value, data[key])
+		}
+	}
+}
+
+func TestRenderInstance_LookupErrors(t *testing.T) {
+	instance := &metav1.ObjectMeta{Name: "app", Namespace: "default", Labels: map[string]string{"team": "payments"}}
+
+	tests := []struct {
+		name    string
+		client  client.Client
+		module  string
+		wantErr string
+	}{
+		{
+			name:    "other tenant's namespace",
+			client:  newLookupClient(),
+			module:  strings.Replace(contextModule, `namespace: "platform"`, `namespace: "tenant-b"`, 1),
+			wantErr: "configmaps tenant-b/env-settings is outside the instance's namespace",
+		},
+		{
+			name:    "cluster-scoped object",
+			client:  newLookupClient(),
+			module:  strings.Replace(contextModule, `kind: "ConfigMap", name: "env-settings", namespace: "platform"`, `kind: "Namespace", name: "platform"`, 1),
+			wantErr: "namespaces are cluster-scoped",
+		},
+		{
+			name:    "missing object",
+			client:  newLookupClient(),
+			module:  strings.Replace(contextModule, `name: "env-settings"`, `name: "missing"`, 1),
+			wantErr: `lookup "settings": failed to get ConfigMap platform/missing`,
+		},
+		{
+			name:    "kind the operator does not allow",
+			client:  newLookupClient(),
+			module:  strings.Replace(contextModule, `apiVersion: "v1", kind: "ConfigMap", name: "env-settings"`, `apiVersion: "apps/v1", kind: "Deployment", name: "env-settings"`, 1),
+			wantErr: `lookup "settings": Deployment.apps is not a lookup kind`,
+		},
+		{
+			name:    "secrets are not allowed",
+			client:  newLookupClient(),
+			module:  strings.Replace(contextModule, `kind: "ConfigMap", name: "env-settings"`, `kind: "Secret", name: "env-settings"`, 1),
+			wantErr: "Secrets cannot be looked up",
+		},
+		{
+			name:   "input without context",
+			client: newLookupClient(),
+			module: `
+#Lookups: settings: {apiVersion: "v1", kind: "ConfigMap", name: "env-settings"}
+#Render: {
+	input: close({metadata: {name: string, namespace: string}, spec: {...}})
+	output: {metadata: {name: input.metadata.name, version: "v1alpha1"}, nodes: [], violations: []}
+}
+`,
+			wantErr: "#Render.input has no context field",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := newContextRenderer(tt.client).RenderInstance(context.Background(), instance,
+				runtime.RawExtension{}, CueRefInput{Type: InlineType, Ref: tt.module}, RenderOptions{})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+const configModule = `
+#Render: {
+	input: {
+		metadata: {name: string, namespace: string}
+		spec: {replicas?: int, ...}
+		config: {
+			registry:     string | *"docker.io"
+			replicaFloor: int | *1
+			...
+		}
+	}
+	_replicas: *input.config.replicaFloor | int
+	if input.spec.replicas != _|_ if input.spec.replicas > input.config.replicaFloor {
+		_replicas: input.spec.replicas
+	}
+	output: {
+		metadata: {name: input.metadata.name, version: "v1alpha1"}
+		nodes: [{
+			id: "config"
+			object: {
+				apiVersion: "v1"
+				kind:       "ConfigMap"
+				metadata: {name: input.metadata.name, namespace: input.metadata.namespace}
+				data: {
+					image:    "\(input.config.registry)/app"
+					replicas: "\(_replicas)"
+				}
+			}
+			applyPolicy: {mode: "Apply"}
+		}]
+		violations: []
+	}
+}
+`
+
+func TestRenderInstance_Config(t *testing.T) {
+	renderer := NewRenderer(createTestLoader())
+	instance := &metav1.ObjectMeta{Name: "app", Namespace: "default"}
+	cueRef := CueRefInput{Type: InlineType, Ref: configModule}
+
+	tests := []struct {
+		name   string
+		spec   string
+		config string
+		want   map[string]string
+	}{
+		{
+			name: "module defaults without config",
+			want: map[string]string{"image": "docker.io/app", "replicas": "1"},
+		},
+		{
+			name:   "config values",
+			config: `{"registry": "registry.example.com", "replicaFloor": 2}`,
+			want:   map[string]string{"image": "registry.example.com/app", "replicas": "2"},
+		},
+		{
+			name:   "spec above the floor",
+			spec:   `{"replicas": 5}`,
+			config: `{"replicaFloor": 2}`,
+			want:   map[string]string{"image": "docker.io/app", "replicas": "5"},
+		},
+		{
+			name:   "spec below the floor",
+			spec:   `{"replicas": 1}`,
+			config: `{"replicaFloor": 3}`,
+			want:   map[string]string{"image": "docker.io/app", "replicas": "3"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := RenderOptions{Config: runtime.RawExtension{Raw: []byte(tt.config)}}
+			g, _, err := renderer.RenderInstance(context.Background(), instance, runtime.RawExtension{Raw: []byte(tt.spec)}, cueRef, opts)
+			if err != nil {
+				t.Fatalf("failed to render: %v", err)
+			}
+			data, _, _ := unstructured.NestedStringMap(g.Nodes[0].Object.Object, "data")
+			for key, value := range tt.want {
+				if data[key] != value {
+					t.Errorf("expected data.%s = %q, got %q", key, value, data[key])
+				}
+			}
+		})
+	}
+}
+
+const refsModule = `
+#Input: {
+	image:    string
+	database: {name: string} @instanceRef(kind="Database")
+}
+
+#Render: {
+	input: {
+		metadata: {name: string, namespace: string}
+		spec: {#Input, platformRef: string}
+		refs: database: {name: string, outputs: {secretName: string, ...}, ...}
+	}
+	output: {
+		metadata: {name: input.metadata.name, version: "v1alpha1"}
+		nodes: [{
+			id: "config"
+			object: {
+				apiVersion: "v1"
+				kind:       "ConfigMap"
+				metadata: {name: input.metadata.name, namespace: input.metadata.namespace}
+				data: secretName: input.refs.database.outputs.secretName
+			}
+			applyPolicy: {mode: "Apply"}
+		}]
+		violations: []
+	}
+}
+`
+
+func TestRenderInstance_InstanceRefs(t *testing.T) {
+	renderer := NewRenderer(createTestLoader())
+	instance := &metav1.ObjectMeta{Name: "app", Namespace: "default"}
+	spec := runtime.RawExtension{Raw: []byte(`{"image": "nginx", "database": {"name": "orders-db"}}`)}
+
+	var got []InstanceReference
+	resolve := func(ctx context.Context, refs []InstanceReference) (map[string]interface{}, error) {
+		got = refs
+		return map[string]interface{}{
+			"database": map[string]interface{}{"name": "orders-db", "outputs": map[string]interface{}{"secretName": "orders-db-conn"}},
+		}, nil
+	}
+
+	g, _, err := renderer.RenderInstance(context.Background(), instance, spec,
+		CueRefInput{Type: InlineType, Ref: refsModule}, RenderOptions{ResolveInstances: resolve})
+	if err != nil {
+		t.Fatalf("failed to render: %v", err)
+	}
+
+	want := []InstanceReference{{Field: "database", Kind: "Database", Name: "orders-db", Namespace: "default"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected references %+v, got %+v", want, got)
+	}
+	if secretName, _, _ := unstructured.NestedString(g.Nodes[0].Object.Object, "data", "secretName"); secretName != "orders-db-conn" {
+		t.Errorf("expected the referenced output to be rendered, got %q", secretName)
+	}
+
+	// Without a resolver the reference cannot be rendered
+	_, _, err = renderer.RenderInstance(context.Background(), instance, spec,
+		CueRefInput{Type: InlineType, Ref: refsModule}, RenderOptions{})
+	if err == nil || !strings.Contains(err.Error(), "require a resolver") {
+		t.Errorf("expected a missing resolver to fail the render, got %v", err)
+	}
+}
@@ -0,0 +1,109 @@
+package platformloader
+
+import (
+	"context"
+	"fmt"
+
+	"cuelang.org/go/cue"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ConvertDefinition is the module definition converting instance specs
+// between API versions, by source and target version, e.g.
+//
+//	#Convert: v1alpha1: v1beta1: {
+//		input: #Versions.v1alpha1
+//		output: replicas: [if input.size == "large" {3}, 1][0]
+//	}
+//
+// input holds the spec at the source version and output the spec at the
+// target version.
+const ConvertDefinition = "#Convert"
+
+// ConvertSpec converts an instance spec from one API version of the module
+// of a CueRef to another with #Convert.<from>.<to>. It reports false if the
+// module declares no such conversion.
+func (r *Renderer) ConvertSpec(
+	ctx context.Context, namespace string, cueRef CueRefInput, from, to string, spec map[string]interface{},
+) (map[string]interface{}, bool, error) {
+	fetchResult, moduleKey, err := r.fetchModule(ctx, namespace, cueRef)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var result evalOutput
+	args := convertArgs{From: from, To: to, Spec: spec}
+	if err := r.evaluate(ctx, convertTask, moduleKey, fetchResult, cueRef, args, &result); err != nil {
+		return nil, false, err
+	}
+	if !result.Found {
+		return nil, false, nil
+	}
+	// Decode through JSON so numbers have the types stored in specs
+	converted, err := decodeObject(runtime.RawExtension{Raw: result.Output})
+	if err != nil {
+		return nil, false, err
+	}
+	return converted, true, nil
+}
+
+// convertArgs are the arguments of the convert task
+type convertArgs struct {
+	From string                 `json:"from"`
+	To   string                 `json:"to"`
+	Spec map[string]interface{} `json:"spec"`
+}
+
+// runConvert is the convert evaluation task, filling #Convert.<from>.<to>
+func runConvert(cueValue cue.Value, req *evalRequest) (interface{}, error) {
+	var args convertArgs
+	if err := decodeJSON(req.Args, &args); err != nil {
+		return nil, fmt.Errorf("invalid convert arguments: %w", err)
+	}
+
+	path := fmt.Sprintf("%s.%s.%s", ConvertDefinition, args.From, args.To)
+	def := cueValue.LookupPath(cue.ParsePath(path))
+	if !def.Exists() {
+		return &evalOutput{}, nil
+	}
+
+	output := def.FillPath(cue.ParsePath("input"), cueValue.Context().Encode(convertJSONNumbers(args.Spec))).
+		LookupPath(cue.ParsePath("output"))
+	if !output.Exists() {
+		return nil, fmt.Errorf("%s has no output field", path)
+	}
+	if err := output.Validate(cue.Concrete(true)); err != nil {
+		return nil, fmt.Errorf("%s.output is incomplete: %w", path, err)
+	}
+
+	raw, err := output.MarshalJSON()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s.output: %w", path, err)
+	}
+	return &evalOutput{Found: true, Output: raw}, nil
+}
+
+// declaredConversions returns the versions #Convert converts to, by the
+// version they convert from
+func declaredConversions(cueValue cue.Value) (map[string][]string, error) {
+	def := cueValue.LookupPath(cue.ParsePath(ConvertDefinition))
+	if !def.Exists() {
+		return nil, nil
+	}
+	froms, err := def.Fields()
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", ConvertDefinition, err)
+	}
+	conversions := make(map[string][]string)
+	for froms.Next() {
+		tos, err := froms.Value().Fields()
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s.%s: %w", ConvertDefinition, froms.Selector(), err)
+		}
+		for tos.Next() {
+			from := froms.Selector().String()
+			conversions[from] = append(conversions[from], tos.Selector().String())
+		}
+	}
+	return conversions, nil
+}
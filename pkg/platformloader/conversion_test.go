@@ -0,0 +1,43 @@
+package platformloader
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+const conversionModule = `
+#Input: replicas: int
+
+#Versions: v1alpha1: size: "small" | "large"
+
+#Convert: v1alpha1: v1beta1: {
+	input: #Versions.v1alpha1
+	output: replicas: [if input.size == "large" {3}, 1][0]
+}
+`
+
+func TestConvertSpec(t *testing.T) {
+	renderer := NewRenderer(createTestLoader())
+	cueRef := CueRefInput{Type: InlineType, Ref: conversionModule}
+
+	converted, found, err := renderer.ConvertSpec(context.Background(), "default", cueRef,
+		"v1alpha1", "v1beta1", map[string]interface{}{"size": "large"})
+	if err != nil || !found {
+		t.Fatalf("expected a conversion, got found=%v err=%v", found, err)
+	}
+	if want := map[string]interface{}{"replicas": int64(3)}; !reflect.DeepEqual(converted, want) {
+		t.Errorf("expected %v, got %v", want, converted)
+	}
+
+	// The input is validated against the source version
+	if _, _, err := renderer.ConvertSpec(context.Background(), "default", cueRef,
+		"v1alpha1", "v1beta1", map[string]interface{}{"size": "huge"}); err == nil {
+		t.Error("expected an error for an invalid source spec")
+	}
+
+	if _, found, err := renderer.ConvertSpec(context.Background(), "default", cueRef,
+		"v1beta1", "v1alpha1", map[string]interface{}{"replicas": 3}); err != nil || found {
+		t.Errorf("expected no conversion for an undeclared pair, got found=%v err=%v", found, err)
+	}
+}
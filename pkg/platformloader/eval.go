@@ -0,0 +1,156 @@
+package platformloader
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/cespare/xxhash/v2"
+)
+
+// evalSlotCount is the number of modules that can be evaluated at once in
+// the manager process
+const evalSlotCount = 16
+
+// Evaluation tasks run on compiled modules, see evalTasks
+const (
+	inspectTask = "inspect"
+	renderTask  = "render"
+	outputsTask = "outputs"
+	convertTask = "convert"
+	schemasTask = "schemas"
+)
+
+// evalRequest asks for a task to be run on a compiled module. Requests and
+// their results are JSON, so they can be sent to render workers.
+type evalRequest struct {
+	// Task names the evaluation, e.g. "render"
+	Task string `json:"task"`
+
+	// ModuleKey identifies the compiled module for caching. Modules without
+	// a key are compiled for this request only.
+	ModuleKey string `json:"moduleKey,omitempty"`
+
+	// Content is the module's source
+	Content []byte `json:"content"`
+
+	// Inline is true for modules inlined in a CueRef, for error messages
+	Inline bool `json:"inline,omitempty"`
+
+	// Args are the task's arguments
+	Args json.RawMessage `json:"args,omitempty"`
+
+	// MaxOutputBytes is the size limit of a rendered graph
+	MaxOutputBytes int `json:"maxOutputBytes,omitempty"`
+}
+
+// evalTask evaluates a compiled module with the decoded arguments of a
+// request, returning a result that encodes to JSON
+type evalTask func(v cue.Value, req *evalRequest) (interface{}, error)
+
+// evalTasks are the tasks a request may name
+var evalTasks = map[string]evalTask{
+	inspectTask: runInspect,
+	renderTask:  runRender,
+	outputsTask: runOutputs,
+	convertTask: runConvert,
+	schemasTask: runSchemas,
+}
+
+// runEval compiles the module of a request, or takes it from cache, and
+// runs the request's task on it
+func runEval(cache *Cache, req *evalRequest) (result interface{}, err error) {
+	task, ok := evalTasks[req.Task]
+	if !ok {
+		return nil, fmt.Errorf("unknown evaluation task %q", req.Task)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("CUE evaluation panicked: %v", r)
+		}
+	}()
+
+	value, err := compileModule(cache, req.ModuleKey, req.Content)
+	if err != nil {
+		if req.Inline {
+			return nil, fmt.Errorf("failed to compile inline CUE: %w", err)
+		}
+		return nil, fmt.Errorf("failed to compile fetched CUE module: %w", err)
+	}
+	return task(value, req)
+}
+
+// compileModule returns the module cached under key, compiling content on
+// a miss. Each module is compiled in its own CUE context, since values
+// sharing a context must not be evaluated concurrently.
+func compileModule(cache *Cache, key string, content []byte) (cue.Value, error) {
+	if key != "" {
+		if value, found := cache.Get(key); found {
+			RecordCacheHit()
+			return value, nil
+		}
+		RecordCacheMiss()
+	}
+
+	value := cuecontext.New().CompileBytes(content)
+	if value.Err() != nil {
+		return cue.Value{}, fmt.Errorf("failed to compile CUE content: %w", value.Err())
+	}
+	if key != "" {
+		cache.Set(key, value)
+	}
+	return value, nil
+}
+
+// evaluate runs a task on the compiled module of req with args and decodes
+// its result into result. Tasks run in a render worker if the loader has
+// any, and in the calling goroutine otherwise.
+func (l *Loader) evaluate(ctx context.Context, req *evalRequest, args, result interface{}) error {
+	var err error
+	if req.Args, err = json.Marshal(args); err != nil {
+		return fmt.Errorf("failed to encode %s arguments: %w", req.Task, err)
+	}
+	req.MaxOutputBytes = l.budget.MaxOutputBytes
+
+	var raw []byte
+	if l.workers != nil {
+		raw, err = l.workers.eval(ctx, req)
+	} else {
+		raw, err = l.evalInProcess(req)
+	}
+	if err != nil {
+		if budgetErr, ok := AsBudgetError(err); ok {
+			RecordRenderBudgetExceeded(budgetErr.Reason)
+		}
+		return err
+	}
+	return decodeJSON(raw, result)
+}
+
+// evalInProcess runs a request in the calling goroutine. Compiled modules
+// are shared through the loader's cache and CUE values are not safe for
+// concurrent use, so evaluations of the same module are serialized.
+func (l *Loader) evalInProcess(req *evalRequest) ([]byte, error) {
+	if req.ModuleKey != "" {
+		lock := &l.evalLocks[xxhash.Sum64String(req.ModuleKey)%evalSlotCount]
+		lock.Lock()
+		defer lock.Unlock()
+	}
+
+	result, err := runEval(l.cache, req)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(result)
+}
+
+// decodeJSON decodes raw into v, keeping numbers in untyped values as
+// json.Number so integers stay integers; see convertJSONNumbers
+func decodeJSON(raw []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"io/fs"
+	"net/url"
 
 	corev1 "k8s.io/api/core/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -37,6 +38,40 @@ type Fetcher interface {
 	Type() string
 }
 
+// Resolver is implemented by fetchers whose refs can move, such as Git
+// branches and OCI tags. Resolve returns the digest a ref currently points
+// to, in the form of FetchResult.Digest, without fetching the module.
+type Resolver interface {
+	Resolve(ctx context.Context, ref string, pullSecret *corev1.Secret) (string, error)
+}
+
+// PinRef returns a git or oci ref that always fetches the module at the
+// given digest from the same repository and path. Refs of other types are
+// returned unchanged, since their digests cannot be fetched.
+func PinRef(fetcherType, ref, digest string) (string, error) {
+	switch fetcherType {
+	case "oci":
+		registry, repo, _, _, err := parseOCIRef(ref)
+		if err != nil {
+			return "", fmt.Errorf("invalid OCI reference: %w", err)
+		}
+		return fmt.Sprintf("%s/%s@%s", registry, repo, digest), nil
+
+	case "git":
+		u, err := url.Parse(ref)
+		if err != nil {
+			return "", fmt.Errorf("invalid Git reference: %w", err)
+		}
+		query := u.Query()
+		query.Set("ref", digest)
+		u.RawQuery = query.Encode()
+		return u.String(), nil
+
+	default:
+		return ref, nil
+	}
+}
+
 // FetcherRegistry manages all available fetchers
 type FetcherRegistry struct {
 	fetchers map[string]Fetcher
@@ -96,6 +131,20 @@ func (r *FetcherRegistry) Fetch(ctx context.Context, fetcherType, ref string, pu
 	return fetcher.Fetch(ctx, ref, pullSecret)
 }
 
+// Resolve returns the digest a ref currently points to. Fetchers that are
+// not Resolvers have no mutable refs.
+func (r *FetcherRegistry) Resolve(ctx context.Context, fetcherType, ref string, pullSecret *corev1.Secret) (string, error) {
+	fetcher, err := r.GetFetcher(fetcherType)
+	if err != nil {
+		return "", err
+	}
+	resolver, ok := fetcher.(Resolver)
+	if !ok {
+		return "", fmt.Errorf("%s refs cannot be re-resolved", fetcherType)
+	}
+	return resolver.Resolve(ctx, ref, pullSecret)
+}
+
 // FetchWithSecretRef fetches a CUE module, resolving the secret reference if provided
 func (r *FetcherRegistry) FetchWithSecretRef(ctx context.Context, fetcherType, ref, namespace string, secretRef *string) (*FetchResult, error) {
 	var pullSecret *corev1.Secret
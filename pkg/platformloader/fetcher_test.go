@@ -0,0 +1,64 @@
+package platformloader
+
+import "testing"
+
+func TestPinRef(t *testing.T) {
+	const digest = "sha256:abc123"
+	const sha = "0123456789abcdef0123456789abcdef01234567"
+
+	tests := []struct {
+		name        string
+		fetcherType string
+		ref         string
+		pin         string
+		want        string
+	}{
+		{
+			name:        "oci tag",
+			fetcherType: "oci",
+			ref:         "ghcr.io/org/platforms/webservice:latest",
+			pin:         digest,
+			want:        "ghcr.io/org/platforms/webservice@" + digest,
+		},
+		{
+			name:        "oci registry with port",
+			fetcherType: "oci",
+			ref:         "localhost:5000/webservice:v1",
+			pin:         digest,
+			want:        "localhost:5000/webservice@" + digest,
+		},
+		{
+			name:        "git branch with path",
+			fetcherType: "git",
+			ref:         "https://github.com/org/platforms.git?ref=main&path=webservice",
+			pin:         sha,
+			want:        "https://github.com/org/platforms.git?path=webservice&ref=" + sha,
+		},
+		{
+			name:        "git default branch",
+			fetcherType: "git",
+			ref:         "https://github.com/org/platforms.git",
+			pin:         sha,
+			want:        "https://github.com/org/platforms.git?ref=" + sha,
+		},
+		{
+			name:        "embedded is unchanged",
+			fetcherType: "embedded",
+			ref:         "webservice",
+			pin:         "sha256:embedded",
+			want:        "webservice",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := PinRef(tt.fetcherType, tt.ref, tt.pin)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
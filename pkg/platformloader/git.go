@@ -7,13 +7,16 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/transport"
 	"github.com/go-git/go-git/v5/plumbing/transport/http"
 	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/go-git/go-git/v5/storage/memory"
 	corev1 "k8s.io/api/core/v1"
 )
 
@@ -52,6 +55,17 @@ func (f *GitFetcher) Fetch(ctx context.Context, ref string, pullSecret *corev1.S
 		return nil, fmt.Errorf("invalid Git reference: %w", err)
 	}
 
+	// A full commit SHA identifies the content, so a cached copy needs no clone
+	if len(gitRef.Ref) == 40 && isCommitRef(gitRef.Ref) {
+		if cached, err := f.cache.Get(gitCacheKey(gitRef, gitRef.Ref)); err == nil {
+			return &FetchResult{
+				Content: cached,
+				Digest:  gitRef.Ref,
+				Source:  fmt.Sprintf("git://%s (cached)", ref),
+			}, nil
+		}
+	}
+
 	// Build authentication
 	auth, err := getGitAuth(pullSecret)
 	if err != nil {
@@ -76,7 +90,7 @@ func (f *GitFetcher) Fetch(ctx context.Context, ref string, pullSecret *corev1.S
 	// If ref is specified, try to set it as reference
 	if gitRef.Ref != "" {
 		// Check if it looks like a SHA
-		if len(gitRef.Ref) == 40 || len(gitRef.Ref) == 7 {
+		if isCommitRef(gitRef.Ref) {
 			// Full clone needed for specific commit
 			cloneOpts.Depth = 0
 		} else {
@@ -100,7 +114,7 @@ func (f *GitFetcher) Fetch(ctx context.Context, ref string, pullSecret *corev1.S
 	}
 
 	// If we have a specific commit SHA, checkout that commit
-	if gitRef.Ref != "" && (len(gitRef.Ref) == 40 || len(gitRef.Ref) == 7) {
+	if gitRef.Ref != "" && isCommitRef(gitRef.Ref) {
 		worktree, err := repo.Worktree()
 		if err != nil {
 			return nil, fmt.Errorf("failed to get worktree: %w", err)
@@ -124,10 +138,7 @@ func (f *GitFetcher) Fetch(ctx context.Context, ref string, pullSecret *corev1.S
 	commitSHA := head.Hash().String()
 
 	// Check cache with commit SHA
-	cacheKey := fmt.Sprintf("git:%s:%s", gitRef.URL, commitSHA)
-	if gitRef.Path != "" {
-		cacheKey += ":" + gitRef.Path
-	}
+	cacheKey := gitCacheKey(gitRef, commitSHA)
 
 	if cached, err := f.cache.Get(cacheKey); err == nil {
 		return &FetchResult{
@@ -160,6 +171,79 @@ func (f *GitFetcher) Fetch(ctx context.Context, ref string, pullSecret *corev1.S
 	}, nil
 }
 
+// Resolve returns the commit SHA a branch or tag points to by listing the
+// remote's references, without cloning. A ref without ?ref= resolves to the
+// remote's HEAD, and a commit SHA resolves to itself.
+func (f *GitFetcher) Resolve(ctx context.Context, ref string, pullSecret *corev1.Secret) (string, error) {
+	gitRef, err := parseGitRef(ref)
+	if err != nil {
+		return "", fmt.Errorf("invalid Git reference: %w", err)
+	}
+	if isCommitRef(gitRef.Ref) {
+		return gitRef.Ref, nil
+	}
+
+	auth, err := getGitAuth(pullSecret)
+	if err != nil {
+		return "", fmt.Errorf("failed to get Git auth: %w", err)
+	}
+	remote := git.NewRemote(memory.NewStorage(), &config.RemoteConfig{
+		Name: "origin",
+		URLs: []string{gitRef.URL},
+	})
+	refs, err := remote.ListContext(ctx, &git.ListOptions{Auth: auth, PeelingOption: git.AppendPeeled})
+	if err != nil {
+		return "", fmt.Errorf("failed to list remote references: %w", err)
+	}
+
+	hashes := make(map[plumbing.ReferenceName]plumbing.Hash, len(refs))
+	symbolic := make(map[plumbing.ReferenceName]plumbing.ReferenceName)
+	for _, r := range refs {
+		if r.Type() == plumbing.SymbolicReference {
+			symbolic[r.Name()] = r.Target()
+			continue
+		}
+		hashes[r.Name()] = r.Hash()
+	}
+
+	var candidates []plumbing.ReferenceName
+	if gitRef.Ref == "" {
+		candidates = []plumbing.ReferenceName{symbolic[plumbing.HEAD], plumbing.HEAD}
+	} else {
+		// Annotated tags are peeled to the commit they point to
+		tag := plumbing.NewTagReferenceName(gitRef.Ref)
+		candidates = []plumbing.ReferenceName{
+			plumbing.NewBranchReferenceName(gitRef.Ref),
+			tag + "^{}",
+			tag,
+		}
+	}
+	for _, name := range candidates {
+		if hash, ok := hashes[name]; ok {
+			return hash.String(), nil
+		}
+	}
+	return "", fmt.Errorf("reference %q not found in %s", gitRef.Ref, gitRef.URL)
+}
+
+// gitCacheKey is the disk cache key of a module at a commit
+func gitCacheKey(gitRef *GitRef, commitSHA string) string {
+	key := fmt.Sprintf("git:%s:%s", gitRef.URL, commitSHA)
+	if gitRef.Path != "" {
+		key += ":" + gitRef.Path
+	}
+	return key
+}
+
+// commitRefPattern matches a full or abbreviated commit SHA
+var commitRefPattern = regexp.MustCompile(`^[0-9a-f]{7}$|^[0-9a-f]{40}$`)
+
+// isCommitRef reports whether a ref names a commit rather than a branch or
+// tag, the same way Fetch decides to check it out
+func isCommitRef(ref string) bool {
+	return commitRefPattern.MatchString(ref)
+}
+
 // parseGitRef parses a Git reference string
 // Format: https://github.com/org/repo.git?ref=v1.0.0&path=modules/webservice
 func parseGitRef(ref string) (*GitRef, error) {
@@ -461,3 +461,71 @@ func TestGitFetcher_CacheHit(t *testing.T) {
 		t.Errorf("Cache content mismatch")
 	}
 }
+
+// TestGitFetcher_ResolveLocalRepo tests resolving branches and tags to
+// commits without cloning
+func TestGitFetcher_ResolveLocalRepo(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available, skipping local repo test")
+	}
+
+	repoDir := t.TempDir()
+	run := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoDir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %v: %s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	commit := func(message string) string {
+		if err := os.WriteFile(filepath.Join(repoDir, "module.cue"), []byte("package test\n\nname: \""+message+"\"\n"), 0644); err != nil {
+			t.Fatalf("Failed to write CUE file: %v", err)
+		}
+		run("add", ".")
+		run("-c", "user.email=test@test.com", "-c", "user.name=Test", "commit", "-m", message)
+		return run("rev-parse", "HEAD")
+	}
+
+	run("init", "-b", "main")
+	first := commit("first")
+	run("-c", "user.email=test@test.com", "-c", "user.name=Test", "tag", "-a", "v1.0.0", "-m", "v1.0.0")
+	run("branch", "develop")
+	second := commit("second")
+
+	fetcher := NewGitFetcher(NewDiskCache(t.TempDir()))
+	tests := []struct {
+		ref     string
+		want    string
+		wantErr bool
+	}{
+		{ref: "main", want: second},
+		{ref: "", want: second},
+		{ref: "v1.0.0", want: first},
+		{ref: first, want: first},
+		{ref: "develop", want: first},
+		{ref: "no-such-branch", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			ref := fmt.Sprintf("file://%s", repoDir)
+			if tt.ref != "" {
+				ref += "?ref=" + tt.ref
+			}
+			got, err := fetcher.Resolve(context.Background(), ref, nil)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolve failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
@@ -34,14 +34,14 @@ func (f *InlineFetcher) Fetch(ctx context.Context, ref string, _ *corev1.Secret)
 		return nil, fmt.Errorf("inline CUE content is empty")
 	}
 
-	content := []byte(ref)
-
-	// Compute a content-based digest
-	digest := fmt.Sprintf("%s:%x", InlineType, xxhash.Sum64(content))
-
 	return &FetchResult{
-		Content: content,
-		Digest:  digest,
+		Content: []byte(ref),
+		Digest:  InlineDigest(ref),
 		Source:  InlineType,
 	}, nil
 }
+
+// InlineDigest is the content-based digest of inline CUE content
+func InlineDigest(content string) string {
+	return fmt.Sprintf("%s:%x", InlineType, xxhash.Sum64String(content))
+}
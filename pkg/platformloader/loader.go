@@ -5,19 +5,38 @@ import (
 	"fmt"
 	"io/fs"
 	"path/filepath"
+	"sync"
 
 	"cuelang.org/go/cue"
 	"cuelang.org/go/cue/cuecontext"
 	"cuelang.org/go/cue/load"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 // Loader handles loading and caching of CUE platform modules
 type Loader struct {
-	ctx      *cue.Context
-	cache    *Cache
-	fetchers *FetcherRegistry
+	ctx          *cue.Context
+	cache        *Cache
+	fetchers     *FetcherRegistry
+	budget       RenderBudget
+	client       client.Client
+	reader       client.Reader
+	clusterFacts map[string]string
+
+	// lookupNamespaces are the namespaces any module may look up objects in
+	lookupNamespaces map[string]bool
+
+	// lookupKinds are the kinds any module may look up
+	lookupKinds map[schema.GroupKind]bool
+
+	// workers evaluate modules, if configured
+	workers *workerPool
+
+	// evalLocks serialize evaluations of each module without workers. A
+	// module is assigned a lock by hash.
+	evalLocks [evalSlotCount]sync.Mutex
 }
 
 // LoaderConfig contains configuration for the Loader
@@ -28,26 +47,87 @@ type LoaderConfig struct {
 	// K8sClient is the Kubernetes client for ConfigMap fetching
 	K8sClient client.Client
 
+	// APIReader reads namespaces and #Lookups objects at render time.
+	// Defaults to K8sClient; an uncached reader avoids starting informers
+	// for every kind modules look up.
+	APIReader client.Reader
+
+	// ClusterFacts are passed to modules as input.context.cluster,
+	// e.g. {"name": "prod-eu-1", "region": "eu-west-1"}
+	ClusterFacts map[string]string
+
+	// LookupNamespaces are the namespaces whose objects any module may read
+	// with #Lookups, besides the namespace of the instance being rendered
+	LookupNamespaces []string
+
+	// LookupKinds are the kinds modules may read with #Lookups, as Kind.group
+	// strings such as "ConfigMap" or "Deployment.apps". Defaults to
+	// DefaultLookupKinds.
+	LookupKinds []string
+
 	// EmbeddedFS is the filesystem containing embedded CUE modules (from go:embed)
 	EmbeddedFS fs.FS
 
 	// EmbeddedRootDir is the root directory within EmbeddedFS (e.g., "platform")
 	EmbeddedRootDir string
+
+	// CacheEntries is the number of compiled modules kept in memory.
+	// Defaults to DefaultCacheEntries.
+	CacheEntries int
+
+	// Budget limits each compile and render. Defaults to DefaultRenderBudget.
+	Budget *RenderBudget
+
+	// Workers configures the render worker processes evaluating modules.
+	// Without workers modules are evaluated in the calling goroutine, and
+	// only the output size limit of the budget is enforced.
+	Workers *WorkerConfig
 }
 
 // NewLoader creates a new platform loader with caching
 func NewLoader() *Loader {
 	return &Loader{
-		ctx:   cuecontext.New(),
-		cache: NewCache(),
+		ctx:         cuecontext.New(),
+		cache:       NewCache(),
+		budget:      DefaultRenderBudget(),
+		lookupKinds: lookupKindSet(DefaultLookupKinds),
 	}
 }
 
 // NewLoaderWithConfig creates a platform loader with fetcher support
 func NewLoaderWithConfig(config LoaderConfig) *Loader {
+	cacheEntries := config.CacheEntries
+	if cacheEntries == 0 {
+		cacheEntries = DefaultCacheEntries
+	}
 	loader := &Loader{
-		ctx:   cuecontext.New(),
-		cache: NewCache(),
+		ctx:          cuecontext.New(),
+		cache:        NewCacheWithLimit(cacheEntries),
+		budget:       DefaultRenderBudget(),
+		clusterFacts: config.ClusterFacts,
+		lookupKinds:  lookupKindSet(DefaultLookupKinds),
+	}
+	if len(config.LookupKinds) > 0 {
+		loader.lookupKinds = lookupKindSet(config.LookupKinds)
+	}
+	for _, namespace := range config.LookupNamespaces {
+		if loader.lookupNamespaces == nil {
+			loader.lookupNamespaces = make(map[string]bool, len(config.LookupNamespaces))
+		}
+		loader.lookupNamespaces[namespace] = true
+	}
+	if config.Budget != nil {
+		loader.budget = *config.Budget
+	}
+	if config.Workers != nil && config.Workers.Count > 0 {
+		loader.workers = newWorkerPool(*config.Workers, loader.budget)
+	}
+	if config.K8sClient != nil {
+		loader.client = config.K8sClient
+		loader.reader = config.APIReader
+		if loader.reader == nil {
+			loader.reader = config.K8sClient
+		}
 	}
 
 	// Initialize fetchers if we have a K8s client or embedded filesystem
@@ -73,16 +153,9 @@ func (l *Loader) FetchModule(ctx context.Context, fetcherType, ref, namespace st
 		return nil, fmt.Errorf("fetchers not initialized; use NewLoaderWithConfig")
 	}
 
-	// Get the pull secret if specified
-	var pullSecret *corev1.Secret
-	if pullSecretRef != nil && *pullSecretRef != "" {
-		pullSecret = &corev1.Secret{}
-		if err := l.fetchers.client.Get(ctx, client.ObjectKey{
-			Namespace: namespace,
-			Name:      *pullSecretRef,
-		}, pullSecret); err != nil {
-			return nil, fmt.Errorf("failed to get pull secret %s: %w", *pullSecretRef, err)
-		}
+	pullSecret, err := l.pullSecret(ctx, namespace, pullSecretRef)
+	if err != nil {
+		return nil, err
 	}
 
 	// Handle ConfigMap fetcher with namespace
@@ -93,6 +166,35 @@ func (l *Loader) FetchModule(ctx context.Context, fetcherType, ref, namespace st
 	return l.fetchers.Fetch(ctx, fetcherType, ref, pullSecret)
 }
 
+// ResolveModule returns the digest a git or oci ref currently points to,
+// which is cheaper than fetching the module to find out whether it moved
+func (l *Loader) ResolveModule(ctx context.Context, fetcherType, ref, namespace string, pullSecretRef *string) (string, error) {
+	if l.fetchers == nil {
+		return "", fmt.Errorf("fetchers not initialized; use NewLoaderWithConfig")
+	}
+
+	pullSecret, err := l.pullSecret(ctx, namespace, pullSecretRef)
+	if err != nil {
+		return "", err
+	}
+	return l.fetchers.Resolve(ctx, fetcherType, ref, pullSecret)
+}
+
+// pullSecret gets the pull secret of a ref, if it has one
+func (l *Loader) pullSecret(ctx context.Context, namespace string, pullSecretRef *string) (*corev1.Secret, error) {
+	if pullSecretRef == nil || *pullSecretRef == "" {
+		return nil, nil
+	}
+	pullSecret := &corev1.Secret{}
+	if err := l.fetchers.client.Get(ctx, client.ObjectKey{
+		Namespace: namespace,
+		Name:      *pullSecretRef,
+	}, pullSecret); err != nil {
+		return nil, fmt.Errorf("failed to get pull secret %s: %w", *pullSecretRef, err)
+	}
+	return pullSecret, nil
+}
+
 // containsSlash checks if the string contains a forward slash
 func containsSlash(s string) bool {
 	for _, c := range s {
@@ -103,13 +205,19 @@ func containsSlash(s string) bool {
 	return false
 }
 
-// LoadFromContent loads a CUE module from raw content bytes
+// LoadFromContent loads a CUE module from raw content bytes. Each module is
+// compiled in its own CUE context, since values sharing a context must not
+// be evaluated concurrently. Untrusted content should be compiled by a
+// render worker instead; see WorkerConfig.
 func (l *Loader) LoadFromContent(content []byte) (cue.Value, error) {
-	value := l.ctx.CompileBytes(content)
-	if value.Err() != nil {
-		return cue.Value{}, fmt.Errorf("failed to compile CUE content: %w", value.Err())
-	}
-	return value, nil
+	return compileModule(nil, "", content)
+}
+
+// LoadCached returns the compiled module cached under key, compiling content
+// on a miss. The key must change whenever content does, e.g. by including
+// the module digest.
+func (l *Loader) LoadCached(key string, content []byte) (cue.Value, error) {
+	return compileModule(l.cache, key, content)
 }
 
 // LoadFromPath loads a CUE module from a filesystem path
@@ -146,6 +254,11 @@ func (l *Loader) LoadFromPath(path string) (cue.Value, error) {
 	return value, nil
 }
 
+// Budget returns the limits applied to each compile and render
+func (l *Loader) Budget() RenderBudget {
+	return l.budget
+}
+
 // Context returns the CUE context used by this loader
 func (l *Loader) Context() *cue.Context {
 	return l.ctx
@@ -6,6 +6,7 @@ import (
 	"testing/fstest"
 
 	"cuelang.org/go/cue"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 func TestNewLoader(t *testing.T) {
@@ -23,6 +24,21 @@ func TestNewLoader(t *testing.T) {
 	}
 }
 
+func TestNewLoaderWithConfig_LookupKinds(t *testing.T) {
+	loader := NewLoaderWithConfig(LoaderConfig{})
+	if !loader.lookupKinds[schema.GroupKind{Kind: "ConfigMap"}] || len(loader.lookupKinds) != 1 {
+		t.Errorf("expected only ConfigMaps to be looked up by default, got %v", loader.lookupKinds)
+	}
+
+	loader = NewLoaderWithConfig(LoaderConfig{LookupKinds: []string{"Deployment.apps"}})
+	if !loader.lookupKinds[schema.GroupKind{Group: "apps", Kind: "Deployment"}] {
+		t.Errorf("expected Deployments to be looked up, got %v", loader.lookupKinds)
+	}
+	if loader.lookupKinds[schema.GroupKind{Kind: "ConfigMap"}] {
+		t.Errorf("expected the configured kinds to replace the defaults, got %v", loader.lookupKinds)
+	}
+}
+
 func TestNewLoaderWithConfig_EmbeddedFS(t *testing.T) {
 	// Create a mock filesystem with a test module
 	testFS := fstest.MapFS{
@@ -56,6 +56,31 @@ var (
 		Help: "Total number of CUE rendering errors",
 	})
 
+	renderCacheHitsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "pequod_cue_render_cache_hits_total",
+		Help: "Total number of renders skipped because the module and spec were unchanged",
+	})
+
+	renderCacheMissesTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "pequod_cue_render_cache_misses_total",
+		Help: "Total number of render cache misses",
+	})
+
+	renderCacheEvictionsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "pequod_cue_render_cache_evictions_total",
+		Help: "Total number of render cache evictions",
+	})
+
+	renderBudgetExceededTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "pequod_cue_render_budget_exceeded_total",
+		Help: "Total number of CUE compiles and renders that exceeded their budget",
+	}, []string{"reason"})
+
+	renderWorkersStartedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "pequod_cue_render_workers_started_total",
+		Help: "Total number of render worker processes started",
+	})
+
 	// Policy validation metrics
 	policyViolationsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
 		Name: "pequod_policy_violations_total",
@@ -75,6 +100,11 @@ func init() {
 		fetchTotal,
 		renderDuration,
 		renderErrorsTotal,
+		renderCacheHitsTotal,
+		renderCacheMissesTotal,
+		renderCacheEvictionsTotal,
+		renderBudgetExceededTotal,
+		renderWorkersStartedTotal,
 		policyViolationsTotal,
 	)
 }
@@ -116,6 +146,31 @@ func RecordRenderError() {
 	renderErrorsTotal.Inc()
 }
 
+// RecordRenderCacheHit records a render cache hit
+func RecordRenderCacheHit() {
+	renderCacheHitsTotal.Inc()
+}
+
+// RecordRenderCacheMiss records a render cache miss
+func RecordRenderCacheMiss() {
+	renderCacheMissesTotal.Inc()
+}
+
+// RecordRenderCacheEviction records a render cache eviction
+func RecordRenderCacheEviction() {
+	renderCacheEvictionsTotal.Inc()
+}
+
+// RecordRenderBudgetExceeded records a compile or render over its budget
+func RecordRenderBudgetExceeded(reason string) {
+	renderBudgetExceededTotal.WithLabelValues(reason).Inc()
+}
+
+// RecordRenderWorkerStarted records a started render worker process
+func RecordRenderWorkerStarted() {
+	renderWorkersStartedTotal.Inc()
+}
+
 // RecordPolicyViolation records a policy violation
 func RecordPolicyViolation(severity string) {
 	policyViolationsTotal.WithLabelValues(severity).Inc()
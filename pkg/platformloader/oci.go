@@ -140,6 +140,27 @@ func (f *OCIFetcher) Fetch(ctx context.Context, ref string, pullSecret *corev1.S
 	}, nil
 }
 
+// Resolve returns the manifest digest a tag points to, or the digest of a
+// digest reference
+func (f *OCIFetcher) Resolve(ctx context.Context, ref string, pullSecret *corev1.Secret) (string, error) {
+	registry, repo, tag, dgst, err := parseOCIRef(ref)
+	if err != nil {
+		return "", fmt.Errorf("invalid OCI reference: %w", err)
+	}
+	if dgst != "" {
+		return dgst, nil
+	}
+
+	authHeader := ""
+	if pullSecret != nil {
+		authHeader, err = getAuthHeader(pullSecret, registry)
+		if err != nil {
+			return "", fmt.Errorf("failed to get auth header: %w", err)
+		}
+	}
+	return f.resolveTag(ctx, registry, repo, tag, authHeader)
+}
+
 // parseOCIRef parses an OCI reference into its components
 // Supports formats:
 //   - registry/repo:tag
@@ -0,0 +1,97 @@
+package platformloader
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"cuelang.org/go/cue"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// OutputsDefinition is the module definition computing an instance's
+// outputs from the live objects of its completed graph, e.g.
+//
+//	#Outputs: {
+//		input: #Render.input
+//		live: [string]: _
+//		output: endpoint: "http://\(live.service.spec.clusterIP):\(input.spec.port)"
+//	}
+//
+// input holds the input the graph was rendered from, and live the applied
+// objects by node ID.
+const OutputsDefinition = "#Outputs"
+
+// RenderOutputs evaluates the module's #Outputs for an instance in
+// namespace with the input its graph was rendered from, see
+// graph.GraphMetadata.Input, and the live objects of the graph by node ID.
+// Returns nil if the module declares no outputs.
+func (r *Renderer) RenderOutputs(
+	ctx context.Context, namespace string, rawInput runtime.RawExtension, cueRef CueRefInput, live map[string]interface{},
+) (map[string]interface{}, error) {
+	fetchResult, moduleKey, err := r.fetchModule(ctx, namespace, cueRef)
+	if err != nil {
+		return nil, err
+	}
+
+	input, err := decodeObject(rawInput)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse input: %w", err)
+	}
+
+	var result evalOutput
+	args := outputsArgs{Input: input, Live: live}
+	if err := r.evaluate(ctx, outputsTask, moduleKey, fetchResult, cueRef, args, &result); err != nil {
+		return nil, err
+	}
+	if !result.Found {
+		return nil, nil
+	}
+	// Decode through JSON so numbers have the types stored in status
+	return decodeObject(runtime.RawExtension{Raw: result.Output})
+}
+
+// outputsArgs are the arguments of the outputs task
+type outputsArgs struct {
+	Input map[string]interface{} `json:"input"`
+	Live  map[string]interface{} `json:"live"`
+}
+
+// evalOutput is the result of the outputs and convert tasks
+type evalOutput struct {
+	// Found is false if the module does not declare the definition
+	Found bool `json:"found"`
+
+	// Output is the definition's output as JSON
+	Output json.RawMessage `json:"output,omitempty"`
+}
+
+// runOutputs is the outputs evaluation task, filling #Outputs
+func runOutputs(cueValue cue.Value, req *evalRequest) (interface{}, error) {
+	var args outputsArgs
+	if err := decodeJSON(req.Args, &args); err != nil {
+		return nil, fmt.Errorf("invalid outputs arguments: %w", err)
+	}
+
+	def := cueValue.LookupPath(cue.ParsePath(OutputsDefinition))
+	if !def.Exists() {
+		return &evalOutput{}, nil
+	}
+
+	filled := def.
+		FillPath(cue.ParsePath("input"), cueValue.Context().Encode(convertJSONNumbers(args.Input))).
+		FillPath(cue.ParsePath("live"), cueValue.Context().Encode(convertJSONNumbers(args.Live)))
+	output := filled.LookupPath(cue.ParsePath("output"))
+	if !output.Exists() {
+		return nil, fmt.Errorf("%s has no output field", OutputsDefinition)
+	}
+	if err := output.Validate(cue.Concrete(true)); err != nil {
+		return nil, fmt.Errorf("%s.output is incomplete: %w", OutputsDefinition, err)
+	}
+
+	raw, err := output.MarshalJSON()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s.output: %w", OutputsDefinition, err)
+	}
+	return &evalOutput{Found: true, Output: raw}, nil
+}
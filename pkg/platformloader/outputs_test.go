@@ -0,0 +1,87 @@
+package platformloader
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const outputsModule = `
+#Input: port: int | *8080
+
+#Render: {
+	input: {
+		metadata: {name: string, namespace: string}
+		spec: {#Input, platformRef: string}
+		config: domain: string | *"example.com"
+	}
+	output: {
+		metadata: {name: input.metadata.name, version: "v1alpha1"}
+		nodes: []
+		violations: []
+	}
+}
+
+#Outputs: {
+	input: #Render.input
+	live: [string]: _
+	output: {
+		endpoint:   "http://\(live.service.spec.clusterIP):\(input.spec.port)"
+		host:       "\(input.metadata.name).\(input.config.domain)"
+		port:       input.spec.port
+		secretName: live.credentials.metadata.name
+	}
+}
+`
+
+func TestRenderOutputs(t *testing.T) {
+	renderer := NewRenderer(createTestLoader())
+	instance := &metav1.ObjectMeta{Name: "web", Namespace: "default"}
+	spec := runtime.RawExtension{Raw: []byte(`{"port": 80}`)}
+	cueRef := CueRefInput{Type: InlineType, Ref: outputsModule}
+
+	// Outputs are computed from the input the graph was rendered from
+	g, _, err := renderer.RenderInstance(context.Background(), instance, spec, cueRef, RenderOptions{
+		Config: runtime.RawExtension{Raw: []byte(`{"domain": "staging.example.com"}`)},
+	})
+	if err != nil {
+		t.Fatalf("failed to render instance: %v", err)
+	}
+	input := runtime.RawExtension{Raw: g.Metadata.Input}
+
+	live := map[string]interface{}{
+		"service":     map[string]interface{}{"spec": map[string]interface{}{"clusterIP": "10.0.0.12"}},
+		"credentials": map[string]interface{}{"metadata": map[string]interface{}{"name": "web-credentials"}},
+	}
+	outputs, err := renderer.RenderOutputs(context.Background(), "default", input, cueRef, live)
+	if err != nil {
+		t.Fatalf("failed to render outputs: %v", err)
+	}
+	want := map[string]interface{}{
+		"endpoint":   "http://10.0.0.12:80",
+		"host":       "web.staging.example.com",
+		"port":       int64(80),
+		"secretName": "web-credentials",
+	}
+	if !reflect.DeepEqual(outputs, want) {
+		t.Errorf("expected outputs %v, got %v", want, outputs)
+	}
+
+	// Outputs of objects that are not live yet cannot be computed
+	delete(live, "service")
+	_, err = renderer.RenderOutputs(context.Background(), "default", input, cueRef, live)
+	if err == nil || !strings.Contains(err.Error(), "incomplete") {
+		t.Errorf("expected incomplete outputs to fail, got %v", err)
+	}
+
+	// Modules without #Outputs have none
+	outputs, err = renderer.RenderOutputs(context.Background(), "default", input,
+		CueRefInput{Type: InlineType, Ref: refsModule}, live)
+	if err != nil || outputs != nil {
+		t.Errorf("expected no outputs, got %v, %v", outputs, err)
+	}
+}
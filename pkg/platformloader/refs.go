@@ -0,0 +1,123 @@
+package platformloader
+
+import (
+	"context"
+	"fmt"
+
+	"cuelang.org/go/cue"
+
+	"github.com/chazu/pequod/pkg/schema"
+)
+
+// RefsField is the field of #Render.input holding the instances referenced
+// by the spec's @instanceRef fields, keyed by field path
+const RefsField = "refs"
+
+// InstanceReference is the value of an @instanceRef field in an instance spec
+type InstanceReference struct {
+	// Field is the path of the referencing field, e.g. "database"
+	Field string
+
+	// Kind and Group identify the referenced instance's type. Group is
+	// empty unless the module's attribute sets it.
+	Kind  string
+	Group string
+
+	// Name and Namespace identify the referenced instance. References are
+	// always to the referencing instance's namespace.
+	Name      string
+	Namespace string
+}
+
+// InstanceResolver resolves the instances an instance references, returning
+// the value passed to the module for each by field path. It is called with
+// no references if the spec sets none, so callers can forget stale ones.
+type InstanceResolver func(ctx context.Context, refs []InstanceReference) (map[string]interface{}, error)
+
+// inspectInstanceRefs reads the module's @instanceRef fields and whether its
+// input accepts the resolved references
+func inspectInstanceRefs(v cue.Value, info *moduleInfo) error {
+	refs, err := schema.NewExtractor().ExtractInstanceRefs(v)
+	if err != nil {
+		return fmt.Errorf("invalid instance reference: %w", err)
+	}
+	info.instanceRefs = refs
+
+	input := v.LookupPath(cue.ParsePath("#Render.input"))
+	info.acceptsRefs = input.Exists() && input.Allows(cue.Str(RefsField))
+	if len(refs) > 0 && !info.acceptsRefs {
+		return fmt.Errorf("module declares @%s fields but #Render.input has no %s field", schema.InstanceRefAttribute, RefsField)
+	}
+	return nil
+}
+
+// instanceReferences returns the references set in an instance's spec
+func instanceReferences(spec map[string]interface{}, fields []schema.InstanceRefField, namespace string) ([]InstanceReference, error) {
+	var refs []InstanceReference
+	for _, field := range fields {
+		value, found := lookupField(spec, field.Path)
+		if !found || value == nil {
+			continue
+		}
+		ref, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("spec.%s must be an object", field.Field())
+		}
+		name, _ := ref["name"].(string)
+		if name == "" {
+			return nil, fmt.Errorf("spec.%s.name must be set", field.Field())
+		}
+		refs = append(refs, InstanceReference{
+			Field:     field.Field(),
+			Kind:      field.Kind,
+			Group:     field.Group,
+			Name:      name,
+			Namespace: namespace,
+		})
+	}
+	return refs, nil
+}
+
+// lookupField returns the value at a path of map keys
+func lookupField(obj map[string]interface{}, path []string) (interface{}, bool) {
+	var value interface{} = obj
+	for _, key := range path {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = m[key]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// resolveInstances resolves the references in input.spec and, for modules
+// accepting them, sets input.refs
+func resolveInstances(
+	ctx context.Context, input map[string]interface{}, namespace string, info *moduleInfo, resolve InstanceResolver,
+) error {
+	spec, _ := input["spec"].(map[string]interface{})
+	refs, err := instanceReferences(spec, info.instanceRefs, namespace)
+	if err != nil {
+		return err
+	}
+
+	resolved := map[string]interface{}{}
+	if resolve != nil {
+		if resolved, err = resolve(ctx, refs); err != nil {
+			return fmt.Errorf("failed to resolve instance references: %w", err)
+		}
+	} else if len(refs) > 0 {
+		return fmt.Errorf("instance references require a resolver")
+	}
+
+	if info.acceptsRefs {
+		if resolved == nil {
+			resolved = map[string]interface{}{}
+		}
+		input[RefsField] = resolved
+	}
+	return nil
+}
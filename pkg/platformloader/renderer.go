@@ -6,23 +6,44 @@ import (
 	"encoding/json"
 	"fmt"
 	"strconv"
+	"strings"
 
 	"cuelang.org/go/cue"
+	"github.com/cespare/xxhash/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 
 	"github.com/chazu/pequod/pkg/graph"
 )
 
+// SensitiveAttribute is the CUE attribute marking rendered fields whose
+// values must not be stored in a ResourceGraph, e.g.
+// `password: input.spec.password @sensitive()`
+const SensitiveAttribute = "sensitive"
+
+// DefaultRenderCacheEntries is the number of rendered graphs kept in memory
+const DefaultRenderCacheEntries = 1024
+
 // Renderer converts CUE evaluation results to Graph artifacts
 type Renderer struct {
 	loader *Loader
+
+	// results caches rendered graphs by module and input, so an unchanged
+	// instance is not evaluated again
+	results *lruCache[*graph.Graph]
+
+	// modules caches what the renderer reads from a module before
+	// rendering, such as its #Lookups, by module key
+	modules *lruCache[*moduleInfo]
 }
 
 // NewRenderer creates a new renderer with the given loader
 func NewRenderer(loader *Loader) *Renderer {
 	return &Renderer{
-		loader: loader,
+		loader:  loader,
+		results: newLRUCache[*graph.Graph](DefaultRenderCacheEntries, RecordRenderCacheEviction),
+		modules: newLRUCache[*moduleInfo](DefaultCacheEntries, nil),
 	}
 }
 
@@ -39,68 +60,159 @@ type CueRefInput struct {
 func (r *Renderer) RenderTransformWithCueRef(
 	ctx context.Context, name, namespace string, rawInput runtime.RawExtension, cueRef CueRefInput,
 ) (*graph.Graph, *FetchResult, error) {
-	var cueValue cue.Value
-	var fetchResult *FetchResult
-	var err error
+	instance := &metav1.ObjectMeta{Name: name, Namespace: namespace}
+	return r.RenderInstance(ctx, instance, rawInput, cueRef, RenderOptions{})
+}
+
+// RenderOptions carries what a module receives besides the instance spec
+type RenderOptions struct {
+	// Config holds the PlatformConfig values selected for the instance,
+	// passed to modules whose #Render.input has a config field
+	Config runtime.RawExtension
+
+	// ResolveInstances resolves the instances referenced by the spec's
+	// @instanceRef fields, passed to modules as input.refs
+	ResolveInstances InstanceResolver
+}
+
+// RenderInstance renders an instance's spec with the module of a CueRef.
+// Modules whose #Render.input has a context field also receive the
+// instance's labels and annotations, its namespace's metadata, the
+// operator's cluster facts and the objects declared in #Lookups. Modules
+// whose input has a config field receive opts.Config, and modules with
+// @instanceRef fields receive the referenced instances in input.refs.
+//
+// Compiled modules are cached by digest, and rendering is skipped entirely
+// when the module and input are unchanged since a previous render.
+// The returned graph is owned by the caller, and carries the input in its
+// metadata for RenderOutputs.
+func (r *Renderer) RenderInstance(
+	ctx context.Context, instance metav1.Object, rawInput runtime.RawExtension, cueRef CueRefInput, opts RenderOptions,
+) (*graph.Graph, *FetchResult, error) {
+	fetchResult, moduleKey, err := r.fetchModule(ctx, instance.GetNamespace(), cueRef)
+	if err != nil {
+		return nil, nil, err
+	}
 
+	info, err := r.inspectModule(ctx, moduleKey, fetchResult, cueRef)
+	if err != nil {
+		RecordRenderError()
+		return nil, nil, err
+	}
+
+	input, err := buildInput(instance, rawInput, cueRef.Ref)
+	if err != nil {
+		return nil, nil, err
+	}
+	if info.acceptsContext {
+		rc, err := r.renderContext(ctx, instance, info)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to build render context: %w", err)
+		}
+		input[ContextField] = rc
+	}
+	if info.acceptsConfig {
+		config, err := decodeObject(opts.Config)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse platform config: %w", err)
+		}
+		input[ConfigField] = config
+	}
+	if err := resolveInstances(ctx, input, instance.GetNamespace(), info, opts.ResolveInstances); err != nil {
+		return nil, nil, err
+	}
+
+	inputJSON, err := json.Marshal(input)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode input: %w", err)
+	}
+	renderKey := fmt.Sprintf("%s/%x", moduleKey, xxhash.Sum64(inputJSON))
+	if g, found := r.results.Get(renderKey); found {
+		RecordRenderCacheHit()
+		return g.DeepCopy(), fetchResult, nil
+	}
+	RecordRenderCacheMiss()
+
+	// Render within the loader's budget
+	var output renderOutput
+	if err := r.evaluate(ctx, renderTask, moduleKey, fetchResult, cueRef, renderArgs{Input: input}, &output); err != nil {
+		RecordRenderError()
+		return nil, nil, err
+	}
+	g, err := r.graphFromOutput(&output)
+	if err != nil {
+		RecordRenderError()
+		return nil, nil, err
+	}
+	g.Metadata.Input = inputJSON
+
+	r.results.Set(renderKey, g.DeepCopy())
+	return g, fetchResult, nil
+}
+
+// fetchModule fetches the module of a CueRef and returns it with the key
+// identifying its compiled form
+func (r *Renderer) fetchModule(ctx context.Context, namespace string, cueRef CueRefInput) (*FetchResult, string, error) {
 	switch cueRef.Type {
 	case InlineType:
-		// Compile inline CUE directly (special case - content is in Ref)
-		cueValue = r.loader.ctx.CompileString(cueRef.Ref)
-		if cueValue.Err() != nil {
-			return nil, nil, fmt.Errorf("failed to compile inline CUE: %w", cueValue.Err())
-		}
-		fetchResult = &FetchResult{
+		// Inline content is in Ref, so it is keyed by its hash
+		fetchResult := &FetchResult{
 			Content: []byte(cueRef.Ref),
-			Digest:  InlineType,
+			Digest:  InlineDigest(cueRef.Ref),
 			Source:  InlineType,
 		}
+		return fetchResult, fetchResult.Digest, nil
 
 	case "embedded", "oci", "git", "configmap":
 		// Use the fetcher system for all external module types
-		fetchResult, err = r.loader.FetchModule(ctx, cueRef.Type, cueRef.Ref, namespace, cueRef.PullSecretRef)
-		if err != nil {
-			return nil, nil, fmt.Errorf("failed to fetch CUE module: %w", err)
-		}
-
-		// Compile the fetched content
-		cueValue, err = r.loader.LoadFromContent(fetchResult.Content)
+		fetchResult, err := r.loader.FetchModule(ctx, cueRef.Type, cueRef.Ref, namespace, cueRef.PullSecretRef)
 		if err != nil {
-			return nil, nil, fmt.Errorf("failed to compile fetched CUE module: %w", err)
+			return nil, "", fmt.Errorf("failed to fetch CUE module: %w", err)
 		}
+		// A digest alone is not enough, e.g. one git commit holds many modules
+		return fetchResult, fmt.Sprintf("%s:%s@%s", cueRef.Type, cueRef.Ref, fetchResult.Digest), nil
 
 	default:
-		return nil, nil, fmt.Errorf("unsupported CueRef type: %s", cueRef.Type)
+		return nil, "", fmt.Errorf("unsupported CueRef type: %s", cueRef.Type)
 	}
+}
 
-	// Render the graph
-	g, err := r.renderWithCueValue(ctx, name, namespace, rawInput, cueValue, cueRef.Ref)
-	if err != nil {
-		return nil, nil, err
+// inspectModule returns the module's #Lookups and whether it accepts a
+// render context, reading them from the compiled module on first use
+func (r *Renderer) inspectModule(
+	ctx context.Context, moduleKey string, fetchResult *FetchResult, cueRef CueRefInput,
+) (*moduleInfo, error) {
+	if info, found := r.modules.Get(moduleKey); found {
+		return info, nil
 	}
 
-	return g, fetchResult, nil
+	info := &moduleInfo{}
+	if err := r.evaluate(ctx, inspectTask, moduleKey, fetchResult, cueRef, nil, info); err != nil {
+		return nil, err
+	}
+	r.modules.Set(moduleKey, info)
+	return info, nil
 }
 
-// renderWithCueValue renders a Transform using a pre-loaded CUE value
-func (r *Renderer) renderWithCueValue(
-	ctx context.Context, name, namespace string, rawInput runtime.RawExtension,
-	cueValue cue.Value, platformRef string,
-) (*graph.Graph, error) {
-
-	// Parse the raw input using json.Decoder with UseNumber() to preserve integer types
-	// Standard json.Unmarshal converts all numbers to float64, which causes CUE type errors
-	var specInput map[string]interface{}
-	if len(rawInput.Raw) > 0 {
-		decoder := json.NewDecoder(bytes.NewReader(rawInput.Raw))
-		decoder.UseNumber()
-		if err := decoder.Decode(&specInput); err != nil {
-			return nil, fmt.Errorf("failed to parse input: %w", err)
-		}
-		// Convert json.Number to native types for CUE compatibility
-		specInput = convertJSONNumbers(specInput).(map[string]interface{})
-	} else {
-		specInput = make(map[string]interface{})
+// evaluate runs a task on the compiled module of a CueRef within the
+// loader's budget
+func (r *Renderer) evaluate(
+	ctx context.Context, task, moduleKey string, fetchResult *FetchResult, cueRef CueRefInput, args, result interface{},
+) error {
+	req := &evalRequest{
+		Task:      task,
+		ModuleKey: moduleKey,
+		Content:   fetchResult.Content,
+		Inline:    cueRef.Type == InlineType,
+	}
+	return r.loader.evaluate(ctx, req, args, result)
+}
+
+// buildInput builds the #Render input of an instance
+func buildInput(instance metav1.Object, rawInput runtime.RawExtension, platformRef string) (map[string]interface{}, error) {
+	specInput, err := decodeObject(rawInput)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse input: %w", err)
 	}
 
 	// Inject platformRef into spec for CUE template to use
@@ -108,19 +220,39 @@ func (r *Renderer) renderWithCueValue(
 
 	// Build the CUE input structure
 	// CUE expects: { metadata: { name, namespace }, spec: { ...platform-specific... } }
-	input := map[string]interface{}{
+	return map[string]interface{}{
 		"metadata": map[string]interface{}{
-			"name":      name,
-			"namespace": namespace,
+			"name":      instance.GetName(),
+			"namespace": instance.GetNamespace(),
 		},
 		"spec": specInput,
-	}
+	}, nil
+}
+
+// renderArgs are the arguments of the render task
+type renderArgs struct {
+	// Input fills #Render.input
+	Input map[string]interface{} `json:"input"`
+}
 
-	return r.renderWithInput(cueValue, input)
+// renderOutput is the result of the render task
+type renderOutput struct {
+	// Output is #Render.output as JSON
+	Output json.RawMessage `json:"output"`
+
+	// SensitiveFields are the paths of the fields marked @sensitive in
+	// each node's object, by node index
+	SensitiveFields [][]string `json:"sensitiveFields,omitempty"`
 }
 
-// renderWithInput is the internal rendering method that takes a loaded CUE value and input
-func (r *Renderer) renderWithInput(cueValue cue.Value, input map[string]interface{}) (*graph.Graph, error) {
+// runRender is the render evaluation task, filling #Render.input
+func runRender(cueValue cue.Value, req *evalRequest) (interface{}, error) {
+	var args renderArgs
+	if err := decodeJSON(req.Args, &args); err != nil {
+		return nil, fmt.Errorf("invalid render arguments: %w", err)
+	}
+	input := convertJSONNumbers(args.Input)
+
 	// Fill the #Render template with our input
 	renderDef := cueValue.LookupPath(cue.ParsePath("#Render"))
 	if !renderDef.Exists() {
@@ -128,7 +260,7 @@ func (r *Renderer) renderWithInput(cueValue cue.Value, input map[string]interfac
 	}
 
 	// Unify the input with the render definition
-	inputValue := r.loader.ctx.Encode(input)
+	inputValue := cueValue.Context().Encode(input)
 	filled := renderDef.FillPath(cue.ParsePath("input"), inputValue)
 	if filled.Err() != nil {
 		return nil, fmt.Errorf("failed to fill input: %w", filled.Err())
@@ -144,12 +276,37 @@ func (r *Renderer) renderWithInput(cueValue cue.Value, input map[string]interfac
 		return nil, fmt.Errorf("output has errors: %w", output.Err())
 	}
 
-	// Convert CUE output to Graph
-	g, err := r.cueValueToGraph(output)
+	jsonBytes, err := output.MarshalJSON()
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal output to JSON: %w", err)
+	}
+	budget := RenderBudget{MaxOutputBytes: req.MaxOutputBytes}
+	if err := budget.checkOutput(len(jsonBytes)); err != nil {
+		return nil, err
+	}
+
+	sensitive, err := sensitiveFields(output)
+	if err != nil {
+		return nil, err
+	}
+	return &renderOutput{Output: jsonBytes, SensitiveFields: sensitive}, nil
+}
+
+// graphFromOutput converts the result of the render task to a Graph
+func (r *Renderer) graphFromOutput(output *renderOutput) (*graph.Graph, error) {
+	g, err := r.parseGraph(output.Output)
 	if err != nil {
 		return nil, fmt.Errorf("failed to convert CUE to Graph: %w", err)
 	}
 
+	for i := range g.Nodes {
+		node := &g.Nodes[i]
+		if i < len(output.SensitiveFields) {
+			node.SensitiveFields = append(node.SensitiveFields, output.SensitiveFields[i]...)
+		}
+		node.MarkSecretData()
+	}
+
 	// Validate the graph
 	if err := g.Validate(); err != nil {
 		return nil, fmt.Errorf("rendered graph is invalid: %w", err)
@@ -158,14 +315,8 @@ func (r *Renderer) renderWithInput(cueValue cue.Value, input map[string]interfac
 	return g, nil
 }
 
-// cueValueToGraph converts a CUE value to a Graph struct
-func (r *Renderer) cueValueToGraph(v cue.Value) (*graph.Graph, error) {
-	// Convert CUE value to JSON
-	jsonBytes, err := v.MarshalJSON()
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal CUE to JSON: %w", err)
-	}
-
+// parseGraph converts rendered JSON to a Graph struct
+func (r *Renderer) parseGraph(jsonBytes []byte) (*graph.Graph, error) {
 	// Unmarshal into a temporary structure
 	var temp struct {
 		Metadata   graph.GraphMetadata `json:"metadata"`
@@ -194,6 +345,96 @@ func (r *Renderer) cueValueToGraph(v cue.Value) (*graph.Graph, error) {
 	}, nil
 }
 
+// sensitiveFields returns the fields of each node's object marked with the
+// @sensitive attribute, by node index
+func sensitiveFields(output cue.Value) ([][]string, error) {
+	iter, err := output.LookupPath(cue.ParsePath("nodes")).List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read nodes: %w", err)
+	}
+	var fields [][]string
+	for i := 0; iter.Next(); i++ {
+		var paths []string
+		object := iter.Value().LookupPath(cue.ParsePath("object"))
+		if err := collectSensitiveFields(object, "", false, &paths); err != nil {
+			id, _ := iter.Value().LookupPath(cue.ParsePath("id")).String()
+			return nil, fmt.Errorf("node %s: %w", id, err)
+		}
+		fields = append(fields, paths)
+	}
+	return fields, nil
+}
+
+// collectSensitiveFields appends the paths of fields below v that carry the
+// @sensitive attribute
+func collectSensitiveFields(v cue.Value, prefix string, inList bool, paths *[]string) error {
+	switch v.IncompleteKind() {
+	case cue.StructKind:
+		iter, err := v.Fields()
+		if err != nil {
+			return err
+		}
+		for iter.Next() {
+			label := iter.Selector().Unquoted()
+			path := joinFieldPath(prefix, label)
+			if attr := iter.Value().Attribute(SensitiveAttribute); attr.Err() == nil {
+				if inList {
+					return fmt.Errorf("sensitive field %s is inside a list; render the value into a Secret and reference it instead", path)
+				}
+				*paths = append(*paths, path)
+				continue
+			}
+			if err := collectSensitiveFields(iter.Value(), path, inList, paths); err != nil {
+				return err
+			}
+		}
+	case cue.ListKind:
+		iter, err := v.List()
+		if err != nil {
+			return err
+		}
+		for iter.Next() {
+			if err := collectSensitiveFields(iter.Value(), prefix+"[*]", true, paths); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// joinFieldPath appends a key to a field path, quoting keys that contain
+// path syntax
+func joinFieldPath(prefix, key string) string {
+	if strings.ContainsAny(key, ".[]") {
+		return fmt.Sprintf(`%s["%s"]`, prefix, key)
+	}
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// decodeObject decodes a JSON object, or returns an empty object if raw is
+// empty. It uses json.Decoder with UseNumber() to preserve integer types;
+// standard json.Unmarshal converts all numbers to float64, which causes CUE
+// type errors.
+func decodeObject(raw runtime.RawExtension) (map[string]interface{}, error) {
+	if len(raw.Raw) == 0 {
+		return make(map[string]interface{}), nil
+	}
+	var obj map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(raw.Raw))
+	decoder.UseNumber()
+	if err := decoder.Decode(&obj); err != nil {
+		return nil, err
+	}
+	if obj == nil {
+		return make(map[string]interface{}), nil
+	}
+	// Convert json.Number to native types for CUE compatibility
+	return convertJSONNumbers(obj).(map[string]interface{}), nil
+}
+
 // convertJSONNumbers recursively converts json.Number values to native Go types
 // This preserves integer types when possible, which is required for CUE type checking
 func convertJSONNumbers(v interface{}) interface{} {
@@ -233,6 +474,8 @@ func (r *Renderer) parseNode(nodeJSON json.RawMessage) (graph.Node, error) {
 		ApplyPolicy graph.ApplyPolicy          `json:"applyPolicy"`
 		DependsOn   []string                   `json:"dependsOn"`
 		ReadyWhen   []graph.ReadinessPredicate `json:"readyWhen"`
+		Hook        *graph.Hook                `json:"hook"`
+		Generate    *graph.GeneratedSecret     `json:"generate"`
 	}
 
 	if err := json.Unmarshal(nodeJSON, &temp); err != nil {
@@ -248,5 +491,7 @@ func (r *Renderer) parseNode(nodeJSON json.RawMessage) (graph.Node, error) {
 		ApplyPolicy: temp.ApplyPolicy,
 		DependsOn:   temp.DependsOn,
 		ReadyWhen:   temp.ReadyWhen,
+		Hook:        temp.Hook,
+		Generate:    temp.Generate,
 	}, nil
 }
@@ -11,6 +11,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 
 	cuembed "github.com/chazu/pequod/cue"
+	"github.com/chazu/pequod/pkg/graph"
 )
 
 func TestNewRenderer(t *testing.T) {
@@ -203,3 +204,109 @@ func TestRenderTransformWithEnvFrom(t *testing.T) {
 	// to accept and pass envFrom to the CUE template
 	t.Skip("EnvFrom support in renderer not yet implemented - CUE template is ready")
 }
+
+func TestRenderMarksSensitiveFields(t *testing.T) {
+	renderer := NewRenderer(createTestLoader())
+
+	module := `
+#Render: {
+	input: {metadata: {name: string, namespace: string}, spec: {password: string, ...}}
+	output: {
+		metadata: {name: "db", version: "v1alpha1"}
+		nodes: [{
+			id: "credentials"
+			object: {
+				apiVersion: "v1"
+				kind:       "Secret"
+				metadata: {name: "db", namespace: input.metadata.namespace}
+				stringData: password: input.spec.password
+			}
+			applyPolicy: {mode: "Apply"}
+		}, {
+			id: "config"
+			object: {
+				apiVersion: "v1"
+				kind:       "ConfigMap"
+				metadata: {name: "db", namespace: input.metadata.namespace}
+				metadata: annotations: "example.com/dsn": "postgres://app:\(input.spec.password)@db" @sensitive()
+				data: user: "app"
+			}
+			applyPolicy: {mode: "Apply"}
+		}]
+		violations: []
+	}
+}
+`
+	g, _, err := renderer.RenderTransformWithCueRef(context.Background(), "db", "default",
+		runtime.RawExtension{Raw: []byte(`{"password":"hunter22"}`)}, CueRefInput{Type: InlineType, Ref: module})
+	if err != nil {
+		t.Fatalf("failed to render: %v", err)
+	}
+
+	if got := g.Nodes[0].SensitiveFields; len(got) != 1 || got[0] != "stringData" {
+		t.Errorf("expected Secret stringData to be sensitive, got %v", got)
+	}
+	want := `metadata.annotations["example.com/dsn"]`
+	if got := g.Nodes[1].SensitiveFields; len(got) != 1 || got[0] != want {
+		t.Errorf("expected %s to be sensitive, got %v", want, got)
+	}
+}
+
+func TestRenderTransformWithCueRef_CachesRenders(t *testing.T) {
+	loader := createTestLoader()
+	renderer := NewRenderer(loader)
+
+	cueRef := CueRefInput{Type: InlineType, Ref: `
+#Render: {
+	input: {metadata: {name: string, namespace: string}, spec: {replicas: int, ...}}
+	output: {
+		metadata: {name: input.metadata.name, version: "v1alpha1"}
+		nodes: [{
+			id: "config"
+			object: {
+				apiVersion: "v1"
+				kind:       "ConfigMap"
+				metadata: {name: input.metadata.name, namespace: input.metadata.namespace}
+				data: replicas: "\(input.spec.replicas)"
+			}
+			applyPolicy: {mode: "Apply"}
+		}]
+		violations: []
+	}
+}
+`}
+	render := func(spec string) *graph.Graph {
+		t.Helper()
+		g, _, err := renderer.RenderTransformWithCueRef(context.Background(), "app", "default",
+			runtime.RawExtension{Raw: []byte(spec)}, cueRef)
+		if err != nil {
+			t.Fatalf("failed to render: %v", err)
+		}
+		return g
+	}
+
+	first := render(`{"replicas":2}`)
+	first.SetHash()
+	first.Nodes[0].Object.SetName("mutated")
+
+	// The cached graph is not affected by changes the caller makes
+	second := render(`{"replicas":2}`)
+	if second.Nodes[0].Object.GetName() != "app" || second.Metadata.RenderHash != "" {
+		t.Errorf("expected an unmodified copy of the cached graph, got %+v", second)
+	}
+	if renderer.results.Size() != 1 {
+		t.Errorf("expected 1 cached render, got %d", renderer.results.Size())
+	}
+
+	// A new spec is rendered again from the cached module
+	third := render(`{"replicas":3}`)
+	if replicas, _, _ := unstructured.NestedString(third.Nodes[0].Object.Object, "data", "replicas"); replicas != "3" {
+		t.Errorf("expected replicas 3, got %q", replicas)
+	}
+	if renderer.results.Size() != 2 {
+		t.Errorf("expected 2 cached renders, got %d", renderer.results.Size())
+	}
+	if loader.cache.Size() != 1 {
+		t.Errorf("expected the module to be compiled once, got %d cached modules", loader.cache.Size())
+	}
+}
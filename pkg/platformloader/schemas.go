@@ -0,0 +1,81 @@
+package platformloader
+
+import (
+	"context"
+	"fmt"
+
+	"cuelang.org/go/cue"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+
+	inputschema "github.com/chazu/pequod/pkg/schema"
+)
+
+// ModuleSchemas are the schemas a module declares for its generated CRD
+type ModuleSchemas struct {
+	// Input is the schema of #Input, the instance spec
+	Input *apiextensionsv1.JSONSchemaProps `json:"input"`
+
+	// Outputs is the schema of #Outputs.output, or nil if the module
+	// declares no outputs
+	Outputs *apiextensionsv1.JSONSchemaProps `json:"outputs,omitempty"`
+
+	// Versions are the spec schemas of further API versions by name
+	Versions map[string]*apiextensionsv1.JSONSchemaProps `json:"versions,omitempty"`
+
+	// Conversions are the versions the module's #Convert converts to, by
+	// the version they convert from
+	Conversions map[string][]string `json:"conversions,omitempty"`
+}
+
+// schemasArgs are the arguments of the schemas task
+type schemasArgs struct {
+	// Versions are the API versions whose #Versions schema is extracted
+	Versions []string `json:"versions,omitempty"`
+}
+
+// ExtractSchemas compiles a module and extracts the schemas of its #Input,
+// its #Outputs and the given #Versions, and the conversions it declares,
+// within the render budget, since the module may be untrusted. inline is
+// true for modules inlined in a CueRef.
+func (l *Loader) ExtractSchemas(ctx context.Context, content []byte, inline bool, versions []string) (*ModuleSchemas, error) {
+	schemas := &ModuleSchemas{}
+	req := &evalRequest{Task: schemasTask, Content: content, Inline: inline}
+	if err := l.evaluate(ctx, req, schemasArgs{Versions: versions}, schemas); err != nil {
+		return nil, err
+	}
+	return schemas, nil
+}
+
+// runSchemas is the schemas evaluation task
+func runSchemas(cueValue cue.Value, req *evalRequest) (interface{}, error) {
+	var args schemasArgs
+	if err := decodeJSON(req.Args, &args); err != nil {
+		return nil, fmt.Errorf("invalid schemas arguments: %w", err)
+	}
+
+	extractor := inputschema.NewExtractor()
+	schemas := &ModuleSchemas{}
+	var err error
+	schemas.Input, err = extractor.ExtractInputSchema(cueValue)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract input schema: %w", err)
+	}
+	schemas.Outputs, err = extractor.ExtractOutputsSchema(cueValue)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract outputs schema: %w", err)
+	}
+	for _, version := range args.Versions {
+		if schemas.Versions == nil {
+			schemas.Versions = make(map[string]*apiextensionsv1.JSONSchemaProps, len(args.Versions))
+		}
+		schemas.Versions[version], err = extractor.ExtractVersionSchema(cueValue, version)
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract the schema of version %s: %w", version, err)
+		}
+	}
+	schemas.Conversions, err = declaredConversions(cueValue)
+	if err != nil {
+		return nil, err
+	}
+	return schemas, nil
+}
@@ -0,0 +1,382 @@
+package platformloader
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime/metrics"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RenderWorkerEnv is set in the environment of render worker processes, to
+// the worker's memory limit in bytes. Binaries running render workers call
+// ServeRenderWorker first thing when IsRenderWorker reports true.
+const RenderWorkerEnv = "PEQUOD_RENDER_WORKER"
+
+const (
+	// workerMemoryOverhead is the memory a render worker may use beyond
+	// MaxMemoryBytes, for the Go runtime and the binary itself
+	workerMemoryOverhead = 256 << 20 // 256MiB
+
+	// workerCacheEntries is the number of compiled modules a render worker
+	// keeps
+	workerCacheEntries = 16
+
+	// workerStderrBytes is how much of a worker's stderr is kept to explain
+	// why it exited
+	workerStderrBytes = 4096
+
+	// workerExitTimeout is how long a worker that closed its output is
+	// given to exit before it is killed
+	workerExitTimeout = time.Second
+
+	// liveHeapMetric is the runtime metric of the heap reachable after the
+	// last GC
+	liveHeapMetric = "/gc/heap/live:bytes"
+)
+
+// WorkerConfig configures the render worker processes that compile and
+// evaluate CUE modules. Each worker runs one evaluation at a time under the
+// render budget: a worker running past the deadline is killed, and a worker
+// running out of memory exits. Either way it is replaced by a new one, so
+// a runaway module holds no resources after its evaluation failed.
+type WorkerConfig struct {
+	// Count is the number of render workers, and so of compiles and renders
+	// running at once
+	Count int
+
+	// Path is the executable started as a worker. Defaults to the running
+	// executable, which must then serve as a worker; see IsRenderWorker.
+	Path string
+
+	// Args are the arguments of the worker executable
+	Args []string
+}
+
+// IsRenderWorker reports whether the process was started as a render
+// worker
+func IsRenderWorker() bool {
+	_, ok := os.LookupEnv(RenderWorkerEnv)
+	return ok
+}
+
+// ServeRenderWorker evaluates the requests read from r and writes their
+// results to w. It limits the process's memory to the limit in
+// RenderWorkerEnv first, and exits the process once r is closed, so it must
+// only be called in a render worker.
+func ServeRenderWorker(r io.Reader, w io.Writer) error {
+	limit, err := strconv.ParseUint(os.Getenv(RenderWorkerEnv), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", RenderWorkerEnv, err)
+	}
+	if limit > 0 {
+		if err := limitMemory(limit); err != nil {
+			return fmt.Errorf("failed to limit render worker memory: %w", err)
+		}
+	}
+
+	// The manager holds the other end of r, so the worker exits as soon as
+	// r closes, even in the middle of an evaluation, and never outlives the
+	// manager. Pdeathsig would not do: it fires when the thread that started
+	// the worker exits, not the manager process.
+	requests := make(chan *evalRequest)
+	go func() {
+		decoder := json.NewDecoder(r)
+		for {
+			req := &evalRequest{}
+			if err := decoder.Decode(req); err != nil {
+				if !errors.Is(err, io.EOF) {
+					fmt.Fprintf(os.Stderr, "failed to read request: %v\n", err)
+					os.Exit(1)
+				}
+				os.Exit(0)
+			}
+			requests <- req
+		}
+	}()
+
+	cache := NewCacheWithLimit(workerCacheEntries)
+	encoder := json.NewEncoder(w)
+	for req := range requests {
+		resp := &evalResponse{}
+		result, err := runEval(cache, req)
+		if err == nil {
+			resp.Result, err = json.Marshal(result)
+		}
+		if budgetErr, ok := AsBudgetError(err); ok {
+			resp.BudgetReason, resp.Error = budgetErr.Reason, budgetErr.Message
+		} else if err != nil {
+			resp.Error = err.Error()
+		}
+		if err := encoder.Encode(resp); err != nil {
+			return fmt.Errorf("failed to write response: %w", err)
+		}
+
+		// Compiled modules count against the limit too, so they are dropped
+		// before they crowd out evaluations
+		if limit > 0 && liveHeap() > limit/2 {
+			cache.Clear()
+		}
+	}
+	return nil
+}
+
+// evalResponse is a render worker's answer to an evalRequest
+type evalResponse struct {
+	// Result is the task's result
+	Result json.RawMessage `json:"result,omitempty"`
+
+	// Error is set if the task failed
+	Error string `json:"error,omitempty"`
+
+	// BudgetReason is set if the task exceeded its budget
+	BudgetReason string `json:"budgetReason,omitempty"`
+}
+
+// err returns the error the response reports, if any
+func (r *evalResponse) err() error {
+	switch {
+	case r.BudgetReason != "":
+		return &BudgetError{Reason: r.BudgetReason, Message: r.Error}
+	case r.Error != "":
+		return errors.New(r.Error)
+	}
+	return nil
+}
+
+// workerPool runs evaluations in render worker processes, starting them
+// on demand
+type workerPool struct {
+	config WorkerConfig
+	budget RenderBudget
+
+	// slots bounds the number of workers
+	slots chan struct{}
+
+	mu   sync.Mutex
+	idle []*renderWorker
+}
+
+// newWorkerPool creates a pool of up to config.Count render workers
+func newWorkerPool(config WorkerConfig, budget RenderBudget) *workerPool {
+	return &workerPool{
+		config: config,
+		budget: budget,
+		slots:  make(chan struct{}, config.Count),
+	}
+}
+
+// eval runs a request in an idle worker. A worker that fails to answer
+// within the budget is killed.
+func (p *workerPool) eval(ctx context.Context, req *evalRequest) ([]byte, error) {
+	select {
+	case p.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer func() { <-p.slots }()
+
+	w, err := p.take()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := w.call(ctx, req, p.budget)
+	if err != nil {
+		w.kill()
+		return nil, err
+	}
+	p.put(w)
+	if err := resp.err(); err != nil {
+		return nil, err
+	}
+	return resp.Result, nil
+}
+
+// take returns an idle worker, or starts one
+func (p *workerPool) take() (*renderWorker, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for len(p.idle) > 0 {
+		w := p.idle[len(p.idle)-1]
+		p.idle = p.idle[:len(p.idle)-1]
+		if !w.hasExited() {
+			return w, nil
+		}
+	}
+	return p.start()
+}
+
+// put returns a worker to the idle workers
+func (p *workerPool) put(w *renderWorker) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.idle = append(p.idle, w)
+}
+
+// start starts a worker process. Workers get an environment of their own,
+// so they do not see the manager's configuration.
+func (p *workerPool) start() (*renderWorker, error) {
+	path := p.config.Path
+	if path == "" {
+		executable, err := os.Executable()
+		if err != nil {
+			return nil, fmt.Errorf("failed to find the render worker executable: %w", err)
+		}
+		path = executable
+	}
+
+	cmd := exec.Command(path, p.config.Args...)
+	cmd.Env = []string{fmt.Sprintf("%s=%d", RenderWorkerEnv, p.budget.MaxMemoryBytes)}
+	stderr := &headBuffer{limit: workerStderrBytes}
+	cmd.Stderr = stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start render worker: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start render worker: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start render worker: %w", err)
+	}
+	RecordRenderWorkerStarted()
+
+	w := &renderWorker{
+		cmd:     cmd,
+		encoder: json.NewEncoder(stdin),
+		decoder: json.NewDecoder(stdout),
+		stderr:  stderr,
+		exited:  make(chan struct{}),
+	}
+	go func() {
+		_ = cmd.Wait()
+		close(w.exited)
+	}()
+	return w, nil
+}
+
+// renderWorker is a render worker process
+type renderWorker struct {
+	cmd     *exec.Cmd
+	encoder *json.Encoder
+	decoder *json.Decoder
+	stderr  *headBuffer
+
+	// exited is closed once the process exited
+	exited chan struct{}
+}
+
+// call sends a request to the worker and waits for its response, until
+// ctx is done or the budget's deadline passed
+func (w *renderWorker) call(ctx context.Context, req *evalRequest, budget RenderBudget) (*evalResponse, error) {
+	resp := &evalResponse{}
+	done := make(chan error, 1)
+	go func() {
+		if err := w.encoder.Encode(req); err != nil {
+			done <- err
+			return
+		}
+		done <- w.decoder.Decode(resp)
+	}()
+
+	var timeout <-chan time.Time
+	if budget.Timeout > 0 {
+		timer := time.NewTimer(budget.Timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return nil, w.exitError(err, budget)
+		}
+		return resp, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-timeout:
+		return nil, &BudgetError{
+			Reason:  ReasonRenderTimeout,
+			Message: fmt.Sprintf("CUE evaluation did not finish within %s", budget.Timeout),
+		}
+	}
+}
+
+// exitError explains why a worker stopped answering
+func (w *renderWorker) exitError(err error, budget RenderBudget) error {
+	select {
+	case <-w.exited:
+	case <-time.After(workerExitTimeout):
+	}
+
+	// Hitting the rlimit crashes the Go runtime in various ways
+	stderr := w.stderr.String()
+	if strings.Contains(stderr, "out of memory") ||
+		(budget.MaxMemoryBytes > 0 && w.hasExited() && peakMemory(w.cmd.ProcessState) > budget.MaxMemoryBytes) {
+		return &BudgetError{
+			Reason:  ReasonRenderTooLarge,
+			Message: fmt.Sprintf("CUE evaluation used more than %d bytes of memory", budget.MaxMemoryBytes),
+		}
+	}
+	if line, _, _ := strings.Cut(strings.TrimSpace(stderr), "\n"); line != "" {
+		return fmt.Errorf("render worker failed: %s", line)
+	}
+	return fmt.Errorf("render worker failed: %w", err)
+}
+
+// kill stops the worker and waits until it exited
+func (w *renderWorker) kill() {
+	_ = w.cmd.Process.Kill()
+	<-w.exited
+}
+
+// hasExited reports whether the worker process exited
+func (w *renderWorker) hasExited() bool {
+	select {
+	case <-w.exited:
+		return true
+	default:
+		return false
+	}
+}
+
+// headBuffer keeps the first bytes written to it, where the Go runtime
+// reports why a process crashed
+type headBuffer struct {
+	mu    sync.Mutex
+	limit int
+	buf   []byte
+}
+
+func (b *headBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if room := b.limit - len(b.buf); room > 0 {
+		b.buf = append(b.buf, p[:min(room, len(p))]...)
+	}
+	return len(p), nil
+}
+
+func (b *headBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return string(b.buf)
+}
+
+// liveHeap returns the bytes of heap reachable after the last GC
+func liveHeap() uint64 {
+	sample := []metrics.Sample{{Name: liveHeapMetric}}
+	metrics.Read(sample)
+	if sample[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return sample[0].Value.Uint64()
+}
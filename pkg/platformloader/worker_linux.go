@@ -0,0 +1,29 @@
+//go:build linux
+
+package platformloader
+
+import (
+	"os"
+	"runtime/debug"
+	"syscall"
+)
+
+// limitMemory limits the data segment of the process, which holds the Go
+// heap, so allocating past the limit crashes the worker with an out of
+// memory error. The garbage collector works harder as the limit nears.
+func limitMemory(limit uint64) error {
+	rlimit := &syscall.Rlimit{Cur: limit + workerMemoryOverhead, Max: limit + workerMemoryOverhead}
+	if err := syscall.Setrlimit(syscall.RLIMIT_DATA, rlimit); err != nil {
+		return err
+	}
+	debug.SetMemoryLimit(int64(limit))
+	return nil
+}
+
+// peakMemory returns the peak resident memory of an exited process
+func peakMemory(state *os.ProcessState) uint64 {
+	if usage, ok := state.SysUsage().(*syscall.Rusage); ok && usage.Maxrss > 0 {
+		return uint64(usage.Maxrss) * 1024
+	}
+	return 0
+}
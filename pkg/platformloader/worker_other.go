@@ -0,0 +1,20 @@
+//go:build !linux
+
+package platformloader
+
+import (
+	"os"
+	"runtime/debug"
+)
+
+// limitMemory makes the garbage collector work harder as the limit nears.
+// Only Linux enforces the limit.
+func limitMemory(limit uint64) error {
+	debug.SetMemoryLimit(int64(limit))
+	return nil
+}
+
+// peakMemory is unknown outside Linux
+func peakMemory(state *os.ProcessState) uint64 {
+	return 0
+}
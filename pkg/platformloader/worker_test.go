@@ -0,0 +1,156 @@
+package platformloader
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+
+	cuembed "github.com/chazu/pequod/cue"
+)
+
+// TestMain serves as a render worker when the tests start one
+func TestMain(m *testing.M) {
+	if IsRenderWorker() {
+		if err := ServeRenderWorker(os.Stdin, os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// newWorkerLoader creates a loader evaluating modules in a render worker
+// running the test binary
+func newWorkerLoader(budget RenderBudget) *Loader {
+	return NewLoaderWithConfig(LoaderConfig{
+		EmbeddedFS:      cuembed.PlatformFS,
+		EmbeddedRootDir: cuembed.PlatformDir,
+		CacheDir:        os.TempDir(),
+		Budget:          &budget,
+		Workers:         &WorkerConfig{Count: 1, Path: os.Args[0]},
+	})
+}
+
+// rangeModule renders no nodes after evaluating a list of n elements
+func rangeModule(n int) string {
+	return fmt.Sprintf(`
+import "list"
+
+#Render: {
+	input: _
+	output: {
+		metadata: {name: "range", version: "v1"}
+		nodes: []
+		violations: [for i in list.Range(0, %d, 1) if i < 0 {severity: "Error", message: "negative"}]
+	}
+}
+`, n)
+}
+
+func TestWorkerPool_Render(t *testing.T) {
+	input := runtime.RawExtension{Raw: []byte(`{"image":"nginx:latest","port":80}`)}
+	cueRef := CueRefInput{Type: "embedded", Ref: "webservice"}
+
+	want, _, err := NewRenderer(createTestLoader()).RenderTransformWithCueRef(
+		context.Background(), "test-app", "default", input, cueRef)
+	if err != nil {
+		t.Fatalf("in-process render failed: %v", err)
+	}
+	got, _, err := NewRenderer(newWorkerLoader(DefaultRenderBudget())).RenderTransformWithCueRef(
+		context.Background(), "test-app", "default", input, cueRef)
+	if err != nil {
+		t.Fatalf("worker render failed: %v", err)
+	}
+
+	wantJSON, _ := json.Marshal(want)
+	gotJSON, _ := json.Marshal(got)
+	if string(gotJSON) != string(wantJSON) {
+		t.Errorf("worker render differs from in-process render:\ngot:  %s\nwant: %s", gotJSON, wantJSON)
+	}
+}
+
+func TestWorkerPool_Timeout(t *testing.T) {
+	renderer := NewRenderer(newWorkerLoader(RenderBudget{Timeout: 100 * time.Millisecond}))
+
+	_, _, err := renderer.RenderTransformWithCueRef(context.Background(), "slow", "default",
+		runtime.RawExtension{}, CueRefInput{Type: InlineType, Ref: rangeModule(10000000)})
+	budgetErr, ok := AsBudgetError(err)
+	if !ok || budgetErr.Reason != ReasonRenderTimeout {
+		t.Fatalf("expected a %s error, got %v", ReasonRenderTimeout, err)
+	}
+
+	// The runaway worker was replaced, so later renders are unaffected
+	if _, _, err := renderer.RenderTransformWithCueRef(context.Background(), "fast", "default",
+		runtime.RawExtension{}, CueRefInput{Type: InlineType, Ref: rangeModule(10)}); err != nil {
+		t.Fatalf("expected the next render to succeed, got %v", err)
+	}
+}
+
+func TestWorkerPool_Memory(t *testing.T) {
+	renderer := NewRenderer(newWorkerLoader(RenderBudget{Timeout: time.Minute, MaxMemoryBytes: 64 << 20}))
+
+	_, _, err := renderer.RenderTransformWithCueRef(context.Background(), "large", "default",
+		runtime.RawExtension{}, CueRefInput{Type: InlineType, Ref: rangeModule(10000000)})
+	budgetErr, ok := AsBudgetError(err)
+	if !ok || budgetErr.Reason != ReasonRenderTooLarge {
+		t.Fatalf("expected a %s error, got %v", ReasonRenderTooLarge, err)
+	}
+
+	if _, _, err := renderer.RenderTransformWithCueRef(context.Background(), "small", "default",
+		runtime.RawExtension{}, CueRefInput{Type: InlineType, Ref: rangeModule(10)}); err != nil {
+		t.Fatalf("expected the next render to succeed, got %v", err)
+	}
+}
+
+func TestWorkerPool_Error(t *testing.T) {
+	renderer := NewRenderer(newWorkerLoader(DefaultRenderBudget()))
+
+	_, _, err := renderer.RenderTransformWithCueRef(context.Background(), "broken", "default",
+		runtime.RawExtension{}, CueRefInput{Type: InlineType, Ref: "#Render: {"})
+	if err == nil {
+		t.Fatal("expected a compile error")
+	}
+	if _, ok := AsBudgetError(err); ok {
+		t.Errorf("expected a compile error, not a budget error: %v", err)
+	}
+}
+
+func TestServeRenderWorker_ExitsWhenStdinCloses(t *testing.T) {
+	cmd := exec.Command(os.Args[0])
+	cmd.Env = []string{RenderWorkerEnv + "=0"}
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		t.Fatalf("failed to create stdin pipe: %v", err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("failed to start worker: %v", err)
+	}
+
+	// Closing stdin in the middle of a long evaluation stops the worker
+	args, _ := json.Marshal(renderArgs{Input: map[string]interface{}{}})
+	req := &evalRequest{Task: renderTask, Content: []byte(rangeModule(100000000)), Inline: true, Args: args}
+	if err := json.NewEncoder(stdin).Encode(req); err != nil {
+		t.Fatalf("failed to send request: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	_ = stdin.Close()
+
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+	select {
+	case err := <-exited:
+		if err != nil {
+			t.Errorf("expected the worker to exit cleanly, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		_ = cmd.Process.Kill()
+		t.Fatal("expected the worker to exit once its stdin closed")
+	}
+}
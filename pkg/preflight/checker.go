@@ -0,0 +1,242 @@
+package preflight
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/chazu/pequod/pkg/graph"
+)
+
+// CheckType identifies which preflight check found a problem
+type CheckType string
+
+const (
+	// CheckKind verifies that the node's GVK is served by the API server
+	CheckKind CheckType = "Kind"
+
+	// CheckPermission verifies that the controller may manage the node's resource
+	CheckPermission CheckType = "Permission"
+
+	// CheckNamespace verifies that the node's namespace exists
+	CheckNamespace CheckType = "Namespace"
+)
+
+// Problem is a single preflight failure
+type Problem struct {
+	// NodeID is the graph node the problem was found on
+	NodeID string
+
+	// Check is the check that failed
+	Check CheckType
+
+	// Message describes the problem
+	Message string
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("node %s: %s", p.NodeID, p.Message)
+}
+
+// Report is the result of a preflight run
+type Report struct {
+	// Problems lists every failed check, in node order
+	Problems []Problem
+}
+
+// Passed returns true if no problems were found
+func (r *Report) Passed() bool {
+	return len(r.Problems) == 0
+}
+
+// Summary returns all problems as a single message
+func (r *Report) Summary() string {
+	msgs := make([]string, 0, len(r.Problems))
+	for _, p := range r.Problems {
+		msgs = append(msgs, p.String())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Checker runs preflight checks against the cluster
+type Checker struct {
+	client client.Client
+}
+
+// NewChecker creates a new preflight checker
+func NewChecker(c client.Client) *Checker {
+	return &Checker{
+		client: c,
+	}
+}
+
+// accessKey identifies a permission checked during a run
+type accessKey struct {
+	resource  schema.GroupResource
+	namespace string
+	verb      string
+}
+
+// Check verifies every node of the graph and returns all problems found.
+// An error is returned only if the checks themselves could not run.
+func (c *Checker) Check(ctx context.Context, g *graph.Graph) (*Report, error) {
+	report := &Report{}
+	installedKinds := crdKinds(g)
+	createdNamespaces := namespaceNodes(g)
+
+	checkedAccess := make(map[accessKey]bool)
+	checkedNamespaces := make(map[string]bool)
+
+	for _, node := range g.Nodes {
+		gvk := node.Object.GroupVersionKind()
+
+		mapping, err := c.client.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			if !meta.IsNoMatchError(err) {
+				return nil, fmt.Errorf("failed to resolve %s for node %s: %w", gvk, node.ID, err)
+			}
+			// A CRD in the same graph will install the kind before it is used
+			if !installedKinds[gvk.GroupKind()] {
+				report.Problems = append(report.Problems, Problem{
+					NodeID:  node.ID,
+					Check:   CheckKind,
+					Message: fmt.Sprintf("kind %s is not served by the API server (is its CRD installed?)", gvk),
+				})
+			}
+			continue
+		}
+
+		namespace := ""
+		if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+			namespace = node.Object.GetNamespace()
+		}
+
+		// Namespace must exist unless the graph creates it
+		if namespace != "" && !createdNamespaces[namespace] && !checkedNamespaces[namespace] {
+			checkedNamespaces[namespace] = true
+			exists, err := c.namespaceExists(ctx, namespace)
+			if err != nil {
+				return nil, err
+			}
+			if !exists {
+				report.Problems = append(report.Problems, Problem{
+					NodeID:  node.ID,
+					Check:   CheckNamespace,
+					Message: fmt.Sprintf("namespace %q does not exist", namespace),
+				})
+			}
+		}
+
+		var denied []string
+		for _, verb := range requiredVerbs(node) {
+			key := accessKey{resource: mapping.Resource.GroupResource(), namespace: namespace, verb: verb}
+			allowed, checked := checkedAccess[key]
+			if !checked {
+				allowed, err = c.canI(ctx, mapping.Resource, namespace, verb)
+				if err != nil {
+					return nil, err
+				}
+				checkedAccess[key] = allowed
+			}
+			if !allowed {
+				denied = append(denied, verb)
+			}
+		}
+		if len(denied) > 0 {
+			report.Problems = append(report.Problems, Problem{
+				NodeID: node.ID,
+				Check:  CheckPermission,
+				Message: fmt.Sprintf("controller is not allowed to %s %s%s",
+					strings.Join(denied, "/"), mapping.Resource.GroupResource(), inNamespace(namespace)),
+			})
+		}
+	}
+
+	return report, nil
+}
+
+// requiredVerbs returns the verbs used to manage a node. Hook Jobs are
+// created and deleted rather than patched.
+func requiredVerbs(node graph.Node) []string {
+	if node.Hook != nil {
+		return []string{"get", "create", "delete"}
+	}
+	verbs := []string{"get", "create", "patch"}
+	if node.ApplyPolicy.RecreateOnImmutableChange() {
+		verbs = append(verbs, "delete")
+	}
+	return verbs
+}
+
+// canI asks the API server whether the controller may perform verb on resource
+func (c *Checker) canI(ctx context.Context, gvr schema.GroupVersionResource, namespace, verb string) (bool, error) {
+	review := &authorizationv1.SelfSubjectAccessReview{
+		Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: namespace,
+				Verb:      verb,
+				Group:     gvr.Group,
+				Version:   gvr.Version,
+				Resource:  gvr.Resource,
+			},
+		},
+	}
+	if err := c.client.Create(ctx, review); err != nil {
+		return false, fmt.Errorf("failed to review access to %s %s: %w", verb, gvr.GroupResource(), err)
+	}
+	return review.Status.Allowed, nil
+}
+
+// namespaceExists reports whether the namespace exists
+func (c *Checker) namespaceExists(ctx context.Context, name string) (bool, error) {
+	ns := &corev1.Namespace{}
+	if err := c.client.Get(ctx, client.ObjectKey{Name: name}, ns); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get namespace %s: %w", name, err)
+	}
+	return true, nil
+}
+
+// crdKinds returns the kinds defined by CustomResourceDefinition nodes in the graph
+func crdKinds(g *graph.Graph) map[schema.GroupKind]bool {
+	kinds := make(map[schema.GroupKind]bool)
+	for _, node := range g.Nodes {
+		if node.Object.GroupVersionKind().GroupKind() != (schema.GroupKind{Group: "apiextensions.k8s.io", Kind: "CustomResourceDefinition"}) {
+			continue
+		}
+		group, _, _ := unstructured.NestedString(node.Object.Object, "spec", "group")
+		kind, _, _ := unstructured.NestedString(node.Object.Object, "spec", "names", "kind")
+		kinds[schema.GroupKind{Group: group, Kind: kind}] = true
+	}
+	return kinds
+}
+
+// namespaceNodes returns the namespaces created by the graph itself
+func namespaceNodes(g *graph.Graph) map[string]bool {
+	namespaces := make(map[string]bool)
+	for _, node := range g.Nodes {
+		gvk := node.Object.GroupVersionKind()
+		if gvk.Group == "" && gvk.Kind == "Namespace" {
+			namespaces[node.Object.GetName()] = true
+		}
+	}
+	return namespaces
+}
+
+// inNamespace formats a namespace suffix for messages
+func inNamespace(namespace string) string {
+	if namespace == "" {
+		return ""
+	}
+	return fmt.Sprintf(" in namespace %q", namespace)
+}
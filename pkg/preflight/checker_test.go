@@ -0,0 +1,139 @@
+package preflight
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/chazu/pequod/pkg/graph"
+)
+
+func newRESTMapper() meta.RESTMapper {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}, meta.RESTScopeRoot)
+	mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"}, meta.RESTScopeRoot)
+	mapper.Add(schema.GroupVersionKind{Group: "apiextensions.k8s.io", Version: "v1", Kind: "CustomResourceDefinition"}, meta.RESTScopeRoot)
+	return mapper
+}
+
+// newTestClient returns a client whose access reviews deny the given
+// "verb resource" pairs and allow everything else
+func newTestClient(denied ...string) client.Client {
+	return fake.NewClientBuilder().
+		WithRESTMapper(newRESTMapper()).
+		WithObjects(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}}).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+				review, ok := obj.(*authorizationv1.SelfSubjectAccessReview)
+				if !ok {
+					return c.Create(ctx, obj, opts...)
+				}
+				attrs := review.Spec.ResourceAttributes
+				review.Status.Allowed = true
+				for _, d := range denied {
+					if d == attrs.Verb+" "+attrs.Resource {
+						review.Status.Allowed = false
+					}
+				}
+				return nil
+			},
+		}).Build()
+}
+
+func newNode(id, apiVersion, kind, namespace string) graph.Node {
+	obj := unstructured.Unstructured{Object: map[string]interface{}{}}
+	obj.SetAPIVersion(apiVersion)
+	obj.SetKind(kind)
+	obj.SetName(id)
+	if namespace != "" {
+		obj.SetNamespace(namespace)
+	}
+	return graph.Node{ID: id, Object: obj}
+}
+
+func TestChecker_Passes(t *testing.T) {
+	g := &graph.Graph{Nodes: []graph.Node{
+		newNode("config", "v1", "ConfigMap", "default"),
+		newNode("deployment", "apps/v1", "Deployment", "default"),
+		newNode("role", "rbac.authorization.k8s.io/v1", "ClusterRole", ""),
+	}}
+
+	report, err := NewChecker(newTestClient()).Check(context.Background(), g)
+	if err != nil {
+		t.Fatalf("Check() failed: %v", err)
+	}
+	if !report.Passed() {
+		t.Errorf("expected preflight to pass, got %s", report.Summary())
+	}
+}
+
+func TestChecker_ReportsEveryProblem(t *testing.T) {
+	g := &graph.Graph{Nodes: []graph.Node{
+		newNode("widget", "example.com/v1", "Widget", "default"),
+		newNode("config", "v1", "ConfigMap", "missing"),
+		newNode("deployment", "apps/v1", "Deployment", "default"),
+		newNode("other-deployment", "apps/v1", "Deployment", "default"),
+	}}
+
+	report, err := NewChecker(newTestClient("patch deployments")).Check(context.Background(), g)
+	if err != nil {
+		t.Fatalf("Check() failed: %v", err)
+	}
+
+	want := []struct {
+		nodeID string
+		check  CheckType
+	}{
+		{"widget", CheckKind},
+		{"config", CheckNamespace},
+		{"deployment", CheckPermission},
+		{"other-deployment", CheckPermission},
+	}
+	if len(report.Problems) != len(want) {
+		t.Fatalf("expected %d problems, got %d: %s", len(want), len(report.Problems), report.Summary())
+	}
+	for i, w := range want {
+		if report.Problems[i].NodeID != w.nodeID || report.Problems[i].Check != w.check {
+			t.Errorf("problem[%d] = %+v, want node %s check %s", i, report.Problems[i], w.nodeID, w.check)
+		}
+	}
+	if !strings.Contains(report.Problems[2].Message, "patch") {
+		t.Errorf("expected denied verb in message, got %q", report.Problems[2].Message)
+	}
+}
+
+func TestChecker_AllowsPrerequisitesCreatedByGraph(t *testing.T) {
+	crd := newNode("widget-crd", "apiextensions.k8s.io/v1", "CustomResourceDefinition", "")
+	_ = unstructured.SetNestedField(crd.Object.Object, "example.com", "spec", "group")
+	_ = unstructured.SetNestedField(crd.Object.Object, "Widget", "spec", "names", "kind")
+
+	ns := newNode("team-ns", "v1", "Namespace", "")
+	ns.Object.SetName("team")
+
+	g := &graph.Graph{Nodes: []graph.Node{
+		crd,
+		ns,
+		newNode("widget", "example.com/v1", "Widget", "default"),
+		newNode("config", "v1", "ConfigMap", "team"),
+	}}
+
+	report, err := NewChecker(newTestClient()).Check(context.Background(), g)
+	if err != nil {
+		t.Fatalf("Check() failed: %v", err)
+	}
+	if !report.Passed() {
+		t.Errorf("expected kinds and namespaces created by the graph to pass, got %s", report.Summary())
+	}
+}
@@ -0,0 +1,5 @@
+// Package preflight verifies that a resource graph can be applied before any
+// of it is: every kind is installed, the controller is allowed to manage it,
+// and every target namespace exists. All problems are reported together so a
+// graph is never left half-applied because of a missing prerequisite.
+package preflight
@@ -99,6 +99,24 @@ func (p *ExistsPredicate) Evaluate(ctx context.Context, c client.Client, obj *un
 	return true, nil
 }
 
+// InstanceReadyPredicate checks if a platform instance is Ready for its
+// current generation, i.e. its own ResourceGraph completed after the last
+// spec change
+type InstanceReadyPredicate struct{}
+
+// Evaluate checks the instance's Ready condition and observed generation
+func (p *InstanceReadyPredicate) Evaluate(ctx context.Context, c client.Client, obj *unstructured.Unstructured) (bool, error) {
+	observed, _, err := unstructured.NestedInt64(obj.Object, "status", "observedGeneration")
+	if err != nil {
+		return false, fmt.Errorf("invalid status.observedGeneration: %w", err)
+	}
+	if observed < obj.GetGeneration() {
+		return false, nil
+	}
+	ready := &ConditionMatchPredicate{ConditionType: "Ready", ConditionStatus: "True"}
+	return ready.Evaluate(ctx, c, obj)
+}
+
 // NewEvaluator creates an Evaluator from a predicate type and parameters
 func NewEvaluator(predicateType, conditionType, conditionStatus string) (Evaluator, error) {
 	switch predicateType {
@@ -120,6 +138,9 @@ func NewEvaluator(predicateType, conditionType, conditionStatus string) (Evaluat
 	case "Exists":
 		return &ExistsPredicate{}, nil
 
+	case "InstanceReady":
+		return &InstanceReadyPredicate{}, nil
+
 	default:
 		return nil, fmt.Errorf("unknown predicate type: %s", predicateType)
 	}
@@ -260,3 +260,52 @@ func TestExistsPredicate(t *testing.T) {
 		})
 	}
 }
+
+func TestInstanceReadyPredicate(t *testing.T) {
+	instance := func(generation, observed int64, ready string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "apps.example.com/v1alpha1",
+			"kind":       "Database",
+			"metadata": map[string]interface{}{
+				"name":       "orders-db",
+				"namespace":  "default",
+				"generation": generation,
+			},
+			"status": map[string]interface{}{
+				"observedGeneration": observed,
+				"conditions": []interface{}{
+					map[string]interface{}{"type": "Ready", "status": ready},
+				},
+			},
+		}}
+	}
+
+	tests := []struct {
+		name      string
+		obj       *unstructured.Unstructured
+		wantReady bool
+	}{
+		{name: "ready for current generation", obj: instance(2, 2, "True"), wantReady: true},
+		{name: "ready for an older generation", obj: instance(3, 2, "True"), wantReady: false},
+		{name: "not ready", obj: instance(2, 2, "False"), wantReady: false},
+		{
+			name: "no status",
+			obj: &unstructured.Unstructured{Object: map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "orders-db", "generation": int64(1)},
+			}},
+			wantReady: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ready, err := (&InstanceReadyPredicate{}).Evaluate(context.Background(), fake.NewClientBuilder().Build(), tt.obj)
+			if err != nil {
+				t.Fatalf("Evaluate() error = %v", err)
+			}
+			if ready != tt.wantReady {
+				t.Errorf("Evaluate() ready = %v, want %v", ready, tt.wantReady)
+			}
+		})
+	}
+}
@@ -0,0 +1,268 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconcile
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	platformv1alpha1 "github.com/chazu/pequod/api/v1alpha1"
+	"github.com/chazu/pequod/pkg/graph"
+)
+
+// ChildDeletionInterval is how often the deletion of an instance checks
+// whether its child instances are gone
+const ChildDeletionInterval = 5 * time.Second
+
+// CompositeCycleError reports a module rendering an instance of its own
+// kind, or of the kind of an instance whose graph contains it
+type CompositeCycleError struct {
+	// Kinds lists the kinds from the outermost instance in the cycle to the
+	// rendered node repeating it
+	Kinds []schema.GroupKind
+}
+
+func (e *CompositeCycleError) Error() string {
+	names := make([]string, len(e.Kinds))
+	for i, gk := range e.Kinds {
+		names[i] = gk.Kind
+	}
+	return fmt.Sprintf("composite instances form a cycle: %s", strings.Join(names, " -> "))
+}
+
+// generatedKinds returns the kinds generated by Transforms
+func generatedKinds(ctx context.Context, c client.Client) (map[schema.GroupKind]bool, error) {
+	transforms := &platformv1alpha1.TransformList{}
+	if err := c.List(ctx, transforms); err != nil {
+		return nil, fmt.Errorf("failed to list Transforms: %w", err)
+	}
+	kinds := make(map[schema.GroupKind]bool, len(transforms.Items))
+	for _, tf := range transforms.Items {
+		generated := tf.Status.GeneratedCRD
+		if generated == nil {
+			continue
+		}
+		gv, err := schema.ParseGroupVersion(generated.APIVersion)
+		if err != nil {
+			continue
+		}
+		kinds[schema.GroupKind{Group: gv.Group, Kind: generated.Kind}] = true
+	}
+	return kinds, nil
+}
+
+// markInstanceNodes marks the nodes of g whose objects are platform
+// instances. Instance nodes without readiness predicates are ready once the
+// instance is.
+func markInstanceNodes(g *graph.Graph, kinds map[schema.GroupKind]bool) {
+	for i := range g.Nodes {
+		node := &g.Nodes[i]
+		node.Instance = node.Hook == nil && kinds[node.Object.GroupVersionKind().GroupKind()]
+		if node.Instance && len(node.ReadyWhen) == 0 {
+			node.ReadyWhen = []graph.ReadinessPredicate{{Type: graph.PredicateTypeInstanceReady}}
+		}
+	}
+}
+
+// checkCompositeCycle returns a CompositeCycleError if g renders an
+// instance of the kind of instance or of one of its ancestors
+func checkCompositeCycle(ctx context.Context, c client.Client, instance *unstructured.Unstructured, g *graph.Graph) error {
+	var ancestry []schema.GroupKind
+	for _, node := range g.Nodes {
+		if !node.Instance {
+			continue
+		}
+		if ancestry == nil {
+			var err error
+			if ancestry, err = compositeAncestry(ctx, c, instance); err != nil {
+				return err
+			}
+		}
+		gk := node.Object.GroupVersionKind().GroupKind()
+		for i, ancestor := range ancestry {
+			if ancestor == gk {
+				kinds := append(append([]schema.GroupKind{}, ancestry[i:]...), gk)
+				return &CompositeCycleError{Kinds: kinds}
+			}
+		}
+	}
+	return nil
+}
+
+// compositeAncestry returns the kinds of the instances whose graphs contain
+// instance, outermost first, followed by its own kind. An instance's parent
+// is the instance controlling it, or for instances applied before children
+// were controlled by their parent, the source of the ResourceGraph
+// controlling it.
+func compositeAncestry(ctx context.Context, c client.Client, instance *unstructured.Unstructured) ([]schema.GroupKind, error) {
+	kinds := []schema.GroupKind{instance.GroupVersionKind().GroupKind()}
+	seen := map[types.UID]bool{instance.GetUID(): true}
+
+	current := instance
+	for {
+		owner := metav1.GetControllerOf(current)
+		if owner == nil {
+			return kinds, nil
+		}
+		source := platformv1alpha1.ObjectReference{
+			APIVersion: owner.APIVersion,
+			Kind:       owner.Kind,
+			Name:       owner.Name,
+			Namespace:  current.GetNamespace(),
+		}
+		if owner.Kind == "ResourceGraph" && owner.APIVersion == platformv1alpha1.GroupVersion.String() {
+			rg := &platformv1alpha1.ResourceGraph{}
+			if err := c.Get(ctx, types.NamespacedName{Namespace: current.GetNamespace(), Name: owner.Name}, rg); err != nil {
+				if apierrors.IsNotFound(err) {
+					return kinds, nil
+				}
+				return nil, fmt.Errorf("failed to get parent ResourceGraph %s: %w", owner.Name, err)
+			}
+			source = rg.Spec.SourceRef
+		}
+
+		gv, err := schema.ParseGroupVersion(source.APIVersion)
+		if err != nil {
+			return nil, fmt.Errorf("invalid parent apiVersion %q of %s: %w", source.APIVersion, current.GetName(), err)
+		}
+		parent := &unstructured.Unstructured{}
+		parent.SetGroupVersionKind(gv.WithKind(source.Kind))
+		if err := c.Get(ctx, types.NamespacedName{Namespace: source.Namespace, Name: source.Name}, parent); err != nil {
+			if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+				return kinds, nil
+			}
+			return nil, fmt.Errorf("failed to get parent %s %s: %w", source.Kind, source.Name, err)
+		}
+		if seen[parent.GetUID()] {
+			return kinds, nil
+		}
+		seen[parent.GetUID()] = true
+
+		kinds = append([]schema.GroupKind{parent.GroupVersionKind().GroupKind()}, kinds...)
+		current = parent
+	}
+}
+
+// childInstance is an instance node of a parent's ResourceGraphs
+type childInstance struct {
+	obj *unstructured.Unstructured
+
+	// dependsOn holds the keys of the child instances this one depends on,
+	// directly or through other nodes
+	dependsOn map[string]bool
+}
+
+// deleteChildInstances deletes the instances rendered into rgs, dependents
+// before the instances they depend on, so that each tears down its own
+// resources first. Returns the number of children that still exist.
+func (h *InstanceHandlers) deleteChildInstances(ctx context.Context, rgs []platformv1alpha1.ResourceGraph) (int, error) {
+	children := map[string]*childInstance{}
+	for i := range rgs {
+		if err := collectChildInstances(&rgs[i], children); err != nil {
+			return 0, err
+		}
+	}
+
+	// Drop the children that are already gone
+	for key, child := range children {
+		if err := h.client.Get(ctx, client.ObjectKeyFromObject(child.obj), child.obj); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return 0, fmt.Errorf("failed to get child %s: %w", key, err)
+			}
+			delete(children, key)
+		}
+	}
+
+	for key, child := range children {
+		if !child.obj.GetDeletionTimestamp().IsZero() {
+			continue
+		}
+		required := false
+		for _, other := range children {
+			if other.dependsOn[key] {
+				required = true
+				break
+			}
+		}
+		if required {
+			continue
+		}
+		if err := h.client.Delete(ctx, child.obj); client.IgnoreNotFound(err) != nil {
+			return 0, fmt.Errorf("failed to delete child %s: %w", key, err)
+		}
+	}
+	return len(children), nil
+}
+
+// collectChildInstances adds the instance nodes of rg to children, keyed by
+// kind, namespace and name
+func collectChildInstances(rg *platformv1alpha1.ResourceGraph, children map[string]*childInstance) error {
+	nodes, err := rg.Spec.GetNodes()
+	if err != nil {
+		return fmt.Errorf("failed to read nodes of ResourceGraph %s: %w", rg.Name, err)
+	}
+
+	byID := make(map[string]*platformv1alpha1.ResourceNode, len(nodes))
+	keys := make(map[string]string, len(nodes))
+	for i := range nodes {
+		node := &nodes[i]
+		byID[node.ID] = node
+		if !node.Instance {
+			continue
+		}
+		obj := &unstructured.Unstructured{}
+		if err := obj.UnmarshalJSON(node.Object.Raw); err != nil {
+			return fmt.Errorf("failed to decode node %s: %w", node.ID, err)
+		}
+		if obj.GetNamespace() == "" {
+			obj.SetNamespace(rg.Namespace)
+		}
+		key := fmt.Sprintf("%s %s/%s", obj.GroupVersionKind().GroupKind(), obj.GetNamespace(), obj.GetName())
+		keys[node.ID] = key
+		if children[key] == nil {
+			children[key] = &childInstance{obj: obj, dependsOn: map[string]bool{}}
+		}
+	}
+
+	for id, key := range keys {
+		visited := map[string]bool{id: true}
+		pending := append([]string{}, byID[id].DependsOn...)
+		for len(pending) > 0 {
+			dep := pending[0]
+			pending = pending[1:]
+			if visited[dep] || byID[dep] == nil {
+				continue
+			}
+			visited[dep] = true
+			if depKey, ok := keys[dep]; ok {
+				children[key].dependsOn[depKey] = true
+			}
+			pending = append(pending, byID[dep].DependsOn...)
+		}
+	}
+	return nil
+}
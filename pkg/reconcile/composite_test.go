@@ -0,0 +1,304 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconcile
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	platformv1alpha1 "github.com/chazu/pequod/api/v1alpha1"
+	"github.com/chazu/pequod/pkg/graph"
+)
+
+// instanceNode returns a graph node holding an instance of kind
+func instanceNode(id, kind string) graph.Node {
+	return graph.Node{ID: id, Object: *newInstance(kind, id, nil)}
+}
+
+func TestMarkInstanceNodes(t *testing.T) {
+	kinds := map[schema.GroupKind]bool{
+		{Group: "apps.example.com", Kind: "Database"}:   true,
+		{Group: "apps.example.com", Kind: "WebService"}: true,
+	}
+	custom := []graph.ReadinessPredicate{{Type: graph.PredicateTypeExists}}
+
+	configMap := graph.Node{ID: "config"}
+	configMap.Object.SetAPIVersion("v1")
+	configMap.Object.SetKind("ConfigMap")
+	web := instanceNode("web", "WebService")
+	web.ReadyWhen = custom
+	g := &graph.Graph{Nodes: []graph.Node{instanceNode("db", "Database"), web, configMap}}
+
+	markInstanceNodes(g, kinds)
+
+	if !g.Nodes[0].Instance || len(g.Nodes[0].ReadyWhen) != 1 || g.Nodes[0].ReadyWhen[0].Type != graph.PredicateTypeInstanceReady {
+		t.Errorf("expected db to be an instance ready with InstanceReady, got %+v", g.Nodes[0])
+	}
+	if !g.Nodes[1].Instance || g.Nodes[1].ReadyWhen[0].Type != graph.PredicateTypeExists {
+		t.Errorf("expected web to keep its own readiness predicates, got %+v", g.Nodes[1])
+	}
+	if g.Nodes[2].Instance || len(g.Nodes[2].ReadyWhen) != 0 {
+		t.Errorf("expected the ConfigMap not to be an instance, got %+v", g.Nodes[2])
+	}
+}
+
+func TestCheckCompositeCycle(t *testing.T) {
+	app := newInstance("Application", "shop", nil)
+	app.SetUID("app-uid")
+	appGraph := &platformv1alpha1.ResourceGraph{
+		ObjectMeta: metav1.ObjectMeta{Name: "shop-abc12345", Namespace: "default", UID: "rg-uid"},
+		Spec: platformv1alpha1.ResourceGraphSpec{
+			SourceRef: platformv1alpha1.ObjectReference{
+				APIVersion: "apps.example.com/v1alpha1",
+				Kind:       "Application",
+				Name:       "shop",
+				Namespace:  "default",
+			},
+		},
+	}
+	web := newInstance("WebService", "shop-web", nil)
+	web.SetUID("web-uid")
+	web.SetOwnerReferences([]metav1.OwnerReference{{
+		APIVersion: platformv1alpha1.GroupVersion.String(),
+		Kind:       "ResourceGraph",
+		Name:       appGraph.Name,
+		UID:        appGraph.UID,
+		Controller: boolPtr(true),
+	}})
+	// Children applied since are controlled by their parent instance
+	cache := newInstance("Cache", "shop-cache", nil)
+	cache.SetUID("cache-uid")
+	cache.SetOwnerReferences([]metav1.OwnerReference{{
+		APIVersion: "apps.example.com/v1alpha1",
+		Kind:       "Application",
+		Name:       app.GetName(),
+		UID:        app.GetUID(),
+		Controller: boolPtr(true),
+	}})
+	c := fake.NewClientBuilder().WithScheme(newTestScheme()).WithObjects(app, appGraph, web, cache).Build()
+
+	tests := []struct {
+		name      string
+		instance  *unstructured.Unstructured
+		nodes     []graph.Node
+		wantCycle string
+	}{
+		{
+			name:     "child of a different kind",
+			instance: web,
+			nodes:    []graph.Node{instanceNode("db", "Database")},
+		},
+		{
+			name:      "child of the parent's kind",
+			instance:  web,
+			nodes:     []graph.Node{instanceNode("app", "Application")},
+			wantCycle: "composite instances form a cycle: Application -> WebService -> Application",
+		},
+		{
+			name:      "child controlled by its parent instance",
+			instance:  cache,
+			nodes:     []graph.Node{instanceNode("app", "Application")},
+			wantCycle: "composite instances form a cycle: Application -> Cache -> Application",
+		},
+		{
+			name:      "child of its own kind",
+			instance:  app,
+			nodes:     []graph.Node{instanceNode("nested", "Application")},
+			wantCycle: "composite instances form a cycle: Application -> Application",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &graph.Graph{Nodes: tt.nodes}
+			for i := range g.Nodes {
+				g.Nodes[i].Instance = true
+			}
+			err := checkCompositeCycle(context.Background(), c, tt.instance, g)
+
+			var cycle *CompositeCycleError
+			switch {
+			case tt.wantCycle == "" && err != nil:
+				t.Errorf("expected no cycle, got %v", err)
+			case tt.wantCycle != "" && !errors.As(err, &cycle):
+				t.Errorf("expected a CompositeCycleError, got %v", err)
+			case tt.wantCycle != "" && err.Error() != tt.wantCycle:
+				t.Errorf("expected %q, got %q", tt.wantCycle, err.Error())
+			}
+		})
+	}
+}
+
+func TestNodeOwnerReference_ReRender(t *testing.T) {
+	parent := metav1.OwnerReference{
+		APIVersion: "apps.example.com/v1alpha1",
+		Kind:       "Application",
+		Name:       "shop",
+		UID:        "app-uid",
+		Controller: boolPtr(true),
+	}
+	revision := func(name string) *platformv1alpha1.ResourceGraph {
+		return &platformv1alpha1.ResourceGraph{ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       "default",
+			UID:             types.UID(name + "-uid"),
+			OwnerReferences: []metav1.OwnerReference{parent},
+		}}
+	}
+	child := &platformv1alpha1.ResourceNode{ID: "db", Instance: true}
+	config := &platformv1alpha1.ResourceNode{ID: "config"}
+
+	// The parent is re-rendered: the first revision is deleted once the
+	// second one is created, before it applied its nodes
+	first, second := revision("shop-aaaaaaaa"), revision("shop-bbbbbbbb")
+	childOwner := NodeOwnerReference(first, child)
+	configOwner := NodeOwnerReference(first, config)
+	live := map[types.UID]bool{parent.UID: true, second.UID: true}
+
+	if !live[childOwner.UID] || childOwner.Kind != "Application" || !*childOwner.Controller {
+		t.Errorf("expected the child instance to be controlled by the parent instance, got %+v", childOwner)
+	}
+	if !reflect.DeepEqual(NodeOwnerReference(second, child), childOwner) {
+		t.Error("expected the child instance to keep its owner across revisions")
+	}
+	secret := &platformv1alpha1.ResourceNode{ID: "password", Generate: &platformv1alpha1.GeneratedSecret{}}
+	if owner := NodeOwnerReference(first, secret); !reflect.DeepEqual(owner, childOwner) {
+		t.Errorf("expected the generated Secret to be controlled by the parent instance, got %+v", owner)
+	}
+	if live[configOwner.UID] || configOwner.Kind != "ResourceGraph" {
+		t.Errorf("expected other nodes to be controlled by their revision, got %+v", configOwner)
+	}
+
+	// Without a controller, a ResourceGraph owns all of its nodes
+	standalone := revision("standalone")
+	standalone.OwnerReferences = nil
+	if owner := NodeOwnerReference(standalone, child); owner.UID != standalone.UID {
+		t.Errorf("expected the standalone ResourceGraph to own the node, got %+v", owner)
+	}
+}
+
+func TestDeleteChildInstances(t *testing.T) {
+	node := func(id, kind string, dependsOn ...string) platformv1alpha1.ResourceNode {
+		raw, _ := json.Marshal(newInstance(kind, id, nil).Object)
+		return platformv1alpha1.ResourceNode{
+			ID:        id,
+			Object:    runtime.RawExtension{Raw: raw},
+			DependsOn: dependsOn,
+			Instance:  kind != "ConfigMap",
+		}
+	}
+	rg := platformv1alpha1.ResourceGraph{ObjectMeta: metav1.ObjectMeta{Name: "shop-abc12345", Namespace: "default"}}
+	// web depends on db through the config node
+	if err := rg.Spec.SetNodes([]platformv1alpha1.ResourceNode{
+		node("db", "Database"),
+		node("config", "ConfigMap", "db"),
+		node("web", "WebService", "config"),
+	}); err != nil {
+		t.Fatalf("failed to set nodes: %v", err)
+	}
+
+	c := fake.NewClientBuilder().WithScheme(newTestScheme()).
+		WithObjects(newInstance("Database", "db", nil), newInstance("WebService", "web", nil)).
+		Build()
+	h := &InstanceHandlers{client: c}
+	exists := func(kind, name string) bool {
+		err := c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: name}, newInstance(kind, name, nil))
+		return err == nil
+	}
+
+	for _, step := range []struct {
+		wantRemaining int
+		wantDB        bool
+		wantWeb       bool
+	}{
+		{wantRemaining: 2, wantDB: true, wantWeb: false},
+		{wantRemaining: 1, wantDB: false, wantWeb: false},
+		{wantRemaining: 0, wantDB: false, wantWeb: false},
+	} {
+		remaining, err := h.deleteChildInstances(context.Background(), []platformv1alpha1.ResourceGraph{rg})
+		if err != nil {
+			t.Fatalf("failed to delete children: %v", err)
+		}
+		if remaining != step.wantRemaining {
+			t.Errorf("expected %d remaining children, got %d", step.wantRemaining, remaining)
+		}
+		if exists("Database", "db") != step.wantDB || exists("WebService", "web") != step.wantWeb {
+			t.Errorf("expected db exists=%v and web exists=%v after %d remaining",
+				step.wantDB, step.wantWeb, remaining)
+		}
+	}
+}
+
+func TestCompositeRevisions_SameName(t *testing.T) {
+	revision := func(kind, name string, nodes ...platformv1alpha1.ResourceNode) *platformv1alpha1.ResourceGraph {
+		rg := newCompletedRevision(kind, "shop")
+		rg.Name = name
+		if err := rg.Spec.SetNodes(nodes); err != nil {
+			t.Fatalf("failed to set nodes: %v", err)
+		}
+		return rg
+	}
+	raw, _ := json.Marshal(newInstance("Database", "shop-db", nil).Object)
+	db := platformv1alpha1.ResourceNode{ID: "db", Object: runtime.RawExtension{Raw: raw}, Instance: true}
+
+	// The parent Application and its child WebService are both named shop
+	parent := revision("Application", "shop-aaaaaaaa", db)
+	child := newInstance("WebService", "shop", nil)
+	child.SetFinalizers([]string{InstanceFinalizer})
+	c := fake.NewClientBuilder().WithScheme(newTestScheme()).
+		WithObjects(parent, revision("WebService", "shop-bbbbbbbb"), child, newInstance("Database", "shop-db", nil)).
+		WithStatusSubresource(&platformv1alpha1.ResourceGraph{}).
+		Build()
+	h := &InstanceHandlers{client: c, refs: NewInstanceRefIndex()}
+	exists := func(obj client.Object) bool {
+		return c.Get(context.Background(), client.ObjectKeyFromObject(obj), obj) == nil
+	}
+
+	if err := h.applyResourceGraph(context.Background(), revision("WebService", "shop-cccccccc"), false); err != nil {
+		t.Fatalf("applyResourceGraph() failed: %v", err)
+	}
+	if !exists(&platformv1alpha1.ResourceGraph{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "shop-aaaaaaaa"}}) {
+		t.Error("expected the child's new revision to keep the parent's ResourceGraph")
+	}
+	if exists(&platformv1alpha1.ResourceGraph{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "shop-bbbbbbbb"}}) {
+		t.Error("expected the child's old revision to be deleted")
+	}
+
+	if _, err := h.handleDeletion(context.Background(), child); err != nil {
+		t.Fatalf("handleDeletion() failed: %v", err)
+	}
+	if !exists(&platformv1alpha1.ResourceGraph{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "shop-aaaaaaaa"}}) {
+		t.Error("expected deleting the child to keep the parent's ResourceGraph")
+	}
+	if !exists(newInstance("Database", "shop-db", nil)) {
+		t.Error("expected deleting the child to keep the parent's other children")
+	}
+	if exists(&platformv1alpha1.ResourceGraph{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "shop-cccccccc"}}) {
+		t.Error("expected deleting the child to delete its own revision")
+	}
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconcile
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	platformv1alpha1 "github.com/chazu/pequod/api/v1alpha1"
+	"github.com/chazu/pequod/pkg/platformloader"
+)
+
+// ConversionHandler serves the conversion webhook of generated CRDs with
+// several versions. An instance's spec is converted with the #Convert
+// definition of the module the instance renders. A pair of versions the
+// module declares no conversion for keeps the spec as is if the versions'
+// spec schemas are the same, and fails otherwise, since the API server would
+// prune the fields the target version lacks. Metadata and status are kept
+// as is.
+type ConversionHandler struct {
+	client   client.Client
+	renderer *platformloader.Renderer
+}
+
+// NewConversionHandler creates a new ConversionHandler
+func NewConversionHandler(c client.Client, renderer *platformloader.Renderer) *ConversionHandler {
+	return &ConversionHandler{client: c, renderer: renderer}
+}
+
+// ServeHTTP handles a ConversionReview
+func (h *ConversionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	review := &apiextensionsv1.ConversionReview{}
+	if err := json.NewDecoder(r.Body).Decode(review); err != nil || review.Request == nil {
+		http.Error(w, "expected a ConversionReview request", http.StatusBadRequest)
+		return
+	}
+
+	response := &apiextensionsv1.ConversionResponse{UID: review.Request.UID}
+	converted, err := h.Convert(r.Context(), review.Request.Objects, review.Request.DesiredAPIVersion)
+	if err != nil {
+		log.FromContext(r.Context()).Error(err, "Failed to convert instances",
+			"desiredAPIVersion", review.Request.DesiredAPIVersion)
+		response.Result = metav1.Status{Status: metav1.StatusFailure, Message: err.Error()}
+	} else {
+		response.ConvertedObjects = converted
+		response.Result = metav1.Status{Status: metav1.StatusSuccess}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(&apiextensionsv1.ConversionReview{
+		TypeMeta: review.TypeMeta,
+		Response: response,
+	}); err != nil {
+		log.FromContext(r.Context()).Error(err, "Failed to write ConversionReview response")
+	}
+}
+
+// Convert converts instances to the desired API version
+func (h *ConversionHandler) Convert(
+	ctx context.Context, objects []runtime.RawExtension, desiredAPIVersion string,
+) ([]runtime.RawExtension, error) {
+	desired, err := schema.ParseGroupVersion(desiredAPIVersion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid desired API version %q: %w", desiredAPIVersion, err)
+	}
+
+	converted := make([]runtime.RawExtension, 0, len(objects))
+	for _, object := range objects {
+		instance := &unstructured.Unstructured{}
+		if err := instance.UnmarshalJSON(object.Raw); err != nil {
+			return nil, fmt.Errorf("failed to decode object: %w", err)
+		}
+		if err := h.convertInstance(ctx, instance, desired); err != nil {
+			return nil, fmt.Errorf("failed to convert %s %s/%s to %s: %w",
+				instance.GetKind(), instance.GetNamespace(), instance.GetName(), desiredAPIVersion, err)
+		}
+		raw, err := instance.MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+		converted = append(converted, runtime.RawExtension{Raw: raw})
+	}
+	return converted, nil
+}
+
+// convertInstance converts an instance to the desired version in place
+func (h *ConversionHandler) convertInstance(ctx context.Context, instance *unstructured.Unstructured, desired schema.GroupVersion) error {
+	gvk := instance.GroupVersionKind()
+	if gvk.Group != desired.Group {
+		return fmt.Errorf("cannot convert between groups %s and %s", gvk.Group, desired.Group)
+	}
+	if gvk.Version == desired.Version {
+		return nil
+	}
+
+	transform, err := findTransformForGroupKind(ctx, h.client, gvk.GroupKind())
+	if err != nil {
+		return err
+	}
+	cueRef, err := instanceCueRef(transform, instance)
+	if err != nil {
+		// Instances of an unknown channel are not rendered, but must
+		// stay readable
+		cueRef = transformCueRef(transform)
+	}
+
+	spec, _, err := unstructured.NestedMap(instance.Object, "spec")
+	if err != nil {
+		return fmt.Errorf("failed to get spec: %w", err)
+	}
+	if spec != nil {
+		convertedSpec, found, err := h.renderer.ConvertSpec(ctx, instance.GetNamespace(), cueRef, gvk.Version, desired.Version, spec)
+		if err != nil {
+			return err
+		}
+		if !found {
+			if err := h.checkSameSchema(ctx, transform, gvk.Version, desired.Version); err != nil {
+				return err
+			}
+		} else if err := unstructured.SetNestedMap(instance.Object, convertedSpec, "spec"); err != nil {
+			return err
+		}
+	}
+	instance.SetAPIVersion(desired.String())
+	return nil
+}
+
+// checkSameSchema returns an error unless the spec schemas of two versions
+// of a Transform's generated CRD are the same, so a spec can be kept as is
+// between them
+func (h *ConversionHandler) checkSameSchema(ctx context.Context, transform *platformv1alpha1.Transform, from, to string) error {
+	definition := &apiextensionsv1.CustomResourceDefinition{}
+	if err := h.client.Get(ctx, client.ObjectKey{Name: transform.Status.GeneratedCRD.Name}, definition); err != nil {
+		return fmt.Errorf("failed to get CRD %s: %w", transform.Status.GeneratedCRD.Name, err)
+	}
+	schemas := make(map[string]*apiextensionsv1.JSONSchemaProps, len(definition.Spec.Versions))
+	for _, v := range definition.Spec.Versions {
+		schemas[v.Name] = specSchema(v)
+	}
+	fromSchema, fromOK := schemas[from]
+	toSchema, toOK := schemas[to]
+	if !fromOK || !toOK || !equality.Semantic.DeepEqual(fromSchema, toSchema) {
+		return fmt.Errorf("the module declares no %s.%s.%s and the spec schemas of the versions differ",
+			platformloader.ConvertDefinition, from, to)
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconcile
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	platformv1alpha1 "github.com/chazu/pequod/api/v1alpha1"
+	"github.com/chazu/pequod/pkg/platformloader"
+)
+
+// reviewConversion posts a ConversionReview of one instance to the handler
+// and returns the response
+func reviewConversion(t *testing.T, handler http.Handler, instance map[string]interface{}, desired string) *apiextensionsv1.ConversionResponse {
+	t.Helper()
+	raw, err := json.Marshal(instance)
+	if err != nil {
+		t.Fatalf("failed to encode instance: %v", err)
+	}
+	body, err := json.Marshal(&apiextensionsv1.ConversionReview{
+		TypeMeta: metav1.TypeMeta{APIVersion: "apiextensions.k8s.io/v1", Kind: "ConversionReview"},
+		Request: &apiextensionsv1.ConversionRequest{
+			UID:               "review-1",
+			DesiredAPIVersion: desired,
+			Objects:           []runtime.RawExtension{{Raw: raw}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to encode review: %v", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/convert", bytes.NewReader(body)))
+	review := &apiextensionsv1.ConversionReview{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), review); err != nil {
+		t.Fatalf("failed to decode response %q: %v", recorder.Body.String(), err)
+	}
+	if review.Response == nil || review.Response.UID != "review-1" {
+		t.Fatalf("expected a response to the review, got %+v", review)
+	}
+	return review.Response
+}
+
+func TestConversionHandler(t *testing.T) {
+	tf := newVersionedTransform()
+	tf.Status.GeneratedCRD = &platformv1alpha1.GeneratedCRDReference{APIVersion: "apps.example.com/v1beta1", Kind: "WebService"}
+	handler := NewConversionHandler(newTestClient(tf), platformloader.NewRenderer(createTestLoader()))
+
+	instance := func(apiVersion string, spec map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"apiVersion": apiVersion,
+			"kind":       "WebService",
+			"metadata":   map[string]interface{}{"name": "web", "namespace": "default"},
+			"spec":       spec,
+			"status":     map[string]interface{}{"phase": "Completed"},
+		}
+	}
+	tests := []struct {
+		name     string
+		instance map[string]interface{}
+		desired  string
+		want     map[string]interface{}
+	}{
+		{
+			name:     "up",
+			instance: instance("apps.example.com/v1alpha1", map[string]interface{}{"size": "large"}),
+			desired:  "apps.example.com/v1beta1",
+			want:     instance("apps.example.com/v1beta1", map[string]interface{}{"replicas": int64(3)}),
+		},
+		{
+			name:     "down",
+			instance: instance("apps.example.com/v1beta1", map[string]interface{}{"replicas": int64(1)}),
+			desired:  "apps.example.com/v1alpha1",
+			want:     instance("apps.example.com/v1alpha1", map[string]interface{}{"size": "small"}),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := reviewConversion(t, handler, tt.instance, tt.desired)
+			if response.Result.Status != metav1.StatusSuccess || len(response.ConvertedObjects) != 1 {
+				t.Fatalf("expected a converted instance, got %+v", response)
+			}
+			got := &unstructured.Unstructured{}
+			if err := got.UnmarshalJSON(response.ConvertedObjects[0].Raw); err != nil {
+				t.Fatalf("failed to decode converted instance: %v", err)
+			}
+			if !reflect.DeepEqual(got.Object, tt.want) {
+				t.Errorf("unexpected conversion:\ngot:  %v\nwant: %v", got.Object, tt.want)
+			}
+		})
+	}
+
+	// A spec the module's conversion rejects fails the review
+	invalid := instance("apps.example.com/v1alpha1", map[string]interface{}{"size": "huge"})
+	if response := reviewConversion(t, handler, invalid, "apps.example.com/v1beta1"); response.Result.Status != metav1.StatusFailure {
+		t.Errorf("expected the conversion to fail, got %+v", response.Result)
+	}
+}
+
+func TestConversionHandler_NoConvert(t *testing.T) {
+	tf := newVersionedTransform()
+	tf.Spec.CueRef.Ref = strings.Replace(versionedModule, "#Convert", "_convert", 1)
+	tf.Status.GeneratedCRD = &platformv1alpha1.GeneratedCRDReference{
+		APIVersion: "apps.example.com/v1beta1",
+		Kind:       "WebService",
+		Name:       "webservices.apps.example.com",
+	}
+	versionSchema := func(name string, properties ...string) apiextensionsv1.CustomResourceDefinitionVersion {
+		spec := apiextensionsv1.JSONSchemaProps{Type: "object", Properties: map[string]apiextensionsv1.JSONSchemaProps{}}
+		for _, property := range properties {
+			spec.Properties[property] = apiextensionsv1.JSONSchemaProps{Type: "string"}
+		}
+		return apiextensionsv1.CustomResourceDefinitionVersion{
+			Name: name,
+			Schema: &apiextensionsv1.CustomResourceValidation{OpenAPIV3Schema: &apiextensionsv1.JSONSchemaProps{
+				Type:       "object",
+				Properties: map[string]apiextensionsv1.JSONSchemaProps{"spec": spec},
+			}},
+		}
+	}
+	definition := &apiextensionsv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: "webservices.apps.example.com"},
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Versions: []apiextensionsv1.CustomResourceDefinitionVersion{
+				versionSchema("v1alpha1", "size"),
+				versionSchema("v1beta1", "replicas"),
+			},
+		},
+	}
+	c := newTestClient(tf, definition)
+	handler := NewConversionHandler(c, platformloader.NewRenderer(createTestLoader()))
+	instance := map[string]interface{}{
+		"apiVersion": "apps.example.com/v1alpha1",
+		"kind":       "WebService",
+		"metadata":   map[string]interface{}{"name": "web", "namespace": "default"},
+		"spec":       map[string]interface{}{"size": "large"},
+	}
+
+	// Keeping the spec as is would lose fields the target version lacks
+	if response := reviewConversion(t, handler, instance, "apps.example.com/v1beta1"); response.Result.Status != metav1.StatusFailure ||
+		!strings.Contains(response.Result.Message, "#Convert.v1alpha1.v1beta1") {
+		t.Errorf("expected the conversion to fail, got %+v", response.Result)
+	}
+
+	// Versions with the same schema need no conversion
+	definition.Spec.Versions[1] = versionSchema("v1beta1", "size")
+	if err := c.Update(context.Background(), definition); err != nil {
+		t.Fatalf("failed to update CRD: %v", err)
+	}
+	if response := reviewConversion(t, handler, instance, "apps.example.com/v1beta1"); response.Result.Status != metav1.StatusSuccess {
+		t.Errorf("expected the spec to be kept as is, got %+v", response.Result)
+	}
+}
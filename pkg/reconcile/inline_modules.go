@@ -0,0 +1,143 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconcile
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	platformv1alpha1 "github.com/chazu/pequod/api/v1alpha1"
+	"github.com/chazu/pequod/pkg/platformloader"
+)
+
+// inlineModuleName returns the name of the ConfigMap keeping the source of
+// a Transform's inline module with the given digest
+func inlineModuleName(transformName, digest string) string {
+	return fmt.Sprintf("%s-module-%s", transformName, strings.TrimPrefix(digest, platformloader.InlineType+":"))
+}
+
+// resolvedRef returns the type and ref that fetch the module a spec
+// reference resolved to. Inline modules are not kept in status: one other
+// than the spec's own source is fetched from the ConfigMap keeping it.
+func resolvedRef(
+	transform *platformv1alpha1.Transform, ref platformv1alpha1.CueReference, resolved platformv1alpha1.ResolvedCueReference,
+) (string, string) {
+	switch {
+	case resolved.Ref != "":
+		return string(resolved.Type), resolved.Ref
+	case resolved.Type == platformv1alpha1.CueRefTypeInline && resolved.Digest != "" &&
+		(ref.Type != platformv1alpha1.CueRefTypeInline || platformloader.InlineDigest(ref.Ref) != resolved.Digest):
+		return string(platformv1alpha1.CueRefTypeConfigMap),
+			transform.Namespace + "/" + inlineModuleName(transform.Name, resolved.Digest)
+	default:
+		return string(ref.Type), ref.Ref
+	}
+}
+
+// refetchable reports whether a resolved module can be fetched again once
+// the spec references another one
+func refetchable(resolved *platformv1alpha1.ResolvedCueReference) bool {
+	if resolved == nil || !pinnable(resolved.Type) {
+		return false
+	}
+	return resolved.Ref != "" || resolved.Type == platformv1alpha1.CueRefTypeInline
+}
+
+// resolveCueRef returns the status of the module a spec reference resolved
+// to. The source of an inline module is kept in a ConfigMap owned by the
+// Transform rather than in status, so rollouts, channels and held back
+// upgrades can render it after the spec changes.
+func (h *TransformHandlers) resolveCueRef(
+	ctx context.Context, tf *platformv1alpha1.Transform, cueRef platformv1alpha1.CueReference, digest string,
+) (*platformv1alpha1.ResolvedCueReference, error) {
+	now := metav1.Now()
+	resolved := &platformv1alpha1.ResolvedCueReference{
+		Type:      cueRef.Type,
+		Digest:    digest,
+		FetchedAt: &now,
+	}
+	if cueRef.Type != platformv1alpha1.CueRefTypeInline {
+		resolved.Ref = pinnedRef(cueRef, digest)
+		return resolved, nil
+	}
+
+	// The ConfigMap is named after the digest of its content, so an
+	// existing one is kept as it is
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        inlineModuleName(tf.Name, digest),
+			Namespace:   tf.Namespace,
+			Labels:      map[string]string{"pequod.io/transform": tf.Name},
+			Annotations: map[string]string{platformloader.ModuleDigestAnnotation: digest},
+		},
+		Data: map[string]string{platformloader.CUEContentKey: cueRef.Ref},
+	}
+	if err := controllerutil.SetControllerReference(tf, cm, h.scheme); err != nil {
+		return nil, err
+	}
+	if err := h.client.Create(ctx, cm); err != nil && !apierrors.IsAlreadyExists(err) {
+		return nil, fmt.Errorf("failed to keep inline module %s: %w", digest, err)
+	}
+	return resolved, nil
+}
+
+// inlineDigests returns the digests of the inline modules a Transform's
+// status references
+func inlineDigests(status *platformv1alpha1.TransformStatus) map[string]bool {
+	resolved := []*platformv1alpha1.ResolvedCueReference{status.ResolvedCueRef}
+	for _, channel := range status.Channels {
+		resolved = append(resolved, channel.ResolvedCueRef)
+	}
+	if status.Rollout != nil {
+		resolved = append(resolved, &status.Rollout.From)
+	}
+
+	digests := map[string]bool{}
+	for _, r := range resolved {
+		if r != nil && r.Type == platformv1alpha1.CueRefTypeInline && r.Digest != "" {
+			digests[r.Digest] = true
+		}
+	}
+	return digests
+}
+
+// releaseInlineModules deletes the ConfigMaps keeping the inline modules a
+// Transform's status referenced before an update but no longer does
+func (h *TransformHandlers) releaseInlineModules(ctx context.Context, tf *platformv1alpha1.Transform, before, after map[string]bool) error {
+	for digest := range before {
+		if after[digest] {
+			continue
+		}
+		cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+			Name:      inlineModuleName(tf.Name, digest),
+			Namespace: tf.Namespace,
+		}}
+		log.FromContext(ctx).V(1).Info("Deleting inline module", "configMap", cm.Name, "digest", digest)
+		if err := h.client.Delete(ctx, cm); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to delete inline module %s: %w", cm.Name, err)
+		}
+	}
+	return nil
+}
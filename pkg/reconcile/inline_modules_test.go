@@ -0,0 +1,125 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconcile
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	platformv1alpha1 "github.com/chazu/pequod/api/v1alpha1"
+	"github.com/chazu/pequod/pkg/platformloader"
+)
+
+func TestTransformHandlers_KeepsInlineModulesOutOfStatus(t *testing.T) {
+	first := schemaModule(`{port: int}`)
+	second := schemaModule(`{port: int, replicas?: int}`)
+	third := schemaModule(`{port: int, replicas?: int, tier?: string}`)
+	tf := &platformv1alpha1.Transform{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "webservice",
+			Namespace:  "default",
+			Finalizers: []string{TransformFinalizer},
+		},
+		Spec: platformv1alpha1.TransformSpec{
+			CueRef:  platformv1alpha1.CueReference{Type: platformv1alpha1.CueRefTypeInline, Ref: first},
+			Group:   "apps.example.com",
+			Version: "v1alpha1",
+			Rollout: &platformv1alpha1.RolloutStrategy{},
+		},
+	}
+	c := newTestClient(tf)
+	handlers := newTestHandlers(c)
+	key := types.NamespacedName{Name: "webservice", Namespace: "default"}
+
+	// reconcile references module, drops the rollout strategy if
+	// withoutRollout, and reconciles the Transform
+	reconcile := func(module string, withoutRollout bool) *platformv1alpha1.Transform {
+		t.Helper()
+		latest := &platformv1alpha1.Transform{}
+		if err := c.Get(context.Background(), key, latest); err != nil {
+			t.Fatalf("failed to get transform: %v", err)
+		}
+		if latest.Spec.CueRef.Ref != module || withoutRollout {
+			latest.Spec.CueRef.Ref = module
+			if withoutRollout {
+				latest.Spec.Rollout = nil
+			}
+			latest.Generation++
+			if err := c.Update(context.Background(), latest); err != nil {
+				t.Fatalf("failed to update transform: %v", err)
+			}
+		}
+		if _, err := handlers.Reconcile(context.Background(), key); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := c.Get(context.Background(), key, latest); err != nil {
+			t.Fatalf("failed to get transform: %v", err)
+		}
+		return latest
+	}
+	// kept returns the inline modules kept for the Transform by digest
+	kept := func() map[string]string {
+		t.Helper()
+		list := &corev1.ConfigMapList{}
+		if err := c.List(context.Background(), list, client.InNamespace("default")); err != nil {
+			t.Fatalf("failed to list ConfigMaps: %v", err)
+		}
+		modules := map[string]string{}
+		for _, cm := range list.Items {
+			modules[cm.Annotations[platformloader.ModuleDigestAnnotation]] = cm.Data[platformloader.CUEContentKey]
+		}
+		return modules
+	}
+
+	updated := reconcile(first, false)
+	resolved := updated.Status.ResolvedCueRef
+	if resolved == nil || resolved.Ref != "" || resolved.Digest != platformloader.InlineDigest(first) {
+		t.Fatalf("expected only the digest of the inline module in status, got %+v", resolved)
+	}
+	if modules := kept(); len(modules) != 1 || modules[resolved.Digest] != first {
+		t.Errorf("expected the inline module to be kept in a ConfigMap, got %v", modules)
+	}
+
+	// The module rolled out from is kept while the rollout references it
+	updated = reconcile(second, false)
+	if updated.Status.Rollout == nil || updated.Status.Rollout.From.Ref != "" {
+		t.Fatalf("expected a rollout from the first module, got %+v", updated.Status.Rollout)
+	}
+	modules := kept()
+	if len(modules) != 2 || modules[platformloader.InlineDigest(first)] != first || modules[platformloader.InlineDigest(second)] != second {
+		t.Errorf("expected both modules to be kept, got %v", modules)
+	}
+	waiting := newInstanceWithHealth("waiting", "Completed", "True", platformloader.InlineDigest(first))
+	cueRef, err := instanceCueRef(updated, waiting)
+	if err != nil {
+		t.Fatalf("instanceCueRef() failed: %v", err)
+	}
+	if want := "default/" + inlineModuleName("webservice", platformloader.InlineDigest(first)); cueRef.Type != "configmap" || cueRef.Ref != want {
+		t.Errorf("expected the instance to render the first module from ConfigMap %s, got %s %q", want, cueRef.Type, cueRef.Ref)
+	}
+
+	// Modules no longer referenced are deleted
+	reconcile(third, true)
+	if modules := kept(); len(modules) != 1 || modules[platformloader.InlineDigest(third)] != third {
+		t.Errorf("expected only the third module to be kept, got %v", modules)
+	}
+}
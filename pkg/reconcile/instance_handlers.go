@@ -19,16 +19,22 @@ package reconcile
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
+	"strconv"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	platformv1alpha1 "github.com/chazu/pequod/api/v1alpha1"
@@ -45,8 +51,33 @@ const (
 
 	// TransformNamespaceAnnotation is the namespace of the Transform
 	TransformNamespaceAnnotation = "pequod.io/transform-namespace"
+
+	// IgnoreFieldsAnnotation lets an instance declare field paths per node that
+	// are owned by other controllers. The value is a JSON object mapping node
+	// IDs to lists of field paths, e.g. {"deployment": ["spec.replicas"]}.
+	IgnoreFieldsAnnotation = "pequod.io/ignore-fields"
+
+	// RollbackOnFailureAnnotation overrides the Transform's rollbackOnFailure
+	// setting for a single instance ("true" or "false")
+	RollbackOnFailureAnnotation = "pequod.io/rollback-on-failure"
+
+	// SensitiveValuesKey is the key of the sensitive values Secret holding
+	// a JSON object of node IDs to field paths to values
+	SensitiveValuesKey = "values"
+
+	// RenderInputKey is the key of the sensitive values Secret holding the
+	// gzip compressed #Render input the ResourceGraph was rendered from
+	RenderInputKey = "input"
+
+	// ConditionTypeDegraded is the Transform condition set when its
+	// instances repeatedly exceed the render budget
+	ConditionTypeDegraded = "Degraded"
 )
 
+// DegradedAfterBudgetViolations is the number of consecutive over-budget
+// renders after which a Transform is marked degraded
+const DegradedAfterBudgetViolations = 3
+
 // InstanceHandlers contains handlers for platform instance reconciliation.
 // Platform instances are CRs created from dynamically generated CRDs (e.g., WebService).
 // This handler renders the CUE template with the instance spec to produce a ResourceGraph.
@@ -55,6 +86,13 @@ type InstanceHandlers struct {
 	scheme   *runtime.Scheme
 	recorder record.EventRecorder
 	renderer *platformloader.Renderer
+
+	// refs records the instances each instance references
+	refs *InstanceRefIndex
+
+	// platformConfigNamespace is the namespace whose PlatformConfigs may
+	// select other namespaces
+	platformConfigNamespace string
 }
 
 // NewInstanceHandlers creates a new handler collection for platform instances
@@ -69,9 +107,18 @@ func NewInstanceHandlers(
 		scheme:   scheme,
 		recorder: recorder,
 		renderer: renderer,
+		refs:     NewInstanceRefIndex(),
+
+		platformConfigNamespace: DefaultPlatformConfigNamespace,
 	}
 }
 
+// SetPlatformConfigNamespace sets the namespace whose PlatformConfigs may
+// select other namespaces
+func (h *InstanceHandlers) SetPlatformConfigNamespace(namespace string) {
+	h.platformConfigNamespace = namespace
+}
+
 // Reconcile handles reconciliation of a platform instance.
 // It finds the source Transform, renders the CUE template, and creates a ResourceGraph.
 func (h *InstanceHandlers) Reconcile(
@@ -101,33 +148,24 @@ func (h *InstanceHandlers) Reconcile(
 		return ctrl.Result{Requeue: true}, nil
 	}
 
-	// Build CueRef from Transform
-	cueRef := platformloader.CueRefInput{
-		Type: string(transform.Spec.CueRef.Type),
-		Ref:  transform.Spec.CueRef.Ref,
-		Path: transform.Spec.CueRef.Path,
-	}
-	if transform.Spec.CueRef.PullSecretRef != nil {
-		cueRef.PullSecretRef = &transform.Spec.CueRef.PullSecretRef.Name
-	}
-
-	// Extract the spec from the instance
-	spec, found, err := unstructured.NestedMap(instance.Object, "spec")
+	cueRef, err := instanceCueRef(transform, instance)
 	if err != nil {
-		return ctrl.Result{}, fmt.Errorf("failed to get spec from instance: %w", err)
+		// Rendered again once the Transform's channels change
+		logger.Error(err, "Instance selects an unknown channel")
+		h.recordEvent(instance, "Warning", "UnknownChannel", "%v", err)
+		return ctrl.Result{}, nil
 	}
-	if !found {
-		spec = make(map[string]interface{})
+	rawSpec, err := instanceSpec(instance)
+	if err != nil {
+		return ctrl.Result{}, err
 	}
 
-	// Create raw extension with the spec
-	rawSpec := runtime.RawExtension{}
-	if len(spec) > 0 {
-		specJSON, err := json.Marshal(spec)
-		if err != nil {
-			return ctrl.Result{}, fmt.Errorf("failed to marshal spec: %w", err)
-		}
-		rawSpec.Raw = specJSON
+	// Resolve the environment's PlatformConfig values
+	config, err := h.resolvePlatformConfig(ctx, instance)
+	if err != nil {
+		logger.Error(err, "Failed to resolve PlatformConfig")
+		h.recordEvent(instance, "Warning", "PlatformConfigFailed", "Failed to resolve PlatformConfig: %v", err)
+		return ctrl.Result{}, err
 	}
 
 	// Render the graph
@@ -135,18 +173,64 @@ func (h *InstanceHandlers) Reconcile(
 		"cueRefType", cueRef.Type,
 		"cueRef", cueRef.Ref)
 
-	g, fetchResult, err := h.renderer.RenderTransformWithCueRef(
-		ctx,
-		instance.GetName(),
-		instance.GetNamespace(),
-		rawSpec,
-		cueRef,
-	)
+	g, fetchResult, err := h.renderer.RenderInstance(ctx, instance, rawSpec, cueRef, platformloader.RenderOptions{
+		Config:           config,
+		ResolveInstances: h.instanceResolver(instance),
+	})
 	if err != nil {
+		var notReady *ReferenceNotReadyError
+		if errors.As(err, &notReady) {
+			logger.Info("Waiting for referenced instance", "reference", notReady.Ref.String(), "reason", notReady.Reason)
+			h.recordEvent(instance, "Normal", "WaitingForReference", "Waiting for %s: %s", notReady.Ref, notReady.Reason)
+			return ctrl.Result{RequeueAfter: ReferenceRequeueInterval}, nil
+		}
+		var cycle *ReferenceCycleError
+		if errors.As(err, &cycle) {
+			// Rendered again once an instance in the cycle changes
+			logger.Error(err, "Instance references form a cycle")
+			h.recordEvent(instance, "Warning", "ReferenceCycle", "%v", cycle)
+			return ctrl.Result{}, nil
+		}
 		logger.Error(err, "Failed to render CUE template")
+		if budgetErr, ok := platformloader.AsBudgetError(err); ok {
+			h.recordEvent(instance, "Warning", budgetErr.Reason, "Failed to render CUE template: %v", err)
+			if statusErr := h.recordRenderBudgetViolation(ctx, transform, budgetErr); statusErr != nil {
+				logger.Error(statusErr, "Failed to record render budget violation on Transform")
+			}
+			return ctrl.Result{}, err
+		}
 		h.recordEvent(instance, "Warning", "RenderFailed", "Failed to render CUE template: %v", err)
 		return ctrl.Result{}, err
 	}
+	if transform.Status.RenderBudgetViolations > 0 {
+		if err := h.clearRenderBudgetViolations(ctx, transform); err != nil {
+			logger.Error(err, "Failed to clear render budget violations on Transform")
+		}
+	}
+
+	// Platform instances rendered as nodes are rolled out by their own graphs
+	kinds, err := generatedKinds(ctx, h.client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	markInstanceNodes(g, kinds)
+	if err := checkCompositeCycle(ctx, h.client, instance, g); err != nil {
+		var cycle *CompositeCycleError
+		if errors.As(err, &cycle) {
+			// Rendered again once the instance or its module changes
+			logger.Error(err, "Composite instances form a cycle")
+			h.recordEvent(instance, "Warning", "CompositeCycle", "%v", cycle)
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	// Merge instance-level field exclusions into the rendered nodes
+	if err := applyInstanceIgnoreFields(instance, g); err != nil {
+		logger.Error(err, "Invalid ignore-fields annotation")
+		h.recordEvent(instance, "Warning", "InvalidIgnoreFields", "Invalid %s annotation: %v", IgnoreFieldsAnnotation, err)
+		return ctrl.Result{}, err
+	}
 
 	g.SetHash()
 
@@ -155,24 +239,60 @@ func (h *InstanceHandlers) Reconcile(
 		"hash", g.Metadata.RenderHash,
 		"source", fetchResult.Source)
 
+	// Move sensitive values out of the nodes now that the hash covers them
+	sensitive, err := extractSensitiveValues(g)
+	if err != nil {
+		h.recordEvent(instance, "Warning", "RenderFailed", "Invalid sensitive fields: %v", err)
+		return ctrl.Result{}, err
+	}
+
+	// The input holds the instance's spec, so it is kept with the sensitive
+	// values rather than in the ResourceGraph
+	input, err := compressRenderInput(g.Metadata.Input)
+	if err != nil {
+		h.recordEvent(instance, "Warning", "RenderFailed", "Failed to store render input: %v", err)
+		return ctrl.Result{}, err
+	}
+
 	// Build the ResourceGraph
 	rg, err := h.buildResourceGraph(instance, transform, g)
 	if err != nil {
 		return ctrl.Result{}, fmt.Errorf("failed to build ResourceGraph: %w", err)
 	}
+	if len(sensitive) > 0 || len(input) > 0 {
+		rg.Spec.SensitiveValuesRef = &platformv1alpha1.LocalObjectReference{Name: sensitiveValuesName(rg.Name)}
+	}
+
+	rollback, err := rollbackOnFailure(instance, transform)
+	if err != nil {
+		h.recordEvent(instance, "Warning", "InvalidRollbackOnFailure", "Invalid %s annotation: %v", RollbackOnFailureAnnotation, err)
+		return ctrl.Result{}, err
+	}
+
+	// Point the instance at the new revision before it can complete, so
+	// that its outcome is rolled up to the instance
+	if err := h.recordRevision(ctx, instance, rg, fetchResult.Digest, instanceChannel(instance)); err != nil {
+		logger.Error(err, "Failed to record ResourceGraph on instance status")
+		return ctrl.Result{}, err
+	}
 
 	// Create or update the ResourceGraph
-	if err := h.applyResourceGraph(ctx, rg); err != nil {
+	if err := h.applyResourceGraph(ctx, rg, rollback); err != nil {
 		logger.Error(err, "Failed to apply ResourceGraph")
 		h.recordEvent(instance, "Warning", "ApplyFailed", "Failed to apply ResourceGraph: %v", err)
 		return ctrl.Result{}, err
 	}
+	if err := h.applySensitiveValues(ctx, rg, sensitive, input); err != nil {
+		logger.Error(err, "Failed to store sensitive values")
+		h.recordEvent(instance, "Warning", "ApplyFailed", "Failed to store sensitive values: %v", err)
+		return ctrl.Result{}, err
+	}
 
 	logger.Info("ResourceGraph applied successfully",
 		"resourceGraph", rg.Name,
-		"nodeCount", len(rg.Spec.Nodes))
+		"nodeCount", rg.Spec.NodeCount)
 
-	h.recordEvent(instance, "Normal", "Rendered", "Created ResourceGraph %s with %d nodes", rg.Name, len(rg.Spec.Nodes))
+	h.recordEvent(instance, "Normal", "Rendered", "Created ResourceGraph %s with %d nodes", rg.Name, rg.Spec.NodeCount)
 
 	return ctrl.Result{}, nil
 }
@@ -200,8 +320,42 @@ func (h *InstanceHandlers) buildResourceGraph(
 			ApplyPolicy: platformv1alpha1.ApplyPolicy{
 				Mode:           string(node.ApplyPolicy.Mode),
 				ConflictPolicy: string(node.ApplyPolicy.ConflictPolicy),
+				IgnoreFields:   node.ApplyPolicy.IgnoreFields,
 			},
-			DependsOn: node.DependsOn,
+			DependsOn:       node.DependsOn,
+			SensitiveFields: node.SensitiveFields,
+			Instance:        node.Instance,
+		}
+		if us := node.ApplyPolicy.UpdateStrategy; us != nil {
+			nodes[i].ApplyPolicy.UpdateStrategy = &platformv1alpha1.UpdateStrategy{
+				Type:              string(us.Type),
+				PropagationPolicy: us.PropagationPolicy,
+			}
+		}
+		if cr := node.ApplyPolicy.ConflictResolution; cr != nil {
+			nodes[i].ApplyPolicy.ConflictResolution = &platformv1alpha1.ConflictResolution{
+				ForceFields:   cr.ForceFields,
+				ForceManagers: cr.ForceManagers,
+			}
+		}
+		if node.Hook != nil {
+			nodes[i].Hook = &platformv1alpha1.Hook{
+				Phase:         string(node.Hook.Phase),
+				CleanupPolicy: string(node.Hook.CleanupPolicy),
+			}
+		}
+		if node.Generate != nil {
+			keys := make([]platformv1alpha1.GeneratedKey, len(node.Generate.Keys))
+			for j, key := range node.Generate.Keys {
+				keys[j] = platformv1alpha1.GeneratedKey{
+					Name:    key.Name,
+					Type:    string(key.Type),
+					Length:  key.Length,
+					Charset: string(key.Charset),
+					Bits:    key.Bits,
+				}
+			}
+			nodes[i].Generate = &platformv1alpha1.GeneratedSecret{Keys: keys}
 		}
 
 		// Convert readiness predicates
@@ -245,7 +399,6 @@ func (h *InstanceHandlers) buildResourceGraph(
 				Name:    g.Metadata.Name,
 				Version: g.Metadata.Version,
 			},
-			Nodes:      nodes,
 			RenderHash: g.Metadata.RenderHash,
 			RenderedAt: metav1.Now(),
 		},
@@ -263,25 +416,189 @@ func (h *InstanceHandlers) buildResourceGraph(
 	}
 	rg.OwnerReferences = []metav1.OwnerReference{ownerRef}
 
+	// Large graphs are stored compressed
+	if err := rg.Spec.SetNodes(nodes); err != nil {
+		return nil, err
+	}
+
 	return rg, nil
 }
 
-// applyResourceGraph creates or updates the ResourceGraph
-func (h *InstanceHandlers) applyResourceGraph(ctx context.Context, rg *platformv1alpha1.ResourceGraph) error {
+// transformCueRef returns the CueRef of the module a Transform adopted,
+// which lags its spec while an upgrade is blocked
+func transformCueRef(transform *platformv1alpha1.Transform) platformloader.CueRefInput {
+	return cueRefInput(transform, transform.Spec.CueRef, transform.Status.ResolvedCueRef)
+}
+
+// cueRefInput returns the CueRef of the module a spec reference of a
+// Transform resolved to, or of the spec reference itself until it is
+// resolved
+func cueRefInput(
+	transform *platformv1alpha1.Transform, ref platformv1alpha1.CueReference, resolved *platformv1alpha1.ResolvedCueReference,
+) platformloader.CueRefInput {
+	cueRef := platformloader.CueRefInput{
+		Type: string(ref.Type),
+		Ref:  ref.Ref,
+		Path: ref.Path,
+	}
+	if resolved != nil {
+		cueRef.Type, cueRef.Ref = resolvedRef(transform, ref, *resolved)
+	}
+	if ref.PullSecretRef != nil {
+		cueRef.PullSecretRef = &ref.PullSecretRef.Name
+	}
+	return cueRef
+}
+
+// instanceSpec returns an instance's spec as the raw input of a render
+func instanceSpec(instance *unstructured.Unstructured) (runtime.RawExtension, error) {
+	rawSpec := runtime.RawExtension{}
+	spec, _, err := unstructured.NestedMap(instance.Object, "spec")
+	if err != nil {
+		return rawSpec, fmt.Errorf("failed to get spec from instance: %w", err)
+	}
+	if len(spec) > 0 {
+		specJSON, err := json.Marshal(spec)
+		if err != nil {
+			return rawSpec, fmt.Errorf("failed to marshal spec: %w", err)
+		}
+		rawSpec.Raw = specJSON
+	}
+	return rawSpec, nil
+}
+
+// applyInstanceIgnoreFields merges the field paths declared in the instance's
+// IgnoreFieldsAnnotation into the matching nodes' apply policies
+func applyInstanceIgnoreFields(instance *unstructured.Unstructured, g *graph.Graph) error {
+	raw, ok := instance.GetAnnotations()[IgnoreFieldsAnnotation]
+	if !ok || raw == "" {
+		return nil
+	}
+
+	var byNode map[string][]string
+	if err := json.Unmarshal([]byte(raw), &byNode); err != nil {
+		return fmt.Errorf("expected a JSON object of node ID to field paths: %w", err)
+	}
+
+	nodeIndex := make(map[string]int, len(g.Nodes))
+	for i, node := range g.Nodes {
+		nodeIndex[node.ID] = i
+	}
+
+	for nodeID, paths := range byNode {
+		i, found := nodeIndex[nodeID]
+		if !found {
+			return fmt.Errorf("node %q does not exist in the rendered graph", nodeID)
+		}
+		if err := graph.ValidateFieldPaths(paths); err != nil {
+			return fmt.Errorf("node %q: %w", nodeID, err)
+		}
+
+		policy := &g.Nodes[i].ApplyPolicy
+		for _, p := range paths {
+			if !slices.Contains(policy.IgnoreFields, p) {
+				policy.IgnoreFields = append(policy.IgnoreFields, p)
+			}
+		}
+	}
+
+	return nil
+}
+
+// rollbackOnFailure reports whether failed revisions of the instance should
+// be rolled back. The instance annotation takes precedence over the Transform.
+func rollbackOnFailure(instance *unstructured.Unstructured, transform *platformv1alpha1.Transform) (bool, error) {
+	raw, ok := instance.GetAnnotations()[RollbackOnFailureAnnotation]
+	if !ok || raw == "" {
+		return transform.Spec.RollbackOnFailure, nil
+	}
+	enabled, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, fmt.Errorf("expected \"true\" or \"false\", got %q", raw)
+	}
+	return enabled, nil
+}
+
+// previousRevision returns the most recently completed ResourceGraph other
+// than the named one, or nil if there is none
+func previousRevision(rgs []platformv1alpha1.ResourceGraph, name string) *platformv1alpha1.ResourceGraph {
+	var prev *platformv1alpha1.ResourceGraph
+	for i := range rgs {
+		candidate := &rgs[i]
+		if candidate.Name == name || candidate.Status.Phase != "Completed" || !candidate.DeletionTimestamp.IsZero() {
+			continue
+		}
+		if prev == nil || completedAt(candidate).After(completedAt(prev).Time) {
+			prev = candidate
+		}
+	}
+	return prev
+}
+
+// completedAt returns when the graph completed, falling back to its creation time
+func completedAt(rg *platformv1alpha1.ResourceGraph) metav1.Time {
+	if rg.Status.CompletedAt != nil {
+		return *rg.Status.CompletedAt
+	}
+	return rg.CreationTimestamp
+}
+
+// NodeOwnerReference returns the controller reference a node of rg is
+// applied with. Nodes are controlled by the revision rg and garbage
+// collected with it, except child instances and generated Secrets, which
+// are controlled by the instance rg was rendered from: the revision is
+// deleted as soon as the next one is created, and collecting them with it
+// would tear down a child's resources or regenerate a Secret's values. A
+// ResourceGraph without a controller owns all of its nodes.
+func NodeOwnerReference(rg *platformv1alpha1.ResourceGraph, node *platformv1alpha1.ResourceNode) metav1.OwnerReference {
+	if node.Instance || node.Generate != nil {
+		if owner := metav1.GetControllerOf(rg); owner != nil {
+			ref := *owner
+			ref.BlockOwnerDeletion = boolPtr(true)
+			return ref
+		}
+	}
+	return metav1.OwnerReference{
+		APIVersion:         platformv1alpha1.GroupVersion.String(),
+		Kind:               "ResourceGraph",
+		Name:               rg.Name,
+		UID:                rg.UID,
+		Controller:         boolPtr(true),
+		BlockOwnerDeletion: boolPtr(true),
+	}
+}
+
+// applyResourceGraph creates or updates the ResourceGraph. Older revisions
+// are deleted, except that with keepPrevious the last completed revision is
+// kept and recorded as the rollback target of the new one.
+func (h *InstanceHandlers) applyResourceGraph(ctx context.Context, rg *platformv1alpha1.ResourceGraph, keepPrevious bool) error {
 	logger := log.FromContext(ctx)
 
-	// Check if a ResourceGraph already exists for this instance
+	// Check if a ResourceGraph already exists for this instance. Composite
+	// parents and children may share a name, so the kind and group are matched too
 	existing := &platformv1alpha1.ResourceGraphList{}
 	if err := h.client.List(ctx, existing,
 		client.InNamespace(rg.Namespace),
-		client.MatchingLabels{"pequod.io/instance": rg.Labels["pequod.io/instance"]},
+		client.MatchingLabels{
+			"pequod.io/instance":       rg.Labels["pequod.io/instance"],
+			"pequod.io/instance-kind":  rg.Labels["pequod.io/instance-kind"],
+			"pequod.io/instance-group": rg.Labels["pequod.io/instance-group"],
+		},
 	); err != nil {
 		return fmt.Errorf("failed to list existing ResourceGraphs: %w", err)
 	}
 
+	var prev *platformv1alpha1.ResourceGraph
+	if keepPrevious {
+		prev = previousRevision(existing.Items, rg.Name)
+	}
+	if prev != nil {
+		rg.Spec.Rollback = &platformv1alpha1.RollbackSpec{PreviousRevision: prev.Name}
+	}
+
 	// Delete old ResourceGraphs with different hashes
 	for _, oldRG := range existing.Items {
-		if oldRG.Name != rg.Name {
+		if oldRG.Name != rg.Name && (prev == nil || oldRG.Name != prev.Name) {
 			logger.Info("Deleting old ResourceGraph", "name", oldRG.Name)
 			if err := h.client.Delete(ctx, &oldRG); err != nil {
 				logger.Error(err, "Failed to delete old ResourceGraph", "name", oldRG.Name)
@@ -307,7 +624,72 @@ func (h *InstanceHandlers) applyResourceGraph(ctx context.Context, rg *platformv
 	existingRG.Labels = rg.Labels
 	existingRG.Annotations = rg.Annotations
 	logger.Info("Updating ResourceGraph", "name", rg.Name)
-	return h.client.Update(ctx, existingRG)
+	if err := h.client.Update(ctx, existingRG); err != nil {
+		return err
+	}
+	existingRG.DeepCopyInto(rg)
+	return nil
+}
+
+// extractSensitiveValues removes the values of sensitive fields from the
+// graph's nodes and returns them by node ID and field path
+func extractSensitiveValues(g *graph.Graph) (map[string]map[string]interface{}, error) {
+	sensitive := make(map[string]map[string]interface{})
+	for i := range g.Nodes {
+		values, err := g.Nodes[i].ExtractSensitive()
+		if err != nil {
+			return nil, fmt.Errorf("node %s: %w", g.Nodes[i].ID, err)
+		}
+		if len(values) > 0 {
+			sensitive[g.Nodes[i].ID] = values
+		}
+	}
+	return sensitive, nil
+}
+
+// sensitiveValuesName returns the name of the Secret holding a
+// ResourceGraph's sensitive values
+func sensitiveValuesName(rgName string) string {
+	return rgName + "-sensitive"
+}
+
+// applySensitiveValues stores the sensitive values of a ResourceGraph and
+// the compressed input it was rendered from in a Secret owned by it, so they
+// are deleted along with the revision
+func (h *InstanceHandlers) applySensitiveValues(
+	ctx context.Context,
+	rg *platformv1alpha1.ResourceGraph,
+	sensitive map[string]map[string]interface{},
+	input []byte,
+) error {
+	if len(sensitive) == 0 && len(input) == 0 {
+		return nil
+	}
+	data := map[string][]byte{}
+	if len(sensitive) > 0 {
+		raw, err := json.Marshal(sensitive)
+		if err != nil {
+			return fmt.Errorf("failed to marshal sensitive values: %w", err)
+		}
+		data[SensitiveValuesKey] = raw
+	}
+	if len(input) > 0 {
+		data[RenderInputKey] = input
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      sensitiveValuesName(rg.Name),
+			Namespace: rg.Namespace,
+		},
+	}
+	_, err := controllerutil.CreateOrUpdate(ctx, h.client, secret, func() error {
+		secret.Labels = map[string]string{"pequod.io/instance": rg.Labels["pequod.io/instance"]}
+		secret.Type = corev1.SecretTypeOpaque
+		secret.Data = data
+		return controllerutil.SetControllerReference(rg, secret, h.scheme)
+	})
+	return err
 }
 
 // handleDeletion handles instance deletion
@@ -319,16 +701,33 @@ func (h *InstanceHandlers) handleDeletion(ctx context.Context, instance *unstruc
 	}
 
 	logger.Info("Handling instance deletion", "name", instance.GetName())
+	h.refs.Delete(instanceKeyOf(instance))
 
 	// Delete associated ResourceGraphs
+	gvk := instance.GroupVersionKind()
 	rgList := &platformv1alpha1.ResourceGraphList{}
 	if err := h.client.List(ctx, rgList,
 		client.InNamespace(instance.GetNamespace()),
-		client.MatchingLabels{"pequod.io/instance": instance.GetName()},
+		client.MatchingLabels{
+			"pequod.io/instance":       instance.GetName(),
+			"pequod.io/instance-kind":  gvk.Kind,
+			"pequod.io/instance-group": gvk.Group,
+		},
 	); err != nil {
 		logger.Error(err, "Failed to list ResourceGraphs for deletion")
 		// Continue with finalizer removal
 	} else {
+		// Child instances tear down their own resources before the parent's
+		remaining, err := h.deleteChildInstances(ctx, rgList.Items)
+		if err != nil {
+			logger.Error(err, "Failed to delete child instances")
+			return ctrl.Result{}, err
+		}
+		if remaining > 0 {
+			h.recordEvent(instance, "Normal", "DeletingChildren", "Waiting for %d child instance(s) to be deleted", remaining)
+			return ctrl.Result{RequeueAfter: ChildDeletionInterval}, nil
+		}
+
 		for _, rg := range rgList.Items {
 			logger.Info("Deleting ResourceGraph", "name", rg.Name)
 			if err := h.client.Delete(ctx, &rg); err != nil && client.IgnoreNotFound(err) != nil {
@@ -359,8 +758,36 @@ func (h *InstanceHandlers) recordEvent(instance *unstructured.Unstructured, even
 	h.recorder.Eventf(instance, eventType, reason, messageFmt, args...)
 }
 
+// resolvePlatformConfig resolves the PlatformConfig values of an instance's
+// namespace, reporting invalid PlatformConfigs with an event on them
+func (h *InstanceHandlers) resolvePlatformConfig(ctx context.Context, instance metav1.Object) (runtime.RawExtension, error) {
+	return ResolvePlatformConfig(ctx, h.client, h.platformConfigNamespace, instance.GetNamespace(),
+		func(pc *platformv1alpha1.PlatformConfig, err error) {
+			log.FromContext(ctx).Info("Skipping invalid PlatformConfig",
+				"platformConfig", client.ObjectKeyFromObject(pc), "error", err.Error())
+			if h.recorder != nil {
+				h.recorder.Eventf(pc, "Warning", "InvalidPlatformConfig", "Skipped when rendering instances: %v", err)
+			}
+		})
+}
+
 // FindTransformForGVK finds the Transform that generated the CRD for the given GVK
 func FindTransformForGVK(ctx context.Context, c client.Client, gvk schema.GroupVersionKind) (*platformv1alpha1.Transform, error) {
+	tf, err := findTransformForGroupKind(ctx, c, gvk.GroupKind())
+	if err != nil {
+		return nil, err
+	}
+
+	// Instances are reconciled at the version the Transform renders
+	if generatedGV, _ := schema.ParseGroupVersion(tf.Status.GeneratedCRD.APIVersion); generatedGV.Version != gvk.Version {
+		return nil, fmt.Errorf("no Transform found for GVK %s", gvk.String())
+	}
+	return tf, nil
+}
+
+// findTransformForGroupKind finds the Transform that generated the CRD of
+// the given group and kind, at any of its versions
+func findTransformForGroupKind(ctx context.Context, c client.Client, gk schema.GroupKind) (*platformv1alpha1.Transform, error) {
 	logger := log.FromContext(ctx)
 
 	// List all Transforms
@@ -369,7 +796,7 @@ func FindTransformForGVK(ctx context.Context, c client.Client, gvk schema.GroupV
 		return nil, fmt.Errorf("failed to list Transforms: %w", err)
 	}
 
-	// Find the Transform whose GeneratedCRD matches this GVK
+	// Find the Transform whose GeneratedCRD matches this group and kind
 	for _, tf := range transforms.Items {
 		if tf.Status.GeneratedCRD == nil {
 			continue
@@ -382,15 +809,13 @@ func FindTransformForGVK(ctx context.Context, c client.Client, gvk schema.GroupV
 			continue
 		}
 
-		// Check if this Transform generated the CRD for our GVK
-		if generatedGV.Group == gvk.Group &&
-			generatedGV.Version == gvk.Version &&
-			tf.Status.GeneratedCRD.Kind == gvk.Kind {
+		// Check if this Transform generated the CRD for our group and kind
+		if generatedGV.Group == gk.Group && tf.Status.GeneratedCRD.Kind == gk.Kind {
 			return &tf, nil
 		}
 	}
 
-	return nil, fmt.Errorf("no Transform found for GVK %s", gvk.String())
+	return nil, fmt.Errorf("no Transform found for %s", gk.String())
 }
 
 // Helper functions for finalizer management on unstructured objects
@@ -425,3 +850,48 @@ func removeFinalizer(obj *unstructured.Unstructured, finalizer string) {
 func boolPtr(b bool) *bool {
 	return &b
 }
+
+// recordRenderBudgetViolation counts an over-budget render against the
+// Transform and marks it degraded once the violations keep repeating
+func (h *InstanceHandlers) recordRenderBudgetViolation(
+	ctx context.Context, transform *platformv1alpha1.Transform, budgetErr *platformloader.BudgetError,
+) error {
+	return h.updateTransformStatus(ctx, transform, func(tf *platformv1alpha1.Transform) {
+		tf.Status.RenderBudgetViolations++
+		if tf.Status.RenderBudgetViolations >= DegradedAfterBudgetViolations {
+			tf.SetCondition(ConditionTypeDegraded, metav1.ConditionTrue, budgetErr.Reason,
+				fmt.Sprintf("%d consecutive renders exceeded the render budget: %s",
+					tf.Status.RenderBudgetViolations, budgetErr.Message))
+		}
+	})
+}
+
+// clearRenderBudgetViolations resets the violation count after a successful
+// render and clears the Degraded condition
+func (h *InstanceHandlers) clearRenderBudgetViolations(ctx context.Context, transform *platformv1alpha1.Transform) error {
+	return h.updateTransformStatus(ctx, transform, func(tf *platformv1alpha1.Transform) {
+		tf.Status.RenderBudgetViolations = 0
+		if cond := tf.GetCondition(ConditionTypeDegraded); cond != nil && cond.Status == metav1.ConditionTrue {
+			tf.SetCondition(ConditionTypeDegraded, metav1.ConditionFalse, "RenderSucceeded", "Instances render within the render budget")
+		}
+	})
+}
+
+// updateTransformStatus applies updateFunc to the latest Transform status,
+// retrying on conflict
+func (h *InstanceHandlers) updateTransformStatus(
+	ctx context.Context, transform *platformv1alpha1.Transform, updateFunc func(*platformv1alpha1.Transform),
+) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		latest := &platformv1alpha1.Transform{}
+		if err := h.client.Get(ctx, client.ObjectKeyFromObject(transform), latest); err != nil {
+			return err
+		}
+		updateFunc(latest)
+		if err := h.client.Status().Update(ctx, latest); err != nil {
+			return err
+		}
+		transform.Status = latest.Status
+		return nil
+	})
+}
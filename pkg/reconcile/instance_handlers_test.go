@@ -0,0 +1,308 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconcile
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	platformv1alpha1 "github.com/chazu/pequod/api/v1alpha1"
+	"github.com/chazu/pequod/pkg/graph"
+	"github.com/chazu/pequod/pkg/platformloader"
+)
+
+func TestRollbackOnFailure(t *testing.T) {
+	tests := []struct {
+		name       string
+		transform  bool
+		annotation string
+		want       bool
+		wantErr    bool
+	}{
+		{name: "disabled by default", want: false},
+		{name: "enabled on transform", transform: true, want: true},
+		{name: "enabled on instance", annotation: "true", want: true},
+		{name: "instance overrides transform", transform: true, annotation: "false", want: false},
+		{name: "invalid annotation", annotation: "sometimes", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instance := &unstructured.Unstructured{Object: map[string]interface{}{}}
+			if tt.annotation != "" {
+				instance.SetAnnotations(map[string]string{RollbackOnFailureAnnotation: tt.annotation})
+			}
+			transform := &platformv1alpha1.Transform{
+				Spec: platformv1alpha1.TransformSpec{RollbackOnFailure: tt.transform},
+			}
+
+			got, err := rollbackOnFailure(instance, transform)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("rollbackOnFailure() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("rollbackOnFailure() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func newRevision(name, phase string, completed time.Time) *platformv1alpha1.ResourceGraph {
+	rg := &platformv1alpha1.ResourceGraph{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels: map[string]string{
+				"pequod.io/instance":       "app",
+				"pequod.io/instance-kind":  "WebService",
+				"pequod.io/instance-group": "apps.example.com",
+			},
+		},
+		Status: platformv1alpha1.ResourceGraphStatus{Phase: phase},
+	}
+	if !completed.IsZero() {
+		rg.Status.CompletedAt = &metav1.Time{Time: completed}
+	}
+	return rg
+}
+
+func TestApplyResourceGraph_KeepsPreviousRevision(t *testing.T) {
+	now := time.Now()
+	c := fake.NewClientBuilder().
+		WithScheme(newTestScheme()).
+		WithObjects(
+			newRevision("app-older", "Completed", now.Add(-2*time.Hour)),
+			newRevision("app-latest", "Completed", now.Add(-time.Hour)),
+			newRevision("app-failed", "Failed", now),
+		).
+		WithStatusSubresource(&platformv1alpha1.ResourceGraph{}).
+		Build()
+	h := &InstanceHandlers{client: c}
+
+	rg := newRevision("app-new", "", time.Time{})
+	if err := h.applyResourceGraph(context.Background(), rg, true); err != nil {
+		t.Fatalf("applyResourceGraph() failed: %v", err)
+	}
+
+	created := &platformv1alpha1.ResourceGraph{}
+	if err := c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "app-new"}, created); err != nil {
+		t.Fatalf("failed to get new revision: %v", err)
+	}
+	if created.Spec.Rollback == nil || created.Spec.Rollback.PreviousRevision != "app-latest" {
+		t.Errorf("expected rollback target app-latest, got %+v", created.Spec.Rollback)
+	}
+
+	list := &platformv1alpha1.ResourceGraphList{}
+	if err := c.List(context.Background(), list); err != nil {
+		t.Fatalf("failed to list revisions: %v", err)
+	}
+	remaining := map[string]bool{}
+	for _, item := range list.Items {
+		remaining[item.Name] = true
+	}
+	if len(remaining) != 2 || !remaining["app-new"] || !remaining["app-latest"] {
+		t.Errorf("expected only app-new and app-latest to remain, got %v", remaining)
+	}
+}
+
+func TestApplyResourceGraph_DeletesOldRevisionsWithoutRollback(t *testing.T) {
+	c := fake.NewClientBuilder().
+		WithScheme(newTestScheme()).
+		WithObjects(newRevision("app-latest", "Completed", time.Now())).
+		WithStatusSubresource(&platformv1alpha1.ResourceGraph{}).
+		Build()
+	h := &InstanceHandlers{client: c}
+
+	rg := newRevision("app-new", "", time.Time{})
+	if err := h.applyResourceGraph(context.Background(), rg, false); err != nil {
+		t.Fatalf("applyResourceGraph() failed: %v", err)
+	}
+
+	if rg.Spec.Rollback != nil {
+		t.Errorf("expected no rollback target, got %+v", rg.Spec.Rollback)
+	}
+	list := &platformv1alpha1.ResourceGraphList{}
+	if err := c.List(context.Background(), list); err != nil {
+		t.Fatalf("failed to list revisions: %v", err)
+	}
+	if len(list.Items) != 1 || list.Items[0].Name != "app-new" {
+		t.Errorf("expected only app-new to remain, got %d revisions", len(list.Items))
+	}
+}
+
+func TestSensitiveValues_StoredOutsideResourceGraph(t *testing.T) {
+	g := &graph.Graph{
+		Metadata: graph.GraphMetadata{
+			Name:       "app",
+			RenderHash: "abcdef0123456789",
+			Input:      []byte(`{"spec":{"password":"hunter22"}}`),
+		},
+		Nodes: []graph.Node{{
+			ID: "credentials",
+			Object: unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Secret",
+				"metadata":   map[string]interface{}{"name": "db", "namespace": "default"},
+				"stringData": map[string]interface{}{"password": "hunter22"},
+			}},
+			SensitiveFields: []string{"stringData"},
+		}},
+	}
+	sensitive, err := extractSensitiveValues(g)
+	if err != nil {
+		t.Fatalf("extractSensitiveValues() failed: %v", err)
+	}
+
+	c := fake.NewClientBuilder().WithScheme(newTestScheme()).Build()
+	h := &InstanceHandlers{client: c, scheme: newTestScheme()}
+
+	instance := &unstructured.Unstructured{}
+	instance.SetAPIVersion("apps.example.com/v1")
+	instance.SetKind("WebService")
+	instance.SetName("app")
+	instance.SetNamespace("default")
+	rg, err := h.buildResourceGraph(instance, &platformv1alpha1.Transform{}, g)
+	if err != nil {
+		t.Fatalf("buildResourceGraph() failed: %v", err)
+	}
+	spec, err := json.Marshal(rg.Spec)
+	if err != nil {
+		t.Fatalf("failed to encode ResourceGraph spec: %v", err)
+	}
+	if strings.Contains(string(spec), "hunter22") {
+		t.Errorf("expected the ResourceGraph spec not to contain the password, got %s", spec)
+	}
+	input, err := compressRenderInput(g.Metadata.Input)
+	if err != nil {
+		t.Fatalf("compressRenderInput() failed: %v", err)
+	}
+
+	if err := h.applyResourceGraph(context.Background(), rg, false); err != nil {
+		t.Fatalf("applyResourceGraph() failed: %v", err)
+	}
+	if err := h.applySensitiveValues(context.Background(), rg, sensitive, input); err != nil {
+		t.Fatalf("applySensitiveValues() failed: %v", err)
+	}
+
+	secret := &corev1.Secret{}
+	if err := c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: sensitiveValuesName(rg.Name)}, secret); err != nil {
+		t.Fatalf("failed to get sensitive values Secret: %v", err)
+	}
+	if owner := metav1.GetControllerOf(secret); owner == nil || owner.Name != rg.Name {
+		t.Errorf("expected the Secret to be controlled by %s, got %v", rg.Name, owner)
+	}
+
+	var stored map[string]map[string]interface{}
+	if err := json.Unmarshal(secret.Data[SensitiveValuesKey], &stored); err != nil {
+		t.Fatalf("failed to decode sensitive values: %v", err)
+	}
+	password, _, _ := unstructured.NestedString(stored["credentials"], "stringData", "password")
+	if password != "hunter22" {
+		t.Errorf("expected the password to be stored, got %v", stored)
+	}
+	storedInput, err := decompressRenderInput(secret.Data[RenderInputKey])
+	if err != nil {
+		t.Fatalf("decompressRenderInput() failed: %v", err)
+	}
+	if string(storedInput) != string(g.Metadata.Input) {
+		t.Errorf("expected the render input to be stored, got %s", storedInput)
+	}
+}
+
+func TestBuildResourceGraph_CompressesLargeGraphs(t *testing.T) {
+	g := &graph.Graph{Metadata: graph.GraphMetadata{Name: "bootstrap", RenderHash: "abcdef0123456789"}}
+	for i := 0; i < platformv1alpha1.MaxInlineNodes+50; i++ {
+		g.Nodes = append(g.Nodes, graph.Node{
+			ID: fmt.Sprintf("role-%d", i),
+			Object: unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "rbac.authorization.k8s.io/v1",
+				"kind":       "Role",
+				"metadata":   map[string]interface{}{"name": fmt.Sprintf("role-%d", i), "namespace": "team-a"},
+			}},
+		})
+	}
+
+	instance := &unstructured.Unstructured{}
+	instance.SetAPIVersion("apps.example.com/v1")
+	instance.SetKind("Namespace")
+	instance.SetName("team-a")
+	rg, err := (&InstanceHandlers{}).buildResourceGraph(instance, &platformv1alpha1.Transform{}, g)
+	if err != nil {
+		t.Fatalf("buildResourceGraph() failed: %v", err)
+	}
+
+	if len(rg.Spec.Nodes) != 0 || len(rg.Spec.CompressedNodes) == 0 {
+		t.Fatalf("expected nodes to be compressed, got %d inline nodes", len(rg.Spec.Nodes))
+	}
+	if rg.Spec.NodeCount != len(g.Nodes) {
+		t.Errorf("expected nodeCount %d, got %d", len(g.Nodes), rg.Spec.NodeCount)
+	}
+
+	nodes, err := rg.Spec.GetNodes()
+	if err != nil {
+		t.Fatalf("GetNodes() failed: %v", err)
+	}
+	if len(nodes) != len(g.Nodes) || nodes[149].ID != "role-149" {
+		t.Errorf("expected %d nodes ending in role-149 after decompression, got %d", len(g.Nodes), len(nodes))
+	}
+}
+
+func TestRenderBudgetViolations_DegradeTransform(t *testing.T) {
+	transform := &platformv1alpha1.Transform{ObjectMeta: metav1.ObjectMeta{Name: "webservice", Namespace: "default"}}
+	c := fake.NewClientBuilder().
+		WithScheme(newTestScheme()).
+		WithObjects(transform).
+		WithStatusSubresource(&platformv1alpha1.Transform{}).
+		Build()
+	h := &InstanceHandlers{client: c}
+	budgetErr := &platformloader.BudgetError{Reason: platformloader.ReasonRenderTimeout, Message: "too slow"}
+
+	for i := 1; i <= DegradedAfterBudgetViolations; i++ {
+		if cond := transform.GetCondition(ConditionTypeDegraded); cond != nil {
+			t.Fatalf("expected no Degraded condition after %d violations, got %+v", i-1, cond)
+		}
+		if err := h.recordRenderBudgetViolation(context.Background(), transform, budgetErr); err != nil {
+			t.Fatalf("recordRenderBudgetViolation() failed: %v", err)
+		}
+	}
+
+	cond := transform.GetCondition(ConditionTypeDegraded)
+	if cond == nil || cond.Status != metav1.ConditionTrue || cond.Reason != platformloader.ReasonRenderTimeout {
+		t.Fatalf("expected the Transform to be degraded, got %+v", cond)
+	}
+
+	if err := h.clearRenderBudgetViolations(context.Background(), transform); err != nil {
+		t.Fatalf("clearRenderBudgetViolations() failed: %v", err)
+	}
+	stored := &platformv1alpha1.Transform{}
+	if err := c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "webservice"}, stored); err != nil {
+		t.Fatalf("failed to get Transform: %v", err)
+	}
+	if stored.Status.RenderBudgetViolations != 0 || stored.GetCondition(ConditionTypeDegraded).Status != metav1.ConditionFalse {
+		t.Errorf("expected violations to be cleared, got %+v", stored.Status)
+	}
+}
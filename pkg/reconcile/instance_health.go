@@ -0,0 +1,245 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconcile
+
+import (
+	"sort"
+	"sync"
+	"unicode/utf8"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	platformv1alpha1 "github.com/chazu/pequod/api/v1alpha1"
+)
+
+const (
+	// instanceHealthEventBuffer is how many instance kinds can have a
+	// notification pending before further kinds wait for one to be delivered
+	instanceHealthEventBuffer = 256
+
+	// maxFailureMessageLength truncates the messages of failing instances
+	// in Transform status
+	maxFailureMessageLength = 256
+)
+
+// instanceHealth is the part of an instance's status rolled up to its
+// Transform
+type instanceHealth struct {
+	phase   string
+	ready   bool
+	reason  string
+	message string
+	digest  string
+}
+
+// failed reports whether the instance's current ResourceGraph failed
+func (h instanceHealth) failed() bool {
+	return h.phase == "Failed" || h.phase == "RolledBack"
+}
+
+// InstanceHealthIndex records the health of every platform instance as the
+// instance informers observe it, so that Transforms roll up their instances
+// without listing them. Each change notifies the kind's Transform through
+// Events, at most once until the notification is delivered.
+type InstanceHealthIndex struct {
+	mu        sync.RWMutex
+	instances map[schema.GroupKind]map[types.NamespacedName]instanceHealth
+	pending   map[schema.GroupKind]bool
+	events    chan event.GenericEvent
+
+	// dirty holds an instance of each kind that changed while Events was
+	// full, to notify once a notification is delivered
+	dirty map[schema.GroupKind]*unstructured.Unstructured
+}
+
+// NewInstanceHealthIndex creates an empty index
+func NewInstanceHealthIndex() *InstanceHealthIndex {
+	return &InstanceHealthIndex{
+		instances: make(map[schema.GroupKind]map[types.NamespacedName]instanceHealth),
+		pending:   make(map[schema.GroupKind]bool),
+		events:    make(chan event.GenericEvent, instanceHealthEventBuffer),
+		dirty:     make(map[schema.GroupKind]*unstructured.Unstructured),
+	}
+}
+
+// Events returns the channel notified with an instance whenever the
+// health of its kind changed
+func (i *InstanceHealthIndex) Events() <-chan event.GenericEvent {
+	return i.events
+}
+
+// Observe records the current status of an instance
+func (i *InstanceHealthIndex) Observe(obj *unstructured.Unstructured) {
+	health := healthOf(obj)
+	gk := obj.GroupVersionKind().GroupKind()
+	key := types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	kind := i.instances[gk]
+	if kind == nil {
+		kind = make(map[types.NamespacedName]instanceHealth)
+		i.instances[gk] = kind
+	}
+	if previous, found := kind[key]; found && previous == health {
+		return
+	}
+	kind[key] = health
+	i.notify(gk, obj)
+}
+
+// Forget removes a deleted instance
+func (i *InstanceHealthIndex) Forget(obj *unstructured.Unstructured) {
+	gk := obj.GroupVersionKind().GroupKind()
+	key := types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if _, found := i.instances[gk][key]; !found {
+		return
+	}
+	delete(i.instances[gk], key)
+	i.notify(gk, obj)
+}
+
+// notify sends obj to Events unless a notification for its kind is still
+// pending. Called with the lock held.
+func (i *InstanceHealthIndex) notify(gk schema.GroupKind, obj *unstructured.Unstructured) {
+	if i.pending[gk] {
+		return
+	}
+	if !i.send(gk, obj) {
+		// Never block the informer; every notification in Events is
+		// delivered, which retries the kind
+		i.dirty[gk] = obj
+	}
+}
+
+// send sends obj to Events if there is room. Called with the lock held.
+func (i *InstanceHealthIndex) send(gk schema.GroupKind, obj *unstructured.Unstructured) bool {
+	select {
+	case i.events <- event.GenericEvent{Object: obj}:
+		i.pending[gk] = true
+		delete(i.dirty, gk)
+		return true
+	default:
+		return false
+	}
+}
+
+// Delivered marks the notification of a kind as taken from Events, so that
+// further changes notify again, and notifies the kinds that changed while
+// Events was full. Call it for every notification, whether or not it could
+// be mapped to a Transform: a kind left pending is never notified again.
+func (i *InstanceHealthIndex) Delivered(gk schema.GroupKind) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	delete(i.pending, gk)
+	for dirty, obj := range i.dirty {
+		if !i.send(dirty, obj) {
+			break
+		}
+	}
+}
+
+// Summary returns the roll-up of the instances of a kind
+func (i *InstanceHealthIndex) Summary(gk schema.GroupKind) *platformv1alpha1.InstanceSummary {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	summary := &platformv1alpha1.InstanceSummary{}
+	digests := map[string]int32{}
+	for key, health := range i.instances[gk] {
+		summary.Total++
+		switch {
+		case health.failed():
+			summary.Failed++
+			summary.FailingInstances = append(summary.FailingInstances, platformv1alpha1.FailingInstance{
+				Name:      key.Name,
+				Namespace: key.Namespace,
+				Reason:    health.reason,
+				Message:   health.message,
+			})
+		case health.ready:
+			summary.Ready++
+		default:
+			summary.Reconciling++
+		}
+		if health.digest != "" {
+			digests[health.digest]++
+		}
+	}
+
+	sort.Slice(summary.FailingInstances, func(a, b int) bool {
+		x, y := summary.FailingInstances[a], summary.FailingInstances[b]
+		if x.Namespace != y.Namespace {
+			return x.Namespace < y.Namespace
+		}
+		return x.Name < y.Name
+	})
+	if len(summary.FailingInstances) > maxReportedInstances {
+		summary.FailingInstances = summary.FailingInstances[:maxReportedInstances]
+	}
+
+	for digest, count := range digests {
+		summary.Digests = append(summary.Digests, platformv1alpha1.DigestCount{Digest: digest, Count: count})
+	}
+	sort.Slice(summary.Digests, func(a, b int) bool {
+		x, y := summary.Digests[a], summary.Digests[b]
+		if x.Count != y.Count {
+			return x.Count > y.Count
+		}
+		return x.Digest < y.Digest
+	})
+	return summary
+}
+
+// healthOf reads the health of an instance from its status
+func healthOf(obj *unstructured.Unstructured) instanceHealth {
+	health := instanceHealth{}
+	health.phase, _, _ = unstructured.NestedString(obj.Object, "status", "phase")
+	health.digest, _, _ = unstructured.NestedString(obj.Object, "status", "moduleDigest")
+
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok || condition["type"] != ConditionTypeReady {
+			continue
+		}
+		health.ready = condition["status"] == "True"
+		health.reason, _ = condition["reason"].(string)
+		health.message, _ = condition["message"].(string)
+	}
+	health.message = truncateMessage(health.message, maxFailureMessageLength)
+	return health
+}
+
+// truncateMessage shortens a message to at most limit bytes, cutting it at
+// a character boundary
+func truncateMessage(message string, limit int) string {
+	if len(message) <= limit {
+		return message
+	}
+	end := limit
+	for end > 0 && !utf8.RuneStart(message[end]) {
+		end--
+	}
+	return message[:end] + "..."
+}
@@ -0,0 +1,242 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconcile
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	platformv1alpha1 "github.com/chazu/pequod/api/v1alpha1"
+)
+
+var webServiceKind = schema.GroupKind{Group: "apps.example.com", Kind: "WebService"}
+
+// newInstanceWithHealth returns a WebService instance in the given phase,
+// rendered from digest
+func newInstanceWithHealth(name, phase, readyStatus, digest string) *unstructured.Unstructured {
+	obj := newInstance("WebService", name, nil)
+	_ = unstructured.SetNestedField(obj.Object, phase, "status", "phase")
+	_ = unstructured.SetNestedField(obj.Object, digest, "status", "moduleDigest")
+	_ = SetInstanceCondition(obj, metav1.Condition{
+		Type:    ConditionTypeReady,
+		Status:  metav1.ConditionStatus(readyStatus),
+		Reason:  phase,
+		Message: name + " is " + phase,
+	})
+	return obj
+}
+
+func TestInstanceHealthIndex_Summary(t *testing.T) {
+	index := NewInstanceHealthIndex()
+	index.Observe(newInstanceWithHealth("api", "Completed", "True", "sha256:new"))
+	index.Observe(newInstanceWithHealth("web", "Completed", "True", "sha256:new"))
+	index.Observe(newInstanceWithHealth("worker", "Executing", "False", "sha256:old"))
+	index.Observe(newInstanceWithHealth("db", "Failed", "False", "sha256:new"))
+	index.Observe(newInstanceWithHealth("cache", "RolledBack", "False", "sha256:old"))
+	index.Observe(newInstance("Database", "other-kind", nil))
+
+	got := index.Summary(webServiceKind)
+	want := &platformv1alpha1.InstanceSummary{
+		Total:       5,
+		Ready:       2,
+		Failed:      2,
+		Reconciling: 1,
+		FailingInstances: []platformv1alpha1.FailingInstance{
+			{Name: "cache", Namespace: "default", Reason: "RolledBack", Message: "cache is RolledBack"},
+			{Name: "db", Namespace: "default", Reason: "Failed", Message: "db is Failed"},
+		},
+		Digests: []platformv1alpha1.DigestCount{
+			{Digest: "sha256:new", Count: 3},
+			{Digest: "sha256:old", Count: 2},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected summary:\ngot:  %+v\nwant: %+v", got, want)
+	}
+
+	index.Forget(newInstance("WebService", "db", nil))
+	if got := index.Summary(webServiceKind); got.Total != 4 || got.Failed != 1 {
+		t.Errorf("expected 4 instances with 1 failed after forgetting one, got %+v", got)
+	}
+}
+
+func TestInstanceHealthIndex_BoundsFailingInstances(t *testing.T) {
+	index := NewInstanceHealthIndex()
+	for i := 0; i < maxReportedInstances+5; i++ {
+		index.Observe(newInstanceWithHealth(fmt.Sprintf("app-%02d", i), "Failed", "False", "sha256:a"))
+	}
+
+	got := index.Summary(webServiceKind)
+	if got.Failed != int32(maxReportedInstances+5) {
+		t.Errorf("expected %d failed, got %d", maxReportedInstances+5, got.Failed)
+	}
+	if len(got.FailingInstances) != maxReportedInstances {
+		t.Errorf("expected %d failing instances listed, got %d", maxReportedInstances, len(got.FailingInstances))
+	}
+}
+
+func TestInstanceHealthIndex_TruncatesMessages(t *testing.T) {
+	// The limit falls inside a two-byte character
+	message := "a" + strings.Repeat("é", maxFailureMessageLength)
+	obj := newInstanceWithHealth("api", "Failed", "False", "sha256:a")
+	_ = SetInstanceCondition(obj, metav1.Condition{
+		Type:    ConditionTypeReady,
+		Status:  metav1.ConditionFalse,
+		Reason:  "Failed",
+		Message: message,
+	})
+
+	got := healthOf(obj).message
+	if !utf8.ValidString(got) {
+		t.Errorf("expected a valid UTF-8 message, got %q", got)
+	}
+	if want := message[:maxFailureMessageLength-1] + "..."; got != want {
+		t.Errorf("expected the message cut at the last whole character:\ngot:  %q\nwant: %q", got, want)
+	}
+}
+
+func TestInstanceHealthIndex_Events(t *testing.T) {
+	index := NewInstanceHealthIndex()
+
+	index.Observe(newInstanceWithHealth("api", "Executing", "False", "sha256:a"))
+	index.Observe(newInstanceWithHealth("web", "Executing", "False", "sha256:a"))
+	if n := len(index.Events()); n != 1 {
+		t.Fatalf("expected one pending notification for the kind, got %d", n)
+	}
+	<-index.Events()
+
+	// Changes before the notification is delivered do not notify again
+	index.Observe(newInstanceWithHealth("api", "Completed", "True", "sha256:a"))
+	if n := len(index.Events()); n != 0 {
+		t.Fatalf("expected no notification before delivery, got %d", n)
+	}
+
+	// Unchanged health does not notify
+	index.Delivered(webServiceKind)
+	index.Observe(newInstanceWithHealth("api", "Completed", "True", "sha256:a"))
+	if n := len(index.Events()); n != 0 {
+		t.Fatalf("expected no notification for unchanged health, got %d", n)
+	}
+
+	// Delivery allows the next change to notify again
+	index.Observe(newInstanceWithHealth("web", "Completed", "True", "sha256:a"))
+	if n := len(index.Events()); n != 1 {
+		t.Fatalf("expected a notification after delivery, got %d", n)
+	}
+}
+
+func TestInstanceHealthIndex_DroppedEvent(t *testing.T) {
+	index := NewInstanceHealthIndex()
+
+	// The notification is delivered, but no Transform is found for it and
+	// no summary is read
+	index.Observe(newInstanceWithHealth("api", "Executing", "False", "sha256:a"))
+	<-index.Events()
+	index.Delivered(webServiceKind)
+
+	index.Observe(newInstanceWithHealth("api", "Completed", "True", "sha256:a"))
+	if n := len(index.Events()); n != 1 {
+		t.Fatalf("expected the kind to be notified again after a dropped event, got %d", n)
+	}
+}
+
+func TestInstanceHealthIndex_EventsFull(t *testing.T) {
+	index := NewInstanceHealthIndex()
+	index.events = make(chan event.GenericEvent, 1)
+
+	index.Observe(newInstanceWithHealth("web", "Executing", "False", "sha256:a"))
+	database := newInstance("Database", "db", nil)
+	index.Observe(database)
+	if n := len(index.Events()); n != 1 {
+		t.Fatalf("expected only the first kind to be notified, got %d", n)
+	}
+
+	// Delivering a notification makes room for the kind that changed while
+	// Events was full
+	<-index.Events()
+	index.Delivered(webServiceKind)
+	select {
+	case e := <-index.Events():
+		if e.Object.GetObjectKind().GroupVersionKind().Kind != "Database" {
+			t.Errorf("expected the Database kind to be notified, got %v", e.Object.GetObjectKind())
+		}
+	default:
+		t.Fatal("expected the kind that changed while Events was full to be notified")
+	}
+}
+
+func TestTransformHandlers_RollsUpInstances(t *testing.T) {
+	tf := &platformv1alpha1.Transform{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "webservice",
+			Namespace:  "default",
+			Generation: 1,
+			Finalizers: []string{TransformFinalizer},
+		},
+		Spec: platformv1alpha1.TransformSpec{
+			CueRef: platformv1alpha1.CueReference{
+				Type: platformv1alpha1.CueRefTypeEmbedded,
+				Ref:  "webservice",
+			},
+			Group: "apps.example.com",
+		},
+		Status: platformv1alpha1.TransformStatus{
+			Phase:              platformv1alpha1.TransformPhaseReady,
+			ObservedGeneration: 1,
+			GeneratedCRD: &platformv1alpha1.GeneratedCRDReference{
+				APIVersion: "apps.example.com/v1alpha1",
+				Kind:       "WebService",
+				Name:       "webservices.apps.example.com",
+			},
+		},
+	}
+	c := newTestClient(tf)
+	handlers := newTestHandlers(c)
+	handlers.health = NewInstanceHealthIndex()
+	handlers.health.Observe(newInstanceWithHealth("api", "Completed", "True", "sha256:a"))
+	handlers.health.Observe(newInstanceWithHealth("db", "Failed", "False", "sha256:a"))
+
+	key := types.NamespacedName{Name: "webservice", Namespace: "default"}
+	if _, err := handlers.Reconcile(context.Background(), key); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	updated := &platformv1alpha1.Transform{}
+	if err := c.Get(context.Background(), key, updated); err != nil {
+		t.Fatalf("failed to get transform: %v", err)
+	}
+	summary := updated.Status.Instances
+	if summary == nil {
+		t.Fatal("expected instance summary in status")
+	}
+	if summary.Total != 2 || summary.Ready != 1 || summary.Failed != 1 {
+		t.Errorf("unexpected counts: %+v", summary)
+	}
+	if len(summary.FailingInstances) != 1 || summary.FailingInstances[0].Name != "db" {
+		t.Errorf("expected db to be listed as failing, got %+v", summary.FailingInstances)
+	}
+}
@@ -0,0 +1,300 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconcile
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	platformv1alpha1 "github.com/chazu/pequod/api/v1alpha1"
+	"github.com/chazu/pequod/pkg/platformloader"
+)
+
+// ReferenceRequeueInterval is how often an instance waiting for a referenced
+// instance is rendered again
+const ReferenceRequeueInterval = 15 * time.Second
+
+// InstanceKey identifies a platform instance of any generated kind
+type InstanceKey struct {
+	schema.GroupKind
+	types.NamespacedName
+}
+
+// instanceKeyOf returns the key of an instance
+func instanceKeyOf(obj *unstructured.Unstructured) InstanceKey {
+	return InstanceKey{
+		GroupKind:      obj.GroupVersionKind().GroupKind(),
+		NamespacedName: client.ObjectKeyFromObject(obj),
+	}
+}
+
+func (k InstanceKey) String() string {
+	return fmt.Sprintf("%s %s", k.Kind, k.NamespacedName)
+}
+
+// ReferenceNotReadyError reports a referenced instance that does not exist
+// or has not completed yet. The referencing instance is rendered again
+// after ReferenceRequeueInterval.
+type ReferenceNotReadyError struct {
+	Ref    InstanceKey
+	Reason string
+}
+
+func (e *ReferenceNotReadyError) Error() string {
+	return fmt.Sprintf("referenced %s is not ready: %s", e.Ref, e.Reason)
+}
+
+// ReferenceCycleError reports instances that reference each other
+type ReferenceCycleError struct {
+	// Cycle lists the instances in the cycle, starting and ending with the
+	// instance being rendered
+	Cycle []InstanceKey
+}
+
+func (e *ReferenceCycleError) Error() string {
+	names := make([]string, len(e.Cycle))
+	for i, key := range e.Cycle {
+		names[i] = key.String()
+	}
+	return fmt.Sprintf("instance references form a cycle: %s", strings.Join(names, " -> "))
+}
+
+// InstanceRefIndex records the instances each instance references, so that
+// dependents are rendered again when a referenced instance changes and
+// reference cycles are detected. It is rebuilt from renders, so after a
+// restart it only knows the instances rendered since.
+type InstanceRefIndex struct {
+	mu   sync.RWMutex
+	refs map[InstanceKey][]InstanceKey
+}
+
+// NewInstanceRefIndex creates an empty index
+func NewInstanceRefIndex() *InstanceRefIndex {
+	return &InstanceRefIndex{refs: make(map[InstanceKey][]InstanceKey)}
+}
+
+// Set records the instances a dependent references, replacing earlier ones
+func (i *InstanceRefIndex) Set(dependent InstanceKey, referenced []InstanceKey) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if len(referenced) == 0 {
+		delete(i.refs, dependent)
+		return
+	}
+	i.refs[dependent] = referenced
+}
+
+// Delete forgets a dependent's references
+func (i *InstanceRefIndex) Delete(dependent InstanceKey) {
+	i.Set(dependent, nil)
+}
+
+// Dependents returns the instances referencing the given instance
+func (i *InstanceRefIndex) Dependents(referenced InstanceKey) []InstanceKey {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	var dependents []InstanceKey
+	for dependent, refs := range i.refs {
+		for _, ref := range refs {
+			if ref == referenced {
+				dependents = append(dependents, dependent)
+				break
+			}
+		}
+	}
+	return dependents
+}
+
+// findCycle returns a path of references leading from start back to
+// itself, or nil if there is none
+func (i *InstanceRefIndex) findCycle(start InstanceKey) []InstanceKey {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	visited := map[InstanceKey]bool{start: true}
+	path := []InstanceKey{start}
+	var visit func(key InstanceKey) bool
+	visit = func(key InstanceKey) bool {
+		for _, next := range i.refs[key] {
+			if next == start {
+				path = append(path, start)
+				return true
+			}
+			if visited[next] {
+				continue
+			}
+			visited[next] = true
+			path = append(path, next)
+			if visit(next) {
+				return true
+			}
+			path = path[:len(path)-1]
+		}
+		return false
+	}
+	if visit(start) {
+		return path
+	}
+	return nil
+}
+
+// Dependents returns the instances referencing obj, for re-rendering them
+// when obj changes
+func (h *InstanceHandlers) Dependents(obj *unstructured.Unstructured) []types.NamespacedName {
+	keys := h.refs.Dependents(instanceKeyOf(obj))
+	names := make([]types.NamespacedName, len(keys))
+	for i, key := range keys {
+		names[i] = key.NamespacedName
+	}
+	return names
+}
+
+// instanceResolver returns the resolver of the instances referenced by
+// instance. Each resolves to its identity and status.outputs, once it has
+// a completed ResourceGraph.
+func (h *InstanceHandlers) instanceResolver(instance *unstructured.Unstructured) platformloader.InstanceResolver {
+	dependent := instanceKeyOf(instance)
+	return func(ctx context.Context, refs []platformloader.InstanceReference) (map[string]interface{}, error) {
+		gvks := make([]schema.GroupVersionKind, len(refs))
+		keys := make([]InstanceKey, 0, len(refs))
+		for i, ref := range refs {
+			gvk, err := referencedGVK(ctx, h.client, ref)
+			if err != nil {
+				h.refs.Set(dependent, keys)
+				return nil, fmt.Errorf("spec.%s: %w", ref.Field, err)
+			}
+			gvks[i] = gvk
+			keys = append(keys, InstanceKey{
+				GroupKind:      gvk.GroupKind(),
+				NamespacedName: types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name},
+			})
+		}
+
+		// Record the references before waiting on them, so that a cycle is
+		// found by whichever instance of it renders last
+		h.refs.Set(dependent, keys)
+		if cycle := h.refs.findCycle(dependent); cycle != nil {
+			return nil, &ReferenceCycleError{Cycle: cycle}
+		}
+
+		resolved := make(map[string]interface{}, len(refs))
+		for i, ref := range refs {
+			value, err := h.resolveInstance(ctx, keys[i], gvks[i])
+			if err != nil {
+				return nil, fmt.Errorf("spec.%s: %w", ref.Field, err)
+			}
+			resolved[ref.Field] = value
+		}
+		return resolved, nil
+	}
+}
+
+// referencedGVK finds the generated kind of a reference from the Transforms
+// that generated it
+func referencedGVK(ctx context.Context, c client.Client, ref platformloader.InstanceReference) (schema.GroupVersionKind, error) {
+	transforms := &platformv1alpha1.TransformList{}
+	if err := c.List(ctx, transforms); err != nil {
+		return schema.GroupVersionKind{}, fmt.Errorf("failed to list Transforms: %w", err)
+	}
+
+	matches := map[schema.GroupVersionKind]bool{}
+	for _, tf := range transforms.Items {
+		generated := tf.Status.GeneratedCRD
+		if generated == nil || generated.Kind != ref.Kind {
+			continue
+		}
+		gv, err := schema.ParseGroupVersion(generated.APIVersion)
+		if err != nil || (ref.Group != "" && gv.Group != ref.Group) {
+			continue
+		}
+		matches[gv.WithKind(generated.Kind)] = true
+	}
+
+	switch len(matches) {
+	case 0:
+		return schema.GroupVersionKind{}, fmt.Errorf("no Transform generates kind %s", ref.Kind)
+	case 1:
+		for gvk := range matches {
+			return gvk, nil
+		}
+	}
+	groups := make([]string, 0, len(matches))
+	for gvk := range matches {
+		groups = append(groups, gvk.Group)
+	}
+	sort.Strings(groups)
+	return schema.GroupVersionKind{}, fmt.Errorf("kind %s is generated in groups %s; set group in @instanceRef",
+		ref.Kind, strings.Join(groups, ", "))
+}
+
+// resolveInstance returns the value a module receives for a referenced
+// instance, or a ReferenceNotReadyError if it has not completed
+func (h *InstanceHandlers) resolveInstance(ctx context.Context, key InstanceKey, gvk schema.GroupVersionKind) (interface{}, error) {
+	referenced := &unstructured.Unstructured{}
+	referenced.SetGroupVersionKind(gvk)
+	if err := h.client.Get(ctx, key.NamespacedName, referenced); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, &ReferenceNotReadyError{Ref: key, Reason: "not found"}
+		}
+		return nil, fmt.Errorf("failed to get %s: %w", key, err)
+	}
+	if !referenced.GetDeletionTimestamp().IsZero() {
+		return nil, &ReferenceNotReadyError{Ref: key, Reason: "being deleted"}
+	}
+
+	revisions := &platformv1alpha1.ResourceGraphList{}
+	if err := h.client.List(ctx, revisions,
+		client.InNamespace(key.Namespace),
+		client.MatchingLabels{
+			"pequod.io/instance":       key.Name,
+			"pequod.io/instance-kind":  gvk.Kind,
+			"pequod.io/instance-group": gvk.Group,
+		},
+	); err != nil {
+		return nil, fmt.Errorf("failed to list ResourceGraphs of %s: %w", key, err)
+	}
+	if previousRevision(revisions.Items, "") == nil {
+		return nil, &ReferenceNotReadyError{Ref: key, Reason: "no ResourceGraph has completed"}
+	}
+
+	outputs, _, err := unstructured.NestedMap(referenced.Object, "status", "outputs")
+	if err != nil {
+		return nil, fmt.Errorf("invalid status.outputs on %s: %w", key, err)
+	}
+	if outputs == nil {
+		outputs = map[string]interface{}{}
+	}
+	return map[string]interface{}{
+		"apiVersion": gvk.GroupVersion().String(),
+		"kind":       gvk.Kind,
+		"name":       key.Name,
+		"namespace":  key.Namespace,
+		"outputs":    outputs,
+	}, nil
+}
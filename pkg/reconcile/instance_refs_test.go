@@ -0,0 +1,154 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconcile
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	platformv1alpha1 "github.com/chazu/pequod/api/v1alpha1"
+	"github.com/chazu/pequod/pkg/platformloader"
+)
+
+func newGeneratingTransform(name, kind string) *platformv1alpha1.Transform {
+	return &platformv1alpha1.Transform{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "platform"},
+		Status: platformv1alpha1.TransformStatus{
+			GeneratedCRD: &platformv1alpha1.GeneratedCRDReference{APIVersion: "apps.example.com/v1alpha1", Kind: kind},
+		},
+	}
+}
+
+func newInstance(kind, name string, outputs map[string]interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	obj.SetAPIVersion("apps.example.com/v1alpha1")
+	obj.SetKind(kind)
+	obj.SetName(name)
+	obj.SetNamespace("default")
+	if outputs != nil {
+		_ = unstructured.SetNestedMap(obj.Object, outputs, "status", "outputs")
+	}
+	return obj
+}
+
+func newCompletedRevision(kind, name string) *platformv1alpha1.ResourceGraph {
+	return &platformv1alpha1.ResourceGraph{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name + "-abc12345",
+			Namespace: "default",
+			Labels: map[string]string{
+				"pequod.io/instance":       name,
+				"pequod.io/instance-kind":  kind,
+				"pequod.io/instance-group": "apps.example.com",
+			},
+		},
+		Status: platformv1alpha1.ResourceGraphStatus{Phase: "Completed"},
+	}
+}
+
+func TestInstanceResolver(t *testing.T) {
+	objects := []client.Object{
+		newGeneratingTransform("webservice", "WebService"),
+		newGeneratingTransform("database", "Database"),
+		newInstance("Database", "orders-db", map[string]interface{}{"secretName": "orders-db-conn"}),
+		newCompletedRevision("Database", "orders-db"),
+		newInstance("Database", "pending-db", nil),
+	}
+	c := fake.NewClientBuilder().WithScheme(newTestScheme()).WithObjects(objects...).Build()
+	h := &InstanceHandlers{client: c, refs: NewInstanceRefIndex()}
+	app := newInstance("WebService", "app", nil)
+
+	ref := func(name string) []platformloader.InstanceReference {
+		return []platformloader.InstanceReference{{Field: "database", Kind: "Database", Name: name, Namespace: "default"}}
+	}
+
+	resolved, err := h.instanceResolver(app)(context.Background(), ref("orders-db"))
+	if err != nil {
+		t.Fatalf("resolver failed: %v", err)
+	}
+	want := map[string]interface{}{
+		"database": map[string]interface{}{
+			"apiVersion": "apps.example.com/v1alpha1",
+			"kind":       "Database",
+			"name":       "orders-db",
+			"namespace":  "default",
+			"outputs":    map[string]interface{}{"secretName": "orders-db-conn"},
+		},
+	}
+	if !reflect.DeepEqual(resolved, want) {
+		t.Errorf("resolver returned %v, want %v", resolved, want)
+	}
+
+	db := InstanceKey{
+		GroupKind:      schema.GroupKind{Group: "apps.example.com", Kind: "Database"},
+		NamespacedName: types.NamespacedName{Namespace: "default", Name: "orders-db"},
+	}
+	if dependents := h.Dependents(newInstance("Database", "orders-db", nil)); !reflect.DeepEqual(dependents, []types.NamespacedName{{Namespace: "default", Name: "app"}}) {
+		t.Errorf("expected app to depend on %s, got %v", db, dependents)
+	}
+
+	for _, name := range []string{"pending-db", "missing-db"} {
+		var notReady *ReferenceNotReadyError
+		if _, err := h.instanceResolver(app)(context.Background(), ref(name)); !errors.As(err, &notReady) {
+			t.Errorf("expected %s not to be ready, got %v", name, err)
+		}
+	}
+}
+
+func TestInstanceResolver_DetectsCycles(t *testing.T) {
+	c := fake.NewClientBuilder().
+		WithScheme(newTestScheme()).
+		WithObjects(newGeneratingTransform("webservice", "WebService")).
+		Build()
+	h := &InstanceHandlers{client: c, refs: NewInstanceRefIndex()}
+
+	// a references b, which references a
+	a, b := newInstance("WebService", "a", nil), newInstance("WebService", "b", nil)
+	refTo := func(name string) []platformloader.InstanceReference {
+		return []platformloader.InstanceReference{{Field: "upstream", Kind: "WebService", Name: name, Namespace: "default"}}
+	}
+	var notReady *ReferenceNotReadyError
+	if _, err := h.instanceResolver(a)(context.Background(), refTo("b")); !errors.As(err, &notReady) {
+		t.Fatalf("expected a to wait for b, got %v", err)
+	}
+
+	_, err := h.instanceResolver(b)(context.Background(), refTo("a"))
+	var cycle *ReferenceCycleError
+	if !errors.As(err, &cycle) || len(cycle.Cycle) != 3 {
+		t.Fatalf("expected a cycle b -> a -> b, got %v", err)
+	}
+	if got := err.Error(); got != "instance references form a cycle: WebService default/b -> WebService default/a -> WebService default/b" {
+		t.Errorf("unexpected error message %q", got)
+	}
+
+	// Dropping the reference breaks the cycle
+	if _, err := h.instanceResolver(b)(context.Background(), nil); err != nil {
+		t.Fatalf("expected no error without references, got %v", err)
+	}
+	if _, err := h.instanceResolver(a)(context.Background(), refTo("b")); !errors.As(err, &notReady) {
+		t.Errorf("expected a to wait for b again, got %v", err)
+	}
+}
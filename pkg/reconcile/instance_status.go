@@ -0,0 +1,143 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconcile
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	platformv1alpha1 "github.com/chazu/pequod/api/v1alpha1"
+)
+
+// ConditionTypeReady is the instance condition reporting whether its
+// current ResourceGraph completed
+const ConditionTypeReady = "Ready"
+
+// recordRevision points the instance's status at the ResourceGraph rendered
+// for its current generation from the module with the given digest, of the
+// given channel. A new revision resets the instance to Pending until the
+// ResourceGraph controller rolls up its outcome.
+func (h *InstanceHandlers) recordRevision(
+	ctx context.Context, instance *unstructured.Unstructured, rg *platformv1alpha1.ResourceGraph, digest, channel string,
+) error {
+	latest := &unstructured.Unstructured{}
+	latest.SetGroupVersionKind(instance.GroupVersionKind())
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := h.client.Get(ctx, client.ObjectKeyFromObject(instance), latest); err != nil {
+			return err
+		}
+		status, _, _ := unstructured.NestedMap(latest.Object, "status")
+		before := status
+
+		current, _, _ := unstructured.NestedString(latest.Object, "status", "resourceGraphRef", "name")
+		if current != rg.Name {
+			if err := unstructured.SetNestedStringMap(latest.Object, map[string]string{
+				"name":      rg.Name,
+				"namespace": rg.Namespace,
+			}, "status", "resourceGraphRef"); err != nil {
+				return err
+			}
+			if err := unstructured.SetNestedField(latest.Object, "Pending", "status", "phase"); err != nil {
+				return err
+			}
+			if err := SetInstanceCondition(latest, metav1.Condition{
+				Type:    ConditionTypeReady,
+				Status:  metav1.ConditionFalse,
+				Reason:  "ResourceGraphPending",
+				Message: fmt.Sprintf("ResourceGraph %s has not completed", rg.Name),
+			}); err != nil {
+				return err
+			}
+		}
+		if err := unstructured.SetNestedField(latest.Object, instance.GetGeneration(), "status", "observedGeneration"); err != nil {
+			return err
+		}
+		if err := unstructured.SetNestedField(latest.Object, digest, "status", "moduleDigest"); err != nil {
+			return err
+		}
+		if err := unstructured.SetNestedField(latest.Object, channel, "status", "channel"); err != nil {
+			return err
+		}
+
+		status, _, _ = unstructured.NestedMap(latest.Object, "status")
+		if equality.Semantic.DeepEqual(before, status) {
+			return nil
+		}
+		return h.client.Status().Update(ctx, latest)
+	})
+}
+
+// SetInstanceCondition adds or replaces a condition in an unstructured
+// instance's status.conditions. The transition time is kept while the
+// condition's status is unchanged.
+func SetInstanceCondition(instance *unstructured.Unstructured, condition metav1.Condition) error {
+	conditions, _, err := unstructured.NestedSlice(instance.Object, "status", "conditions")
+	if err != nil {
+		return err
+	}
+
+	transitionTime := condition.LastTransitionTime
+	if transitionTime.IsZero() {
+		transitionTime = metav1.Now()
+	}
+	for _, c := range conditions {
+		if m, ok := c.(map[string]interface{}); ok && m["type"] == condition.Type && m["status"] == string(condition.Status) {
+			if previous, ok := m["lastTransitionTime"].(string); ok {
+				if t, err := time.Parse(time.RFC3339, previous); err == nil {
+					transitionTime = metav1.NewTime(t)
+				}
+			}
+		}
+	}
+
+	if err := RemoveInstanceCondition(instance, condition.Type); err != nil {
+		return err
+	}
+	conditions, _, _ = unstructured.NestedSlice(instance.Object, "status", "conditions")
+	conditions = append(conditions, map[string]interface{}{
+		"type":               condition.Type,
+		"status":             string(condition.Status),
+		"reason":             condition.Reason,
+		"message":            condition.Message,
+		"lastTransitionTime": transitionTime.UTC().Format(time.RFC3339),
+	})
+	return unstructured.SetNestedSlice(instance.Object, conditions, "status", "conditions")
+}
+
+// RemoveInstanceCondition drops a condition type from an unstructured
+// instance's status.conditions
+func RemoveInstanceCondition(instance *unstructured.Unstructured, conditionType string) error {
+	conditions, found, err := unstructured.NestedSlice(instance.Object, "status", "conditions")
+	if err != nil || !found {
+		return err
+	}
+	kept := conditions[:0]
+	for _, c := range conditions {
+		if m, ok := c.(map[string]interface{}); ok && m["type"] == conditionType {
+			continue
+		}
+		kept = append(kept, c)
+	}
+	return unstructured.SetNestedSlice(instance.Object, kept, "status", "conditions")
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconcile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	platformv1alpha1 "github.com/chazu/pequod/api/v1alpha1"
+	"github.com/chazu/pequod/pkg/platformloader"
+)
+
+// OutputsProjector writes the #Outputs of an instance's module to the
+// instance's status.outputs once its ResourceGraph completes
+type OutputsProjector struct {
+	client   client.Client
+	renderer *platformloader.Renderer
+}
+
+// NewOutputsProjector creates a new OutputsProjector
+func NewOutputsProjector(c client.Client, renderer *platformloader.Renderer) *OutputsProjector {
+	return &OutputsProjector{client: c, renderer: renderer}
+}
+
+// Project computes the outputs of rg's source instance from the input rg
+// was rendered from and live, the applied objects of the graph by node ID,
+// and writes them to the instance's status if they changed. Modules without
+// #Outputs leave the status untouched.
+func (p *OutputsProjector) Project(
+	ctx context.Context, rg *platformv1alpha1.ResourceGraph, live map[string]interface{},
+) error {
+	transformName := rg.Annotations[TransformAnnotation]
+	if transformName == "" {
+		return nil
+	}
+	input, err := p.renderInput(ctx, rg)
+	if err != nil || input == nil {
+		return err
+	}
+	transform := &platformv1alpha1.Transform{}
+	transformKey := types.NamespacedName{
+		Name:      transformName,
+		Namespace: rg.Annotations[TransformNamespaceAnnotation],
+	}
+	if err := p.client.Get(ctx, transformKey, transform); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to get Transform %s: %w", transformKey, err)
+	}
+
+	source := rg.Spec.SourceRef
+	gv, err := schema.ParseGroupVersion(source.APIVersion)
+	if err != nil {
+		return fmt.Errorf("invalid sourceRef apiVersion %q: %w", source.APIVersion, err)
+	}
+	instanceKey := types.NamespacedName{Name: source.Name, Namespace: source.Namespace}
+	instance := &unstructured.Unstructured{}
+	instance.SetGroupVersionKind(gv.WithKind(source.Kind))
+	if err := p.client.Get(ctx, instanceKey, instance); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to get %s %s: %w", source.Kind, instanceKey, err)
+	}
+	if !instance.GetDeletionTimestamp().IsZero() {
+		return nil
+	}
+
+	cueRef, err := instanceCueRef(transform, instance)
+	if errors.Is(err, ErrUnknownChannel) {
+		// The instance was not rendered either
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	outputs, err := p.renderer.RenderOutputs(ctx, instance.GetNamespace(), runtime.RawExtension{Raw: input}, cueRef, live)
+	if err != nil {
+		return err
+	}
+	if outputs == nil {
+		return nil
+	}
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := p.client.Get(ctx, instanceKey, instance); err != nil {
+			return err
+		}
+		current, _, _ := unstructured.NestedMap(instance.Object, "status", "outputs")
+		if equality.Semantic.DeepEqual(current, outputs) {
+			return nil
+		}
+		if err := unstructured.SetNestedMap(instance.Object, outputs, "status", "outputs"); err != nil {
+			return err
+		}
+		return p.client.Status().Update(ctx, instance)
+	})
+}
+
+// renderInput returns the input rg was rendered from, which the instance
+// controller stores in its sensitive values Secret. Graphs rendered before
+// inputs were stored have none; the next render stores it.
+func (p *OutputsProjector) renderInput(ctx context.Context, rg *platformv1alpha1.ResourceGraph) ([]byte, error) {
+	ref := rg.Spec.SensitiveValuesRef
+	if ref == nil {
+		return nil, nil
+	}
+	secret := &corev1.Secret{}
+	if err := p.client.Get(ctx, types.NamespacedName{Namespace: rg.Namespace, Name: ref.Name}, secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get render input: %w", err)
+	}
+	data, ok := secret.Data[RenderInputKey]
+	if !ok {
+		return nil, nil
+	}
+	return decompressRenderInput(data)
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconcile
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	platformv1alpha1 "github.com/chazu/pequod/api/v1alpha1"
+	"github.com/chazu/pequod/pkg/platformloader"
+)
+
+const outputsModule = `
+#Input: port: int
+
+#Render: {
+	input: {
+		metadata: {name: string, namespace: string}
+		spec: {#Input, platformRef: string}
+	}
+	output: {
+		metadata: {name: input.metadata.name, version: "v1alpha1"}
+		nodes: []
+		violations: []
+	}
+}
+
+#Outputs: {
+	input: #Render.input
+	live: [string]: _
+	output: endpoint: "http://\(live.service.spec.clusterIP):\(input.spec.port)"
+}
+`
+
+func TestOutputsProjector_Project(t *testing.T) {
+	transform := newGeneratingTransform("webservice", "WebService")
+	transform.Spec.CueRef = platformv1alpha1.CueReference{Type: platformv1alpha1.CueRefTypeInline, Ref: outputsModule}
+	instance := newInstance("WebService", "web", nil)
+	// The spec changed since the revision was rendered with port 80
+	_ = unstructured.SetNestedField(instance.Object, int64(8080), "spec", "port")
+
+	rg := newCompletedRevision("WebService", "web")
+	rg.Annotations = map[string]string{
+		TransformAnnotation:          transform.Name,
+		TransformNamespaceAnnotation: transform.Namespace,
+	}
+	rg.Spec.SourceRef = platformv1alpha1.ObjectReference{
+		APIVersion: "apps.example.com/v1alpha1",
+		Kind:       "WebService",
+		Name:       "web",
+		Namespace:  "default",
+	}
+	rg.Spec.SensitiveValuesRef = &platformv1alpha1.LocalObjectReference{Name: sensitiveValuesName(rg.Name)}
+	input, err := compressRenderInput(
+		[]byte(`{"metadata": {"name": "web", "namespace": "default"}, "spec": {"port": 80, "platformRef": ""}}`))
+	if err != nil {
+		t.Fatalf("compressRenderInput() failed: %v", err)
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: sensitiveValuesName(rg.Name), Namespace: rg.Namespace},
+		Data:       map[string][]byte{RenderInputKey: input},
+	}
+	c := fake.NewClientBuilder().WithScheme(newTestScheme()).WithObjects(transform, instance, secret).
+		WithStatusSubresource(instance).Build()
+	projector := NewOutputsProjector(c, platformloader.NewRenderer(createTestLoader()))
+	live := map[string]interface{}{
+		"service": map[string]interface{}{"spec": map[string]interface{}{"clusterIP": "10.0.0.12"}},
+	}
+
+	if err := projector.Project(context.Background(), rg, live); err != nil {
+		t.Fatalf("failed to project outputs: %v", err)
+	}
+
+	updated := newInstance("WebService", "web", nil)
+	if err := c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "web"}, updated); err != nil {
+		t.Fatalf("failed to get instance: %v", err)
+	}
+	outputs, _, _ := unstructured.NestedMap(updated.Object, "status", "outputs")
+	want := map[string]interface{}{"endpoint": "http://10.0.0.12:80"}
+	if !reflect.DeepEqual(outputs, want) {
+		t.Errorf("expected status.outputs %v, got %v", want, outputs)
+	}
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconcile
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	platformv1alpha1 "github.com/chazu/pequod/api/v1alpha1"
+)
+
+// DefaultPlatformConfigNamespace is the namespace whose PlatformConfigs may
+// select other namespaces unless the operator is configured otherwise
+const DefaultPlatformConfigNamespace = "pequod-system"
+
+// PlatformConfigSelects reports whether a PlatformConfig applies to instances
+// in the given namespace. A PlatformConfig applies to its own namespace, and
+// to the namespaces its namespace selector matches if it is in
+// selectorNamespace. Selectors of PlatformConfigs elsewhere are ignored, so
+// a tenant cannot pass values to other tenants' instances.
+func PlatformConfigSelects(pc *platformv1alpha1.PlatformConfig, ns *corev1.Namespace, selectorNamespace string) (bool, error) {
+	if pc.Namespace == ns.Name {
+		return true, nil
+	}
+	if pc.Spec.NamespaceSelector == nil || pc.Namespace != selectorNamespace {
+		return false, nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(pc.Spec.NamespaceSelector)
+	if err != nil {
+		return false, fmt.Errorf("invalid namespaceSelector: %w", err)
+	}
+	return selector.Matches(labels.Set(ns.Labels)), nil
+}
+
+// ResolvePlatformConfig merges the values of every PlatformConfig selecting
+// the namespace, where PlatformConfigs in selectorNamespace may select other
+// namespaces; see PlatformConfigSelects. Objects are merged key by key; any
+// other value, including a list, replaces the value of a lower precedence
+// PlatformConfig, and null removes it. Precedence is decided by, in order:
+//
+//  1. a higher spec.priority
+//  2. a PlatformConfig in the namespace itself over one selecting it
+//  3. namespace and name, the later one alphabetically winning
+//
+// Invalid PlatformConfigs are passed to skip, if set, and left out, so one
+// broken PlatformConfig does not fail every render. Returns an empty
+// RawExtension if no PlatformConfig selects the namespace.
+func ResolvePlatformConfig(
+	ctx context.Context, c client.Client, selectorNamespace, namespace string,
+	skip func(pc *platformv1alpha1.PlatformConfig, err error),
+) (runtime.RawExtension, error) {
+	if namespace == "" {
+		return runtime.RawExtension{}, nil
+	}
+	if skip == nil {
+		skip = func(*platformv1alpha1.PlatformConfig, error) {}
+	}
+
+	ns := &corev1.Namespace{}
+	if err := c.Get(ctx, client.ObjectKey{Name: namespace}, ns); err != nil {
+		return runtime.RawExtension{}, fmt.Errorf("failed to get namespace %s: %w", namespace, err)
+	}
+
+	list := &platformv1alpha1.PlatformConfigList{}
+	if err := c.List(ctx, list); err != nil {
+		return runtime.RawExtension{}, fmt.Errorf("failed to list PlatformConfigs: %w", err)
+	}
+
+	var selected []*platformv1alpha1.PlatformConfig
+	for i := range list.Items {
+		pc := &list.Items[i]
+		ok, err := PlatformConfigSelects(pc, ns, selectorNamespace)
+		if err != nil {
+			skip(pc, err)
+			continue
+		}
+		if ok {
+			selected = append(selected, pc)
+		}
+	}
+	if len(selected) == 0 {
+		return runtime.RawExtension{}, nil
+	}
+
+	// Merge from lowest to highest precedence
+	sort.Slice(selected, func(i, j int) bool {
+		a, b := selected[i], selected[j]
+		if a.Spec.Priority != b.Spec.Priority {
+			return a.Spec.Priority < b.Spec.Priority
+		}
+		if aLocal, bLocal := a.Namespace == namespace, b.Namespace == namespace; aLocal != bLocal {
+			return bLocal
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+
+	merged := map[string]interface{}{}
+	for _, pc := range selected {
+		if len(pc.Spec.Values.Raw) == 0 {
+			continue
+		}
+		var values map[string]interface{}
+		if err := json.Unmarshal(pc.Spec.Values.Raw, &values); err != nil {
+			skip(pc, fmt.Errorf("invalid values: %w", err))
+			continue
+		}
+		mergeValues(merged, values)
+	}
+
+	raw, err := json.Marshal(merged)
+	if err != nil {
+		return runtime.RawExtension{}, fmt.Errorf("failed to encode platform config: %w", err)
+	}
+	return runtime.RawExtension{Raw: raw}, nil
+}
+
+// mergeValues merges src into dst, recursing into objects
+func mergeValues(dst, src map[string]interface{}) {
+	for key, value := range src {
+		if value == nil {
+			delete(dst, key)
+			continue
+		}
+		srcObj, srcIsObj := value.(map[string]interface{})
+		dstObj, dstIsObj := dst[key].(map[string]interface{})
+		if srcIsObj {
+			if !dstIsObj {
+				dstObj = map[string]interface{}{}
+				dst[key] = dstObj
+			}
+			mergeValues(dstObj, srcObj)
+			continue
+		}
+		dst[key] = value
+	}
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconcile
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	platformv1alpha1 "github.com/chazu/pequod/api/v1alpha1"
+)
+
+func newPlatformConfig(namespace, name string, priority int32, selector *metav1.LabelSelector, values string) *platformv1alpha1.PlatformConfig {
+	return &platformv1alpha1.PlatformConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: platformv1alpha1.PlatformConfigSpec{
+			NamespaceSelector: selector,
+			Priority:          priority,
+			Values:            runtime.RawExtension{Raw: []byte(values)},
+		},
+	}
+}
+
+func TestResolvePlatformConfig(t *testing.T) {
+	staging := &metav1.LabelSelector{MatchLabels: map[string]string{"environment": "staging"}}
+	c := fake.NewClientBuilder().
+		WithScheme(newTestScheme()).
+		WithObjects(
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a", Labels: map[string]string{"environment": "staging"}}},
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-b"}},
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "platform"}},
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "unconfigured"}},
+			newPlatformConfig("platform", "staging", 0, staging,
+				`{"baseDomain": "staging.example.com", "registry": "registry.example.com", "replicaFloor": 2, "nodeSelector": {"pool": "general", "zone": "a"}}`),
+			newPlatformConfig("platform", "staging-registry", 10, staging, `{"registry": "mirror.example.com"}`),
+			newPlatformConfig("team-a", "team-a", 0, nil, `{"replicaFloor": 1, "nodeSelector": {"pool": "team-a", "zone": null}}`),
+			newPlatformConfig("team-b", "team-b", 0, nil, `{"baseDomain": "b.example.com"}`),
+			// Outside the selector namespace, so it only applies to team-b
+			newPlatformConfig("team-b", "everywhere", 100, &metav1.LabelSelector{}, `{"registry": "b.example.com"}`),
+		).
+		Build()
+
+	tests := []struct {
+		namespace string
+		want      map[string]interface{}
+	}{
+		{
+			namespace: "team-a",
+			want: map[string]interface{}{
+				"baseDomain":   "staging.example.com",
+				"registry":     "mirror.example.com",
+				"replicaFloor": float64(1),
+				"nodeSelector": map[string]interface{}{"pool": "team-a"},
+			},
+		},
+		{
+			namespace: "team-b",
+			want:      map[string]interface{}{"baseDomain": "b.example.com", "registry": "b.example.com"},
+		},
+		{
+			// A PlatformConfig always applies to its own namespace
+			namespace: "platform",
+			want: map[string]interface{}{
+				"baseDomain":   "staging.example.com",
+				"registry":     "mirror.example.com",
+				"replicaFloor": float64(2),
+				"nodeSelector": map[string]interface{}{"pool": "general", "zone": "a"},
+			},
+		},
+		{
+			namespace: "unconfigured",
+			want:      nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.namespace, func(t *testing.T) {
+			raw, err := ResolvePlatformConfig(context.Background(), c, "platform", tt.namespace, nil)
+			if err != nil {
+				t.Fatalf("ResolvePlatformConfig() failed: %v", err)
+			}
+			if tt.want == nil {
+				if len(raw.Raw) != 0 {
+					t.Errorf("expected no config, got %s", raw.Raw)
+				}
+				return
+			}
+			var got map[string]interface{}
+			if err := json.Unmarshal(raw.Raw, &got); err != nil {
+				t.Fatalf("failed to decode config %s: %v", raw.Raw, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ResolvePlatformConfig() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolvePlatformConfig_SkipsInvalid(t *testing.T) {
+	invalid := &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+		{Key: "environment", Operator: "Unknown"},
+	}}
+	c := fake.NewClientBuilder().
+		WithScheme(newTestScheme()).
+		WithObjects(
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}},
+			newPlatformConfig("platform", "broken", 0, invalid, `{"registry": "broken.example.com"}`),
+			newPlatformConfig("team-a", "team-a", 0, nil, `{"baseDomain": "a.example.com"}`),
+		).
+		Build()
+
+	var skipped []string
+	raw, err := ResolvePlatformConfig(context.Background(), c, "platform", "team-a",
+		func(pc *platformv1alpha1.PlatformConfig, err error) {
+			skipped = append(skipped, pc.Name)
+		})
+	if err != nil {
+		t.Fatalf("ResolvePlatformConfig() failed: %v", err)
+	}
+	if string(raw.Raw) != `{"baseDomain":"a.example.com"}` {
+		t.Errorf("expected the valid PlatformConfig's values, got %s", raw.Raw)
+	}
+	if !reflect.DeepEqual(skipped, []string{"broken"}) {
+		t.Errorf("expected the broken PlatformConfig to be skipped, got %v", skipped)
+	}
+}
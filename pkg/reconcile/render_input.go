@@ -0,0 +1,65 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconcile
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+)
+
+// MaxRenderInputSize is the largest compressed #Render input stored with a
+// ResourceGraph, leaving room for its sensitive values in the same Secret
+const MaxRenderInputSize = 512 * 1024
+
+// compressRenderInput gzips the JSON input a graph was rendered from for
+// storage in the sensitive values Secret. An empty input stays empty.
+func compressRenderInput(input []byte) ([]byte, error) {
+	if len(input) == 0 {
+		return nil, nil
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(input); err != nil {
+		return nil, fmt.Errorf("failed to compress render input: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress render input: %w", err)
+	}
+	if buf.Len() > MaxRenderInputSize {
+		return nil, fmt.Errorf("render input is too large: %d bytes compressed, limit is %d",
+			buf.Len(), MaxRenderInputSize)
+	}
+	return buf.Bytes(), nil
+}
+
+// decompressRenderInput returns the JSON input stored by compressRenderInput
+func decompressRenderInput(data []byte) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress render input: %w", err)
+	}
+	defer func() { _ = zr.Close() }()
+
+	input, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress render input: %w", err)
+	}
+	return input, nil
+}
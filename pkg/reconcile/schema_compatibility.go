@@ -0,0 +1,254 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconcile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	platformv1alpha1 "github.com/chazu/pequod/api/v1alpha1"
+	"github.com/chazu/pequod/pkg/crd"
+)
+
+const (
+	// AllowBreakingChangesAnnotation on a Transform applies the breaking
+	// schema changes of its module anyway. It is removed once they are
+	// applied, so later breaking changes are refused again.
+	AllowBreakingChangesAnnotation = "pequod.io/allow-breaking-changes"
+
+	// ConditionTypeSchemaCompatible is the Transform condition reporting
+	// whether the latest schema changes of its module were applied
+	ConditionTypeSchemaCompatible = "SchemaCompatible"
+
+	// maxRecordedSchemaChanges bounds the changes listed in Transform status
+	maxRecordedSchemaChanges = 20
+
+	// maxReportedSchemaChanges is how many breaking changes a
+	// SchemaCompatible condition lists
+	maxReportedSchemaChanges = 5
+)
+
+// ErrBreakingSchemaChange is returned when breaking changes to the spec
+// schema keep the current CRD and module in place
+var ErrBreakingSchemaChange = errors.New("refusing breaking schema changes")
+
+// isBreakingSchemaChange reports whether err refused breaking schema changes
+func isBreakingSchemaChange(err error) bool {
+	return errors.Is(err, ErrBreakingSchemaChange)
+}
+
+// allowedSchemaChanges are breaking schema changes the
+// AllowBreakingChangesAnnotation lets through, recorded once the CRD is
+// applied
+type allowedSchemaChanges struct {
+	compatibility *platformv1alpha1.SchemaCompatibility
+	details       string
+}
+
+// checkSchemaCompatibility compares the spec schemas of a generated CRD
+// with those of the CRD in the cluster, if any, and records the changes in
+// Transform status. Breaking changes are refused with
+// ErrBreakingSchemaChange unless the Transform has the
+// AllowBreakingChangesAnnotation, in which case they are returned for
+// recordAllowedSchemaChanges.
+func (h *TransformHandlers) checkSchemaCompatibility(
+	ctx context.Context, tf *platformv1alpha1.Transform, generated *apiextensionsv1.CustomResourceDefinition,
+) (*allowedSchemaChanges, error) {
+	current := &apiextensionsv1.CustomResourceDefinition{}
+	if err := h.client.Get(ctx, client.ObjectKey{Name: generated.Name}, current); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get CRD %s: %w", generated.Name, err)
+	}
+
+	changes := schemaChanges(current, generated)
+	if len(changes) == 0 {
+		// A module that reverted its breaking changes is no longer blocked
+		if cond := tf.GetCondition(ConditionTypeSchemaCompatible); cond == nil || cond.Status == metav1.ConditionTrue {
+			return nil, nil
+		}
+		return nil, h.updateStatusWithRetry(ctx, tf, func(latest *platformv1alpha1.Transform) {
+			if latest.Status.SchemaCompatibility != nil {
+				latest.Status.SchemaCompatibility.Blocked = false
+			}
+			latest.SetCondition(ConditionTypeSchemaCompatible, metav1.ConditionTrue, "Unchanged",
+				"The module no longer changes the spec schema")
+		})
+	}
+
+	compatibility := &platformv1alpha1.SchemaCompatibility{CheckedAt: metav1.Now()}
+	var breaking []string
+	for _, change := range changes {
+		if !change.Breaking {
+			compatibility.Compatible++
+			continue
+		}
+		compatibility.Breaking++
+		if len(breaking) < maxReportedSchemaChanges {
+			breaking = append(breaking, fmt.Sprintf("%s in %s: %s", change.Path, change.Version, change.Message))
+		}
+	}
+	if len(changes) > maxRecordedSchemaChanges {
+		changes = changes[:maxRecordedSchemaChanges]
+	}
+	compatibility.Changes = changes
+
+	if compatibility.Breaking == 0 {
+		return nil, h.updateStatusWithRetry(ctx, tf, func(latest *platformv1alpha1.Transform) {
+			latest.Status.SchemaCompatibility = compatibility
+			latest.SetCondition(ConditionTypeSchemaCompatible, metav1.ConditionTrue, "Compatible",
+				fmt.Sprintf("%d compatible schema change(s)", compatibility.Compatible))
+		})
+	}
+
+	logger := log.FromContext(ctx)
+	details := strings.Join(breaking, "; ")
+	if more := int(compatibility.Breaking) - len(breaking); more > 0 {
+		details = fmt.Sprintf("%s and %d more", details, more)
+	}
+
+	if _, allow := tf.Annotations[AllowBreakingChangesAnnotation]; allow {
+		logger.Info("Applying breaking schema changes", "crd", generated.Name, "breaking", compatibility.Breaking)
+		return &allowedSchemaChanges{compatibility: compatibility, details: details}, nil
+	}
+
+	message := fmt.Sprintf("%d breaking schema change(s): %s; set the %s annotation to apply them",
+		compatibility.Breaking, details, AllowBreakingChangesAnnotation)
+	compatibility.Blocked = true
+	if cond := tf.GetCondition(ConditionTypeSchemaCompatible); cond == nil || cond.Message != message {
+		logger.Info("Refusing breaking schema changes", "crd", generated.Name, "breaking", compatibility.Breaking)
+		h.recordEvent(tf, "Warning", "BreakingSchemaChange", "%s", message)
+	}
+	// Like a held back upgrade, the current CRD and module stay Ready at this
+	// generation, so the refusal is not re-evaluated on every status update
+	if err := h.updateStatusWithRetry(ctx, tf, func(latest *platformv1alpha1.Transform) {
+		latest.Status.SchemaCompatibility = compatibility
+		latest.Status.Phase = platformv1alpha1.TransformPhaseReady
+		latest.Status.ObservedGeneration = latest.Generation
+		latest.SetCondition(ConditionTypeSchemaCompatible, metav1.ConditionFalse, "BreakingChanges", message)
+	}); err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("%w: %s", ErrBreakingSchemaChange, details)
+}
+
+// breakingChangesAllowed reports whether the AllowBreakingChangesAnnotation
+// was set on a Transform whose breaking schema changes were refused
+func breakingChangesAllowed(tf *platformv1alpha1.Transform) bool {
+	_, allow := tf.Annotations[AllowBreakingChangesAnnotation]
+	return allow && tf.Status.SchemaCompatibility != nil && tf.Status.SchemaCompatibility.Blocked
+}
+
+// recordAllowedSchemaChanges records breaking schema changes applied by the
+// AllowBreakingChangesAnnotation, and removes the annotation so later
+// breaking changes are refused again
+func (h *TransformHandlers) recordAllowedSchemaChanges(
+	ctx context.Context, tf *platformv1alpha1.Transform, allowed *allowedSchemaChanges,
+) error {
+	if err := h.removeAnnotation(ctx, tf, AllowBreakingChangesAnnotation); err != nil {
+		return err
+	}
+	compatibility := allowed.compatibility
+	h.recordEvent(tf, "Warning", "BreakingSchemaChangeAllowed", "Applied %d breaking schema change(s): %s",
+		compatibility.Breaking, allowed.details)
+	compatibility.Allowed = true
+	return h.updateStatusWithRetry(ctx, tf, func(latest *platformv1alpha1.Transform) {
+		latest.Status.SchemaCompatibility = compatibility
+		latest.SetCondition(ConditionTypeSchemaCompatible, metav1.ConditionTrue, "BreakingChangesAllowed",
+			fmt.Sprintf("%d breaking schema change(s) applied by %s: %s",
+				compatibility.Breaking, AllowBreakingChangesAnnotation, allowed.details))
+	})
+}
+
+// schemaChanges returns the changes to the spec schemas of the versions a
+// CRD keeps serving, and the served versions it removes or stops serving,
+// breaking changes first. Versions the current CRD does not have are new
+// and have no changes.
+func schemaChanges(current, generated *apiextensionsv1.CustomResourceDefinition) []platformv1alpha1.SchemaChange {
+	currentSpecs := make(map[string]*apiextensionsv1.JSONSchemaProps, len(current.Spec.Versions))
+	for _, v := range current.Spec.Versions {
+		currentSpecs[v.Name] = specSchema(v)
+	}
+	generatedServed := make(map[string]bool, len(generated.Spec.Versions))
+	for _, v := range generated.Spec.Versions {
+		generatedServed[v.Name] = v.Served
+	}
+
+	var changes []platformv1alpha1.SchemaChange
+	for _, v := range current.Spec.Versions {
+		if !v.Served || generatedServed[v.Name] {
+			continue
+		}
+		message := "version removed"
+		if _, kept := generatedServed[v.Name]; kept {
+			message = "version no longer served"
+		}
+		changes = append(changes, platformv1alpha1.SchemaChange{
+			Version:  v.Name,
+			Path:     "spec",
+			Kind:     string(crd.VersionRemoved),
+			Breaking: true,
+			Message:  message,
+		})
+	}
+	for _, v := range generated.Spec.Versions {
+		currentSpec, ok := currentSpecs[v.Name]
+		if !ok {
+			continue
+		}
+		for _, change := range crd.CompareSchemas(currentSpec, specSchema(v)) {
+			path := "spec"
+			if change.Path != "" {
+				path += "." + change.Path
+			}
+			changes = append(changes, platformv1alpha1.SchemaChange{
+				Version:  v.Name,
+				Path:     path,
+				Kind:     string(change.Kind),
+				Breaking: change.Breaking,
+				Message:  change.Message,
+			})
+		}
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Breaking && !changes[j].Breaking
+	})
+	return changes
+}
+
+// specSchema returns the spec schema of a CRD version, or nil if it has
+// none
+func specSchema(v apiextensionsv1.CustomResourceDefinitionVersion) *apiextensionsv1.JSONSchemaProps {
+	if v.Schema == nil || v.Schema.OpenAPIV3Schema == nil {
+		return nil
+	}
+	spec, ok := v.Schema.OpenAPIV3Schema.Properties["spec"]
+	if !ok {
+		return nil
+	}
+	return &spec
+}
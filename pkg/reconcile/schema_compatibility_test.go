@@ -0,0 +1,290 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconcile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	platformv1alpha1 "github.com/chazu/pequod/api/v1alpha1"
+	"github.com/chazu/pequod/pkg/platformloader"
+)
+
+// schemaModule renders nothing for an #Input of input
+func schemaModule(input string) string {
+	return fmt.Sprintf(`
+#Input: %s
+
+#Render: {
+	input: {
+		metadata: {name: string, namespace: string}
+		spec: {#Input, platformRef: string}
+	}
+	output: {
+		metadata: {name: input.metadata.name, version: "v1alpha1"}
+		nodes: []
+		violations: []
+	}
+}
+`, input)
+}
+
+func TestTransformHandlers_SchemaCompatibility(t *testing.T) {
+	tf := &platformv1alpha1.Transform{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "webservice",
+			Namespace:  "default",
+			Finalizers: []string{TransformFinalizer},
+		},
+		Spec: platformv1alpha1.TransformSpec{
+			CueRef: platformv1alpha1.CueReference{
+				Type: platformv1alpha1.CueRefTypeInline,
+				Ref:  schemaModule(`{port: int, tier: "small" | "large"}`),
+			},
+			Group:   "apps.example.com",
+			Version: "v1alpha1",
+		},
+	}
+	c := newTestClient(tf)
+	handlers := newTestHandlers(c)
+	key := types.NamespacedName{Name: "webservice", Namespace: "default"}
+	if _, err := handlers.Reconcile(context.Background(), key); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// changeModule references module, and reconciles the Transform again
+	changeModule := func(module string, annotations map[string]string) *platformv1alpha1.Transform {
+		t.Helper()
+		latest := &platformv1alpha1.Transform{}
+		if err := c.Get(context.Background(), key, latest); err != nil {
+			t.Fatalf("failed to get transform: %v", err)
+		}
+		latest.Spec.CueRef.Ref = module
+		latest.Annotations = annotations
+		latest.Generation++
+		if err := c.Update(context.Background(), latest); err != nil {
+			t.Fatalf("failed to update transform: %v", err)
+		}
+		if _, err := handlers.Reconcile(context.Background(), key); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := c.Get(context.Background(), key, latest); err != nil {
+			t.Fatalf("failed to get transform: %v", err)
+		}
+		return latest
+	}
+	portType := func() string {
+		t.Helper()
+		generated := &apiextensionsv1.CustomResourceDefinition{}
+		if err := c.Get(context.Background(), client.ObjectKey{Name: "webservices.apps.example.com"}, generated); err != nil {
+			t.Fatalf("failed to get CRD: %v", err)
+		}
+		return generated.Spec.Versions[0].Schema.OpenAPIV3Schema.Properties["spec"].Properties["port"].Type
+	}
+
+	// A widened enum and a new optional field are applied
+	compatible := schemaModule(`{port: int, tier: "small" | "medium" | "large", replicas?: int}`)
+	updated := changeModule(compatible, nil)
+	if cond := updated.GetCondition(ConditionTypeSchemaCompatible); cond == nil || cond.Status != metav1.ConditionTrue ||
+		cond.Reason != "Compatible" {
+		t.Errorf("expected SchemaCompatible=True, got %+v", cond)
+	}
+	if sc := updated.Status.SchemaCompatibility; sc == nil || sc.Compatible != 2 || sc.Breaking != 0 {
+		t.Errorf("expected 2 compatible changes, got %+v", sc)
+	}
+
+	// A changed type is refused, keeping the CRD and module
+	breaking := schemaModule(`{port: string, tier: "small" | "medium" | "large", replicas?: int}`)
+	updated = changeModule(breaking, nil)
+	cond := updated.GetCondition(ConditionTypeSchemaCompatible)
+	if cond == nil || cond.Status != metav1.ConditionFalse || cond.Reason != "BreakingChanges" {
+		t.Fatalf("expected SchemaCompatible=False, got %+v", cond)
+	}
+	sc := updated.Status.SchemaCompatibility
+	if sc == nil || !sc.Blocked || sc.Breaking != 1 || len(sc.Changes) != 1 {
+		t.Fatalf("expected one blocking change, got %+v", sc)
+	}
+	want := platformv1alpha1.SchemaChange{
+		Version: "v1alpha1", Path: "spec.port", Kind: "TypeChanged", Breaking: true,
+		Message: "type changed from integer to string",
+	}
+	if sc.Changes[0] != want {
+		t.Errorf("unexpected change:\ngot:  %+v\nwant: %+v", sc.Changes[0], want)
+	}
+	if updated.Status.Phase != platformv1alpha1.TransformPhaseReady || updated.Status.ObservedGeneration != updated.Generation {
+		t.Errorf("expected the Transform to stay Ready at generation %d, got phase %s at %d",
+			updated.Generation, updated.Status.Phase, updated.Status.ObservedGeneration)
+	}
+	if updated.Status.ResolvedCueRef.Digest != platformloader.InlineDigest(compatible) {
+		t.Error("expected the previous module to be kept")
+	}
+	if got := portType(); got != "integer" {
+		t.Errorf("expected the CRD to keep port as integer, got %s", got)
+	}
+
+	// Reconciling the refusal again does not compare the schemas again
+	resourceVersion := updated.ResourceVersion
+	if _, err := handlers.Reconcile(context.Background(), key); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := c.Get(context.Background(), key, updated); err != nil {
+		t.Fatalf("failed to get transform: %v", err)
+	}
+	if updated.ResourceVersion != resourceVersion {
+		t.Error("expected the refused schema changes not to be checked again")
+	}
+
+	// The override annotation applies them once, without a spec change
+	updated.Annotations = map[string]string{AllowBreakingChangesAnnotation: "true"}
+	if err := c.Update(context.Background(), updated); err != nil {
+		t.Fatalf("failed to update transform: %v", err)
+	}
+	if _, err := handlers.Reconcile(context.Background(), key); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := c.Get(context.Background(), key, updated); err != nil {
+		t.Fatalf("failed to get transform: %v", err)
+	}
+	if cond := updated.GetCondition(ConditionTypeSchemaCompatible); cond == nil || cond.Status != metav1.ConditionTrue ||
+		cond.Reason != "BreakingChangesAllowed" {
+		t.Errorf("expected SchemaCompatible=True, got %+v", cond)
+	}
+	if sc := updated.Status.SchemaCompatibility; sc == nil || !sc.Allowed || sc.Blocked {
+		t.Errorf("expected the breaking changes to be allowed, got %+v", sc)
+	}
+	if _, ok := updated.Annotations[AllowBreakingChangesAnnotation]; ok {
+		t.Error("expected the override annotation to be removed")
+	}
+	if updated.Status.Phase != platformv1alpha1.TransformPhaseReady ||
+		updated.Status.ResolvedCueRef.Digest != platformloader.InlineDigest(breaking) {
+		t.Errorf("expected the new module to be adopted, got phase %s", updated.Status.Phase)
+	}
+	if got := portType(); got != "string" {
+		t.Errorf("expected the CRD to change port to string, got %s", got)
+	}
+}
+
+func TestSchemaChanges_Versions(t *testing.T) {
+	version := func(name string, served bool) apiextensionsv1.CustomResourceDefinitionVersion {
+		return apiextensionsv1.CustomResourceDefinitionVersion{
+			Name:   name,
+			Served: served,
+			Schema: &apiextensionsv1.CustomResourceValidation{OpenAPIV3Schema: &apiextensionsv1.JSONSchemaProps{
+				Type:       "object",
+				Properties: map[string]apiextensionsv1.JSONSchemaProps{"spec": {Type: "object"}},
+			}},
+		}
+	}
+	current := &apiextensionsv1.CustomResourceDefinition{Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+		Versions: []apiextensionsv1.CustomResourceDefinitionVersion{
+			version("v1alpha1", true), version("v1alpha2", true), version("v1beta1", true), version("v1beta2", false),
+		},
+	}}
+	generated := &apiextensionsv1.CustomResourceDefinition{Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+		Versions: []apiextensionsv1.CustomResourceDefinitionVersion{version("v1alpha2", false), version("v1beta1", true)},
+	}}
+
+	// Versions nobody could use before are not reported
+	want := []platformv1alpha1.SchemaChange{
+		{Version: "v1alpha1", Path: "spec", Kind: "VersionRemoved", Breaking: true, Message: "version removed"},
+		{Version: "v1alpha2", Path: "spec", Kind: "VersionRemoved", Breaking: true, Message: "version no longer served"},
+	}
+	if got := schemaChanges(current, generated); !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected changes:\ngot:  %+v\nwant: %+v", got, want)
+	}
+}
+
+func TestTransformHandlers_AllowBreakingChangesApplyFails(t *testing.T) {
+	tf := &platformv1alpha1.Transform{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "webservice",
+			Namespace:  "default",
+			Finalizers: []string{TransformFinalizer},
+		},
+		Spec: platformv1alpha1.TransformSpec{
+			CueRef: platformv1alpha1.CueReference{
+				Type: platformv1alpha1.CueRefTypeInline,
+				Ref:  schemaModule(`{port: int}`),
+			},
+			Group:   "apps.example.com",
+			Version: "v1alpha1",
+		},
+	}
+	failApply := false
+	c := fake.NewClientBuilder().
+		WithScheme(newTestScheme()).
+		WithObjects(tf).
+		WithStatusSubresource(&platformv1alpha1.Transform{}, &apiextensionsv1.CustomResourceDefinition{}).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+				if _, ok := obj.(*apiextensionsv1.CustomResourceDefinition); ok && failApply {
+					return errors.New("apply failed")
+				}
+				return c.Patch(ctx, obj, patch, opts...)
+			},
+		}).
+		Build()
+	handlers := newTestHandlers(c)
+	key := types.NamespacedName{Name: "webservice", Namespace: "default"}
+	if _, err := handlers.Reconcile(context.Background(), key); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	latest := &platformv1alpha1.Transform{}
+	if err := c.Get(context.Background(), key, latest); err != nil {
+		t.Fatalf("failed to get transform: %v", err)
+	}
+	latest.Spec.CueRef.Ref = schemaModule(`{port: string}`)
+	latest.Annotations = map[string]string{AllowBreakingChangesAnnotation: "true"}
+	latest.Generation++
+	if err := c.Update(context.Background(), latest); err != nil {
+		t.Fatalf("failed to update transform: %v", err)
+	}
+
+	// The annotation stays until its changes are applied
+	failApply = true
+	if _, err := handlers.Reconcile(context.Background(), key); err == nil {
+		t.Fatal("expected the failed apply to fail the reconcile")
+	}
+	if err := c.Get(context.Background(), key, latest); err != nil {
+		t.Fatalf("failed to get transform: %v", err)
+	}
+	if _, ok := latest.Annotations[AllowBreakingChangesAnnotation]; !ok {
+		t.Error("expected the override annotation to be kept")
+	}
+
+	failApply = false
+	if _, err := handlers.Reconcile(context.Background(), key); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := c.Get(context.Background(), key, latest); err != nil {
+		t.Fatalf("failed to get transform: %v", err)
+	}
+	if _, ok := latest.Annotations[AllowBreakingChangesAnnotation]; ok {
+		t.Error("expected the override annotation to be removed")
+	}
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconcile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	platformv1alpha1 "github.com/chazu/pequod/api/v1alpha1"
+	"github.com/chazu/pequod/pkg/crd"
+	"github.com/chazu/pequod/pkg/platformloader"
+)
+
+// ChannelAnnotation on a platform instance selects the Transform channel
+// whose module renders it. Instances without it use the default channel,
+// the Transform's spec.cueRef.
+const ChannelAnnotation = "pequod.io/channel"
+
+// ErrUnknownChannel is returned for an instance selecting a channel its
+// Transform does not declare
+var ErrUnknownChannel = errors.New("unknown module channel")
+
+// instanceChannel returns the channel an instance selects
+func instanceChannel(instance *unstructured.Unstructured) string {
+	if channel := instance.GetAnnotations()[ChannelAnnotation]; channel != "" {
+		return channel
+	}
+	return platformv1alpha1.DefaultChannel
+}
+
+// channelCueRef returns the CueRef of the module a channel resolved to, or
+// of its spec until the Transform resolved it
+func channelCueRef(transform *platformv1alpha1.Transform, name string) (platformloader.CueRefInput, error) {
+	for _, channel := range transform.Spec.Channels {
+		if channel.Name != name {
+			continue
+		}
+		var resolved *platformv1alpha1.ResolvedCueReference
+		for _, status := range transform.Status.Channels {
+			if status.Name == name {
+				resolved = status.ResolvedCueRef
+			}
+		}
+		return cueRefInput(transform, channel.CueRef, resolved), nil
+	}
+	return platformloader.CueRefInput{}, fmt.Errorf("%w %q in Transform %s", ErrUnknownChannel, name, transform.Name)
+}
+
+// defaultChannelInstances returns the instances rendered from the
+// Transform's spec.cueRef, which upgrade analysis and rollouts cover
+func defaultChannelInstances(instances []unstructured.Unstructured) []unstructured.Unstructured {
+	var selected []unstructured.Unstructured
+	for i := range instances {
+		if instanceChannel(&instances[i]) == platformv1alpha1.DefaultChannel {
+			selected = append(selected, instances[i])
+		}
+	}
+	return selected
+}
+
+// fetchChannels fetches the module of every channel of the Transform and
+// merges its schemas into schemas, so that the generated CRD accepts the
+// instances of every channel. It returns the channels' resolved modules.
+func (h *TransformHandlers) fetchChannels(
+	ctx context.Context, tf *platformv1alpha1.Transform, schemas *moduleSchemas,
+) ([]platformv1alpha1.ChannelStatus, error) {
+	if len(tf.Spec.Channels) == 0 {
+		return nil, nil
+	}
+	logger := log.FromContext(ctx)
+
+	inputs := []*apiextensionsv1.JSONSchemaProps{schemas.input}
+	outputs := []*apiextensionsv1.JSONSchemaProps{schemas.outputs}
+	statuses := make([]platformv1alpha1.ChannelStatus, 0, len(tf.Spec.Channels))
+	for _, channel := range tf.Spec.Channels {
+		channelSchemas, fetchResult, err := h.fetchAndExtractSchema(ctx, tf, channel.CueRef, nil)
+		if err != nil {
+			return nil, fmt.Errorf("channel %s: %w", channel.Name, err)
+		}
+		inputs = append(inputs, channelSchemas.input)
+		outputs = append(outputs, channelSchemas.outputs)
+
+		resolved, err := h.resolveCueRef(ctx, tf, channel.CueRef, fetchResult.Digest)
+		if err != nil {
+			return nil, fmt.Errorf("channel %s: %w", channel.Name, err)
+		}
+		statuses = append(statuses, platformv1alpha1.ChannelStatus{Name: channel.Name, ResolvedCueRef: resolved})
+		logger.V(1).Info("Fetched module channel", "channel", channel.Name, "digest", fetchResult.Digest)
+	}
+
+	// A channel without #Outputs leaves the merged outputs free-form
+	schemas.input = crd.MergeSchemas(inputs...)
+	schemas.outputs = crd.MergeSchemas(outputs...)
+	return statuses, nil
+}
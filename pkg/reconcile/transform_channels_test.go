@@ -0,0 +1,150 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconcile
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	platformv1alpha1 "github.com/chazu/pequod/api/v1alpha1"
+	"github.com/chazu/pequod/pkg/platformloader"
+)
+
+// betaModule is a channel module whose #Input adds a replicas field
+const betaModule = `
+#Input: {
+	port:      int
+	replicas?: int
+}
+
+#Render: {
+	input: {
+		metadata: {name: string, namespace: string}
+		spec: {#Input, platformRef: string}
+	}
+	output: {
+		metadata: {name: input.metadata.name, version: "v1alpha1"}
+		nodes: []
+		violations: []
+	}
+}
+`
+
+// newChannelTransform returns a webservice Transform on currentModule with
+// a beta channel
+func newChannelTransform() *platformv1alpha1.Transform {
+	tf := newUpgradingTransform(currentModule, platformv1alpha1.UpgradeAnalysisReport)
+	tf.Status = platformv1alpha1.TransformStatus{}
+	tf.Spec.Channels = []platformv1alpha1.ModuleChannel{{
+		Name:   "beta",
+		CueRef: platformv1alpha1.CueReference{Type: platformv1alpha1.CueRefTypeInline, Ref: betaModule},
+	}}
+	return tf
+}
+
+func TestInstanceCueRef_Channels(t *testing.T) {
+	tf := newChannelTransform()
+	beta := newUpgradeInstance("beta", 80)
+	beta.SetAnnotations(map[string]string{ChannelAnnotation: "beta"})
+
+	// The channel's spec is rendered until the Transform resolved it
+	got, err := instanceCueRef(tf, beta)
+	if err != nil || got.Ref != betaModule {
+		t.Fatalf("expected the beta channel's module, got %q (%v)", got.Ref, err)
+	}
+
+	tf.Status.Channels = []platformv1alpha1.ChannelStatus{{
+		Name:           "beta",
+		ResolvedCueRef: &platformv1alpha1.ResolvedCueReference{Type: platformv1alpha1.CueRefTypeInline, Ref: "pinned"},
+	}}
+	if got, _ := instanceCueRef(tf, beta); got.Ref != "pinned" {
+		t.Errorf("expected the beta channel's resolved module, got %q", got.Ref)
+	}
+
+	stable := newUpgradeInstance("stable", 80)
+	stable.SetAnnotations(map[string]string{ChannelAnnotation: platformv1alpha1.DefaultChannel})
+	if got, _ := instanceCueRef(tf, stable); got.Ref != currentModule {
+		t.Errorf("expected the default channel to render spec.cueRef, got %q", got.Ref)
+	}
+
+	unknown := newUpgradeInstance("unknown", 80)
+	unknown.SetAnnotations(map[string]string{ChannelAnnotation: "nightly"})
+	if _, err := instanceCueRef(tf, unknown); !errors.Is(err, ErrUnknownChannel) {
+		t.Errorf("expected ErrUnknownChannel, got %v", err)
+	}
+}
+
+func TestTransformHandlers_Channels(t *testing.T) {
+	tf := newChannelTransform()
+	c := newTestClient(tf)
+	handlers := newTestHandlers(c)
+
+	key := types.NamespacedName{Name: "webservice", Namespace: "default"}
+	if _, err := handlers.Reconcile(context.Background(), key); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	updated := &platformv1alpha1.Transform{}
+	if err := c.Get(context.Background(), key, updated); err != nil {
+		t.Fatalf("failed to get transform: %v", err)
+	}
+	if len(updated.Status.Channels) != 1 || updated.Status.Channels[0].Name != "beta" {
+		t.Fatalf("expected the beta channel in status, got %+v", updated.Status.Channels)
+	}
+	if resolved := updated.Status.Channels[0].ResolvedCueRef; resolved == nil || resolved.Digest != platformloader.InlineDigest(betaModule) {
+		t.Errorf("expected the beta channel to resolve to its module, got %+v", resolved)
+	}
+
+	generated := &apiextensionsv1.CustomResourceDefinition{}
+	if err := c.Get(context.Background(), client.ObjectKey{Name: updated.Status.GeneratedCRD.Name}, generated); err != nil {
+		t.Fatalf("failed to get CRD: %v", err)
+	}
+	version := generated.Spec.Versions[0]
+	spec := version.Schema.OpenAPIV3Schema.Properties["spec"]
+	if _, ok := spec.Properties["port"]; !ok {
+		t.Errorf("expected the default channel's port field, got %+v", spec.Properties)
+	}
+	if _, ok := spec.Properties["replicas"]; !ok {
+		t.Errorf("expected the beta channel's replicas field, got %+v", spec.Properties)
+	}
+	if version.AdditionalPrinterColumns[0].Name != "Channel" {
+		t.Errorf("expected a Channel printer column, got %+v", version.AdditionalPrinterColumns)
+	}
+}
+
+func TestUpgradeAnalyzer_SkipsChannelInstances(t *testing.T) {
+	tf := newUpgradingTransform(breakingModule, platformv1alpha1.UpgradeAnalysisGate)
+	beta := newUpgradeInstance("beta", 80)
+	beta.SetAnnotations(map[string]string{ChannelAnnotation: "beta"})
+	c := newTestClient(tf, defaultNamespace(), newUpgradeInstance("api", 8080), beta)
+	analyzer := NewUpgradeAnalyzer(c, newTestScheme(), platformloader.NewRenderer(createTestLoader()))
+
+	from := platformloader.CueRefInput{Type: platformloader.InlineType, Ref: currentModule}
+	to := platformloader.CueRefInput{Type: platformloader.InlineType, Ref: breakingModule}
+	analysis, err := analyzer.Analyze(context.Background(), tf, from, to, "old", "new")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if analysis.Instances != 1 || analysis.Failed != 0 {
+		t.Errorf("expected only the default channel's instance to be analyzed, got %+v", analysis)
+	}
+}
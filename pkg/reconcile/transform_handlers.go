@@ -3,14 +3,19 @@ package reconcile
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
-	"cuelang.org/go/cue"
 	"github.com/authzed/controller-idioms/pause"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	k8sschema "k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/retry"
@@ -23,7 +28,6 @@ import (
 	"github.com/chazu/pequod/pkg/crd"
 	"github.com/chazu/pequod/pkg/platformloader"
 	"github.com/chazu/pequod/pkg/rbac"
-	"github.com/chazu/pequod/pkg/schema"
 )
 
 const (
@@ -32,6 +36,19 @@ const (
 
 	// TransformFinalizer is the finalizer added to Transform resources
 	TransformFinalizer = "pequod.io/transform-finalizer"
+
+	// ForceDeleteAnnotation set to "true" lets a Transform with the Delete
+	// policy delete its generated CRD, and with it every instance, while
+	// instances still exist
+	ForceDeleteAnnotation = "pequod.io/force-delete"
+
+	// ConditionTypeDeletionBlocked is the Transform condition set while its
+	// deletion waits for the instances of its generated CRD to be deleted
+	ConditionTypeDeletionBlocked = "DeletionBlocked"
+
+	// maxReportedInstances is how many instance names a DeletionBlocked
+	// condition lists
+	maxReportedInstances = 10
 )
 
 var (
@@ -41,6 +58,10 @@ var (
 
 	// CRDEstablishmentPollInterval is the poll interval for CRD establishment checks.
 	CRDEstablishmentPollInterval = 100 * time.Millisecond
+
+	// DeletionBlockedRequeueInterval is how often a Transform whose deletion
+	// is blocked by instances checks whether they are gone
+	DeletionBlockedRequeueInterval = 30 * time.Second
 )
 
 // TransformHandlers contains all handlers for Transform reconciliation
@@ -49,7 +70,6 @@ type TransformHandlers struct {
 	scheme    *runtime.Scheme
 	recorder  record.EventRecorder
 	loader    *platformloader.Loader
-	extractor *schema.Extractor
 	generator *crd.Generator
 
 	// RBAC management
@@ -59,6 +79,17 @@ type TransformHandlers struct {
 	// ServiceAccount configuration for RBAC bindings
 	serviceAccountName      string
 	serviceAccountNamespace string
+
+	// health rolls up the generated CRD's instances, if set
+	health *InstanceHealthIndex
+
+	// analyzer previews new module digests against the existing instances,
+	// if set
+	analyzer *UpgradeAnalyzer
+
+	// conversion is the webhook generated CRDs with several versions use,
+	// if set
+	conversion *crd.ConversionWebhook
 }
 
 // TransformHandlersConfig holds configuration for TransformHandlers
@@ -102,7 +133,6 @@ func NewTransformHandlersWithConfig(
 		scheme:                  scheme,
 		recorder:                recorder,
 		loader:                  loader,
-		extractor:               schema.NewExtractor(),
 		generator:               crd.NewGenerator(),
 		rbacGenerator:           rbac.NewGenerator(),
 		rbacApplier:             rbac.NewApplier(k8sClient),
@@ -207,12 +237,31 @@ func (h *TransformHandlers) Reconcile(ctx context.Context, nn types.NamespacedNa
 		}
 	}
 
+	if err := h.rollUpInstances(ctx, tf); err != nil {
+		logger.Error(err, "failed to roll up instance health")
+		return ctrl.Result{}, err
+	}
+	rolloutCheck, err := h.progressRollout(ctx, tf)
+	if err != nil {
+		logger.Error(err, "failed to progress rollout")
+		return ctrl.Result{}, err
+	}
+
 	// Early exit: if Transform is already Ready and spec hasn't changed, skip reconciliation
-	// This prevents a reconcile loop where status updates trigger unnecessary re-processing
+	// This prevents a reconcile loop where status updates trigger unnecessary re-processing.
+	// A ref that moved since it was fetched is reconciled again, and so are
+	// refused breaking schema changes once they are allowed.
 	if tf.Status.Phase == platformv1alpha1.TransformPhaseReady &&
-		tf.Status.ObservedGeneration == tf.Generation {
-		logger.V(1).Info("Transform already reconciled, skipping")
-		return ctrl.Result{}, nil
+		tf.Status.ObservedGeneration == tf.Generation && !breakingChangesAllowed(tf) {
+		moved, nextRefresh, err := h.refreshCueRef(ctx, tf)
+		if err != nil {
+			logger.Error(err, "failed to record CUE module refresh")
+			return ctrl.Result{}, err
+		}
+		if !moved {
+			logger.V(1).Info("Transform already reconciled, skipping")
+			return ctrl.Result{RequeueAfter: sooner(nextRefresh, rolloutCheck)}, nil
+		}
 	}
 
 	// Update phase to Fetching
@@ -224,15 +273,23 @@ func (h *TransformHandlers) Reconcile(ctx context.Context, nn types.NamespacedNa
 	}
 
 	// Step 4: Fetch CUE module and extract schema
-	inputSchema, fetchResult, err := h.fetchAndExtractSchema(ctx, tf)
+	schemas, fetchResult, err := h.fetchAndExtractSchema(ctx, tf, tf.Spec.CueRef, extraVersions(tf))
+	var channels []platformv1alpha1.ChannelStatus
+	if err == nil {
+		channels, err = h.fetchChannels(ctx, tf, schemas)
+	}
 	if err != nil {
 		fetchErr := err
+		reason := "FetchFailed"
+		if budgetErr, ok := platformloader.AsBudgetError(err); ok {
+			reason = budgetErr.Reason
+		}
 		if statusErr := h.updateStatusWithRetry(ctx, tf, func(latestTf *platformv1alpha1.Transform) {
 			latestTf.Status.Phase = platformv1alpha1.TransformPhaseFailed
 			latestTf.SetCondition(
 				"CueFetched",
 				metav1.ConditionFalse,
-				"FetchFailed",
+				reason,
 				fmt.Sprintf("Failed to fetch/extract CUE schema: %v", fetchErr),
 			)
 		}); statusErr != nil {
@@ -256,8 +313,24 @@ func (h *TransformHandlers) Reconcile(ctx context.Context, nn types.NamespacedNa
 		return ctrl.Result{}, err
 	}
 
+	// A new module digest is analyzed against the existing instances, and
+	// held back if the policy gates it
+	blocked, err := h.analyzeUpgrade(ctx, tf, fetchResult)
+	if err != nil {
+		logger.Error(err, "failed to analyze module upgrade")
+		return ctrl.Result{}, err
+	}
+	if blocked {
+		return ctrl.Result{RequeueAfter: refreshInterval(tf)}, nil
+	}
+
 	// Step 5: Generate and apply CRD
-	generatedCRD, err := h.generateAndApplyCRD(ctx, tf, inputSchema)
+	generatedCRD, err := h.generateAndApplyCRD(ctx, tf, schemas)
+	if isBreakingSchemaChange(err) {
+		// Refused breaking changes were recorded with the SchemaCompatible
+		// condition, and wait for a new module or the override annotation
+		return ctrl.Result{RequeueAfter: refreshInterval(tf)}, nil
+	}
 	if err != nil {
 		crdErr := err
 		if statusErr := h.updateStatusWithRetry(ctx, tf, func(latestTf *platformv1alpha1.Transform) {
@@ -274,6 +347,12 @@ func (h *TransformHandlers) Reconcile(ctx context.Context, nn types.NamespacedNa
 		return ctrl.Result{}, crdErr
 	}
 
+	// Instances still stored as a previous storage version are rewritten
+	if err := h.migrateStorageVersion(ctx, tf, generatedCRD); err != nil {
+		logger.Error(err, "failed to migrate storage version")
+		return ctrl.Result{}, err
+	}
+
 	// Step 6: Generate and apply RBAC (if managedResources defined)
 	generatedRBAC, err := h.generateAndApplyRBAC(ctx, tf)
 	if err != nil {
@@ -293,74 +372,94 @@ func (h *TransformHandlers) Reconcile(ctx context.Context, nn types.NamespacedNa
 	}
 
 	// Step 7: Update final status
-	return h.updateStatus(ctx, tf, generatedCRD, generatedRBAC, fetchResult)
+	result, err := h.updateStatus(ctx, tf, generatedCRD, generatedRBAC, fetchResult, channels)
+	if err == nil {
+		result.RequeueAfter = sooner(refreshInterval(tf), rolloutCheck)
+	}
+	return result, err
 }
 
-// fetchAndExtractSchema fetches the CUE module and extracts the input schema
+// moduleSchemas are the schemas extracted from a module
+type moduleSchemas struct {
+	// input is the schema of #Input, the generated CRD's spec
+	input *apiextensionsv1.JSONSchemaProps
+
+	// outputs is the schema of #Outputs, or nil if the module has none
+	outputs *apiextensionsv1.JSONSchemaProps
+
+	// versions are the spec schemas of further API versions from
+	// #Versions, by version
+	versions map[string]*apiextensionsv1.JSONSchemaProps
+
+	// conversions are the versions #Convert converts to, by the version
+	// they convert from
+	conversions map[string][]string
+}
+
+// fetchAndExtractSchema fetches the CUE module at cueRef and extracts its
+// input and outputs schemas, and the schemas of the given further versions
 func (h *TransformHandlers) fetchAndExtractSchema(
-	ctx context.Context, tf *platformv1alpha1.Transform,
-) (*apiextensionsv1.JSONSchemaProps, *platformloader.FetchResult, error) {
+	ctx context.Context, tf *platformv1alpha1.Transform, cueRef platformv1alpha1.CueReference, versions []string,
+) (*moduleSchemas, *platformloader.FetchResult, error) {
 	logger := log.FromContext(ctx)
 
 	var fetchResult *platformloader.FetchResult
-	var cueValue cue.Value
 	var err error
 
 	// Build the fetch parameters
 	var pullSecretRef *string
-	if tf.Spec.CueRef.PullSecretRef != nil {
-		pullSecretRef = &tf.Spec.CueRef.PullSecretRef.Name
+	if cueRef.PullSecretRef != nil {
+		pullSecretRef = &cueRef.PullSecretRef.Name
 	}
 
-	switch tf.Spec.CueRef.Type {
+	switch cueRef.Type {
 	case platformv1alpha1.CueRefTypeInline:
-		// Compile inline CUE (special case - content is in Ref)
-		cueValue = h.loader.Context().CompileString(tf.Spec.CueRef.Ref)
-		if cueValue.Err() != nil {
-			return nil, nil, fmt.Errorf("failed to compile inline CUE: %w", cueValue.Err())
-		}
+		// Inline CUE is a special case - content is in Ref
 		fetchResult = &platformloader.FetchResult{
-			Content: []byte(tf.Spec.CueRef.Ref),
-			Digest:  platformloader.InlineType,
+			Content: []byte(cueRef.Ref),
+			Digest:  platformloader.InlineDigest(cueRef.Ref),
 			Source:  platformloader.InlineType,
 		}
 
 	case platformv1alpha1.CueRefTypeEmbedded, platformv1alpha1.CueRefTypeOCI, platformv1alpha1.CueRefTypeGit, platformv1alpha1.CueRefTypeConfigMap:
 		// Use fetcher system for all external module types
-		fetchResult, err = h.loader.FetchModule(ctx, string(tf.Spec.CueRef.Type), tf.Spec.CueRef.Ref, tf.Namespace, pullSecretRef)
+		fetchResult, err = h.loader.FetchModule(ctx, string(cueRef.Type), cueRef.Ref, tf.Namespace, pullSecretRef)
 		if err != nil {
 			return nil, nil, fmt.Errorf("failed to fetch CUE module: %w", err)
 		}
 
-		cueValue, err = h.loader.LoadFromContent(fetchResult.Content)
-		if err != nil {
-			return nil, nil, fmt.Errorf("failed to compile fetched CUE module: %w", err)
-		}
-
 	default:
-		return nil, nil, fmt.Errorf("unsupported CueRef type: %s", tf.Spec.CueRef.Type)
+		return nil, nil, fmt.Errorf("unsupported CueRef type: %s", cueRef.Type)
 	}
 
 	logger.Info("CUE module fetched successfully",
 		"source", fetchResult.Source,
 		"digest", fetchResult.Digest)
 
-	// Extract the input schema from CUE
-	inputSchema, err := h.extractor.ExtractInputSchema(cueValue)
+	// Compile the module and extract the schemas from CUE within the
+	// render budget, since the module may be untrusted
+	extracted, err := h.loader.ExtractSchemas(ctx, fetchResult.Content, cueRef.Type == platformv1alpha1.CueRefTypeInline, versions)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to extract input schema: %w", err)
+		return nil, nil, err
+	}
+	schemas := &moduleSchemas{
+		input:       extracted.Input,
+		outputs:     extracted.Outputs,
+		versions:    extracted.Versions,
+		conversions: extracted.Conversions,
 	}
 
 	logger.Info("Input schema extracted successfully",
-		"properties", len(inputSchema.Properties),
-		"required", len(inputSchema.Required))
+		"properties", len(schemas.input.Properties),
+		"required", len(schemas.input.Required),
+		"outputs", schemas.outputs != nil)
 
-	return inputSchema, fetchResult, nil
+	return schemas, fetchResult, nil
 }
 
 // generateAndApplyCRD generates a CRD from the schema and applies it to the cluster
 func (h *TransformHandlers) generateAndApplyCRD(
-	ctx context.Context, tf *platformv1alpha1.Transform, inputSchema *apiextensionsv1.JSONSchemaProps,
+	ctx context.Context, tf *platformv1alpha1.Transform, schemas *moduleSchemas,
 ) (*platformv1alpha1.GeneratedCRDReference, error) {
 	logger := log.FromContext(ctx)
 
@@ -372,24 +471,48 @@ func (h *TransformHandlers) generateAndApplyCRD(
 		Categories:         tf.Spec.Categories,
 		TransformName:      tf.Name,
 		TransformNamespace: tf.Namespace,
+		OutputsSchema:      schemas.outputs,
+		Channels:           len(tf.Spec.Channels) > 0,
+		Versions:           crdVersions(tf, schemas),
+		Conversion:         h.conversion,
+	}
+	if len(config.Versions) > 1 && config.Conversion == nil {
+		return nil, fmt.Errorf("serving %d versions needs the conversion webhook, which the manager is not configured with",
+			len(config.Versions))
+	}
+	if err := checkConversions(config.Versions, schemas.conversions); err != nil {
+		return nil, err
 	}
 
 	// Derive platform name from Transform name
 	platformName := tf.Name
 
 	// Generate the CRD
-	generatedCRD := h.generator.GenerateCRD(platformName, inputSchema, config)
+	generatedCRD := h.generator.GenerateCRD(platformName, schemas.input, config)
 
 	logger.Info("Generated CRD",
 		"name", generatedCRD.Name,
 		"kind", generatedCRD.Spec.Names.Kind,
 		"group", generatedCRD.Spec.Group)
 
+	// Breaking changes to the schemas of the CRD in the cluster are refused
+	allowed, err := h.checkSchemaCompatibility(ctx, tf, generatedCRD)
+	if err != nil {
+		return nil, err
+	}
+
 	// Apply the CRD to the cluster
 	if err := h.generator.ApplyCRD(ctx, h.client, generatedCRD); err != nil {
 		return nil, fmt.Errorf("failed to apply CRD: %w", err)
 	}
 
+	// The override annotation is only used up once its changes are applied
+	if allowed != nil {
+		if err := h.recordAllowedSchemaChanges(ctx, tf, allowed); err != nil {
+			return nil, err
+		}
+	}
+
 	logger.Info("CRD applied successfully", "name", generatedCRD.Name)
 
 	// Wait for the CRD to be established before proceeding
@@ -523,28 +646,46 @@ func (h *TransformHandlers) generateAndApplyRBAC(
 	return ref, nil
 }
 
+// pinnedRef returns the ref that fetches the module a Transform's ref
+// resolved to, so instances keep rendering it after the ref moves
+func pinnedRef(cueRef platformv1alpha1.CueReference, digest string) string {
+	pinned, err := platformloader.PinRef(string(cueRef.Type), cueRef.Ref, digest)
+	if err != nil {
+		return cueRef.Ref
+	}
+	return pinned
+}
+
 // updateStatus updates the Transform status with the generated CRD reference
 func (h *TransformHandlers) updateStatus(
 	ctx context.Context, tf *platformv1alpha1.Transform,
 	generatedCRD *platformv1alpha1.GeneratedCRDReference,
 	generatedRBAC *platformv1alpha1.GeneratedRBACReference,
 	fetchResult *platformloader.FetchResult,
+	channels []platformv1alpha1.ChannelStatus,
 ) (ctrl.Result, error) {
 	logger := log.FromContext(ctx)
 
 	// Capture digest for closure
 	var fetchDigest string
+	var resolved *platformv1alpha1.ResolvedCueReference
 	if fetchResult != nil {
 		fetchDigest = fetchResult.Digest
+		var err error
+		if resolved, err = h.resolveCueRef(ctx, tf, tf.Spec.CueRef, fetchDigest); err != nil {
+			return ctrl.Result{}, err
+		}
 	}
 
 	// Use retry-on-conflict for status update
+	var inlineBefore, inlineAfter map[string]bool
 	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
 		// Re-fetch the latest version
 		latestTf := &platformv1alpha1.Transform{}
 		if err := h.client.Get(ctx, client.ObjectKeyFromObject(tf), latestTf); err != nil {
 			return err
 		}
+		inlineBefore = inlineDigests(&latestTf.Status)
 
 		// Update phase
 		latestTf.Status.Phase = platformv1alpha1.TransformPhaseReady
@@ -557,13 +698,13 @@ func (h *TransformHandlers) updateStatus(
 
 		// Update ResolvedCueRef with fetch result
 		if fetchDigest != "" {
-			now := metav1.Now()
-			latestTf.Status.ResolvedCueRef = &platformv1alpha1.ResolvedCueReference{
-				Digest:    fetchDigest,
-				FetchedAt: &now,
-			}
+			latestTf.Status.Rollout = nextRollout(latestTf, fetchDigest)
+			latestTf.Status.ResolvedCueRef = resolved.DeepCopy()
 		}
 
+		latestTf.Status.Channels = channels
+		inlineAfter = inlineDigests(&latestTf.Status)
+
 		// Set conditions
 		latestTf.SetCondition(
 			"CRDGenerated",
@@ -605,6 +746,10 @@ func (h *TransformHandlers) updateStatus(
 	if err != nil {
 		return ctrl.Result{}, fmt.Errorf("failed to update status: %w", err)
 	}
+	if err := h.releaseInlineModules(ctx, tf, inlineBefore, inlineAfter); err != nil {
+		// Continue - they are deleted with the Transform
+		logger.Error(err, "Failed to delete inline modules no longer referenced")
+	}
 
 	logger.Info("Transform reconciled successfully",
 		"phase", platformv1alpha1.TransformPhaseReady,
@@ -632,13 +777,33 @@ func (h *TransformHandlers) handleDeletion(ctx context.Context, tf *platformv1al
 
 	logger.Info("Handling Transform deletion", "name", tf.Name)
 
+	// Deleting the CRD deletes every instance and everything they created,
+	// so it waits for the instances to be deleted first unless forced
+	orphan := tf.Spec.DeletionPolicy == platformv1alpha1.DeletionPolicyOrphan
+	if tf.Status.GeneratedCRD != nil && !orphan && tf.Annotations[ForceDeleteAnnotation] != "true" {
+		instances, err := h.listInstances(ctx, tf.Status.GeneratedCRD)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if len(instances) > 0 {
+			return h.blockDeletion(ctx, tf, instances)
+		}
+	}
+
 	// Record deletion event
 	if h.recorder != nil {
 		h.recorder.Event(tf, "Normal", "Deleting", "Transform is being deleted")
 	}
 
+	if tf.Status.GeneratedCRD != nil && orphan {
+		logger.Info("Orphaning generated CRD", "name", tf.Status.GeneratedCRD.Name)
+		if h.recorder != nil {
+			h.recorder.Eventf(tf, "Normal", "CRDOrphaned", "Kept generated CRD %s and its instances", tf.Status.GeneratedCRD.Name)
+		}
+	}
+
 	// Delete the generated CRD if it exists
-	if tf.Status.GeneratedCRD != nil {
+	if tf.Status.GeneratedCRD != nil && !orphan {
 		crdName := tf.Status.GeneratedCRD.Name
 		logger.Info("Deleting generated CRD", "name", crdName)
 
@@ -683,3 +848,90 @@ func (h *TransformHandlers) handleDeletion(ctx context.Context, tf *platformv1al
 	logger.Info("Transform deletion handled successfully")
 	return ctrl.Result{}, nil
 }
+
+// listInstances returns the namespaced names of the instances of a
+// generated CRD, sorted
+func (h *TransformHandlers) listInstances(ctx context.Context, generated *platformv1alpha1.GeneratedCRDReference) ([]string, error) {
+	items, err := listGeneratedInstances(ctx, h.client, generated)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(items))
+	for i, item := range items {
+		names[i] = item.GetNamespace() + "/" + item.GetName()
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// listGeneratedInstances lists the instances of a generated CRD. A CRD that
+// no longer exists has none.
+func listGeneratedInstances(
+	ctx context.Context, c client.Client, generated *platformv1alpha1.GeneratedCRDReference,
+) ([]unstructured.Unstructured, error) {
+	if generated == nil || generated.APIVersion == "" || generated.Kind == "" {
+		return nil, nil
+	}
+	gv, err := k8sschema.ParseGroupVersion(generated.APIVersion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid generated CRD apiVersion %q: %w", generated.APIVersion, err)
+	}
+
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(gv.WithKind(generated.Kind + "List"))
+	if err := c.List(ctx, list); err != nil {
+		if meta.IsNoMatchError(err) || errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to list %s instances: %w", generated.Kind, err)
+	}
+	return list.Items, nil
+}
+
+// blockDeletion reports the instances holding up the Transform's deletion
+// and checks again later
+func (h *TransformHandlers) blockDeletion(
+	ctx context.Context, tf *platformv1alpha1.Transform, instances []string,
+) (ctrl.Result, error) {
+	listed := instances
+	if len(listed) > maxReportedInstances {
+		listed = listed[:maxReportedInstances]
+	}
+	message := fmt.Sprintf("%d %s instance(s) still exist: %s", len(instances), tf.Status.GeneratedCRD.Kind, strings.Join(listed, ", "))
+	if len(instances) > len(listed) {
+		message += fmt.Sprintf(", and %d more", len(instances)-len(listed))
+	}
+
+	log.FromContext(ctx).Info("Transform deletion blocked by instances", "count", len(instances))
+	if h.recorder != nil {
+		h.recorder.Eventf(tf, "Warning", "DeletionBlocked",
+			"%s; delete them, set deletionPolicy to Orphan, or annotate the Transform with %s=true", message, ForceDeleteAnnotation)
+	}
+	err := h.updateStatusWithRetry(ctx, tf, func(latest *platformv1alpha1.Transform) {
+		latest.SetCondition(ConditionTypeDeletionBlocked, metav1.ConditionTrue, "InstancesExist", message)
+	})
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: DeletionBlockedRequeueInterval}, nil
+}
+
+// rollUpInstances writes the summary of the generated CRD's instances to the
+// Transform's status if it changed
+func (h *TransformHandlers) rollUpInstances(ctx context.Context, tf *platformv1alpha1.Transform) error {
+	if h.health == nil || tf.Status.GeneratedCRD == nil {
+		return nil
+	}
+	gv, err := k8sschema.ParseGroupVersion(tf.Status.GeneratedCRD.APIVersion)
+	if err != nil {
+		return fmt.Errorf("invalid generated CRD apiVersion %q: %w", tf.Status.GeneratedCRD.APIVersion, err)
+	}
+
+	summary := h.health.Summary(gv.WithKind(tf.Status.GeneratedCRD.Kind).GroupKind())
+	if equality.Semantic.DeepEqual(tf.Status.Instances, summary) {
+		return nil
+	}
+	return h.updateStatusWithRetry(ctx, tf, func(latest *platformv1alpha1.Transform) {
+		latest.Status.Instances = summary
+	})
+}
@@ -22,6 +22,7 @@ import (
 	"testing"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -54,6 +55,7 @@ func newTestScheme() *runtime.Scheme {
 	_ = platformv1alpha1.AddToScheme(scheme)
 	_ = apiextensionsv1.AddToScheme(scheme)
 	_ = rbacv1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
 	return scheme
 }
 
@@ -335,6 +337,108 @@ func TestTransformHandlers_HandleDeletion(t *testing.T) {
 	}
 }
 
+func TestTransformHandlers_HandleDeletion_Instances(t *testing.T) {
+	tests := []struct {
+		name         string
+		policy       platformv1alpha1.DeletionPolicy
+		annotations  map[string]string
+		wantBlocked  bool
+		wantCRDGone  bool
+		wantFinalize bool
+	}{
+		{
+			name:        "instances block deletion",
+			wantBlocked: true,
+		},
+		{
+			name:         "force annotation deletes the CRD",
+			annotations:  map[string]string{ForceDeleteAnnotation: "true"},
+			wantCRDGone:  true,
+			wantFinalize: true,
+		},
+		{
+			name:         "orphan policy keeps the CRD",
+			policy:       platformv1alpha1.DeletionPolicyOrphan,
+			wantFinalize: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := metav1.Now()
+			tf := &platformv1alpha1.Transform{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "webservice",
+					Namespace:         "default",
+					Annotations:       tt.annotations,
+					Finalizers:        []string{TransformFinalizer},
+					DeletionTimestamp: &now,
+				},
+				Spec: platformv1alpha1.TransformSpec{
+					CueRef: platformv1alpha1.CueReference{
+						Type: platformv1alpha1.CueRefTypeEmbedded,
+						Ref:  "webservice",
+					},
+					Group:          "apps.example.com",
+					DeletionPolicy: tt.policy,
+				},
+				Status: platformv1alpha1.TransformStatus{
+					GeneratedCRD: &platformv1alpha1.GeneratedCRDReference{
+						APIVersion: "apps.example.com/v1alpha1",
+						Kind:       "WebService",
+						Name:       "webservices.apps.example.com",
+					},
+				},
+			}
+			crd := &apiextensionsv1.CustomResourceDefinition{
+				ObjectMeta: metav1.ObjectMeta{Name: "webservices.apps.example.com"},
+			}
+
+			c := newTestClient(tf, crd, newInstance("WebService", "api", nil), newInstance("WebService", "web", nil))
+			handlers := newTestHandlers(c)
+
+			result, err := handlers.Reconcile(context.Background(), types.NamespacedName{Name: "webservice", Namespace: "default"})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			updated := &platformv1alpha1.Transform{}
+			err = c.Get(context.Background(), client.ObjectKeyFromObject(tf), updated)
+			if tt.wantFinalize {
+				if err == nil && controllerutil.ContainsFinalizer(updated, TransformFinalizer) {
+					t.Error("expected finalizer to be removed")
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("failed to get transform: %v", err)
+				}
+				if !controllerutil.ContainsFinalizer(updated, TransformFinalizer) {
+					t.Error("expected finalizer to be kept")
+				}
+			}
+
+			if tt.wantBlocked {
+				if result.RequeueAfter != DeletionBlockedRequeueInterval {
+					t.Errorf("expected requeue after %v, got %v", DeletionBlockedRequeueInterval, result.RequeueAfter)
+				}
+				cond := updated.GetCondition(ConditionTypeDeletionBlocked)
+				if cond == nil || cond.Status != metav1.ConditionTrue {
+					t.Fatalf("expected DeletionBlocked condition, got %+v", cond)
+				}
+				want := "2 WebService instance(s) still exist: default/api, default/web"
+				if cond.Message != want {
+					t.Errorf("expected message %q, got %q", want, cond.Message)
+				}
+			}
+
+			err = c.Get(context.Background(), client.ObjectKeyFromObject(crd), &apiextensionsv1.CustomResourceDefinition{})
+			if gone := err != nil; gone != tt.wantCRDGone {
+				t.Errorf("expected CRD deleted=%v, got err=%v", tt.wantCRDGone, err)
+			}
+		})
+	}
+}
+
 func TestTransformReconciler_Reconcile(t *testing.T) {
 	// Setup: Transform with finalizer
 	tf := &platformv1alpha1.Transform{
@@ -9,6 +9,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
+	"github.com/chazu/pequod/pkg/crd"
 	"github.com/chazu/pequod/pkg/platformloader"
 )
 
@@ -41,6 +42,23 @@ func (r *TransformReconciler) SetRecorder(recorder record.EventRecorder) {
 	r.handlers.recorder = recorder
 }
 
+// SetInstanceHealth sets the index the handlers roll up instance health from
+func (r *TransformReconciler) SetInstanceHealth(index *InstanceHealthIndex) {
+	r.handlers.health = index
+}
+
+// SetUpgradeAnalyzer sets the analyzer new module digests are checked
+// against the existing instances with
+func (r *TransformReconciler) SetUpgradeAnalyzer(analyzer *UpgradeAnalyzer) {
+	r.handlers.analyzer = analyzer
+}
+
+// SetConversionWebhook sets the webhook generated CRDs with several
+// versions convert instances with
+func (r *TransformReconciler) SetConversionWebhook(webhook *crd.ConversionWebhook) {
+	r.handlers.conversion = webhook
+}
+
 // Reconcile executes the reconciliation pipeline for Transform
 func (r *TransformReconciler) Reconcile(
 	ctx context.Context,
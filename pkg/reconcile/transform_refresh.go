@@ -0,0 +1,112 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconcile
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	platformv1alpha1 "github.com/chazu/pequod/api/v1alpha1"
+)
+
+// MinRefreshInterval is the shortest refreshInterval honored, so that a
+// Transform cannot hammer its registry or Git host
+const MinRefreshInterval = 30 * time.Second
+
+// refreshInterval returns how often the Transform's ref is resolved again,
+// or 0 if it is not refreshed
+func refreshInterval(tf *platformv1alpha1.Transform) time.Duration {
+	ref := tf.Spec.CueRef
+	if ref.RefreshInterval == nil || ref.RefreshInterval.Duration <= 0 {
+		return 0
+	}
+	if ref.Type != platformv1alpha1.CueRefTypeGit && ref.Type != platformv1alpha1.CueRefTypeOCI {
+		return 0
+	}
+	return max(ref.RefreshInterval.Duration, MinRefreshInterval)
+}
+
+// untilRefresh returns how long until the Transform's ref is due to be
+// resolved again. Zero or less means it is due.
+func untilRefresh(tf *platformv1alpha1.Transform, now time.Time) time.Duration {
+	resolved := tf.Status.ResolvedCueRef
+	if resolved == nil {
+		return 0
+	}
+	last := resolved.FetchedAt
+	if resolved.CheckedAt != nil && (last == nil || resolved.CheckedAt.After(last.Time)) {
+		last = resolved.CheckedAt
+	}
+	if last == nil {
+		return 0
+	}
+	return last.Add(refreshInterval(tf)).Sub(now)
+}
+
+// refreshCueRef resolves the Transform's ref if it is due for refresh and
+// reports whether it moved to a new digest, and otherwise how long until
+// the next refresh (0 if the ref is not refreshed). Failures to resolve are
+// recorded and retried on the next interval, keeping the current module.
+func (h *TransformHandlers) refreshCueRef(ctx context.Context, tf *platformv1alpha1.Transform) (bool, time.Duration, error) {
+	interval := refreshInterval(tf)
+	if interval == 0 || tf.Status.ResolvedCueRef == nil {
+		return false, 0, nil
+	}
+	if remaining := untilRefresh(tf, time.Now()); remaining > 0 {
+		return false, remaining, nil
+	}
+	logger := log.FromContext(ctx)
+
+	var pullSecretRef *string
+	if tf.Spec.CueRef.PullSecretRef != nil {
+		pullSecretRef = &tf.Spec.CueRef.PullSecretRef.Name
+	}
+	current := tf.Status.ResolvedCueRef.Digest
+	digest, err := h.loader.ResolveModule(ctx, string(tf.Spec.CueRef.Type), tf.Spec.CueRef.Ref, tf.Namespace, pullSecretRef)
+	if err != nil {
+		logger.Error(err, "Failed to refresh CUE module reference", "ref", tf.Spec.CueRef.Ref)
+		if h.recorder != nil {
+			h.recorder.Eventf(tf, "Warning", "RefreshFailed", "Failed to resolve %s: %v", tf.Spec.CueRef.Ref, err)
+		}
+	}
+
+	// A digest held back by upgrade analysis is not adopted again until the
+	// ref moves on
+	if blocked := tf.Status.UpgradeAnalysis; blocked != nil && blocked.Blocked && blocked.ToDigest == digest {
+		digest = current
+	}
+	// An abbreviated commit ref resolves to itself, a prefix of the full SHA
+	if err == nil && digest != current && !strings.HasPrefix(current, digest) {
+		logger.Info("CUE module reference moved", "ref", tf.Spec.CueRef.Ref, "from", current, "to", digest)
+		if h.recorder != nil {
+			h.recorder.Eventf(tf, "Normal", "DigestChanged", "%s moved from %s to %s", tf.Spec.CueRef.Ref, current, digest)
+		}
+		return true, interval, nil
+	}
+
+	err = h.updateStatusWithRetry(ctx, tf, func(latest *platformv1alpha1.Transform) {
+		if latest.Status.ResolvedCueRef != nil {
+			now := metav1.Now()
+			latest.Status.ResolvedCueRef.CheckedAt = &now
+		}
+	})
+	return false, interval, err
+}
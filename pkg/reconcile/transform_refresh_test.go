@@ -0,0 +1,177 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconcile
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	platformv1alpha1 "github.com/chazu/pequod/api/v1alpha1"
+)
+
+func TestRefreshInterval(t *testing.T) {
+	tests := []struct {
+		name     string
+		refType  platformv1alpha1.CueRefType
+		interval *metav1.Duration
+		want     time.Duration
+	}{
+		{name: "unset", refType: platformv1alpha1.CueRefTypeGit},
+		{name: "git", refType: platformv1alpha1.CueRefTypeGit, interval: &metav1.Duration{Duration: 5 * time.Minute}, want: 5 * time.Minute},
+		{name: "oci", refType: platformv1alpha1.CueRefTypeOCI, interval: &metav1.Duration{Duration: time.Hour}, want: time.Hour},
+		{name: "raised to minimum", refType: platformv1alpha1.CueRefTypeOCI, interval: &metav1.Duration{Duration: time.Second}, want: MinRefreshInterval},
+		{name: "immutable type", refType: platformv1alpha1.CueRefTypeEmbedded, interval: &metav1.Duration{Duration: time.Minute}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tf := &platformv1alpha1.Transform{Spec: platformv1alpha1.TransformSpec{
+				CueRef: platformv1alpha1.CueReference{Type: tt.refType, RefreshInterval: tt.interval},
+			}}
+			if got := refreshInterval(tf); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+// newGitRepo creates a local Git repository with one commit on main and
+// returns its directory and the commit
+func newGitRepo(t *testing.T) (string, string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available, skipping local repo test")
+	}
+	dir := t.TempDir()
+	run := func(args ...string) string {
+		cmd := exec.Command("git", append([]string{"-c", "user.email=test@test.com", "-c", "user.name=Test"}, args...)...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %v: %s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	if err := os.WriteFile(filepath.Join(dir, "module.cue"), []byte("package test\n"), 0644); err != nil {
+		t.Fatalf("failed to write module: %v", err)
+	}
+	run("init", "-b", "main")
+	run("add", ".")
+	run("commit", "-m", "initial")
+	return dir, run("rev-parse", "HEAD")
+}
+
+func TestTransformHandlers_RefreshCueRef(t *testing.T) {
+	repo, head := newGitRepo(t)
+	longAgo := metav1.NewTime(time.Now().Add(-time.Hour))
+	recent := metav1.Now()
+
+	tests := []struct {
+		name       string
+		digest     string
+		checkedAt  metav1.Time
+		wantMoved  bool
+		wantEvent  string
+		wantChecks bool
+	}{
+		{
+			name:      "branch moved",
+			digest:    "0000000000000000000000000000000000000000",
+			checkedAt: longAgo,
+			wantMoved: true,
+			wantEvent: "DigestChanged",
+		},
+		{
+			name:       "branch unchanged",
+			digest:     head,
+			checkedAt:  longAgo,
+			wantChecks: true,
+		},
+		{
+			name:      "not due",
+			digest:    "0000000000000000000000000000000000000000",
+			checkedAt: recent,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkedAt := tt.checkedAt
+			tf := &platformv1alpha1.Transform{
+				ObjectMeta: metav1.ObjectMeta{Name: "webservice", Namespace: "default"},
+				Spec: platformv1alpha1.TransformSpec{
+					CueRef: platformv1alpha1.CueReference{
+						Type:            platformv1alpha1.CueRefTypeGit,
+						Ref:             "file://" + repo + "?ref=main",
+						RefreshInterval: &metav1.Duration{Duration: 5 * time.Minute},
+					},
+				},
+				Status: platformv1alpha1.TransformStatus{
+					ResolvedCueRef: &platformv1alpha1.ResolvedCueReference{
+						Digest:    tt.digest,
+						FetchedAt: &longAgo,
+						CheckedAt: &checkedAt,
+					},
+				},
+			}
+			c := newTestClient(tf)
+			handlers := newTestHandlers(c)
+			recorder := handlers.recorder.(*record.FakeRecorder)
+
+			moved, next, err := handlers.refreshCueRef(context.Background(), tf)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if moved != tt.wantMoved {
+				t.Errorf("expected moved=%v, got %v", tt.wantMoved, moved)
+			}
+			if next <= 0 || next > 5*time.Minute {
+				t.Errorf("expected next refresh within the interval, got %v", next)
+			}
+
+			select {
+			case event := <-recorder.Events:
+				if tt.wantEvent == "" || !strings.Contains(event, tt.wantEvent) {
+					t.Errorf("unexpected event %q", event)
+				}
+				if !strings.Contains(event, head) {
+					t.Errorf("expected event to name the new digest, got %q", event)
+				}
+			default:
+				if tt.wantEvent != "" {
+					t.Errorf("expected %s event", tt.wantEvent)
+				}
+			}
+
+			updated := &platformv1alpha1.Transform{}
+			if err := c.Get(context.Background(), client.ObjectKeyFromObject(tf), updated); err != nil {
+				t.Fatalf("failed to get transform: %v", err)
+			}
+			checked := updated.Status.ResolvedCueRef.CheckedAt.After(tt.checkedAt.Time)
+			if checked != tt.wantChecks {
+				t.Errorf("expected checkedAt updated=%v, got %v", tt.wantChecks, checked)
+			}
+		})
+	}
+}
@@ -0,0 +1,418 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconcile
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	platformv1alpha1 "github.com/chazu/pequod/api/v1alpha1"
+	"github.com/chazu/pequod/pkg/platformloader"
+)
+
+const (
+	// RolloutResumeAnnotation on a Transform resumes its halted rollout with
+	// the next batch. It is removed once the rollout resumed.
+	RolloutResumeAnnotation = "pequod.io/resume-rollout"
+
+	// ConditionTypeRolloutHalted is the Transform condition set while its
+	// rollout is halted by a failed or stalled batch
+	ConditionTypeRolloutHalted = "RolloutHalted"
+
+	// maxRolloutBatchSize bounds the instances of a batch, which are listed
+	// in Transform status
+	maxRolloutBatchSize = 500
+)
+
+var (
+	defaultRolloutBatchSize = intstr.FromString("25%")
+
+	// DefaultRolloutProgressDeadline is how long a batch may take to become
+	// ready when the rollout strategy does not say
+	DefaultRolloutProgressDeadline = 10 * time.Minute
+)
+
+// nextRollout returns the rollout of a newly adopted module digest, given
+// the Transform's status before adopting it. Without a rollout strategy, or
+// a previous module that can be fetched again, there is no rollout. A
+// rollout interrupted by another digest starts over from the module it
+// was rolling out from.
+func nextRollout(tf *platformv1alpha1.Transform, digest string) *platformv1alpha1.RolloutStatus {
+	previous := tf.Status.ResolvedCueRef
+	if previous == nil || previous.Digest == digest {
+		return tf.Status.Rollout
+	}
+	if tf.Spec.Rollout == nil || !refetchable(previous) {
+		return nil
+	}
+
+	from := *previous
+	if current := tf.Status.Rollout; current != nil && current.Phase != platformv1alpha1.RolloutPhaseComplete {
+		from = current.From
+	}
+	if from.Digest == digest {
+		return nil
+	}
+	return &platformv1alpha1.RolloutStatus{
+		Phase:    platformv1alpha1.RolloutPhaseProgressing,
+		From:     from,
+		ToDigest: digest,
+	}
+}
+
+// instanceCueRef returns the CueRef of the module an instance renders: its
+// channel's module, or the Transform's module unless a rollout has not
+// reached the instance yet
+func instanceCueRef(transform *platformv1alpha1.Transform, instance *unstructured.Unstructured) (platformloader.CueRefInput, error) {
+	if channel := instanceChannel(instance); channel != platformv1alpha1.DefaultChannel {
+		return channelCueRef(transform, channel)
+	}
+	cueRef := transformCueRef(transform)
+	rollout := transform.Status.Rollout
+	if rollout == nil || rollout.Phase == platformv1alpha1.RolloutPhaseComplete || rolledOut(rollout, instance) {
+		return cueRef, nil
+	}
+	cueRef.Type, cueRef.Ref = resolvedRef(transform, transform.Spec.CueRef, rollout.From)
+	return cueRef, nil
+}
+
+// rolledOut reports whether an instance renders the module a rollout rolls
+// out: it was rendered from it already, is in the current batch, or has
+// never been rendered
+func rolledOut(rollout *platformv1alpha1.RolloutStatus, instance *unstructured.Unstructured) bool {
+	digest, _, _ := unstructured.NestedString(instance.Object, "status", "moduleDigest")
+	if digest == "" || digest == rollout.ToDigest {
+		return true
+	}
+	return slices.Contains(rollout.Batch, instance.GetNamespace()+"/"+instance.GetName())
+}
+
+// progressRollout moves the Transform's rollout along: it checks the
+// current batch, halts it if an instance failed or the batch stalled, and
+// starts the next batch once the pause after a ready batch is over. It
+// returns how long until the rollout should be checked again, or 0.
+func (h *TransformHandlers) progressRollout(ctx context.Context, tf *platformv1alpha1.Transform) (time.Duration, error) {
+	rollout := tf.Status.Rollout
+	if rollout == nil || rollout.Phase == platformv1alpha1.RolloutPhaseComplete {
+		return 0, nil
+	}
+	logger := log.FromContext(ctx)
+
+	// Dropping the strategy rolls the module out to every instance at once
+	if tf.Spec.Rollout == nil {
+		logger.Info("Rollout strategy removed, rolling out to all instances", "digest", rollout.ToDigest)
+		var before, after map[string]bool
+		if err := h.updateStatusWithRetry(ctx, tf, func(latest *platformv1alpha1.Transform) {
+			before = inlineDigests(&latest.Status)
+			latest.Status.Rollout = nil
+			clearRolloutHalted(latest)
+			after = inlineDigests(&latest.Status)
+		}); err != nil {
+			return 0, err
+		}
+		return 0, h.releaseInlineModules(ctx, tf, before, after)
+	}
+
+	instances, err := listGeneratedInstances(ctx, h.client, tf.Status.GeneratedCRD)
+	if err != nil {
+		return 0, err
+	}
+	// Instances of other channels render their channel's module
+	instances = defaultChannelInstances(instances)
+	updated := rollout.DeepCopy()
+	countRollout(updated, instances)
+
+	requeue, halted, err := h.stepRollout(ctx, tf, updated, instances)
+	if err != nil {
+		return 0, err
+	}
+	if equality.Semantic.DeepEqual(rollout, updated) {
+		return requeue, nil
+	}
+	err = h.updateStatusWithRetry(ctx, tf, func(latest *platformv1alpha1.Transform) {
+		latest.Status.Rollout = updated
+		switch {
+		case halted:
+			latest.SetCondition(ConditionTypeRolloutHalted, metav1.ConditionTrue, "BatchFailed", updated.Message)
+		case updated.Phase != platformv1alpha1.RolloutPhaseHalted:
+			clearRolloutHalted(latest)
+		}
+	})
+	return requeue, err
+}
+
+// stepRollout advances rollout in place and reports whether it halted just
+// now
+func (h *TransformHandlers) stepRollout(
+	ctx context.Context, tf *platformv1alpha1.Transform, rollout *platformv1alpha1.RolloutStatus,
+	instances []unstructured.Unstructured,
+) (time.Duration, bool, error) {
+	now := time.Now()
+	strategy := tf.Spec.Rollout
+
+	if rollout.Phase == platformv1alpha1.RolloutPhaseHalted {
+		if _, resume := tf.Annotations[RolloutResumeAnnotation]; !resume {
+			return 0, false, nil
+		}
+		if err := h.removeAnnotation(ctx, tf, RolloutResumeAnnotation); err != nil {
+			return 0, false, err
+		}
+		h.recordEvent(tf, "Normal", "RolloutResumed", "Resuming the rollout of %s after batch %d", rollout.ToDigest, rollout.BatchNumber)
+		rollout.Message = ""
+		rollout.BatchReadyAt = &metav1.Time{Time: now}
+	}
+
+	// Wait for the current batch to be ready
+	if len(rollout.Batch) > 0 && rollout.BatchReadyAt == nil {
+		failed, pending := checkBatch(rollout, instances)
+		deadline := progressDeadline(strategy)
+		elapsed := now.Sub(rollout.BatchStartedAt.Time)
+		switch {
+		case len(failed) > 0:
+			rollout.Message = fmt.Sprintf("batch %d: %s failed", rollout.BatchNumber, formatInstances(failed))
+		case len(pending) > 0 && elapsed >= deadline:
+			rollout.Message = fmt.Sprintf("batch %d: %s not ready after %s", rollout.BatchNumber, formatInstances(pending), deadline)
+		case len(pending) > 0:
+			rollout.Phase = platformv1alpha1.RolloutPhaseProgressing
+			return deadline - elapsed, false, nil
+		default:
+			rollout.BatchReadyAt = &metav1.Time{Time: now}
+		}
+		if rollout.Message != "" {
+			rollout.Phase = platformv1alpha1.RolloutPhaseHalted
+			h.recordEvent(tf, "Warning", "RolloutHalted", "Halted the rollout of %s: %s", rollout.ToDigest, rollout.Message)
+			return 0, true, nil
+		}
+	}
+
+	// Pause after a ready batch
+	if rollout.BatchReadyAt != nil && strategy.PauseBetweenBatches != nil {
+		if remaining := rollout.BatchReadyAt.Add(strategy.PauseBetweenBatches.Duration).Sub(now); remaining > 0 {
+			rollout.Phase = platformv1alpha1.RolloutPhasePaused
+			return remaining, false, nil
+		}
+	}
+
+	batch, err := h.nextBatch(ctx, strategy, rollout, instances)
+	if err != nil {
+		return 0, false, err
+	}
+	if len(batch) == 0 {
+		rollout.Phase = platformv1alpha1.RolloutPhaseComplete
+		rollout.Batch = nil
+		h.recordEvent(tf, "Normal", "RolloutComplete", "Rolled %s out to %d instance(s)", rollout.ToDigest, rollout.Updated)
+		return 0, false, nil
+	}
+
+	rollout.Phase = platformv1alpha1.RolloutPhaseProgressing
+	rollout.BatchNumber++
+	rollout.Batch = batch
+	rollout.BatchStartedAt = &metav1.Time{Time: now}
+	rollout.BatchReadyAt = nil
+	h.recordEvent(tf, "Normal", "RolloutBatchStarted", "Batch %d: rolling %s out to %d instance(s)",
+		rollout.BatchNumber, rollout.ToDigest, len(batch))
+	return progressDeadline(strategy), false, nil
+}
+
+// progressDeadline returns how long a batch may take to become ready
+func progressDeadline(strategy *platformv1alpha1.RolloutStrategy) time.Duration {
+	if strategy.ProgressDeadline != nil && strategy.ProgressDeadline.Duration > 0 {
+		return strategy.ProgressDeadline.Duration
+	}
+	return DefaultRolloutProgressDeadline
+}
+
+// countRollout counts the instances rendered from a module and those
+// rendered from the rollout's module
+func countRollout(rollout *platformv1alpha1.RolloutStatus, instances []unstructured.Unstructured) {
+	rollout.Total, rollout.Updated = 0, 0
+	for i := range instances {
+		digest, _, _ := unstructured.NestedString(instances[i].Object, "status", "moduleDigest")
+		if digest == "" || !instances[i].GetDeletionTimestamp().IsZero() {
+			continue
+		}
+		rollout.Total++
+		if digest == rollout.ToDigest {
+			rollout.Updated++
+		}
+	}
+}
+
+// checkBatch returns the instances of the current batch that failed with
+// the rollout's module and those not ready with it yet. Deleted instances
+// are done.
+func checkBatch(rollout *platformv1alpha1.RolloutStatus, instances []unstructured.Unstructured) (failed, pending []string) {
+	byKey := make(map[string]*unstructured.Unstructured, len(instances))
+	for i := range instances {
+		byKey[instances[i].GetNamespace()+"/"+instances[i].GetName()] = &instances[i]
+	}
+	for _, key := range rollout.Batch {
+		instance, found := byKey[key]
+		if !found {
+			continue
+		}
+		health := healthOf(instance)
+		switch {
+		case health.digest != rollout.ToDigest:
+			pending = append(pending, key)
+		case health.failed():
+			failed = append(failed, key)
+		case !health.ready:
+			pending = append(pending, key)
+		}
+	}
+	return failed, pending
+}
+
+// nextBatch picks the next instances to roll out to: those rendered from
+// another module and not part of the current batch, ordered by their
+// namespace's order label, namespace and name
+func (h *TransformHandlers) nextBatch(
+	ctx context.Context, strategy *platformv1alpha1.RolloutStrategy, rollout *platformv1alpha1.RolloutStatus,
+	instances []unstructured.Unstructured,
+) ([]string, error) {
+	type candidate struct {
+		key   string
+		order string
+	}
+	orders := map[string]string{}
+	var remaining []candidate
+	for i := range instances {
+		instance := &instances[i]
+		key := instance.GetNamespace() + "/" + instance.GetName()
+		digest, _, _ := unstructured.NestedString(instance.Object, "status", "moduleDigest")
+		if digest == "" || digest == rollout.ToDigest || !instance.GetDeletionTimestamp().IsZero() ||
+			slices.Contains(rollout.Batch, key) {
+			continue
+		}
+
+		order, found := orders[instance.GetNamespace()]
+		if !found && strategy.NamespaceOrderLabel != "" {
+			ns := &corev1.Namespace{}
+			if err := h.client.Get(ctx, types.NamespacedName{Name: instance.GetNamespace()}, ns); client.IgnoreNotFound(err) != nil {
+				return nil, fmt.Errorf("failed to get namespace %s: %w", instance.GetNamespace(), err)
+			}
+			order = ns.Labels[strategy.NamespaceOrderLabel]
+			orders[instance.GetNamespace()] = order
+		}
+		remaining = append(remaining, candidate{key: key, order: order})
+	}
+
+	sort.Slice(remaining, func(i, j int) bool {
+		x, y := remaining[i], remaining[j]
+		if x.order != y.order {
+			return orderBefore(x.order, y.order)
+		}
+		return x.key < y.key
+	})
+
+	size := rolloutBatchSize(strategy, int(rollout.Total))
+	if len(remaining) > size {
+		remaining = remaining[:size]
+	}
+	batch := make([]string, len(remaining))
+	for i, c := range remaining {
+		batch[i] = c.key
+	}
+	return batch, nil
+}
+
+// orderBefore compares namespace order label values: numerically when both
+// are integers, otherwise as strings, with unlabeled namespaces last
+func orderBefore(x, y string) bool {
+	if x == "" || y == "" {
+		return y == ""
+	}
+	a, errA := strconv.Atoi(x)
+	b, errB := strconv.Atoi(y)
+	if errA == nil && errB == nil {
+		return a < b
+	}
+	return x < y
+}
+
+// rolloutBatchSize resolves the strategy's batch size against the number
+// of instances
+func rolloutBatchSize(strategy *platformv1alpha1.RolloutStrategy, total int) int {
+	batchSize := &defaultRolloutBatchSize
+	if strategy.BatchSize != nil {
+		batchSize = strategy.BatchSize
+	}
+	size, err := intstr.GetScaledValueFromIntOrPercent(batchSize, total, true)
+	if err != nil {
+		size = 1
+	}
+	return min(max(size, 1), maxRolloutBatchSize)
+}
+
+// formatInstances lists the first instances of keys for a status message
+func formatInstances(keys []string) string {
+	if len(keys) <= maxReportedInstances {
+		return strings.Join(keys, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(keys[:maxReportedInstances], ", "), len(keys)-maxReportedInstances)
+}
+
+// removeAnnotation removes a one-shot annotation, such as the one that
+// resumed a rollout, once it took effect
+func (h *TransformHandlers) removeAnnotation(ctx context.Context, tf *platformv1alpha1.Transform, key string) error {
+	patch := client.MergeFrom(tf.DeepCopy())
+	annotations := tf.GetAnnotations()
+	delete(annotations, key)
+	tf.SetAnnotations(annotations)
+	if err := h.client.Patch(ctx, tf, patch); err != nil {
+		return fmt.Errorf("failed to remove %s annotation: %w", key, err)
+	}
+	return nil
+}
+
+// sooner returns the shorter of two requeue intervals, where 0 means none
+func sooner(a, b time.Duration) time.Duration {
+	if a <= 0 || (b > 0 && b < a) {
+		return b
+	}
+	return a
+}
+
+// recordEvent records an event on the Transform if a recorder is set
+func (h *TransformHandlers) recordEvent(tf *platformv1alpha1.Transform, eventType, reason, messageFmt string, args ...interface{}) {
+	if h.recorder == nil {
+		return
+	}
+	h.recorder.Eventf(tf, eventType, reason, messageFmt, args...)
+}
+
+// clearRolloutHalted marks the RolloutHalted condition false if it is set
+func clearRolloutHalted(tf *platformv1alpha1.Transform) {
+	if tf.GetCondition(ConditionTypeRolloutHalted) != nil {
+		tf.SetCondition(ConditionTypeRolloutHalted, metav1.ConditionFalse, "Progressing", "The rollout is not halted")
+	}
+}
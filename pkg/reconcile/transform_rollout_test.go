@@ -0,0 +1,333 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconcile
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	platformv1alpha1 "github.com/chazu/pequod/api/v1alpha1"
+)
+
+const (
+	oldDigest = "1111111111111111111111111111111111111111"
+	newDigest = "2222222222222222222222222222222222222222"
+)
+
+var adoptedModule = platformv1alpha1.ResolvedCueReference{
+	Type:   platformv1alpha1.CueRefTypeGit,
+	Ref:    "https://github.com/example/platform.git?ref=" + oldDigest,
+	Digest: oldDigest,
+}
+
+func TestNextRollout(t *testing.T) {
+	strategy := &platformv1alpha1.RolloutStrategy{}
+	embedded := &platformv1alpha1.ResolvedCueReference{Type: platformv1alpha1.CueRefTypeEmbedded, Ref: "webservice", Digest: oldDigest}
+	interrupted := &platformv1alpha1.RolloutStatus{
+		Phase:    platformv1alpha1.RolloutPhaseProgressing,
+		From:     adoptedModule,
+		ToDigest: "3333333333333333333333333333333333333333",
+	}
+
+	tests := []struct {
+		name     string
+		strategy *platformv1alpha1.RolloutStrategy
+		previous *platformv1alpha1.ResolvedCueReference
+		rollout  *platformv1alpha1.RolloutStatus
+		digest   string
+		want     *platformv1alpha1.RolloutStatus
+	}{
+		{name: "first module", strategy: strategy, digest: newDigest},
+		{name: "no strategy", previous: &adoptedModule, digest: newDigest},
+		{name: "module not refetchable", strategy: strategy, previous: embedded, digest: newDigest},
+		{
+			name:     "new digest",
+			strategy: strategy,
+			previous: &adoptedModule,
+			digest:   newDigest,
+			want:     &platformv1alpha1.RolloutStatus{Phase: platformv1alpha1.RolloutPhaseProgressing, From: adoptedModule, ToDigest: newDigest},
+		},
+		{
+			name:     "same digest keeps rollout",
+			strategy: strategy,
+			previous: &platformv1alpha1.ResolvedCueReference{Type: platformv1alpha1.CueRefTypeGit, Ref: "r", Digest: interrupted.ToDigest},
+			rollout:  interrupted,
+			digest:   interrupted.ToDigest,
+			want:     interrupted,
+		},
+		{
+			name:     "interrupted rollout starts over from its module",
+			strategy: strategy,
+			previous: &platformv1alpha1.ResolvedCueReference{Type: platformv1alpha1.CueRefTypeGit, Ref: "r", Digest: interrupted.ToDigest},
+			rollout:  interrupted,
+			digest:   newDigest,
+			want:     &platformv1alpha1.RolloutStatus{Phase: platformv1alpha1.RolloutPhaseProgressing, From: adoptedModule, ToDigest: newDigest},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tf := &platformv1alpha1.Transform{
+				Spec:   platformv1alpha1.TransformSpec{Rollout: tt.strategy},
+				Status: platformv1alpha1.TransformStatus{ResolvedCueRef: tt.previous, Rollout: tt.rollout},
+			}
+			if got := nextRollout(tf, tt.digest); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("unexpected rollout:\ngot:  %+v\nwant: %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInstanceCueRef(t *testing.T) {
+	tf := &platformv1alpha1.Transform{
+		Spec: platformv1alpha1.TransformSpec{
+			CueRef: platformv1alpha1.CueReference{Type: platformv1alpha1.CueRefTypeGit, Ref: "https://github.com/example/platform.git?ref=main"},
+		},
+		Status: platformv1alpha1.TransformStatus{
+			ResolvedCueRef: &platformv1alpha1.ResolvedCueReference{
+				Type:   platformv1alpha1.CueRefTypeGit,
+				Ref:    "https://github.com/example/platform.git?ref=" + newDigest,
+				Digest: newDigest,
+			},
+			Rollout: &platformv1alpha1.RolloutStatus{
+				Phase:    platformv1alpha1.RolloutPhaseProgressing,
+				From:     adoptedModule,
+				ToDigest: newDigest,
+				Batch:    []string{"default/batched"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		instance *unstructured.Unstructured
+		want     string
+	}{
+		{name: "waiting for its batch", instance: newInstanceWithHealth("waiting", "Completed", "True", oldDigest), want: adoptedModule.Ref},
+		{name: "in the current batch", instance: newInstanceWithHealth("batched", "Completed", "True", oldDigest), want: tf.Status.ResolvedCueRef.Ref},
+		{name: "rolled out", instance: newInstanceWithHealth("done", "Completed", "True", newDigest), want: tf.Status.ResolvedCueRef.Ref},
+		{name: "never rendered", instance: newInstance("WebService", "new", nil), want: tf.Status.ResolvedCueRef.Ref},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := instanceCueRef(tf, tt.instance)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.Ref != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got.Ref)
+			}
+		})
+	}
+
+	tf.Status.Rollout.Phase = platformv1alpha1.RolloutPhaseComplete
+	if got, _ := instanceCueRef(tf, newInstanceWithHealth("waiting", "Completed", "True", oldDigest)); got.Ref != tf.Status.ResolvedCueRef.Ref {
+		t.Errorf("expected a complete rollout to render the Transform's module, got %q", got.Ref)
+	}
+}
+
+// rolloutFixture is a Transform rolling newDigest out to instances in
+// namespaces ordered by a wave label
+type rolloutFixture struct {
+	t        *testing.T
+	client   client.Client
+	handlers *TransformHandlers
+	key      types.NamespacedName
+}
+
+func newRolloutFixture(t *testing.T, strategy *platformv1alpha1.RolloutStrategy) *rolloutFixture {
+	tf := &platformv1alpha1.Transform{
+		ObjectMeta: metav1.ObjectMeta{Name: "webservice", Namespace: "default"},
+		Spec:       platformv1alpha1.TransformSpec{Rollout: strategy},
+		Status: platformv1alpha1.TransformStatus{
+			GeneratedCRD: &platformv1alpha1.GeneratedCRDReference{APIVersion: "apps.example.com/v1alpha1", Kind: "WebService"},
+			Rollout: &platformv1alpha1.RolloutStatus{
+				Phase:    platformv1alpha1.RolloutPhaseProgressing,
+				From:     adoptedModule,
+				ToDigest: newDigest,
+			},
+		},
+	}
+	objs := []client.Object{
+		tf,
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "canary", Labels: map[string]string{"wave": "0"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "prod", Labels: map[string]string{"wave": "10"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "staging", Labels: map[string]string{"wave": "2"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "adhoc"}},
+	}
+	for _, key := range []string{"prod/api", "adhoc/tool", "staging/api", "canary/api"} {
+		namespace, name, _ := strings.Cut(key, "/")
+		instance := newInstanceWithHealth(name, "Completed", "True", oldDigest)
+		instance.SetNamespace(namespace)
+		objs = append(objs, instance)
+	}
+	c := newTestClient(objs...)
+	return &rolloutFixture{t: t, client: c, handlers: newTestHandlers(c), key: client.ObjectKeyFromObject(tf)}
+}
+
+// progress runs progressRollout and returns the Transform after it
+func (f *rolloutFixture) progress() (*platformv1alpha1.Transform, time.Duration) {
+	f.t.Helper()
+	tf := f.transform()
+	requeue, err := f.handlers.progressRollout(context.Background(), tf)
+	if err != nil {
+		f.t.Fatalf("unexpected error: %v", err)
+	}
+	return f.transform(), requeue
+}
+
+func (f *rolloutFixture) transform() *platformv1alpha1.Transform {
+	f.t.Helper()
+	tf := &platformv1alpha1.Transform{}
+	if err := f.client.Get(context.Background(), f.key, tf); err != nil {
+		f.t.Fatalf("failed to get transform: %v", err)
+	}
+	return tf
+}
+
+// render records that an instance was rendered from digest and reached
+// phase
+func (f *rolloutFixture) render(key, phase, readyStatus, digest string) {
+	f.t.Helper()
+	namespace, name, _ := strings.Cut(key, "/")
+	instance := newInstance("WebService", name, nil)
+	if err := f.client.Get(context.Background(), types.NamespacedName{Namespace: namespace, Name: name}, instance); err != nil {
+		f.t.Fatalf("failed to get instance: %v", err)
+	}
+	rendered := newInstanceWithHealth(name, phase, readyStatus, digest)
+	instance.Object["status"] = rendered.Object["status"]
+	if err := f.client.Update(context.Background(), instance); err != nil {
+		f.t.Fatalf("failed to update instance: %v", err)
+	}
+}
+
+func TestTransformHandlers_ProgressRollout(t *testing.T) {
+	batchSize := intstr.FromString("50%")
+	f := newRolloutFixture(t, &platformv1alpha1.RolloutStrategy{BatchSize: &batchSize, NamespaceOrderLabel: "wave"})
+
+	// Canary namespaces first, numerically ordered, unlabeled last
+	tf, _ := f.progress()
+	rollout := tf.Status.Rollout
+	if want := []string{"canary/api", "staging/api"}; !reflect.DeepEqual(rollout.Batch, want) {
+		t.Fatalf("expected first batch %v, got %v", want, rollout.Batch)
+	}
+	if rollout.BatchNumber != 1 || rollout.Total != 4 || rollout.Updated != 0 {
+		t.Errorf("unexpected progress: %+v", rollout)
+	}
+
+	// A batch still rendering is waited for
+	f.render("canary/api", "Completed", "True", newDigest)
+	f.render("staging/api", "Executing", "False", newDigest)
+	if tf, requeue := f.progress(); tf.Status.Rollout.BatchNumber != 1 || requeue <= 0 {
+		t.Fatalf("expected to wait for batch 1, got %+v and requeue %v", tf.Status.Rollout, requeue)
+	}
+
+	// A failed instance halts the rollout
+	f.render("staging/api", "Failed", "False", newDigest)
+	tf, _ = f.progress()
+	if tf.Status.Rollout.Phase != platformv1alpha1.RolloutPhaseHalted || !strings.Contains(tf.Status.Rollout.Message, "staging/api") {
+		t.Fatalf("expected the rollout to halt on staging/api, got %+v", tf.Status.Rollout)
+	}
+	if cond := tf.GetCondition(ConditionTypeRolloutHalted); cond == nil || cond.Status != metav1.ConditionTrue {
+		t.Errorf("expected RolloutHalted condition, got %+v", cond)
+	}
+	if tf, _ = f.progress(); tf.Status.Rollout.Phase != platformv1alpha1.RolloutPhaseHalted {
+		t.Fatalf("expected the rollout to stay halted, got %+v", tf.Status.Rollout)
+	}
+
+	// Resuming starts the next batch
+	tf.Annotations = map[string]string{RolloutResumeAnnotation: "true"}
+	if err := f.client.Update(context.Background(), tf); err != nil {
+		t.Fatalf("failed to annotate transform: %v", err)
+	}
+	tf, _ = f.progress()
+	if want := []string{"prod/api", "adhoc/tool"}; !reflect.DeepEqual(tf.Status.Rollout.Batch, want) {
+		t.Fatalf("expected second batch %v, got %+v", want, tf.Status.Rollout)
+	}
+	if _, found := tf.Annotations[RolloutResumeAnnotation]; found {
+		t.Error("expected the resume annotation to be removed")
+	}
+	if cond := tf.GetCondition(ConditionTypeRolloutHalted); cond == nil || cond.Status != metav1.ConditionFalse {
+		t.Errorf("expected RolloutHalted to be cleared, got %+v", cond)
+	}
+
+	f.render("prod/api", "Completed", "True", newDigest)
+	f.render("adhoc/tool", "Completed", "True", newDigest)
+	tf, _ = f.progress()
+	if tf.Status.Rollout.Phase != platformv1alpha1.RolloutPhaseComplete || tf.Status.Rollout.Updated != 4 {
+		t.Errorf("expected the rollout to complete, got %+v", tf.Status.Rollout)
+	}
+}
+
+func TestTransformHandlers_ProgressRollout_Stalled(t *testing.T) {
+	f := newRolloutFixture(t, &platformv1alpha1.RolloutStrategy{
+		BatchSize:        &intstr.IntOrString{Type: intstr.Int, IntVal: 1},
+		ProgressDeadline: &metav1.Duration{Duration: time.Nanosecond},
+	})
+	tf, _ := f.progress()
+	if len(tf.Status.Rollout.Batch) != 1 {
+		t.Fatalf("expected a batch of 1, got %v", tf.Status.Rollout.Batch)
+	}
+
+	tf, _ = f.progress()
+	if tf.Status.Rollout.Phase != platformv1alpha1.RolloutPhaseHalted || !strings.Contains(tf.Status.Rollout.Message, "not ready") {
+		t.Errorf("expected the rollout to halt as stalled, got %+v", tf.Status.Rollout)
+	}
+}
+
+func TestTransformHandlers_ProgressRollout_Pause(t *testing.T) {
+	batchSize := intstr.FromInt32(2)
+	f := newRolloutFixture(t, &platformv1alpha1.RolloutStrategy{
+		BatchSize:           &batchSize,
+		PauseBetweenBatches: &metav1.Duration{Duration: time.Hour},
+	})
+	tf, _ := f.progress()
+	for _, key := range tf.Status.Rollout.Batch {
+		f.render(key, "Completed", "True", newDigest)
+	}
+
+	tf, requeue := f.progress()
+	if tf.Status.Rollout.Phase != platformv1alpha1.RolloutPhasePaused || tf.Status.Rollout.BatchNumber != 1 {
+		t.Fatalf("expected to pause after batch 1, got %+v", tf.Status.Rollout)
+	}
+	if requeue <= 59*time.Minute || requeue > time.Hour {
+		t.Errorf("expected to check again after the pause, got %v", requeue)
+	}
+}
+
+func TestSooner(t *testing.T) {
+	tests := []struct{ a, b, want time.Duration }{
+		{0, 0, 0},
+		{time.Minute, 0, time.Minute},
+		{0, time.Second, time.Second},
+		{time.Minute, time.Second, time.Second},
+	}
+	for _, tt := range tests {
+		if got := sooner(tt.a, tt.b); got != tt.want {
+			t.Errorf("sooner(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconcile
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	platformv1alpha1 "github.com/chazu/pequod/api/v1alpha1"
+	"github.com/chazu/pequod/pkg/crd"
+)
+
+// ConditionTypeStorageMigrated is the Transform condition reporting whether
+// every instance is stored as the storage version of its generated CRD
+const ConditionTypeStorageMigrated = "StorageMigrated"
+
+// extraVersions returns the versions of a Transform whose schemas come from
+// the module's #Versions rather than its #Input
+func extraVersions(tf *platformv1alpha1.Transform) []string {
+	var versions []string
+	for _, v := range tf.Spec.Versions {
+		if v.Name != tf.Spec.Version {
+			versions = append(versions, v.Name)
+		}
+	}
+	return versions
+}
+
+// storageVersion returns the storage version a Transform declares, or ""
+// if it declares no versions
+func storageVersion(tf *platformv1alpha1.Transform) string {
+	for _, v := range tf.Spec.Versions {
+		if v.Storage {
+			return v.Name
+		}
+	}
+	return ""
+}
+
+// crdVersions returns the versions of a Transform's generated CRD with
+// their spec schemas, or nil if it declares no versions. The rendered
+// version spec.version has the module's #Input.
+func crdVersions(tf *platformv1alpha1.Transform, schemas *moduleSchemas) []crd.VersionConfig {
+	if len(tf.Spec.Versions) == 0 {
+		return nil
+	}
+	versions := make([]crd.VersionConfig, 0, len(tf.Spec.Versions))
+	for _, v := range tf.Spec.Versions {
+		schema := schemas.versions[v.Name]
+		if v.Name == tf.Spec.Version {
+			schema = schemas.input
+		}
+		versions = append(versions, crd.VersionConfig{
+			Name:       v.Name,
+			Schema:     schema,
+			Served:     v.Served == nil || *v.Served,
+			Storage:    v.Storage,
+			Deprecated: v.Deprecated,
+		})
+	}
+	return versions
+}
+
+// checkConversions refuses versions the API server cannot convert: it
+// converts between the storage version and each served version, and a
+// module without a #Convert for a pair would leave the spec as is, so the
+// API server prunes the fields the other version lacks. A pair needs a
+// #Convert in each direction unless the versions' spec schemas are the same.
+func checkConversions(versions []crd.VersionConfig, conversions map[string][]string) error {
+	var storage *crd.VersionConfig
+	for i := range versions {
+		if versions[i].Storage {
+			storage = &versions[i]
+		}
+	}
+	if storage == nil {
+		return nil
+	}
+
+	var missing []string
+	for _, v := range versions {
+		if v.Name == storage.Name || !v.Served || equality.Semantic.DeepEqual(v.Schema, storage.Schema) {
+			continue
+		}
+		for _, pair := range [][2]string{{storage.Name, v.Name}, {v.Name, storage.Name}} {
+			if !slices.Contains(conversions[pair[0]], pair[1]) {
+				missing = append(missing, fmt.Sprintf("#Convert.%s.%s", pair[0], pair[1]))
+			}
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("the module must declare %s, since the spec schemas of the versions differ",
+			strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// migrateStorageVersion rewrites the instances of a generated CRD that may
+// still be stored as a previous storage version, then drops the previous
+// versions from the CRD's stored versions so they can be removed. An
+// instance is rewritten by updating it unchanged, which the API server
+// stores as the current storage version.
+func (h *TransformHandlers) migrateStorageVersion(
+	ctx context.Context, tf *platformv1alpha1.Transform, generated *platformv1alpha1.GeneratedCRDReference,
+) error {
+	storage := storageVersion(tf)
+	if storage == "" {
+		return nil
+	}
+	logger := log.FromContext(ctx)
+
+	definition := &apiextensionsv1.CustomResourceDefinition{}
+	if err := h.client.Get(ctx, client.ObjectKey{Name: generated.Name}, definition); err != nil {
+		return fmt.Errorf("failed to get CRD %s: %w", generated.Name, err)
+	}
+	stored := definition.Status.StoredVersions
+	if len(stored) == 0 || slices.Equal(stored, []string{storage}) {
+		return h.setStorageMigrated(ctx, tf, metav1.ConditionTrue, "Migrated",
+			fmt.Sprintf("Instances are stored as %s", storage))
+	}
+
+	migrated, err := h.rewriteInstances(ctx, generated)
+	if err == nil {
+		definition.Status.StoredVersions = []string{storage}
+		err = h.client.Status().Update(ctx, definition)
+	}
+	if err != nil {
+		if statusErr := h.setStorageMigrated(ctx, tf, metav1.ConditionFalse, "MigrationFailed",
+			fmt.Sprintf("Failed to migrate instances stored as %v to %s: %v", stored, storage, err)); statusErr != nil {
+			logger.Error(statusErr, "failed to update status after storage migration failure")
+		}
+		return fmt.Errorf("failed to migrate instances to storage version %s: %w", storage, err)
+	}
+
+	logger.Info("Migrated instances to storage version", "crd", generated.Name, "from", stored, "to", storage,
+		"instances", migrated)
+	h.recordEvent(tf, "Normal", "StorageVersionMigrated", "Migrated %d instance(s) stored as %v to %s",
+		migrated, stored, storage)
+	return h.setStorageMigrated(ctx, tf, metav1.ConditionTrue, "Migrated", fmt.Sprintf("Instances are stored as %s", storage))
+}
+
+// rewriteInstances updates every instance of a generated CRD unchanged and
+// returns how many it rewrote
+func (h *TransformHandlers) rewriteInstances(ctx context.Context, generated *platformv1alpha1.GeneratedCRDReference) (int, error) {
+	instances, err := listGeneratedInstances(ctx, h.client, generated)
+	if err != nil {
+		return 0, err
+	}
+	migrated := 0
+	for i := range instances {
+		instance := &instances[i]
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			latest := &unstructured.Unstructured{}
+			latest.SetGroupVersionKind(instance.GroupVersionKind())
+			if err := h.client.Get(ctx, client.ObjectKeyFromObject(instance), latest); err != nil {
+				return err
+			}
+			return h.client.Update(ctx, latest)
+		})
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return migrated, fmt.Errorf("failed to rewrite %s/%s: %w", instance.GetNamespace(), instance.GetName(), err)
+		}
+		migrated++
+	}
+	return migrated, nil
+}
+
+// setStorageMigrated sets the StorageMigrated condition unless it is set
+// already
+func (h *TransformHandlers) setStorageMigrated(
+	ctx context.Context, tf *platformv1alpha1.Transform, status metav1.ConditionStatus, reason, message string,
+) error {
+	if current := tf.GetCondition(ConditionTypeStorageMigrated); current != nil &&
+		current.Status == status && current.Reason == reason && current.Message == message {
+		return nil
+	}
+	return h.updateStatusWithRetry(ctx, tf, func(latest *platformv1alpha1.Transform) {
+		latest.SetCondition(ConditionTypeStorageMigrated, status, reason, message)
+	})
+}
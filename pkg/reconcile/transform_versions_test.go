@@ -0,0 +1,200 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconcile
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	platformv1alpha1 "github.com/chazu/pequod/api/v1alpha1"
+	"github.com/chazu/pequod/pkg/crd"
+)
+
+// versionedModule renders v1beta1 instances, and converts to and from the
+// v1alpha1 spec in #Versions
+const versionedModule = `
+#Input: replicas: int
+
+#Versions: v1alpha1: size: "small" | "large"
+
+#Convert: {
+	v1alpha1: v1beta1: {
+		input: #Versions.v1alpha1
+		output: replicas: [if input.size == "large" {3}, 1][0]
+	}
+	v1beta1: v1alpha1: {
+		input: #Input
+		output: size: [if input.replicas > 1 {"large"}, "small"][0]
+	}
+}
+
+#Render: {
+	input: {
+		metadata: {name: string, namespace: string}
+		spec: {#Input, platformRef: string}
+	}
+	output: {
+		metadata: {name: input.metadata.name, version: "v1beta1"}
+		nodes: []
+		violations: []
+	}
+}
+`
+
+// newVersionedTransform returns a webservice Transform serving v1alpha1 and
+// v1beta1 from versionedModule, stored as v1beta1
+func newVersionedTransform() *platformv1alpha1.Transform {
+	return &platformv1alpha1.Transform{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "webservice",
+			Namespace:  "default",
+			Finalizers: []string{TransformFinalizer},
+		},
+		Spec: platformv1alpha1.TransformSpec{
+			CueRef:  platformv1alpha1.CueReference{Type: platformv1alpha1.CueRefTypeInline, Ref: versionedModule},
+			Group:   "apps.example.com",
+			Version: "v1beta1",
+			Versions: []platformv1alpha1.APIVersion{
+				{Name: "v1alpha1", Deprecated: true},
+				{Name: "v1beta1", Storage: true},
+			},
+		},
+	}
+}
+
+func TestTransformHandlers_Versions(t *testing.T) {
+	tf := newVersionedTransform()
+	c := newTestClient(tf)
+	handlers := newTestHandlers(c)
+	key := types.NamespacedName{Name: "webservice", Namespace: "default"}
+
+	// Several versions cannot be served without the conversion webhook
+	if _, err := handlers.Reconcile(context.Background(), key); err == nil || !strings.Contains(err.Error(), "conversion webhook") {
+		t.Fatalf("expected an error without the conversion webhook, got %v", err)
+	}
+
+	handlers.conversion = &crd.ConversionWebhook{ServiceName: "pequod-webhook", ServiceNamespace: "pequod-system"}
+	if _, err := handlers.Reconcile(context.Background(), key); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	generated := &apiextensionsv1.CustomResourceDefinition{}
+	if err := c.Get(context.Background(), client.ObjectKey{Name: "webservices.apps.example.com"}, generated); err != nil {
+		t.Fatalf("failed to get CRD: %v", err)
+	}
+	specs := map[string]apiextensionsv1.JSONSchemaProps{}
+	for _, v := range generated.Spec.Versions {
+		specs[v.Name] = v.Schema.OpenAPIV3Schema.Properties["spec"]
+		if v.Storage != (v.Name == "v1beta1") || v.Deprecated != (v.Name == "v1alpha1") || !v.Served {
+			t.Errorf("unexpected flags on version %s: %+v", v.Name, v)
+		}
+	}
+	if _, ok := specs["v1alpha1"].Properties["size"]; !ok {
+		t.Errorf("expected v1alpha1 to have the #Versions.v1alpha1 schema, got %+v", specs["v1alpha1"])
+	}
+	if _, ok := specs["v1beta1"].Properties["replicas"]; !ok {
+		t.Errorf("expected v1beta1 to have the #Input schema, got %+v", specs["v1beta1"])
+	}
+	if generated.Spec.Conversion == nil || generated.Spec.Conversion.Strategy != apiextensionsv1.WebhookConverter {
+		t.Errorf("expected webhook conversion, got %+v", generated.Spec.Conversion)
+	}
+
+	updated := &platformv1alpha1.Transform{}
+	if err := c.Get(context.Background(), key, updated); err != nil {
+		t.Fatalf("failed to get transform: %v", err)
+	}
+	if updated.Status.GeneratedCRD.APIVersion != "apps.example.com/v1beta1" {
+		t.Errorf("expected instances to be rendered at v1beta1, got %s", updated.Status.GeneratedCRD.APIVersion)
+	}
+}
+
+func TestTransformHandlers_VersionsWithoutConvert(t *testing.T) {
+	tf := newVersionedTransform()
+	tf.Spec.CueRef.Ref = strings.Replace(versionedModule, "v1beta1: v1alpha1: {", "_v1beta1: v1alpha1: {", 1)
+	c := newTestClient(tf)
+	handlers := newTestHandlers(c)
+	handlers.conversion = &crd.ConversionWebhook{ServiceName: "pequod-webhook", ServiceNamespace: "pequod-system"}
+
+	// v1alpha1 instances could not be read from v1beta1 storage
+	_, err := handlers.Reconcile(context.Background(), types.NamespacedName{Name: "webservice", Namespace: "default"})
+	if err == nil || !strings.Contains(err.Error(), "#Convert.v1beta1.v1alpha1") {
+		t.Fatalf("expected the missing conversion to be refused, got %v", err)
+	}
+	generated := &apiextensionsv1.CustomResourceDefinition{}
+	if err := c.Get(context.Background(), client.ObjectKey{Name: "webservices.apps.example.com"}, generated); err == nil {
+		t.Errorf("expected no CRD to be generated")
+	}
+}
+
+func TestTransformHandlers_MigrateStorageVersion(t *testing.T) {
+	tf := newVersionedTransform()
+	definition := &apiextensionsv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: "webservices.apps.example.com"},
+		Status:     apiextensionsv1.CustomResourceDefinitionStatus{StoredVersions: []string{"v1alpha1", "v1beta1"}},
+	}
+	instance := &unstructured.Unstructured{}
+	instance.SetAPIVersion("apps.example.com/v1beta1")
+	instance.SetKind("WebService")
+	instance.SetName("web")
+	instance.SetNamespace("default")
+	c := newTestClient(tf, definition, instance)
+	handlers := newTestHandlers(c)
+	if err := c.Get(context.Background(), client.ObjectKeyFromObject(instance), instance); err != nil {
+		t.Fatalf("failed to get instance: %v", err)
+	}
+
+	generated := &platformv1alpha1.GeneratedCRDReference{
+		APIVersion: "apps.example.com/v1beta1",
+		Kind:       "WebService",
+		Name:       definition.Name,
+	}
+	if err := handlers.migrateStorageVersion(context.Background(), tf, generated); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := c.Get(context.Background(), client.ObjectKeyFromObject(definition), definition); err != nil {
+		t.Fatalf("failed to get CRD: %v", err)
+	}
+	if !reflect.DeepEqual(definition.Status.StoredVersions, []string{"v1beta1"}) {
+		t.Errorf("expected only the storage version to be stored, got %v", definition.Status.StoredVersions)
+	}
+
+	migrated := &unstructured.Unstructured{}
+	migrated.SetGroupVersionKind(instance.GroupVersionKind())
+	if err := c.Get(context.Background(), client.ObjectKeyFromObject(instance), migrated); err != nil {
+		t.Fatalf("failed to get instance: %v", err)
+	}
+	if migrated.GetResourceVersion() == instance.GetResourceVersion() {
+		t.Error("expected the instance to be rewritten")
+	}
+
+	updated := &platformv1alpha1.Transform{}
+	if err := c.Get(context.Background(), client.ObjectKeyFromObject(tf), updated); err != nil {
+		t.Fatalf("failed to get transform: %v", err)
+	}
+	if cond := updated.GetCondition(ConditionTypeStorageMigrated); cond == nil || cond.Status != metav1.ConditionTrue {
+		t.Errorf("expected StorageMigrated=True, got %+v", cond)
+	}
+}
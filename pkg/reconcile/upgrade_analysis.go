@@ -0,0 +1,292 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconcile
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	platformv1alpha1 "github.com/chazu/pequod/api/v1alpha1"
+	"github.com/chazu/pequod/pkg/graph"
+	"github.com/chazu/pequod/pkg/platformloader"
+)
+
+const (
+	// ConditionTypeUpgradeBlocked is the Transform condition set while a new
+	// module digest is held back by a failed upgrade analysis
+	ConditionTypeUpgradeBlocked = "UpgradeBlocked"
+
+	// maxImpactResults bounds the instances detailed in an UpgradeAnalysis
+	maxImpactResults = 20
+)
+
+// UpgradeAnalyzer previews a new module by rendering every instance of a
+// Transform with both the current and the new module
+type UpgradeAnalyzer struct {
+	client client.Client
+
+	// instances renders instances the way the instance controller does,
+	// with its own reference index
+	instances *InstanceHandlers
+}
+
+// NewUpgradeAnalyzer creates a new UpgradeAnalyzer
+func NewUpgradeAnalyzer(c client.Client, scheme *runtime.Scheme, renderer *platformloader.Renderer) *UpgradeAnalyzer {
+	return &UpgradeAnalyzer{
+		client:    c,
+		instances: NewInstanceHandlers(c, scheme, nil, renderer),
+	}
+}
+
+// SetPlatformConfigNamespace sets the namespace whose PlatformConfigs may
+// select other namespaces
+func (a *UpgradeAnalyzer) SetPlatformConfigNamespace(namespace string) {
+	a.instances.SetPlatformConfigNamespace(namespace)
+}
+
+// Analyze renders every instance of the Transform's default channel from
+// the modules at from and to, and compares the rendered nodes and violations.
+// Instances that fail to render with the current module are left out of
+// the counts, since the new module does not change their outcome.
+func (a *UpgradeAnalyzer) Analyze(
+	ctx context.Context, tf *platformv1alpha1.Transform, from, to platformloader.CueRefInput, fromDigest, toDigest string,
+) (*platformv1alpha1.UpgradeAnalysis, error) {
+	instances, err := listGeneratedInstances(ctx, a.client, tf.Status.GeneratedCRD)
+	if err != nil {
+		return nil, err
+	}
+	instances = defaultChannelInstances(instances)
+	kinds, err := generatedKinds(ctx, a.client)
+	if err != nil {
+		return nil, err
+	}
+
+	analysis := &platformv1alpha1.UpgradeAnalysis{
+		FromDigest: fromDigest,
+		ToDigest:   toDigest,
+		AnalyzedAt: metav1.Now(),
+	}
+	var results []platformv1alpha1.InstanceImpact
+	for i := range instances {
+		instance := &instances[i]
+		if !instance.GetDeletionTimestamp().IsZero() {
+			continue
+		}
+		before, err := a.render(ctx, instance, from, kinds)
+		if err != nil {
+			continue
+		}
+		analysis.Instances++
+
+		impact := platformv1alpha1.InstanceImpact{Name: instance.GetName(), Namespace: instance.GetNamespace()}
+		after, err := a.render(ctx, instance, to, kinds)
+		if err != nil {
+			analysis.Failed++
+			impact.Error = err.Error()
+			results = append(results, impact)
+			continue
+		}
+
+		diffGraphs(before, after, &impact)
+		changed := len(impact.Added)+len(impact.Removed)+len(impact.Changed) > 0
+		if changed {
+			analysis.Changed++
+		}
+		if len(impact.NewViolations) > 0 {
+			analysis.NewViolations++
+		}
+		if changed || len(impact.NewViolations) > 0 {
+			results = append(results, impact)
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		x, y := results[i], results[j]
+		if (x.Error != "") != (y.Error != "") {
+			return x.Error != ""
+		}
+		if x.Namespace != y.Namespace {
+			return x.Namespace < y.Namespace
+		}
+		return x.Name < y.Name
+	})
+	if len(results) > maxImpactResults {
+		results = results[:maxImpactResults]
+	}
+	analysis.Results = results
+	return analysis, nil
+}
+
+// analyzeUpgrade analyzes a fetched module whose digest differs from the
+// one the Transform adopted, and reports whether the Transform's upgrade
+// analysis policy holds it back. A held back module leaves the Transform
+// Ready with its current module and the UpgradeBlocked condition set.
+func (h *TransformHandlers) analyzeUpgrade(
+	ctx context.Context, tf *platformv1alpha1.Transform, fetchResult *platformloader.FetchResult,
+) (bool, error) {
+	logger := log.FromContext(ctx)
+	current := tf.Status.ResolvedCueRef
+	if h.analyzer == nil || tf.Spec.UpgradeAnalysis == platformv1alpha1.UpgradeAnalysisNone || !refetchable(current) ||
+		tf.Status.GeneratedCRD == nil || current.Digest == fetchResult.Digest {
+		return false, h.unblockUpgrade(ctx, tf, nil)
+	}
+
+	// An analysis of the same upgrade is not repeated
+	analysis := tf.Status.UpgradeAnalysis.DeepCopy()
+	analyzed := false
+	if analysis == nil || analysis.FromDigest != current.Digest || analysis.ToDigest != fetchResult.Digest {
+		from := transformCueRef(tf)
+		to := from
+		to.Type = string(tf.Spec.CueRef.Type)
+		to.Ref = pinnedRef(tf.Spec.CueRef, fetchResult.Digest)
+
+		var err error
+		analysis, err = h.analyzer.Analyze(ctx, tf, from, to, current.Digest, fetchResult.Digest)
+		if err != nil {
+			return false, err
+		}
+		analyzed = true
+		logger.Info("Analyzed module upgrade",
+			"from", analysis.FromDigest, "to", analysis.ToDigest,
+			"instances", analysis.Instances, "changed", analysis.Changed, "failed", analysis.Failed)
+		if h.recorder != nil {
+			h.recorder.Eventf(tf, "Normal", "UpgradeAnalyzed",
+				"Rendered %d instance(s) with %s: %d changed, %d failed, %d with new violations",
+				analysis.Instances, analysis.ToDigest, analysis.Changed, analysis.Failed, analysis.NewViolations)
+		}
+	}
+	analysis.Blocked = tf.Spec.UpgradeAnalysis == platformv1alpha1.UpgradeAnalysisGate && analysis.Failed > 0
+
+	if !analysis.Blocked {
+		return false, h.unblockUpgrade(ctx, tf, analysis)
+	}
+
+	message := fmt.Sprintf("%d of %d instance(s) fail to render with %s; keeping %s",
+		analysis.Failed, analysis.Instances, analysis.ToDigest, analysis.FromDigest)
+	if analyzed && h.recorder != nil {
+		h.recorder.Event(tf, "Warning", "UpgradeBlocked", message)
+	}
+	return true, h.updateStatusWithRetry(ctx, tf, func(latest *platformv1alpha1.Transform) {
+		latest.Status.UpgradeAnalysis = analysis
+		latest.Status.Phase = platformv1alpha1.TransformPhaseReady
+		latest.Status.ObservedGeneration = latest.Generation
+		latest.SetCondition(ConditionTypeUpgradeBlocked, metav1.ConditionTrue, "RenderFailures", message)
+	})
+}
+
+// unblockUpgrade records the analysis of an adopted module, if any, and
+// clears the UpgradeBlocked condition
+func (h *TransformHandlers) unblockUpgrade(
+	ctx context.Context, tf *platformv1alpha1.Transform, analysis *platformv1alpha1.UpgradeAnalysis,
+) error {
+	blocked := tf.GetCondition(ConditionTypeUpgradeBlocked)
+	if analysis == nil && (blocked == nil || blocked.Status != metav1.ConditionTrue) {
+		return nil
+	}
+	return h.updateStatusWithRetry(ctx, tf, func(latest *platformv1alpha1.Transform) {
+		if analysis != nil {
+			latest.Status.UpgradeAnalysis = analysis
+		}
+		if blocked != nil {
+			latest.SetCondition(ConditionTypeUpgradeBlocked, metav1.ConditionFalse, "Unblocked",
+				"The module is no longer held back")
+		}
+	})
+}
+
+// pinnable reports whether a module of the type can be fetched again at
+// an earlier digest, which analysis needs to render the current module
+func pinnable(refType platformv1alpha1.CueRefType) bool {
+	switch refType {
+	case platformv1alpha1.CueRefTypeGit, platformv1alpha1.CueRefTypeOCI, platformv1alpha1.CueRefTypeInline:
+		return true
+	default:
+		return false
+	}
+}
+
+// render renders an instance from cueRef with the same inputs and node
+// adjustments as the instance controller
+func (a *UpgradeAnalyzer) render(
+	ctx context.Context, instance *unstructured.Unstructured, cueRef platformloader.CueRefInput, kinds map[schema.GroupKind]bool,
+) (*graph.Graph, error) {
+	rawSpec, err := instanceSpec(instance)
+	if err != nil {
+		return nil, err
+	}
+	config, err := a.instances.resolvePlatformConfig(ctx, instance)
+	if err != nil {
+		return nil, err
+	}
+	g, _, err := a.instances.renderer.RenderInstance(ctx, instance, rawSpec, cueRef, platformloader.RenderOptions{
+		Config:           config,
+		ResolveInstances: a.instances.instanceResolver(instance),
+	})
+	if err != nil {
+		return nil, err
+	}
+	markInstanceNodes(g, kinds)
+	if err := applyInstanceIgnoreFields(instance, g); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// diffGraphs records the nodes and violations that differ between the
+// renders of an instance
+func diffGraphs(before, after *graph.Graph, impact *platformv1alpha1.InstanceImpact) {
+	previous := make(map[string]*graph.Node, len(before.Nodes))
+	for i := range before.Nodes {
+		previous[before.Nodes[i].ID] = &before.Nodes[i]
+	}
+	for i := range after.Nodes {
+		node := &after.Nodes[i]
+		old, found := previous[node.ID]
+		switch {
+		case !found:
+			impact.Added = append(impact.Added, node.ID)
+		case !equality.Semantic.DeepEqual(old, node):
+			impact.Changed = append(impact.Changed, node.ID)
+		}
+		delete(previous, node.ID)
+	}
+	for id := range previous {
+		impact.Removed = append(impact.Removed, id)
+	}
+	sort.Strings(impact.Added)
+	sort.Strings(impact.Removed)
+	sort.Strings(impact.Changed)
+
+	reported := make(map[graph.Violation]bool, len(before.Violations))
+	for _, v := range before.Violations {
+		reported[v] = true
+	}
+	for _, v := range after.Violations {
+		if !reported[v] {
+			impact.NewViolations = append(impact.NewViolations, fmt.Sprintf("%s: %s", v.Path, v.Message))
+		}
+	}
+}
@@ -0,0 +1,263 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconcile
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	platformv1alpha1 "github.com/chazu/pequod/api/v1alpha1"
+	"github.com/chazu/pequod/pkg/platformloader"
+)
+
+// upgradeModule renders a ConfigMap for an instance's port, constrained by
+// portConstraint, and a Service if withService
+func upgradeModule(portConstraint string, withService bool) string {
+	service := ""
+	if withService {
+		service = `, {
+			id: "service"
+			object: {
+				apiVersion: "v1"
+				kind:       "Service"
+				metadata: {name: input.metadata.name, namespace: input.metadata.namespace}
+				spec: ports: [{port: input.spec.port}]
+			}
+			applyPolicy: {mode: "Apply"}
+		}`
+	}
+	return fmt.Sprintf(`
+#Input: port: int & %s
+
+#Render: {
+	input: {
+		metadata: {name: string, namespace: string}
+		spec: {#Input, platformRef: string}
+	}
+	output: {
+		metadata: {name: input.metadata.name, version: "v1alpha1"}
+		nodes: [{
+			id: "config"
+			object: {
+				apiVersion: "v1"
+				kind:       "ConfigMap"
+				metadata: {name: input.metadata.name, namespace: input.metadata.namespace}
+				data: port: "\(input.spec.port)"
+			}
+			applyPolicy: {mode: "Apply"}
+		}%s]
+		violations: []
+	}
+}
+`, portConstraint, service)
+}
+
+var (
+	currentModule  = upgradeModule(">0", false)
+	additiveModule = upgradeModule(">0", true)
+	breakingModule = upgradeModule(">=1024", false)
+)
+
+// defaultNamespace returns the namespace instances are rendered in
+func defaultNamespace() *corev1.Namespace {
+	return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}}
+}
+
+// newUpgradeInstance returns a WebService instance listening on port
+func newUpgradeInstance(name string, port int64) *unstructured.Unstructured {
+	obj := newInstance("WebService", name, nil)
+	_ = unstructured.SetNestedField(obj.Object, port, "spec", "port")
+	return obj
+}
+
+// newUpgradingTransform returns a Ready webservice Transform that adopted
+// currentModule and whose spec now references module
+func newUpgradingTransform(module string, policy platformv1alpha1.UpgradeAnalysisPolicy) *platformv1alpha1.Transform {
+	return &platformv1alpha1.Transform{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "webservice",
+			Namespace:  "default",
+			Generation: 2,
+			Finalizers: []string{TransformFinalizer},
+		},
+		Spec: platformv1alpha1.TransformSpec{
+			CueRef:          platformv1alpha1.CueReference{Type: platformv1alpha1.CueRefTypeInline, Ref: module},
+			Group:           "apps.example.com",
+			Version:         "v1alpha1",
+			UpgradeAnalysis: policy,
+		},
+		Status: platformv1alpha1.TransformStatus{
+			Phase:              platformv1alpha1.TransformPhaseReady,
+			ObservedGeneration: 1,
+			GeneratedCRD: &platformv1alpha1.GeneratedCRDReference{
+				APIVersion: "apps.example.com/v1alpha1",
+				Kind:       "WebService",
+				Name:       "webservices.apps.example.com",
+			},
+			ResolvedCueRef: &platformv1alpha1.ResolvedCueReference{
+				Type:   platformv1alpha1.CueRefTypeInline,
+				Ref:    currentModule,
+				Digest: platformloader.InlineDigest(currentModule),
+			},
+		},
+	}
+}
+
+func TestUpgradeAnalyzer_Analyze(t *testing.T) {
+	tests := []struct {
+		name   string
+		module string
+		want   *platformv1alpha1.UpgradeAnalysis
+	}{
+		{
+			name:   "unchanged",
+			module: currentModule,
+			want:   &platformv1alpha1.UpgradeAnalysis{Instances: 2},
+		},
+		{
+			name:   "added node",
+			module: additiveModule,
+			want: &platformv1alpha1.UpgradeAnalysis{
+				Instances: 2,
+				Changed:   2,
+				Results: []platformv1alpha1.InstanceImpact{
+					{Name: "api", Namespace: "default", Added: []string{"service"}},
+					{Name: "web", Namespace: "default", Added: []string{"service"}},
+				},
+			},
+		},
+		{
+			name:   "render failure",
+			module: breakingModule,
+			want: &platformv1alpha1.UpgradeAnalysis{
+				Instances: 2,
+				Failed:    1,
+				Results:   []platformv1alpha1.InstanceImpact{{Name: "web", Namespace: "default"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tf := newUpgradingTransform(tt.module, platformv1alpha1.UpgradeAnalysisReport)
+			c := newTestClient(tf, defaultNamespace(), newUpgradeInstance("web", 80), newUpgradeInstance("api", 8080))
+			analyzer := NewUpgradeAnalyzer(c, newTestScheme(), platformloader.NewRenderer(createTestLoader()))
+
+			from := platformloader.CueRefInput{Type: platformloader.InlineType, Ref: currentModule}
+			to := platformloader.CueRefInput{Type: platformloader.InlineType, Ref: tt.module}
+			got, err := analyzer.Analyze(context.Background(), tf, from, to, "old", "new")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			// Render errors are compared by presence only
+			for i := range got.Results {
+				if got.Results[i].Error == "" {
+					continue
+				}
+				if tt.want.Failed == 0 {
+					t.Errorf("unexpected render error for %s: %s", got.Results[i].Name, got.Results[i].Error)
+				}
+				got.Results[i].Error = ""
+			}
+			got.AnalyzedAt = metav1.Time{}
+			tt.want.FromDigest, tt.want.ToDigest = "old", "new"
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("unexpected analysis:\ngot:  %+v\nwant: %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransformHandlers_AnalyzesUpgrade(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      platformv1alpha1.UpgradeAnalysisPolicy
+		module      string
+		wantBlocked bool
+	}{
+		{name: "gate holds back render failures", policy: platformv1alpha1.UpgradeAnalysisGate, module: breakingModule, wantBlocked: true},
+		{name: "gate adopts compatible module", policy: platformv1alpha1.UpgradeAnalysisGate, module: additiveModule},
+		{name: "report adopts render failures", policy: platformv1alpha1.UpgradeAnalysisReport, module: breakingModule},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tf := newUpgradingTransform(tt.module, tt.policy)
+			c := newTestClient(tf, defaultNamespace(), newUpgradeInstance("web", 80), newUpgradeInstance("api", 8080))
+			handlers := newTestHandlers(c)
+			handlers.analyzer = NewUpgradeAnalyzer(c, newTestScheme(), platformloader.NewRenderer(createTestLoader()))
+
+			key := types.NamespacedName{Name: "webservice", Namespace: "default"}
+			if _, err := handlers.Reconcile(context.Background(), key); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			updated := &platformv1alpha1.Transform{}
+			if err := c.Get(context.Background(), client.ObjectKeyFromObject(tf), updated); err != nil {
+				t.Fatalf("failed to get transform: %v", err)
+			}
+			analysis := updated.Status.UpgradeAnalysis
+			if analysis == nil || analysis.ToDigest != platformloader.InlineDigest(tt.module) {
+				t.Fatalf("expected an analysis of the new module, got %+v", analysis)
+			}
+			if analysis.Blocked != tt.wantBlocked {
+				t.Errorf("expected blocked=%v, got %v", tt.wantBlocked, analysis.Blocked)
+			}
+
+			wantModule := tt.module
+			if tt.wantBlocked {
+				wantModule = currentModule
+			}
+			if want := platformloader.InlineDigest(wantModule); updated.Status.ResolvedCueRef.Digest != want {
+				t.Errorf("expected the adopted module to be %s, got %s", want, updated.Status.ResolvedCueRef.Digest)
+			}
+			if updated.Status.Phase != platformv1alpha1.TransformPhaseReady || updated.Status.ObservedGeneration != 2 {
+				t.Errorf("expected Ready at generation 2, got %s at %d", updated.Status.Phase, updated.Status.ObservedGeneration)
+			}
+			blocked := updated.GetCondition(ConditionTypeUpgradeBlocked)
+			if gotBlocked := blocked != nil && blocked.Status == metav1.ConditionTrue; gotBlocked != tt.wantBlocked {
+				t.Errorf("expected UpgradeBlocked=%v, got %+v", tt.wantBlocked, blocked)
+			}
+		})
+	}
+}
+
+func TestTransformCueRef_PrefersAdoptedModule(t *testing.T) {
+	tf := newUpgradingTransform(breakingModule, platformv1alpha1.UpgradeAnalysisGate)
+	if got := transformCueRef(tf); got.Ref != currentModule {
+		t.Errorf("expected instances to render the adopted module, got %q", got.Ref)
+	}
+
+	// Inline modules other than the spec's are fetched from their ConfigMap
+	tf.Status.ResolvedCueRef.Ref = ""
+	got := transformCueRef(tf)
+	if want := "default/" + inlineModuleName("webservice", platformloader.InlineDigest(currentModule)); got.Type != "configmap" || got.Ref != want {
+		t.Errorf("expected the adopted module from ConfigMap %s, got %s %q", want, got.Type, got.Ref)
+	}
+
+	tf.Status.ResolvedCueRef.Digest = platformloader.InlineDigest(breakingModule)
+	if got := transformCueRef(tf); got.Type != platformloader.InlineType || got.Ref != breakingModule {
+		t.Errorf("expected the spec module once it is adopted, got %q", got.Ref)
+	}
+}
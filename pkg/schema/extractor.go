@@ -11,6 +11,10 @@ import (
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 )
 
+// VersionsDefinition holds the #Input of each further API version of a
+// module by version name, e.g. `#Versions: v1alpha1: {size: string}`
+const VersionsDefinition = "#Versions"
+
 // Extractor converts CUE values to JSONSchema for CRD generation
 type Extractor struct{}
 
@@ -22,13 +26,8 @@ func NewExtractor() *Extractor {
 // ExtractInputSchema extracts the input schema from a CUE module.
 // It looks for #Input or #Spec definitions and converts them to JSONSchema.
 func (e *Extractor) ExtractInputSchema(cueValue cue.Value) (*apiextensionsv1.JSONSchemaProps, error) {
-	// Try to find #Input first, then fall back to #Spec
-	inputDef := cueValue.LookupPath(cue.ParsePath("#Input"))
-	if !inputDef.Exists() {
-		inputDef = cueValue.LookupPath(cue.ParsePath("#Spec"))
-	}
-
-	if !inputDef.Exists() {
+	inputDef, ok := inputDefinition(cueValue)
+	if !ok {
 		return nil, fmt.Errorf("no #Input or #Spec definition found in CUE module")
 	}
 
@@ -39,6 +38,81 @@ func (e *Extractor) ExtractInputSchema(cueValue cue.Value) (*apiextensionsv1.JSO
 	return e.CueToJSONSchema(inputDef)
 }
 
+// ExtractVersionSchema extracts the input schema of an API version from a
+// module's #Versions
+func (e *Extractor) ExtractVersionSchema(cueValue cue.Value, version string) (*apiextensionsv1.JSONSchemaProps, error) {
+	def := cueValue.LookupPath(cue.ParsePath(VersionsDefinition + "." + version))
+	if !def.Exists() {
+		return nil, fmt.Errorf("no %s.%s definition found in CUE module", VersionsDefinition, version)
+	}
+	if def.Err() != nil {
+		return nil, fmt.Errorf("error in %s.%s: %w", VersionsDefinition, version, def.Err())
+	}
+	return e.CueToJSONSchema(def)
+}
+
+// ExtractOutputsSchema extracts the schema of a module's #Outputs.output,
+// the outputs written to instance status. Returns nil if the module
+// declares no outputs.
+func (e *Extractor) ExtractOutputsSchema(cueValue cue.Value) (*apiextensionsv1.JSONSchemaProps, error) {
+	output := cueValue.LookupPath(cue.ParsePath("#Outputs.output"))
+	if !output.Exists() {
+		return nil, nil
+	}
+	if output.Err() != nil {
+		return nil, fmt.Errorf("error in #Outputs: %w", output.Err())
+	}
+	return e.outputSchema(output)
+}
+
+// outputSchema converts an #Outputs value to a JSON schema. Values computed
+// from live objects cannot be evaluated until the objects exist, so they
+// are typed from their expression where possible and free-form otherwise.
+func (e *Extractor) outputSchema(v cue.Value) (*apiextensionsv1.JSONSchemaProps, error) {
+	if v.Err() != nil {
+		if op, _ := v.Expr(); op == cue.InterpolationOp {
+			return &apiextensionsv1.JSONSchemaProps{Type: "string"}, nil
+		}
+		return &apiextensionsv1.JSONSchemaProps{XPreserveUnknownFields: boolPtr(true)}, nil
+	}
+	if v.IncompleteKind() != cue.StructKind {
+		return e.CueToJSONSchema(v)
+	}
+
+	schema := &apiextensionsv1.JSONSchemaProps{
+		Type:       "object",
+		Properties: make(map[string]apiextensionsv1.JSONSchemaProps),
+	}
+	iter, err := v.Fields(cue.Optional(true))
+	if err != nil {
+		return nil, fmt.Errorf("failed to iterate struct fields: %w", err)
+	}
+	for iter.Next() {
+		fieldName := strings.TrimSuffix(iter.Selector().String(), "?")
+		if strings.HasPrefix(fieldName, "_") || strings.HasPrefix(fieldName, "#") {
+			continue
+		}
+		fieldSchema, err := e.outputSchema(iter.Value())
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract schema for field %s: %w", fieldName, err)
+		}
+		schema.Properties[fieldName] = *fieldSchema
+		if !iter.IsOptional() {
+			schema.Required = append(schema.Required, fieldName)
+		}
+	}
+	return schema, nil
+}
+
+// inputDefinition returns the module's #Input definition, falling back to #Spec
+func inputDefinition(cueValue cue.Value) (cue.Value, bool) {
+	inputDef := cueValue.LookupPath(cue.ParsePath("#Input"))
+	if !inputDef.Exists() {
+		inputDef = cueValue.LookupPath(cue.ParsePath("#Spec"))
+	}
+	return inputDef, inputDef.Exists()
+}
+
 // CueToJSONSchema converts a CUE value to JSONSchema
 func (e *Extractor) CueToJSONSchema(v cue.Value) (*apiextensionsv1.JSONSchemaProps, error) {
 	schema := &apiextensionsv1.JSONSchemaProps{}
@@ -289,13 +363,21 @@ func (e *Extractor) extractStructSchema(v cue.Value, schema *apiextensionsv1.JSO
 			continue
 		}
 
-		// Extract field schema
-		fieldSchema, err := e.CueToJSONSchema(fieldValue)
+		// References to other instances have a fixed schema
+		ref, isRef, err := parseInstanceRef(fieldValue)
 		if err != nil {
-			return fmt.Errorf("failed to extract schema for field %s: %w", fieldName, err)
+			return fmt.Errorf("field %s: %w", fieldName, err)
+		}
+		if isRef {
+			schema.Properties[fieldName] = *instanceRefSchema(ref)
+		} else {
+			// Extract field schema
+			fieldSchema, err := e.CueToJSONSchema(fieldValue)
+			if err != nil {
+				return fmt.Errorf("failed to extract schema for field %s: %w", fieldName, err)
+			}
+			schema.Properties[fieldName] = *fieldSchema
 		}
-
-		schema.Properties[fieldName] = *fieldSchema
 
 		// Check if field is required (not optional)
 		if !iter.IsOptional() {
@@ -412,3 +412,71 @@ func TestExtractor_CueToJSONSchema_IntegerEnum(t *testing.T) {
 		t.Errorf("expected 3 enum values, got %d", len(schema.Enum))
 	}
 }
+
+func TestExtractor_ExtractOutputsSchema(t *testing.T) {
+	ctx := cuecontext.New()
+	extractor := NewExtractor()
+
+	v := ctx.CompileString(`
+#Outputs: {
+	input: spec: port: int
+	live: [string]: _
+	output: {
+		endpoint: "http://\(live.service.spec.clusterIP):\(input.spec.port)"
+		port:     input.spec.port
+		secret:   live.credentials.metadata.name
+	}
+}
+`)
+	if v.Err() != nil {
+		t.Fatalf("failed to compile CUE: %v", v.Err())
+	}
+
+	schema, err := extractor.ExtractOutputsSchema(v)
+	if err != nil {
+		t.Fatalf("failed to extract outputs schema: %v", err)
+	}
+	if schema == nil || schema.Type != "object" {
+		t.Fatalf("expected an object schema, got %+v", schema)
+	}
+	if got := schema.Properties["endpoint"].Type; got != "string" {
+		t.Errorf("expected endpoint type string, got %q", got)
+	}
+	if got := schema.Properties["port"].Type; got != "integer" {
+		t.Errorf("expected port type integer, got %q", got)
+	}
+	if secret := schema.Properties["secret"]; secret.XPreserveUnknownFields == nil || !*secret.XPreserveUnknownFields {
+		t.Errorf("expected a free-form schema for a value taken from a live object, got %+v", secret)
+	}
+
+	// Modules without #Outputs have no outputs schema
+	schema, err = extractor.ExtractOutputsSchema(ctx.CompileString(`#Input: name: string`))
+	if err != nil || schema != nil {
+		t.Errorf("expected no schema, got %+v, %v", schema, err)
+	}
+}
+
+func TestExtractor_ExtractVersionSchema(t *testing.T) {
+	ctx := cuecontext.New()
+	extractor := NewExtractor()
+
+	v := ctx.CompileString(`
+#Input: replicas: int
+#Versions: v1alpha1: size: "small" | "large"
+`)
+	if v.Err() != nil {
+		t.Fatalf("failed to compile CUE: %v", v.Err())
+	}
+
+	schema, err := extractor.ExtractVersionSchema(v, "v1alpha1")
+	if err != nil {
+		t.Fatalf("failed to extract version schema: %v", err)
+	}
+	if _, ok := schema.Properties["size"]; !ok || len(schema.Properties) != 1 {
+		t.Errorf("expected the v1alpha1 schema, got %+v", schema.Properties)
+	}
+
+	if _, err := extractor.ExtractVersionSchema(v, "v2"); err == nil {
+		t.Error("expected an error for a version the module does not define")
+	}
+}
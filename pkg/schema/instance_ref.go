@@ -0,0 +1,116 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+
+	"cuelang.org/go/cue"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// InstanceRefAttribute marks an #Input field that references another
+// platform instance in the same namespace, e.g.
+//
+//	database: {name: string} @instanceRef(kind="Database")
+//
+// The optional group argument selects between kinds of the same name
+// generated by different Transforms.
+const InstanceRefAttribute = "instanceRef"
+
+// InstanceRefField is an #Input field declared with @instanceRef
+type InstanceRefField struct {
+	// Path is the field path from the root of the spec
+	Path []string
+
+	// Kind is the kind of the referenced instance
+	Kind string
+
+	// Group is the API group of the referenced instance, if set
+	Group string
+}
+
+// Field returns the field path joined with dots, e.g. "storage.database"
+func (f InstanceRefField) Field() string {
+	return strings.Join(f.Path, ".")
+}
+
+// ExtractInstanceRefs returns the fields of a module's #Input (or #Spec)
+// declared with @instanceRef. Fields inside lists are not supported.
+func (e *Extractor) ExtractInstanceRefs(cueValue cue.Value) ([]InstanceRefField, error) {
+	inputDef, ok := inputDefinition(cueValue)
+	if !ok {
+		return nil, nil
+	}
+	return findInstanceRefs(inputDef, nil)
+}
+
+// findInstanceRefs walks the struct fields of v collecting @instanceRef fields
+func findInstanceRefs(v cue.Value, path []string) ([]InstanceRefField, error) {
+	if v.IncompleteKind()&cue.StructKind == 0 {
+		return nil, nil
+	}
+	iter, err := v.Fields(cue.Optional(true))
+	if err != nil {
+		return nil, nil
+	}
+
+	var refs []InstanceRefField
+	for iter.Next() {
+		name := strings.TrimSuffix(iter.Selector().String(), "?")
+		if strings.HasPrefix(name, "_") || strings.HasPrefix(name, "#") {
+			continue
+		}
+		fieldPath := append(append([]string{}, path...), name)
+
+		ref, ok, err := parseInstanceRef(iter.Value())
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", strings.Join(fieldPath, "."), err)
+		}
+		if ok {
+			ref.Path = fieldPath
+			refs = append(refs, ref)
+			continue
+		}
+
+		nested, err := findInstanceRefs(iter.Value(), fieldPath)
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, nested...)
+	}
+	return refs, nil
+}
+
+// parseInstanceRef reads the @instanceRef attribute of a field, if any
+func parseInstanceRef(v cue.Value) (InstanceRefField, bool, error) {
+	attr := v.Attribute(InstanceRefAttribute)
+	if attr.Err() != nil {
+		return InstanceRefField{}, false, nil
+	}
+	kind, found, err := attr.Lookup(0, "kind")
+	if err != nil || !found || kind == "" {
+		return InstanceRefField{}, false, fmt.Errorf("@%s requires a kind", InstanceRefAttribute)
+	}
+	group, _, err := attr.Lookup(0, "group")
+	if err != nil {
+		return InstanceRefField{}, false, fmt.Errorf("invalid @%s group: %w", InstanceRefAttribute, err)
+	}
+	return InstanceRefField{Kind: kind, Group: group}, true, nil
+}
+
+// instanceRefSchema is the CRD schema of an @instanceRef field
+func instanceRefSchema(ref InstanceRefField) *apiextensionsv1.JSONSchemaProps {
+	minLength := int64(1)
+	return &apiextensionsv1.JSONSchemaProps{
+		Type:        "object",
+		Description: fmt.Sprintf("Reference to a %s instance in the same namespace", ref.Kind),
+		Properties: map[string]apiextensionsv1.JSONSchemaProps{
+			"name": {
+				Type:        "string",
+				Description: fmt.Sprintf("Name of the %s", ref.Kind),
+				MinLength:   &minLength,
+			},
+		},
+		Required: []string{"name"},
+	}
+}
@@ -0,0 +1,61 @@
+package schema
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue/cuecontext"
+)
+
+func TestExtractor_InstanceRefs(t *testing.T) {
+	ctx := cuecontext.New()
+	extractor := NewExtractor()
+
+	v := ctx.CompileString(`
+#Input: {
+	image:    string
+	database: {name: string} @instanceRef(kind="Database")
+	storage: cache?: {name: string} @instanceRef(kind="Cache", group="apps.example.com")
+}
+`)
+	if v.Err() != nil {
+		t.Fatalf("failed to compile CUE: %v", v.Err())
+	}
+
+	refs, err := extractor.ExtractInstanceRefs(v)
+	if err != nil {
+		t.Fatalf("ExtractInstanceRefs() failed: %v", err)
+	}
+	want := []InstanceRefField{
+		{Path: []string{"database"}, Kind: "Database"},
+		{Path: []string{"storage", "cache"}, Kind: "Cache", Group: "apps.example.com"},
+	}
+	if !reflect.DeepEqual(refs, want) {
+		t.Errorf("ExtractInstanceRefs() = %+v, want %+v", refs, want)
+	}
+	if refs[1].Field() != "storage.cache" {
+		t.Errorf("expected field storage.cache, got %q", refs[1].Field())
+	}
+
+	schema, err := extractor.ExtractInputSchema(v)
+	if err != nil {
+		t.Fatalf("failed to extract input schema: %v", err)
+	}
+	database := schema.Properties["database"]
+	if database.Type != "object" || !reflect.DeepEqual(database.Required, []string{"name"}) ||
+		!strings.Contains(database.Description, "Database") {
+		t.Errorf("expected an instance reference schema, got %+v", database)
+	}
+	if _, found := database.Properties["name"]; !found || len(database.Properties) != 1 {
+		t.Errorf("expected only a name property, got %+v", database.Properties)
+	}
+}
+
+func TestExtractor_InstanceRefs_RequireKind(t *testing.T) {
+	v := cuecontext.New().CompileString(`#Input: database: {name: string} @instanceRef(group="example.com")`)
+
+	if _, err := NewExtractor().ExtractInstanceRefs(v); err == nil || !strings.Contains(err.Error(), "requires a kind") {
+		t.Fatalf("expected a missing kind to be rejected, got %v", err)
+	}
+}